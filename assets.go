@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mookie/internal/assets"
+	"mookie/internal/db"
+	"mookie/static"
+	"os"
+	"path/filepath"
+)
+
+// assets.go - Define handling of the assets embedded into the binary
+//
+// Static files and the database schema are always embedded and templates are compiled in,
+// so the binary runs from any working directory. Build with `go build -tags embed` to serve
+// the embedded static files by default, EmbedStatic in the config can still override it.
+//
+// Run with -build-assets before building to bundle the configured esbuild entry points and
+// write static/manifest.json, otherwise static files are fingerprinted at startup.
+
+// extractAssets writes the embedded assets to dir so they can be customized
+func extractAssets(dir string) error {
+	// Static files
+	err := fs.WalkDir(static.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, "static", path)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := static.FS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeAsset(target, data)
+	})
+	if err != nil {
+		return fmt.Errorf("error extracting static files: %w", err)
+	}
+
+	// Database schema
+	if err := writeAsset(filepath.Join(dir, "schema.sql"), []byte(db.Schema())); err != nil {
+		return fmt.Errorf("error extracting schema: %w", err)
+	}
+
+	return nil
+}
+
+// buildStaticAssets bundles the configured entry points into the static folder and writes its asset manifest
+func buildStaticAssets(configPath string) (*assets.Manifest, error) {
+	cfg := setupConfig(&configPath, nil)
+
+	var hooks []assets.Hook
+	if len(cfg.Assets.Bundle) > 0 {
+		hooks = append(hooks, assets.Esbuild(cfg.Assets.EsbuildPath, cfg.Assets.Bundle, cfg.Assets.BundleDir, cfg.Assets.Minify))
+	}
+	manifest, err := assets.Run(context.Background(), "static", assets.DefaultPrefix, hooks...)
+	if err != nil {
+		return nil, fmt.Errorf("error building assets: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeAsset writes a single asset, refusing to overwrite existing files
+func writeAsset(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
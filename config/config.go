@@ -1,11 +1,17 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 /*
@@ -16,6 +22,14 @@ import (
 	1. Create a config file or use defaults
 	2. Load config with NewWithPath
 	3. Access values through Config struct
+	4. Fix the fields listed in the error if the config is invalid - see Validate
+	5. Optionally reload changes at runtime with Watch and OnChange - see watch.go
+	6. Optionally load the config from Consul or etcd with NewWithRemote - see remote.go
+	7. Debug which source won for each key with Explain - see explain.go
+	8. Read custom keys without changing the Config struct with Get - see get.go
+	9. Declare typed config sections for services with RegisterSection - see sections.go
+	10. Store secrets encrypted as enc:... values decrypted with MOOKIE_CONFIG_KEY - see encrypt.go
+	11. Generate a commented config with every key and its default with WriteSample - see sample.go
 
 	Example usage:
 		// Load config
@@ -28,47 +42,417 @@ import (
 	- Prefix: MOOKIE_ (customize as needed)
 	- Format: MOOKIE_BINDADDRESS, MOOKIE_PORT, etc.
 	- Overrides file config when present
+	- Any key can be read from a file with MOOKIE_<KEY>_FILE, e.g. MOOKIE_MAIL_PASSWORD_FILE=/run/secrets/smtp
+	  for Docker and Kubernetes secrets - a trailing newline is removed, list values are split on whitespace
+	- Setting both MOOKIE_<KEY> and MOOKIE_<KEY>_FILE is an error
+	- ${VAR} in string values of the file is replaced with the environment variable, e.g.
+	  DatabasePath = "${HOME}/data/app.db" - undefined variables are an error, write $$ for a literal $
 
 	Config precedence:
-	1. Environment variables
-	2. Config file values
-	3. Default values
+	1. Environment variables, including _FILE variables
+	2. Command line flags bound with BindFlag, e.g. -port 9090 - see flags.go
+	3. config.local.toml - machine specific overrides, keep it out of version control
+	4. config.<env>.toml if MOOKIE_ENV is set, e.g. config.production.toml for MOOKIE_ENV=production
+	5. Config file values, or the remote config with NewWithRemote - overlays only apply to files
+	6. Default values
+	Overlay files are named after the config file and merged key by key, they are optional.
+
+	Environments:
+	- Env is one of "development" (default), "staging" or "production", usually set with MOOKIE_ENV
+	- It switches the defaults of LogLevel, LogFormat, ErrorDetail, AllowedOrigins and DebugRoutes, values set in
+	  the file or environment still win
+	- Branch on it with cfg.IsProduction, cfg.IsStaging and cfg.IsDevelopment
+	- Set MOOKIE_ENV=production in deployments, development shows error details and allows any origin
 
 	Default values:
+	- Env: "development"
 	- BindAddress: "0.0.0.0"
 	- Port: 8080
 	- DatabasePath: "app.db"
 	- LogFile: "" (stdout)
-	- LogLevel: "normal"
+	- LogLevel: "debug" in development, "normal" otherwise (one of "normal" or "debug")
+	- LogFormat: "text" in development, "json" otherwise
+	- ErrorDetail: true in development (panic messages and stack traces in 500 responses), false otherwise
+	- AllowedOrigins: ["*"] in development (any origin), [] otherwise (websockets from the same host only)
+	- DebugRoutes: true in development (admin only debug pages, e.g. /admin/debug/services), false otherwise
+	- PIDFile: "" (no PID file)
+	- SwaggerUI: false
+	- EmbedStatic: false (serve static files from disk), true when built with -tags embed
+	- WatchConfig: true (reload the config file on changes and SIGHUP)
+	- Server.ReadHeaderTimeout: 10s (time to read the request headers, guards against slowloris)
+	- Server.ReadTimeout: 30s (time to read the whole request including the body, 0 for no limit)
+	- Server.WriteTimeout: 60s (time from the end of the request headers to the end of the response,
+	  0 for no limit - websockets are not affected once upgraded)
+	- Server.IdleTimeout: 120s (time keep-alive connections wait for the next request)
+	- Server.MaxHeaderBytes: 1048576 (1 MB, size limit of the request headers)
+	- Server.TrustedProxies: [] (IPs or CIDRs of the reverse proxies whose X-Forwarded-For and X-Real-IP
+	  headers are trusted, clients are identified by the peer address otherwise)
+	- Server.WebsocketPongTimeout: 60s (time websocket clients have to answer a ping before they're
+	  disconnected, 0 disables pings)
+	- Server.WebsocketPingInterval: 54s (time between pings of websocket clients, shorter than the pong timeout)
+	- Server.WebsocketRedisURL: "" (relay websocket broadcasts between instances through Redis, e.g.
+	  redis://:password@localhost:6379, empty for a single instance)
+	- Server.WebsocketRedisChannel: "mookie:websocket" (Redis channel the broadcasts are relayed through)
+	- GraphQL.Enabled: false (the endpoint requires the admin role)
+	- GraphQL.Playground: false (served in development only)
+	- TLS.Enabled: false
+	- TLS.CertFile, TLS.KeyFile: "" (required unless TLS.AutoCert is set)
+	- TLS.AutoCert: false (Let's Encrypt certificates for TLS.Domains)
+	- TLS.CacheDir: "certs"
+	- TLS.HTTPPort: 80 (ACME challenge and redirect listener)
+	- TLS.HTTPSPort: 443 (public HTTPS port used in redirects)
+	- TLS.RedirectHTTP: true (always on with AutoCert)
+	- Metrics.Enabled: false
+	- Metrics.Path: "/metrics"
+	- Socket.Path: "" (listen on BindAddress:Port, otherwise on the unix socket)
+	- Socket.Mode: "0660"
+	- Socket.Owner, Socket.Group: "" (unchanged)
+	- Mail.Transport: "log" (one of "smtp", "log" or "maildir")
+	- Mail.From: "mookie <noreply@localhost>"
+	- Mail.Host: "localhost"
+	- Mail.Port: 587
+	- Mail.Username, Mail.Password: "" (no SMTP auth)
+	- Mail.Encryption: "starttls" (one of "starttls", "tls" or "none")
+	- Mail.MaildirPath: "maildir"
+	- Tracing.Enabled: false
+	- Tracing.Endpoint: "localhost:4318" (OTLP/HTTP collector)
+	- Tracing.Insecure: true (plain HTTP to the collector)
+	- Tracing.ServiceName: "mookie"
+	- Tracing.SampleRatio: 1.0 (fraction of new traces recorded)
+	- Search.Enabled: false
+	- Search.Backend: "fts5" (one of "fts5" or "bleve", each requires its build tag)
+	- Search.BlevePath: "search.bleve"
+	- Cache.MaxEntries: 100000 (items in the shared memory cache, the least recently used are evicted,
+	  0 for no bound)
+	- Cache.MaxBytes: 0 (approximate memory footprint of the memory cache items, the least recently used
+	  are evicted, 0 for no budget)
+	- Cache.MaxItemBytes: 1048576 (1 MB, approximate size of a memory cache item, larger items aren't cached,
+	  0 for no limit)
+	- Cache.CleanupInterval: 1m (how often expired items are deleted from the memory cache, 0 disables
+	  the cleanup)
+	- Cache.Codec: "gob" (serialization of the SQLite cache values, one of "gob" or "json")
+	- Cache.SnapshotPath: "" (file the memory cache is saved to and loaded from at startup, empty to
+	  start cold)
+	- Cache.SnapshotInterval: 5m (how often the memory cache snapshot is saved, 0 saves it at shutdown only)
+	- Audit.RetentionDays: 365 (0 keeps audit entries forever)
+	- Cron.Enabled: true (run the background tasks)
+	- Cron.Interval: 1m (interval of background tasks registered without one)
+	- Cron.Schedules: [] (intervals of background tasks by name as "name:interval", e.g. "search:30s",
+	  0 disables a task)
+	- Cron.HistoryDays: 0 (days the runs of cron tasks are kept, 0 doesn't record them)
+	- Assets.Fingerprint: true (hashed static file URLs with immutable caching)
+	- Assets.EsbuildPath: "esbuild"
+	- Assets.Bundle: [] (esbuild entry points relative to static/, bundled by -build-assets)
+	- Assets.BundleDir: "dist" (bundle output folder inside static/)
+	- Assets.Minify: true
+	- RateLimit.Enabled: false (limit HTTP requests per client IP)
+	- RateLimit.Requests: 300 (requests per RateLimit.WindowSeconds, bursts up to the same number)
+	- RateLimit.WindowSeconds: 60
+	- RateLimit.LoginAttempts: 10 (failed logins per client IP and LoginWindowSeconds, 0 disables)
+	- RateLimit.LoginWindowSeconds: 900
+	- RateLimit.WebsocketMessages: 20 (incoming messages per second and websocket client, 0 disables)
+	- Captcha.Provider: "" (disabled, one of "turnstile", "hcaptcha" or "recaptcha")
+	- Captcha.SiteKey, Captcha.SecretKey: ""
+	- Crypto.Keys: [] (encryption keys as "version:base64", the highest version encrypts new data -
+	  create the keyring of features encrypting data with crypto.ParseKeys and crypto.NewKeyring)
+	- Messaging.SMSProvider: "" (disabled, "twilio" for Twilio compatible gateways)
+	- Messaging.TwilioAccountSID, Messaging.TwilioAuthToken, Messaging.TwilioFrom: ""
+	- Messaging.TwilioBaseURL: "https://api.twilio.com"
+	- Messaging.VAPIDPublicKey, Messaging.VAPIDPrivateKey: "" (Web Push is disabled without keys,
+	  generate them with -gen-vapid-keys)
+	- Messaging.VAPIDSubject: "mailto:admin@localhost"
+	- Payments.StripeSecretKey: "" (payments are disabled without a key)
+	- Payments.StripePublishableKey, Payments.StripeWebhookSecret: ""
+	- Payments.Prices: [] (Stripe price IDs users may subscribe to, the first is the default)
+	- Payments.SuccessURL, Payments.CancelURL, Payments.PortalReturnURL: "/" (relative URLs are
+	  resolved against the request host)
 */
 
 // Config defines the application configuration
 type Config struct {
-	BindAddress  string `mapstructure:"BindAddress"`
-	Port         int    `mapstructure:"Port"`
-	DatabasePath string `mapstructure:"DatabasePath"`
-	LogFile      string `mapstructure:"LogFile"`
-	LogLevel     string `mapstructure:"LogLevel"`
+	Env            string          `mapstructure:"Env" validate:"oneof=development staging production" desc:"Environment switching the defaults of LogLevel, LogFormat, ErrorDetail, AllowedOrigins and DebugRoutes, one of development, staging or production"`
+	BindAddress    string          `mapstructure:"BindAddress" desc:"Address to listen on"`
+	Port           int             `mapstructure:"Port" validate:"min=1,max=65535" desc:"Port to listen on"`
+	DatabasePath   string          `mapstructure:"DatabasePath" validate:"required" desc:"Path of the SQLite database, file: URIs with parameters are allowed"`
+	LogFile        string          `mapstructure:"LogFile" validate:"omitempty,writable" desc:"File to write logs to, empty for stdout"`
+	LogLevel       string          `mapstructure:"LogLevel" validate:"oneof=normal debug" desc:"Log level, one of normal or debug, defaults to normal outside development"`
+	LogFormat      string          `mapstructure:"LogFormat" validate:"oneof=text json" desc:"Log format, text for reading or json for log collectors, defaults to json outside development"`
+	ErrorDetail    bool            `mapstructure:"ErrorDetail" desc:"Show panic messages and stack traces in 500 responses, defaults to false outside development"`
+	AllowedOrigins []string        `mapstructure:"AllowedOrigins" desc:"Origins allowed to open websockets besides the request host, e.g. https://app.example.com, * allows any, defaults to none outside development"`
+	DebugRoutes    bool            `mapstructure:"DebugRoutes" desc:"Serve admin only debug pages like /admin/debug/services listing the wired services, defaults to false outside development"`
+	PIDFile        string          `mapstructure:"PIDFile" validate:"omitempty,writable" desc:"File to write the process ID to, empty for none"`
+	SwaggerUI      bool            `mapstructure:"SwaggerUI" desc:"Serve the Swagger UI for the OpenAPI document"`
+	EmbedStatic    bool            `mapstructure:"EmbedStatic" desc:"Serve the static files embedded in the binary instead of the static directory"`
+	WatchConfig    bool            `mapstructure:"WatchConfig" desc:"Reload the config file on changes and SIGHUP"`
+	Server         ServerConfig    `mapstructure:"Server" desc:"HTTP server timeouts and limits"`
+	GraphQL        GraphQLConfig   `mapstructure:"GraphQL" desc:"Optional GraphQL endpoint"`
+	TLS            TLSConfig       `mapstructure:"TLS" desc:"HTTPS with certificate files or automatic Let's Encrypt certificates"`
+	Metrics        MetricsConfig   `mapstructure:"Metrics" desc:"Prometheus metrics endpoint"`
+	Socket         SocketConfig    `mapstructure:"Socket" desc:"Unix domain socket listener used instead of TCP"`
+	Mail           MailConfig      `mapstructure:"Mail" desc:"Sending emails through SMTP, or to the log or a maildir during development"`
+	Tracing        TracingConfig   `mapstructure:"Tracing" desc:"OpenTelemetry tracing exporter and sampling"`
+	Search         SearchConfig    `mapstructure:"Search" desc:"Full text search index"`
+	Cache          CacheConfig     `mapstructure:"Cache" desc:"Shared memory cache"`
+	Audit          AuditConfig     `mapstructure:"Audit" desc:"Audit log retention"`
+	Cron           CronConfig      `mapstructure:"Cron" desc:"Background tasks, their intervals and run history"`
+	Assets         AssetsConfig    `mapstructure:"Assets" desc:"Static file fingerprinting and esbuild bundling run by -build-assets"`
+	RateLimit      RateLimitConfig `mapstructure:"RateLimit" desc:"Request, login attempt and websocket message limits"`
+	Captcha        CaptchaConfig   `mapstructure:"Captcha" desc:"Captcha provider protecting forms"`
+	Crypto         CryptoConfig    `mapstructure:"Crypto" desc:"Versioned keys encrypting and signing application data"`
+	Messaging      MessagingConfig `mapstructure:"Messaging" desc:"SMS gateway and Web Push keys"`
+	Payments       PaymentsConfig  `mapstructure:"Payments" desc:"Stripe keys and the prices users may subscribe to"`
+
+	// watcher is shared by the configs reloaded from the same file
+	watcher *watcher
+	// viper holds the resolved values including custom keys, read by Get
+	viper *viper.Viper
+	// sections are the decoded sections registered with RegisterSection
+	sections map[string]reflect.Value
+}
+
+// ServerConfig defines the timeouts and limits of the HTTP servers
+// Durations are written like '30s' or '2m', 0 disables a timeout
+type ServerConfig struct {
+	ReadHeaderTimeout     time.Duration `mapstructure:"ReadHeaderTimeout" validate:"min=0" desc:"Time to read the request headers"`
+	ReadTimeout           time.Duration `mapstructure:"ReadTimeout" validate:"min=0" desc:"Time to read the whole request including the body, 0 for no limit"`
+	WriteTimeout          time.Duration `mapstructure:"WriteTimeout" validate:"min=0" desc:"Time from the end of the request headers to the end of the response, 0 for no limit"`
+	IdleTimeout           time.Duration `mapstructure:"IdleTimeout" validate:"min=0" desc:"Time keep-alive connections wait for the next request"`
+	MaxHeaderBytes        int           `mapstructure:"MaxHeaderBytes" validate:"min=0" desc:"Size limit of the request headers in bytes"`
+	TrustedProxies        []string      `mapstructure:"TrustedProxies" validate:"proxies" desc:"IPs or CIDRs of the reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted, e.g. 10.0.0.0/8"`
+	WebsocketPongTimeout  time.Duration `mapstructure:"WebsocketPongTimeout" validate:"min=0" desc:"Time websocket clients have to answer a ping before they're disconnected, 0 disables pings"`
+	WebsocketPingInterval time.Duration `mapstructure:"WebsocketPingInterval" validate:"min=0" desc:"Time between pings of websocket clients, 9/10 of the pong timeout unless shorter"`
+	WebsocketRedisURL     string        `mapstructure:"WebsocketRedisURL" secret:"true" desc:"Redis URL relaying websocket broadcasts between instances, redis:// or rediss:// for TLS, empty for a single instance"`
+	WebsocketRedisChannel string        `mapstructure:"WebsocketRedisChannel" validate:"required" desc:"Redis channel websocket broadcasts are relayed through, shared by the instances"`
+}
+
+// GraphQLConfig defines the optional GraphQL endpoint configuration
+type GraphQLConfig struct {
+	Enabled    bool `mapstructure:"Enabled" desc:"Serve the GraphQL endpoint to admins"`
+	Playground bool `mapstructure:"Playground" desc:"Serve the GraphQL playground to admins, in development only"`
+}
+
+// TLSConfig defines HTTPS serving with certificate files or automatic Let's Encrypt certificates
+type TLSConfig struct {
+	Enabled  bool     `mapstructure:"Enabled" desc:"Serve HTTPS"`
+	CertFile string   `mapstructure:"CertFile" desc:"Certificate file, required unless AutoCert is set"`
+	KeyFile  string   `mapstructure:"KeyFile" desc:"Private key file, required unless AutoCert is set"`
+	AutoCert bool     `mapstructure:"AutoCert" desc:"Get certificates for Domains from Let's Encrypt"`
+	Domains  []string `mapstructure:"Domains" desc:"Domains to get certificates for with AutoCert"`
+	Email    string   `mapstructure:"Email" desc:"Contact email for Let's Encrypt"`
+	CacheDir string   `mapstructure:"CacheDir" desc:"Directory caching the Let's Encrypt certificates"`
+	HTTPPort int      `mapstructure:"HTTPPort" validate:"min=1,max=65535" desc:"Port of the HTTP listener for redirects and ACME challenges"`
+	// HTTPSPort is the public HTTPS port used in redirects, it differs from Port behind port forwarding
+	HTTPSPort    int  `mapstructure:"HTTPSPort" validate:"min=1,max=65535" desc:"Public HTTPS port used in redirects"`
+	RedirectHTTP bool `mapstructure:"RedirectHTTP" desc:"Redirect HTTP to HTTPS, always on with AutoCert"`
+}
+
+// MetricsConfig defines the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"Enabled" desc:"Serve the metrics endpoint"`
+	Path    string `mapstructure:"Path" validate:"required" desc:"Path of the metrics endpoint"`
+}
+
+// SocketConfig defines an optional unix domain socket listener used instead of TCP
+type SocketConfig struct {
+	Path  string `mapstructure:"Path" desc:"Path of the unix socket, empty to listen on BindAddress and Port"`
+	Mode  string `mapstructure:"Mode" desc:"Octal file mode of the socket"`
+	Owner string `mapstructure:"Owner" desc:"User owning the socket, empty to leave unchanged"`
+	Group string `mapstructure:"Group" desc:"Group owning the socket, empty to leave unchanged"`
+}
+
+// MailConfig defines how emails are sent - through SMTP or to the log or a maildir during development
+type MailConfig struct {
+	Transport   string `mapstructure:"Transport" validate:"oneof=smtp log maildir" desc:"How emails are sent, one of smtp, log or maildir"`
+	From        string `mapstructure:"From" validate:"required" desc:"Sender address"`
+	Host        string `mapstructure:"Host" desc:"SMTP server host"`
+	Port        int    `mapstructure:"Port" validate:"min=1,max=65535" desc:"SMTP server port"`
+	Username    string `mapstructure:"Username" desc:"SMTP username, empty for no authentication"`
+	Password    string `mapstructure:"Password" secret:"true" desc:"SMTP password"`
+	Encryption  string `mapstructure:"Encryption" validate:"oneof=starttls tls none" desc:"SMTP encryption, one of starttls, tls or none"`
+	MaildirPath string `mapstructure:"MaildirPath" desc:"Directory the maildir transport writes to"`
+}
+
+// TracingConfig defines the OpenTelemetry exporter and sampling
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"Enabled" desc:"Export traces"`
+	Endpoint    string  `mapstructure:"Endpoint" desc:"OTLP/HTTP collector address"`
+	Insecure    bool    `mapstructure:"Insecure" desc:"Send traces over plain HTTP"`
+	ServiceName string  `mapstructure:"ServiceName" desc:"Service name of the traces"`
+	SampleRatio float64 `mapstructure:"SampleRatio" validate:"min=0,max=1" desc:"Fraction of new traces recorded, from 0 to 1"`
+}
+
+// SearchConfig defines the full text search index
+type SearchConfig struct {
+	Enabled   bool   `mapstructure:"Enabled" desc:"Enable full text search"`
+	Backend   string `mapstructure:"Backend" validate:"oneof=fts5 bleve" desc:"Search backend, one of fts5 or bleve"`
+	BlevePath string `mapstructure:"BlevePath" desc:"Directory of the Bleve index"`
+}
+
+// CacheConfig defines the size, memory budget, cleanup and snapshots of the shared memory cache and the SQLite cache codec
+type CacheConfig struct {
+	MaxEntries       int           `mapstructure:"MaxEntries" validate:"min=0" desc:"Items kept in the shared memory cache, the least recently used are evicted, 0 for no bound"`
+	MaxBytes         int64         `mapstructure:"MaxBytes" validate:"min=0" desc:"Approximate memory footprint of the memory cache items, the least recently used are evicted, 0 for no budget"`
+	MaxItemBytes     int64         `mapstructure:"MaxItemBytes" validate:"min=0" desc:"Approximate size of a memory cache item, larger items aren't cached, 0 for no limit"`
+	CleanupInterval  time.Duration `mapstructure:"CleanupInterval" validate:"min=0" desc:"How often expired items are deleted from the memory cache, 0 disables the cleanup"`
+	Codec            string        `mapstructure:"Codec" validate:"oneof=gob json" desc:"Serialization of the SQLite cache values, one of gob or json"`
+	SnapshotPath     string        `mapstructure:"SnapshotPath" desc:"File the memory cache is saved to and loaded from at startup, empty to start cold"`
+	SnapshotInterval time.Duration `mapstructure:"SnapshotInterval" validate:"min=0" desc:"How often the memory cache snapshot is saved, 0 saves it at shutdown only"`
+}
+
+// AuditConfig defines how long audit log entries are kept
+type AuditConfig struct {
+	RetentionDays int `mapstructure:"RetentionDays" validate:"min=0" desc:"Days audit log entries are kept, 0 keeps them forever"`
+}
+
+// CronConfig defines the background tasks run by the cron runner
+type CronConfig struct {
+	Enabled     bool          `mapstructure:"Enabled" desc:"Run the background tasks, disable on instances that only serve requests"`
+	Interval    time.Duration `mapstructure:"Interval" validate:"min=0" desc:"Interval of background tasks registered without one"`
+	Schedules   []string      `mapstructure:"Schedules" desc:"Intervals of background tasks by name as name:interval, e.g. search:30s, 0 disables a task"`
+	HistoryDays int           `mapstructure:"HistoryDays" validate:"min=0" desc:"Days the runs of background tasks are kept for the admin, 0 doesn't record them"`
+}
+
+// AssetsConfig defines static file fingerprinting and the esbuild bundling run by -build-assets
+type AssetsConfig struct {
+	Fingerprint bool     `mapstructure:"Fingerprint" desc:"Serve static files with content hashes in their names"`
+	EsbuildPath string   `mapstructure:"EsbuildPath" desc:"Path of the esbuild binary"`
+	Bundle      []string `mapstructure:"Bundle" desc:"Entry points bundled with esbuild, relative to static"`
+	BundleDir   string   `mapstructure:"BundleDir" desc:"Directory in static the bundles are written to"`
+	Minify      bool     `mapstructure:"Minify" desc:"Minify bundles"`
+}
+
+// RateLimitConfig defines the request, login attempt and websocket message limits
+type RateLimitConfig struct {
+	Enabled            bool `mapstructure:"Enabled" desc:"Limit requests per client"`
+	Requests           int  `mapstructure:"Requests" validate:"min=0" desc:"Requests allowed per window"`
+	WindowSeconds      int  `mapstructure:"WindowSeconds" validate:"min=0" desc:"Length of the request window in seconds"`
+	LoginAttempts      int  `mapstructure:"LoginAttempts" validate:"min=0" desc:"Failed login attempts allowed per window, 0 for no limit"`
+	LoginWindowSeconds int  `mapstructure:"LoginWindowSeconds" validate:"min=0" desc:"Length of the login attempt window in seconds"`
+	WebsocketMessages  int  `mapstructure:"WebsocketMessages" validate:"min=0" desc:"Websocket messages allowed per second, 0 for no limit"`
+}
+
+// CaptchaConfig defines the captcha provider protecting forms
+type CaptchaConfig struct {
+	Provider  string `mapstructure:"Provider" validate:"omitempty,oneof=turnstile hcaptcha recaptcha" desc:"Captcha provider, one of turnstile, hcaptcha or recaptcha, empty to disable"`
+	SiteKey   string `mapstructure:"SiteKey" desc:"Public site key of the provider"`
+	SecretKey string `mapstructure:"SecretKey" secret:"true" desc:"Secret key of the provider"`
+}
+
+// CryptoConfig defines the versioned keys used to encrypt and sign application data
+type CryptoConfig struct {
+	Keys []string `mapstructure:"Keys" secret:"true" desc:"Keys as version:base64, generate with openssl rand -base64 32"`
+}
+
+// MessagingConfig defines the SMS gateway and Web Push keys
+type MessagingConfig struct {
+	SMSProvider      string `mapstructure:"SMSProvider" validate:"omitempty,oneof=twilio" desc:"SMS provider, twilio or empty to disable SMS"`
+	TwilioAccountSID string `mapstructure:"TwilioAccountSID" desc:"Twilio account SID"`
+	TwilioAuthToken  string `mapstructure:"TwilioAuthToken" secret:"true" desc:"Twilio auth token"`
+	TwilioFrom       string `mapstructure:"TwilioFrom" desc:"Phone number SMS are sent from"`
+	TwilioBaseURL    string `mapstructure:"TwilioBaseURL" validate:"omitempty,url" desc:"Twilio API URL, change for compatible gateways"`
+	VAPIDPublicKey   string `mapstructure:"VAPIDPublicKey" desc:"Web Push public key, generate a pair with -gen-vapid-keys"`
+	VAPIDPrivateKey  string `mapstructure:"VAPIDPrivateKey" secret:"true" desc:"Web Push private key"`
+	VAPIDSubject     string `mapstructure:"VAPIDSubject" desc:"Contact URL or mailto: address sent to push services"`
+}
+
+// PaymentsConfig defines the Stripe keys and the prices users may subscribe to
+type PaymentsConfig struct {
+	StripeSecretKey      string   `mapstructure:"StripeSecretKey" secret:"true" desc:"Stripe secret key, empty to disable payments"`
+	StripePublishableKey string   `mapstructure:"StripePublishableKey" desc:"Stripe publishable key"`
+	StripeWebhookSecret  string   `mapstructure:"StripeWebhookSecret" secret:"true" desc:"Signing secret of the webhook endpoint pointed at /webhooks/stripe"`
+	Prices               []string `mapstructure:"Prices" desc:"Stripe price IDs users may subscribe to, the first is the default"`
+	SuccessURL           string   `mapstructure:"SuccessURL" desc:"URL users return to after checking out"`
+	CancelURL            string   `mapstructure:"CancelURL" desc:"URL users return to after canceling a checkout"`
+	PortalReturnURL      string   `mapstructure:"PortalReturnURL" desc:"URL users return to from the billing portal"`
+}
+
+// Environments of Env
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// IsProduction reports whether the config is for production
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// IsStaging reports whether the config is for staging
+func (c *Config) IsStaging() bool {
+	return c.Env == EnvStaging
+}
+
+// IsDevelopment reports whether the config is for development, the default environment
+func (c *Config) IsDevelopment() bool {
+	return c.Env == EnvDevelopment
+}
+
+// envKeys are the keys whose defaults setEnvDefaults switches with Env, WriteSample comments them
+// out so a generated config file doesn't pin the development values in production
+var envKeys = map[string]bool{"LogLevel": true, "LogFormat": true, "ErrorDetail": true, "AllowedOrigins": true, "DebugRoutes": true}
+
+// setEnvDefaults sets the defaults that depend on Env, after the file is read so Env may be set there
+func setEnvDefaults(v *viper.Viper) {
+	development := v.GetString("Env") == EnvDevelopment
+	if development {
+		v.SetDefault("LogLevel", "debug")
+		v.SetDefault("LogFormat", "text")
+		v.SetDefault("ErrorDetail", true)
+		v.SetDefault("AllowedOrigins", []string{"*"})
+		v.SetDefault("DebugRoutes", true)
+		return
+	}
+	v.SetDefault("LogLevel", "normal")
+	v.SetDefault("LogFormat", "json")
+	v.SetDefault("ErrorDetail", false)
+	v.SetDefault("AllowedOrigins", []string{})
+	v.SetDefault("DebugRoutes", false)
 }
 
 // NewWithPath creates a new config from the given path.
 func NewWithPath(configPath string) (*Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		cfg := getDefaultConfig()
-		data, err := toml.Marshal(cfg)
-		if err != nil {
+		var sample bytes.Buffer
+		if err := WriteSample(&sample); err != nil {
 			return nil, fmt.Errorf("error creating default config: %w", err)
 		}
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
+		if err := os.WriteFile(configPath, sample.Bytes(), 0644); err != nil {
 			return nil, fmt.Errorf("error writing default config: %w", err)
 		}
 	}
-	return loadConfig(configPath)
+	cfg, err := loadConfig(configPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", configPath, err)
+	}
+	cfg.watcher = &watcher{path: configPath, current: cfg}
+	cfg.publish()
+	return cfg, nil
+}
+
+// loadConfig loads the config from the given path, or from the remote if it isn't nil.
+func loadConfig(configPath string, remote *Remote) (*Config, error) {
+	v, err := newViper(configPath, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.viper = v
+	if cfg.sections, err = decodeSections(v); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
 }
 
-// loadConfig loads the config from the given path.
-// If the file does not exist, it creates a default config file.
-func loadConfig(configPath string) (*Config, error) {
+// newViper reads the config file or remote with the defaults and environment variables applied
+func newViper(configPath string, remote *Remote) (*viper.Viper, error) {
 	v := viper.New()
 
 	// Set some defaults
@@ -76,32 +460,405 @@ func loadConfig(configPath string) (*Config, error) {
 	v.SetDefault("Port", 8080)
 	v.SetDefault("DatabasePath", "app.db")
 	v.SetDefault("LogFile", "")
-	v.SetDefault("LogLevel", "normal")
+	v.SetDefault("Env", EnvDevelopment)
+	v.SetDefault("PIDFile", "")
+	v.SetDefault("SwaggerUI", false)
+	v.SetDefault("EmbedStatic", embedStaticDefault)
+	v.SetDefault("WatchConfig", true)
+	v.SetDefault("Server.ReadHeaderTimeout", 10*time.Second)
+	v.SetDefault("Server.ReadTimeout", 30*time.Second)
+	v.SetDefault("Server.WriteTimeout", 60*time.Second)
+	v.SetDefault("Server.IdleTimeout", 120*time.Second)
+	v.SetDefault("Server.MaxHeaderBytes", 1<<20)
+	v.SetDefault("Server.TrustedProxies", []string{})
+	v.SetDefault("Server.WebsocketPongTimeout", 60*time.Second)
+	v.SetDefault("Server.WebsocketPingInterval", 54*time.Second)
+	v.SetDefault("Server.WebsocketRedisURL", "")
+	v.SetDefault("Server.WebsocketRedisChannel", "mookie:websocket")
+	v.SetDefault("GraphQL.Enabled", false)
+	v.SetDefault("GraphQL.Playground", false)
+	v.SetDefault("TLS.Enabled", false)
+	v.SetDefault("TLS.CertFile", "")
+	v.SetDefault("TLS.KeyFile", "")
+	v.SetDefault("TLS.AutoCert", false)
+	v.SetDefault("TLS.Domains", []string{})
+	v.SetDefault("TLS.Email", "")
+	v.SetDefault("TLS.CacheDir", "certs")
+	v.SetDefault("TLS.HTTPPort", 80)
+	v.SetDefault("TLS.HTTPSPort", 443)
+	v.SetDefault("TLS.RedirectHTTP", true)
+	v.SetDefault("Metrics.Enabled", false)
+	v.SetDefault("Metrics.Path", "/metrics")
+	v.SetDefault("Socket.Path", "")
+	v.SetDefault("Socket.Mode", "0660")
+	v.SetDefault("Socket.Owner", "")
+	v.SetDefault("Socket.Group", "")
+	v.SetDefault("Mail.Transport", "log")
+	v.SetDefault("Mail.From", "mookie <noreply@localhost>")
+	v.SetDefault("Mail.Host", "localhost")
+	v.SetDefault("Mail.Port", 587)
+	v.SetDefault("Mail.Username", "")
+	v.SetDefault("Mail.Password", "")
+	v.SetDefault("Mail.Encryption", "starttls")
+	v.SetDefault("Mail.MaildirPath", "maildir")
+	v.SetDefault("Tracing.Enabled", false)
+	v.SetDefault("Tracing.Endpoint", "localhost:4318")
+	v.SetDefault("Tracing.Insecure", true)
+	v.SetDefault("Tracing.ServiceName", "mookie")
+	v.SetDefault("Tracing.SampleRatio", 1.0)
+	v.SetDefault("Search.Enabled", false)
+	v.SetDefault("Search.Backend", "fts5")
+	v.SetDefault("Search.BlevePath", "search.bleve")
+	v.SetDefault("Cache.MaxEntries", 100000)
+	v.SetDefault("Cache.MaxBytes", 0)
+	v.SetDefault("Cache.MaxItemBytes", 1<<20)
+	v.SetDefault("Cache.CleanupInterval", time.Minute)
+	v.SetDefault("Cache.Codec", "gob")
+	v.SetDefault("Cache.SnapshotPath", "")
+	v.SetDefault("Cache.SnapshotInterval", 5*time.Minute)
+	v.SetDefault("Audit.RetentionDays", 365)
+	v.SetDefault("Cron.Enabled", true)
+	v.SetDefault("Cron.Interval", time.Minute)
+	v.SetDefault("Cron.Schedules", []string{})
+	v.SetDefault("Cron.HistoryDays", 0)
+	v.SetDefault("Assets.Fingerprint", true)
+	v.SetDefault("Assets.EsbuildPath", "esbuild")
+	v.SetDefault("Assets.Bundle", []string{})
+	v.SetDefault("Assets.BundleDir", "dist")
+	v.SetDefault("Assets.Minify", true)
+	v.SetDefault("RateLimit.Enabled", false)
+	v.SetDefault("RateLimit.Requests", 300)
+	v.SetDefault("RateLimit.WindowSeconds", 60)
+	v.SetDefault("RateLimit.LoginAttempts", 10)
+	v.SetDefault("RateLimit.LoginWindowSeconds", 900)
+	v.SetDefault("RateLimit.WebsocketMessages", 20)
+	v.SetDefault("Captcha.Provider", "")
+	v.SetDefault("Captcha.SiteKey", "")
+	v.SetDefault("Captcha.SecretKey", "")
+	v.SetDefault("Crypto.Keys", []string{})
+	v.SetDefault("Messaging.SMSProvider", "")
+	v.SetDefault("Messaging.TwilioAccountSID", "")
+	v.SetDefault("Messaging.TwilioAuthToken", "")
+	v.SetDefault("Messaging.TwilioFrom", "")
+	v.SetDefault("Messaging.TwilioBaseURL", "https://api.twilio.com")
+	v.SetDefault("Messaging.VAPIDPublicKey", "")
+	v.SetDefault("Messaging.VAPIDPrivateKey", "")
+	v.SetDefault("Messaging.VAPIDSubject", "mailto:admin@localhost")
+	v.SetDefault("Payments.StripeSecretKey", "")
+	v.SetDefault("Payments.StripePublishableKey", "")
+	v.SetDefault("Payments.StripeWebhookSecret", "")
+	v.SetDefault("Payments.Prices", []string{})
+	v.SetDefault("Payments.SuccessURL", "/")
+	v.SetDefault("Payments.CancelURL", "/")
+	v.SetDefault("Payments.PortalReturnURL", "/")
+	setSectionDefaults(v)
 
-	v.SetConfigFile(configPath)
 	v.SetConfigType("toml")
 	v.AutomaticEnv()
 	v.SetEnvPrefix("MOOKIE") // Change this to your app's name
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("error reading config: %w", err)
+	if remote != nil {
+		data, err := remote.Fetch(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("error reading config from %s: %w", remote, err)
+		}
+	} else {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading config: %w", err)
+		}
+		overlays, err := overlayPaths(configPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range overlays {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("error reading config: %w", err)
+			}
+			if err := v.MergeConfig(bytes.NewReader(data)); err != nil {
+				return nil, fmt.Errorf("error reading config %s: %w", path, err)
+			}
+		}
 	}
-
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	setEnvDefaults(v)
+	if err := expandEnv(v); err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	applyFlags(v)
+	if err := applyFileEnv(v); err != nil {
+		return nil, err
+	}
+	if err := decryptValues(v); err != nil {
+		return nil, err
+	}
+	return v, nil
 }
 
 // getDefaultConfig returns the default config.
 func getDefaultConfig() *Config {
 	return &Config{
-		BindAddress:  "0.0.0.0",
-		Port:         8080,
-		DatabasePath: "app.db",
-		LogFile:      "",
-		LogLevel:     "normal",
+		BindAddress:    "0.0.0.0",
+		Port:           8080,
+		DatabasePath:   "app.db",
+		LogFile:        "",
+		Env:            EnvDevelopment,
+		LogLevel:       "debug",
+		LogFormat:      "text",
+		ErrorDetail:    true,
+		AllowedOrigins: []string{"*"},
+		DebugRoutes:    true,
+		PIDFile:        "",
+		SwaggerUI:      false,
+		EmbedStatic:    embedStaticDefault,
+		WatchConfig:    true,
+		Server: ServerConfig{
+			ReadHeaderTimeout:     10 * time.Second,
+			ReadTimeout:           30 * time.Second,
+			WriteTimeout:          60 * time.Second,
+			IdleTimeout:           120 * time.Second,
+			MaxHeaderBytes:        1 << 20,
+			TrustedProxies:        []string{},
+			WebsocketPongTimeout:  60 * time.Second,
+			WebsocketPingInterval: 54 * time.Second,
+			WebsocketRedisURL:     "",
+			WebsocketRedisChannel: "mookie:websocket",
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:    false,
+			Playground: false,
+		},
+		TLS: TLSConfig{
+			Enabled:      false,
+			AutoCert:     false,
+			Domains:      []string{},
+			CacheDir:     "certs",
+			HTTPPort:     80,
+			HTTPSPort:    443,
+			RedirectHTTP: true,
+		},
+		Metrics: MetricsConfig{
+			Enabled: false,
+			Path:    "/metrics",
+		},
+		Socket: SocketConfig{
+			Mode: "0660",
+		},
+		Mail: MailConfig{
+			Transport:   "log",
+			From:        "mookie <noreply@localhost>",
+			Host:        "localhost",
+			Port:        587,
+			Encryption:  "starttls",
+			MaildirPath: "maildir",
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "mookie",
+			SampleRatio: 1.0,
+		},
+		Search: SearchConfig{
+			Enabled:   false,
+			Backend:   "fts5",
+			BlevePath: "search.bleve",
+		},
+		Cache: CacheConfig{
+			MaxEntries:       100000,
+			MaxBytes:         0,
+			MaxItemBytes:     1 << 20,
+			CleanupInterval:  time.Minute,
+			Codec:            "gob",
+			SnapshotPath:     "",
+			SnapshotInterval: 5 * time.Minute,
+		},
+		Audit: AuditConfig{
+			RetentionDays: 365,
+		},
+		Cron: CronConfig{
+			Enabled:     true,
+			Interval:    time.Minute,
+			Schedules:   []string{},
+			HistoryDays: 0,
+		},
+		Assets: AssetsConfig{
+			Fingerprint: true,
+			EsbuildPath: "esbuild",
+			Bundle:      []string{},
+			BundleDir:   "dist",
+			Minify:      true,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:            false,
+			Requests:           300,
+			WindowSeconds:      60,
+			LoginAttempts:      10,
+			LoginWindowSeconds: 900,
+			WebsocketMessages:  20,
+		},
+		Captcha: CaptchaConfig{
+			Provider:  "",
+			SiteKey:   "",
+			SecretKey: "",
+		},
+		Crypto: CryptoConfig{
+			Keys: []string{},
+		},
+		Messaging: MessagingConfig{
+			SMSProvider:      "",
+			TwilioAccountSID: "",
+			TwilioAuthToken:  "",
+			TwilioFrom:       "",
+			TwilioBaseURL:    "https://api.twilio.com",
+			VAPIDPublicKey:   "",
+			VAPIDPrivateKey:  "",
+			VAPIDSubject:     "mailto:admin@localhost",
+		},
+		Payments: PaymentsConfig{
+			StripeSecretKey:      "",
+			StripePublishableKey: "",
+			StripeWebhookSecret:  "",
+			Prices:               []string{},
+			SuccessURL:           "/",
+			CancelURL:            "/",
+			PortalReturnURL:      "/",
+		},
+	}
+}
+
+// applyFileEnv sets every key with a MOOKIE_<KEY>_FILE environment variable to the contents of that file
+func applyFileEnv(v *viper.Viper) error {
+	for _, key := range v.AllKeys() {
+		env := strings.ToUpper(v.GetEnvPrefix() + "_" + strings.ReplaceAll(key, ".", "_"))
+		path, ok := os.LookupEnv(env + "_FILE")
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(env); set {
+			return fmt.Errorf("error reading config: both %s and %s_FILE are set", env, env)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading config: %s_FILE: %w", env, err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		switch v.Get(key).(type) {
+		case []string, []any:
+			v.Set(key, strings.Fields(value))
+		default:
+			v.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// overlayCandidates returns the overlay files of the config file in merge order, existing or not:
+// config.<MOOKIE_ENV>.toml if MOOKIE_ENV is set, then config.local.toml
+func overlayCandidates(configPath string) ([]string, error) {
+	ext := filepath.Ext(configPath)
+	base := strings.TrimSuffix(configPath, ext)
+	var paths []string
+	if env := os.Getenv("MOOKIE_ENV"); env != "" {
+		if filepath.Base(env) != env || env == "local" {
+			return nil, fmt.Errorf("error reading config: invalid MOOKIE_ENV %q", env)
+		}
+		paths = append(paths, base+"."+env+ext)
+	}
+	return append(paths, base+".local"+ext), nil
+}
+
+// overlayPaths returns the existing overlay files of the config file in merge order
+func overlayPaths(configPath string) ([]string, error) {
+	candidates, err := overlayCandidates(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// keyNames maps the lowercase keys of viper to the keys as they are written in the Config struct
+// and the registered sections, for error messages
+type keyNames map[string]string
+
+// newKeyNames returns the names of the known keys
+func newKeyNames() keyNames {
+	names := keyNames{}
+	add := func(key string, _ reflect.StructField, _ reflect.Value) {
+		names[strings.ToLower(key)] = key
+	}
+	walkSettings(reflect.ValueOf(Config{}), "", add)
+	for _, s := range registeredSections() {
+		walkSettings(s.defaults, s.name+".", add)
+	}
+	return names
+}
+
+// of returns the name of a lowercase key, custom keys stay lowercase
+func (n keyNames) of(key string) string {
+	if name, ok := n[key]; ok {
+		return name
+	}
+	return key
+}
+
+// envReference matches ${VAR} references in config values and the $$ escape
+var envReference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references in the string values of the config file with environment variables
+// Values set through environment variables aren't expanded, undefined variables are an error
+func expandEnv(v *viper.Viper) error {
+	names := newKeyNames()
+	var undefined []string
+	expand := func(key, value string) string {
+		return envReference.ReplaceAllStringFunc(value, func(ref string) string {
+			if ref == "$$" {
+				return "$"
+			}
+			name := ref[2 : len(ref)-1]
+			env, ok := os.LookupEnv(name)
+			if !ok {
+				undefined = append(undefined, fmt.Sprintf("%s in %s", ref, names.of(key)))
+			}
+			return env
+		})
+	}
+
+	for _, key := range v.AllKeys() {
+		env := strings.ToUpper(v.GetEnvPrefix() + "_" + strings.ReplaceAll(key, ".", "_"))
+		if !v.InConfig(key) || os.Getenv(env) != "" {
+			continue
+		}
+		switch value := v.Get(key).(type) {
+		case string:
+			if expanded := expand(key, value); expanded != value {
+				v.Set(key, expanded)
+			}
+		case []any:
+			expanded := make([]any, len(value))
+			for i, item := range value {
+				if s, ok := item.(string); ok {
+					expanded[i] = expand(key, s)
+				} else {
+					expanded[i] = item
+				}
+			}
+			v.Set(key, expanded)
+		}
+	}
+	if len(undefined) > 0 {
+		sort.Strings(undefined)
+		return fmt.Errorf("error reading config: undefined environment variables: %s", strings.Join(undefined, ", "))
 	}
+	return nil
 }
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// loadTestConfig writes the config file and its overlays, named by suffix, to a temp dir and reads them
+func loadTestConfig(t *testing.T, content string, overlays map[string]string) (*viper.Viper, error) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for suffix, overlay := range overlays {
+		if err := os.WriteFile(filepath.Join(dir, "config."+suffix+".toml"), []byte(overlay), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return newViper(path, nil)
+}
+
+func TestExpandEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     map[string]string
+		key     string
+		want    any
+		wantErr string
+	}{
+		{name: "variable", config: `DatabasePath = '${DATA_DIR}/app.db'`, env: map[string]string{"DATA_DIR": "/data"}, key: "DatabasePath", want: "/data/app.db"},
+		{name: "empty variable", config: `DatabasePath = '${DATA_DIR}app.db'`, env: map[string]string{"DATA_DIR": ""}, key: "DatabasePath", want: "app.db"},
+		{name: "escaped dollar", config: `[Mail]` + "\n" + `Password = 'pa$$${DATA_DIR}$$'`, env: map[string]string{"DATA_DIR": "x"}, key: "Mail.Password", want: "pa$x$"},
+		{name: "escaped reference", config: `[Mail]` + "\n" + `Password = '$${DATA_DIR}'`, key: "Mail.Password", want: "${DATA_DIR}"},
+		{name: "bare dollar", config: `[Mail]` + "\n" + `Password = 'pa$word'`, key: "Mail.Password", want: "pa$word"},
+		{name: "list", config: `[TLS]` + "\n" + `Domains = ['${DOMAIN}', 'www.${DOMAIN}']`, env: map[string]string{"DOMAIN": "example.com"}, key: "TLS.Domains", want: []string{"example.com", "www.example.com"}},
+		{name: "undefined variable", config: `DatabasePath = '${MOOKIE_TEST_UNDEFINED}/app.db'`, key: "DatabasePath", wantErr: "${MOOKIE_TEST_UNDEFINED} in DatabasePath"},
+		{name: "env over file", config: `DatabasePath = '${MOOKIE_TEST_UNDEFINED}/app.db'`, env: map[string]string{"MOOKIE_DATABASEPATH": "/env/${DATA_DIR}.db"}, key: "DatabasePath", want: "/env/${DATA_DIR}.db"},
+		{name: "env over default", config: ``, env: map[string]string{"MOOKIE_PORT": "9090"}, key: "Port", want: "9090"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			v, err := loadTestConfig(t, tt.config, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error about %s, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got any = v.GetString(tt.key)
+			if _, ok := tt.want.([]string); ok {
+				got = v.GetStringSlice(tt.key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %s = %q, got %q", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyFileEnv(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prices := filepath.Join(dir, "prices")
+	if err := os.WriteFile(prices, []byte("price_a\nprice_b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		key     string
+		want    any
+		wantErr string
+	}{
+		{name: "file", env: map[string]string{"MOOKIE_MAIL_PASSWORD_FILE": secret}, key: "Mail.Password", want: "s3cret"},
+		{name: "list file", env: map[string]string{"MOOKIE_PAYMENTS_PRICES_FILE": prices}, key: "Payments.Prices", want: []string{"price_a", "price_b"}},
+		{name: "file over config file", env: map[string]string{"MOOKIE_DATABASEPATH_FILE": secret}, key: "DatabasePath", want: "s3cret"},
+		{name: "conflicting variable", env: map[string]string{"MOOKIE_MAIL_PASSWORD_FILE": secret, "MOOKIE_MAIL_PASSWORD": "plain"}, wantErr: "both MOOKIE_MAIL_PASSWORD and MOOKIE_MAIL_PASSWORD_FILE are set"},
+		{name: "conflicting empty variable", env: map[string]string{"MOOKIE_MAIL_PASSWORD_FILE": secret, "MOOKIE_MAIL_PASSWORD": ""}, wantErr: "both MOOKIE_MAIL_PASSWORD and MOOKIE_MAIL_PASSWORD_FILE are set"},
+		{name: "missing file", env: map[string]string{"MOOKIE_MAIL_PASSWORD_FILE": filepath.Join(dir, "missing")}, wantErr: "MOOKIE_MAIL_PASSWORD_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			v, err := loadTestConfig(t, `DatabasePath = 'file.db'`, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error about %s, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got any = v.GetString(tt.key)
+			if _, ok := tt.want.([]string); ok {
+				got = v.GetStringSlice(tt.key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %s = %q, got %q", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOverlayCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no environment", want: []string{"conf/app.local.toml"}},
+		{name: "environment", env: "production", want: []string{"conf/app.production.toml", "conf/app.local.toml"}},
+		{name: "path traversal", env: "../secrets", wantErr: true},
+		{name: "nested path", env: "prod/eu", wantErr: true},
+		{name: "local", env: "local", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MOOKIE_ENV", tt.env)
+			got, err := overlayCandidates("conf/app.toml")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid MOOKIE_ENV") {
+					t.Fatalf("expected an invalid MOOKIE_ENV error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOverlays(t *testing.T) {
+	base := "Port = 1\nBindAddress = 'base'\nDatabasePath = 'base.db'\n[Mail]\nFrom = 'base'\nHost = 'base'\n"
+	overlays := map[string]string{
+		"staging": "Port = 2\nBindAddress = 'staging'\n[Mail]\nFrom = 'staging'\n",
+		"local":   "Port = 3\n[Mail]\nHost = 'local'\n",
+	}
+	t.Setenv("MOOKIE_ENV", "staging")
+	t.Setenv("MOOKIE_DATABASEPATH", "env.db")
+	v, err := loadTestConfig(t, base, overlays)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The local overlay wins over the environment overlay, which wins over the config file,
+	// sections are merged key by key and environment variables win over every file
+	want := map[string]string{
+		"Port":         "3",
+		"BindAddress":  "staging",
+		"DatabasePath": "env.db",
+		"Mail.From":    "staging",
+		"Mail.Host":    "local",
+	}
+	for key, value := range want {
+		if got := v.GetString(key); got != value {
+			t.Errorf("expected %s = %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestNewWithPath_DefaultFile(t *testing.T) {
+	t.Setenv("MOOKIE_ENV", "production")
+	t.Setenv("MOOKIE_DATABASEPATH", filepath.Join(t.TempDir(), "app.db"))
+	path := filepath.Join(t.TempDir(), "config.toml")
+	cfg, err := NewWithPath(path)
+	if err != nil {
+		t.Fatalf("NewWithPath returned error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the default config to be written: %v", err)
+	}
+
+	// The written file mustn't pin the development defaults
+	if cfg.ErrorDetail {
+		t.Error("expected ErrorDetail = false in production")
+	}
+	if len(cfg.AllowedOrigins) != 0 {
+		t.Errorf("expected no AllowedOrigins in production, got %q", cfg.AllowedOrigins)
+	}
+	if cfg.DebugRoutes {
+		t.Error("expected DebugRoutes = false in production")
+	}
+	if cfg.LogLevel != "normal" || cfg.LogFormat != "json" {
+		t.Errorf("expected the production log defaults, got %s %s", cfg.LogLevel, cfg.LogFormat)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build embed
+
+package config
+
+// embedStaticDefault is the default for EmbedStatic - single binary builds serve embedded assets
+const embedStaticDefault = true
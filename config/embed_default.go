@@ -0,0 +1,6 @@
+//go:build !embed
+
+package config
+
+// embedStaticDefault is the default for EmbedStatic - build with -tags embed to default to embedded assets
+const embedStaticDefault = false
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"mookie/internal/crypto"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	Encrypted values: secrets in the config file can be stored encrypted as enc:<ciphertext> and
+	are decrypted at load time, so credentials in config.toml aren't plaintext on disk. Values are
+	encrypted with AES-256-GCM using the versioned keys of the crypto package.
+
+	How to use:
+	1. Generate a key with `openssl rand -base64 32` and set MOOKIE_CONFIG_KEY="1:<key>", or put
+	   it in a file and set MOOKIE_CONFIG_KEY_FILE
+	2. Encrypt a value with `echo -n 'secret' | mookie -encrypt-value`
+	3. Paste the output into the config, e.g. Password = 'enc:v1:...'
+
+	Notes:
+	- Keep the key out of the config file and version control, it decrypts every value
+	- Several keys can be set separated by whitespace for rotation, new values use the highest version
+	- Environment variables may also hold encrypted values
+	- -print-config masks encrypted values even for keys that aren't tagged as secret
+*/
+
+// encryptedPrefix marks encrypted config values
+const encryptedPrefix = "enc:"
+
+// encryptedData binds encrypted values to the config, see crypto.Keyring.Encrypt
+var encryptedData = []byte("config")
+
+// ErrNoConfigKey is returned for encrypted values when neither MOOKIE_CONFIG_KEY nor MOOKIE_CONFIG_KEY_FILE is set
+var ErrNoConfigKey = errors.New("config: encrypted values need MOOKIE_CONFIG_KEY or MOOKIE_CONFIG_KEY_FILE")
+
+// EncryptValue encrypts a config value with the key from MOOKIE_CONFIG_KEY or MOOKIE_CONFIG_KEY_FILE
+func EncryptValue(plaintext string) (string, error) {
+	keyring, err := configKeyring()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := keyring.EncryptString(plaintext, encryptedData)
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + sealed, nil
+}
+
+// configKeyring creates a keyring from MOOKIE_CONFIG_KEY or the file in MOOKIE_CONFIG_KEY_FILE
+func configKeyring() (*crypto.Keyring, error) {
+	value, ok := os.LookupEnv("MOOKIE_CONFIG_KEY")
+	if path, set := os.LookupEnv("MOOKIE_CONFIG_KEY_FILE"); set {
+		if ok {
+			return nil, errors.New("config: both MOOKIE_CONFIG_KEY and MOOKIE_CONFIG_KEY_FILE are set")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: MOOKIE_CONFIG_KEY_FILE: %w", err)
+		}
+		value = string(data)
+	}
+	if strings.TrimSpace(value) == "" {
+		return nil, ErrNoConfigKey
+	}
+	keys, err := crypto.ParseKeys(strings.Fields(value))
+	if err != nil {
+		return nil, fmt.Errorf("config: MOOKIE_CONFIG_KEY: %w", err)
+	}
+	return crypto.NewKeyring(keys)
+}
+
+// decryptValues replaces the encrypted string values of all keys with their plaintext
+// The keyring is only created if there are encrypted values
+func decryptValues(v *viper.Viper) error {
+	var keyring *crypto.Keyring
+	var failed []string
+	names := newKeyNames()
+	decrypt := func(key, value string) string {
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			return value
+		}
+		if keyring == nil {
+			var err error
+			if keyring, err = configKeyring(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", names.of(key), err))
+				return value
+			}
+		}
+		plain, err := keyring.DecryptString(strings.TrimPrefix(value, encryptedPrefix), encryptedData)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", names.of(key), err))
+			return value
+		}
+		return plain
+	}
+
+	for _, key := range v.AllKeys() {
+		switch value := v.Get(key).(type) {
+		case string:
+			if plain := decrypt(key, value); plain != value {
+				v.Set(key, plain)
+			}
+		case []any:
+			decrypted := make([]any, len(value))
+			for i, item := range value {
+				if s, ok := item.(string); ok {
+					decrypted[i] = decrypt(key, s)
+				} else {
+					decrypted[i] = item
+				}
+			}
+			v.Set(key, decrypted)
+		case []string:
+			decrypted := make([]string, len(value))
+			for i, item := range value {
+				decrypted[i] = decrypt(key, item)
+			}
+			v.Set(key, decrypted)
+		}
+		// Stop after the first missing key instead of reporting it for every value
+		if keyring == nil && len(failed) > 0 {
+			break
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("error decrypting config: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// encrypted reports whether a raw config value is encrypted
+func encrypted(value any) bool {
+	switch value := value.(type) {
+	case string:
+		return strings.HasPrefix(value, encryptedPrefix)
+	case []any:
+		for _, item := range value {
+			if encrypted(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
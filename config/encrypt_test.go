@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mookie/internal/crypto"
+)
+
+// setConfigKey sets MOOKIE_CONFIG_KEY to a new key and returns it
+func setConfigKey(t *testing.T, version int) crypto.Key {
+	t.Helper()
+	key, err := crypto.GenerateKey(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MOOKIE_CONFIG_KEY", key.String())
+	return key
+}
+
+func TestDecryptValues(t *testing.T) {
+	key := setConfigKey(t, 1)
+	password, err := EncryptValue("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(password, "enc:v1:") {
+		t.Fatalf("expected an enc:v1: value, got %q", password)
+	}
+	price, _ := EncryptValue("price_b")
+	config := "[Mail]\nPassword = '" + password + "'\n[Payments]\nPrices = ['price_a', '" + price + "']\n"
+
+	// Flip a character in the middle, the last ones may only carry padding bits
+	flipped := byte('A')
+	if password[20] == 'A' {
+		flipped = 'B'
+	}
+	tampered := password[:20] + string(flipped) + password[21:]
+
+	other, _ := crypto.GenerateKey(1)
+	rotated, _ := crypto.GenerateKey(2)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(key.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		unset   string
+		config  string
+		wantErr string
+	}{
+		{name: "key", env: map[string]string{"MOOKIE_CONFIG_KEY": key.String()}, config: config},
+		{name: "key file", env: map[string]string{"MOOKIE_CONFIG_KEY_FILE": keyFile}, unset: "MOOKIE_CONFIG_KEY", config: config},
+		{name: "key and key file", env: map[string]string{"MOOKIE_CONFIG_KEY": key.String(), "MOOKIE_CONFIG_KEY_FILE": keyFile}, config: config, wantErr: "both MOOKIE_CONFIG_KEY and MOOKIE_CONFIG_KEY_FILE are set"},
+		{name: "rotated keys", env: map[string]string{"MOOKIE_CONFIG_KEY": key.String() + " " + rotated.String()}, config: config},
+		{name: "environment variable", env: map[string]string{"MOOKIE_CONFIG_KEY": key.String(), "MOOKIE_MAIL_PASSWORD": password}, config: "[Payments]\nPrices = ['price_a', '" + price + "']\n"},
+		{name: "wrong key", env: map[string]string{"MOOKIE_CONFIG_KEY": other.String()}, config: config, wantErr: "Mail.Password: " + crypto.ErrInvalid.Error()},
+		{name: "unknown version", env: map[string]string{"MOOKIE_CONFIG_KEY": rotated.String()}, config: config, wantErr: "Mail.Password: " + crypto.ErrUnknownVersion.Error()},
+		{name: "tampered value", env: map[string]string{"MOOKIE_CONFIG_KEY": key.String()}, config: "[Mail]\nPassword = '" + tampered + "'\n", wantErr: "Mail.Password: " + crypto.ErrInvalid.Error()},
+		{name: "no key", env: map[string]string{"MOOKIE_CONFIG_KEY": ""}, config: config, wantErr: ErrNoConfigKey.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.unset != "" {
+				// t.Setenv restores the variable after the test
+				t.Setenv(tt.unset, "")
+				os.Unsetenv(tt.unset)
+			}
+			v, err := loadTestConfig(t, tt.config, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error about %s, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.GetString("Mail.Password"); got != "s3cret" {
+				t.Errorf("expected the decrypted password, got %q", got)
+			}
+			if got := v.GetStringSlice("Payments.Prices"); len(got) != 2 || got[0] != "price_a" || got[1] != "price_b" {
+				t.Errorf("expected the decrypted prices, got %q", got)
+			}
+		})
+	}
+}
+
+func TestEncryptValue_NoKey(t *testing.T) {
+	t.Setenv("MOOKIE_CONFIG_KEY", "")
+	if _, err := EncryptValue("s3cret"); !errors.Is(err, ErrNoConfigKey) {
+		t.Errorf("expected ErrNoConfigKey, got %v", err)
+	}
+}
+
+func TestConfig_LogValue(t *testing.T) {
+	setConfigKey(t, 1)
+	password, err := EncryptValue("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	config := "[Mail]\nHost = 'smtp.example.com'\nPassword = '" + password + "'\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path, nil)
+	if err != nil {
+		t.Fatalf("loadConfig returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("Loaded config", "config", cfg)
+	logged := buf.String()
+	if strings.Contains(logged, "s3cret") {
+		t.Errorf("expected the decrypted password to be masked, got %s", logged)
+	}
+	for _, want := range []string{`config.Mail.Password=********`, `config.Mail.Host=smtp.example.com`, `config.Captcha.SecretKey=""`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %s in the log, got %s", want, logged)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	Effective config: Explain lists every config key with its resolved value and the source that
+	won - the default, the config file, an overlay or the remote, a flag or an environment variable. Used by -print-config
+	to debug which value overrides which.
+
+	Example printing the settings:
+		settings, err := config.Explain("config.toml", nil)
+		for _, s := range settings {
+			fmt.Printf("%s = %s (%s)\n", s.Key, s.Value, s.Source)
+		}
+
+	Notes:
+	- Values of fields tagged secret:"true" and encrypted values are masked, empty secrets stay empty
+	  to show they're unset
+	- Explain doesn't create a missing config file or validate the config, see NewWithPath and Validate
+	- Config is a slog.LogValuer logging the values with the same masking, the values are decrypted by
+	  then so only tagged fields are masked
+*/
+
+// Sources of config values
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceRemote  = "remote"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// secretMask replaces the values of secret fields
+const secretMask = "********"
+
+// Setting is a resolved config value and where it came from
+type Setting struct {
+	// Key is the config key, e.g. "Mail.Password"
+	Key   string
+	Value string
+	// Source is one of the Source constants
+	Source string
+	// Origin names the source, e.g. the file path or the environment variable
+	Origin string
+	Secret bool
+}
+
+// Explain resolves the config like NewWithRemote, a nil remote reads only the file,
+// and returns the settings in the order of the Config struct followed by the registered sections
+func Explain(configPath string, remote *Remote) ([]Setting, error) {
+	source, origin := SourceFile, configPath
+	v, err := newViper(configPath, remote)
+	if remote != nil {
+		source, origin = SourceRemote, remote.String()
+		if errors.Is(err, ErrRemoteUnavailable) {
+			source, origin = SourceFile, configPath
+			v, err = newViper(configPath, nil)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.sections, err = decodeSections(v); err != nil {
+		return nil, err
+	}
+	// Read the file and its overlays separately to tell which one sets a key, the last one wins
+	var files []*viper.Viper
+	if source == SourceFile {
+		if files, err = readFiles(configPath); err != nil {
+			return nil, err
+		}
+	}
+	// The raw remote values tell which values were encrypted
+	var remoteValues *viper.Viper
+	if source == SourceRemote {
+		remoteValues = viper.New()
+		remoteValues.SetConfigType("toml")
+		remote.mu.Lock()
+		err := remoteValues.ReadConfig(bytes.NewReader(remote.last))
+		remote.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flags := setFlags()
+
+	var settings []Setting
+	explain := func(key string, field reflect.StructField, value reflect.Value) {
+		s := Setting{Key: key, Value: formatValue(value), Source: SourceDefault, Secret: field.Tag.Get("secret") == "true"}
+		env := strings.ToUpper(v.GetEnvPrefix() + "_" + strings.ReplaceAll(key, ".", "_"))
+		var raw any
+		switch {
+		case os.Getenv(env) != "":
+			s.Source, s.Origin = SourceEnv, env
+			raw = os.Getenv(env)
+		case envSet(env + "_FILE"):
+			s.Source, s.Origin = SourceEnv, env+"_FILE"
+		case flags[strings.ToLower(key)].name != "":
+			s.Source, s.Origin = SourceFlag, "-"+flags[strings.ToLower(key)].name
+		case v.InConfig(key):
+			s.Source, s.Origin = source, origin
+			if remoteValues != nil {
+				raw = remoteValues.Get(key)
+			}
+			for _, file := range files {
+				if file.InConfig(key) {
+					s.Origin = file.ConfigFileUsed()
+					raw = file.Get(key)
+				}
+			}
+		}
+		// Encrypted values are secret even without the tag
+		s.Secret = s.Secret || encrypted(raw)
+		if s.Secret && !value.IsZero() {
+			s.Value = secretMask
+		}
+		settings = append(settings, s)
+	}
+	walkSettings(reflect.ValueOf(cfg), "", explain)
+	for _, s := range registeredSections() {
+		walkSettings(sectionWrapper(s.name, cfg.sections[s.name]), "", explain)
+	}
+	return settings, nil
+}
+
+// LogValue logs the settings with the values of secret fields masked, so logging the config doesn't leak them
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	log := func(key string, field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && !value.IsZero() {
+			attrs = append(attrs, slog.String(key, secretMask))
+			return
+		}
+		attrs = append(attrs, slog.Any(key, value.Interface()))
+	}
+	walkSettings(reflect.ValueOf(c).Elem(), "", log)
+	for _, s := range registeredSections() {
+		if section, ok := c.sections[s.name]; ok {
+			walkSettings(sectionWrapper(s.name, section), "", log)
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// readFiles reads the config file and each existing overlay into its own viper instance, in merge order
+func readFiles(configPath string) ([]*viper.Viper, error) {
+	overlays, err := overlayPaths(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []*viper.Viper
+	for _, path := range append([]string{configPath}, overlays...) {
+		v := viper.New()
+		v.SetConfigFile(path)
+		v.SetConfigType("toml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading config %s: %w", path, err)
+		}
+		files = append(files, v)
+	}
+	return files, nil
+}
+
+// walkSettings calls fn for every exported field, named by its mapstructure tag, descending into sections
+func walkSettings(value reflect.Value, prefix string, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			name = field.Name
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			walkSettings(value.Field(i), key+".", fn)
+			continue
+		}
+		fn(key, field, value.Field(i))
+	}
+}
+
+// formatValue formats a value like the config file, strings quoted and lists in brackets
+func formatValue(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return strconv.Quote(value.String())
+	case reflect.Slice:
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = formatValue(value.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// envSet reports whether the environment variable is set, also when it's empty like applyFileEnv checks it
+func envSet(name string) bool {
+	_, ok := os.LookupEnv(name)
+	return ok
+}
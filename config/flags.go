@@ -0,0 +1,94 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	Command line flags: BindFlag registers a flag that sets a config key, so common settings can be
+	changed without editing the config, e.g. `./mookie -port 9090`.
+
+	Example binding the standard flags and a custom one:
+		config.BindFlags(flag.CommandLine)   // -port, -bind, -db, -log-level
+		config.BindFlag(flag.CommandLine, "metrics", "Metrics.Enabled", "serve the metrics endpoint")
+		flag.Parse()
+		cfg, err := config.NewWithPath("config.toml")
+
+	Notes:
+	- Flags override the config file and its overlays but not environment variables, so a value set
+	  explicitly in the environment of a deployment still wins
+	- Only flags given on the command line override the config, unset flags have no default
+	- Values are converted like config file values, -port 9090 sets the int Port, bool flags need
+	  a value, e.g. -metrics=true
+*/
+
+// flagBinding is a flag registered by BindFlag
+type flagBinding struct {
+	fs   *flag.FlagSet
+	name string
+	key  string
+}
+
+var (
+	flagsMu      sync.Mutex
+	flagBindings []flagBinding
+)
+
+// BindFlag registers the flag name on fs that sets the config key when it is given
+func BindFlag(fs *flag.FlagSet, name, key, usage string) {
+	fs.String(name, "", usage+" - overrides "+key)
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	flagBindings = append(flagBindings, flagBinding{fs: fs, name: name, key: key})
+}
+
+// BindFlags registers the standard flags -port, -bind, -db and -log-level on fs
+func BindFlags(fs *flag.FlagSet) {
+	BindFlag(fs, "port", "Port", "port to listen on")
+	BindFlag(fs, "bind", "BindAddress", "address to listen on")
+	BindFlag(fs, "db", "DatabasePath", "path to the SQLite database")
+	BindFlag(fs, "log-level", "LogLevel", "log level, normal or debug")
+}
+
+// flagValue is the value of a bound flag given on the command line
+type flagValue struct {
+	name  string
+	value string
+}
+
+// setFlags returns the value of every bound flag given on the command line by config key
+func setFlags() map[string]flagValue {
+	flagsMu.Lock()
+	defer flagsMu.Unlock()
+	values := map[string]flagValue{}
+	for _, b := range flagBindings {
+		b.fs.Visit(func(f *flag.Flag) {
+			if f.Name == b.name {
+				values[strings.ToLower(b.key)] = flagValue{name: b.name, value: f.Value.String()}
+			}
+		})
+	}
+	return values
+}
+
+// applyFlags sets the keys of the bound flags given on the command line, unless an environment
+// variable sets the key
+func applyFlags(v *viper.Viper) {
+	for key, f := range setFlags() {
+		if envOverrides(v, key) {
+			continue
+		}
+		v.Set(key, f.value)
+	}
+}
+
+// envOverrides reports whether MOOKIE_<KEY> or MOOKIE_<KEY>_FILE sets the key
+func envOverrides(v *viper.Viper, key string) bool {
+	env := strings.ToUpper(v.GetEnvPrefix() + "_" + strings.ReplaceAll(key, ".", "_"))
+	return os.Getenv(env) != "" || envSet(env+"_FILE")
+}
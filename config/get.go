@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	Custom keys: services read keys they add to config.toml with Get, without adding fields to the
+	Config struct. Values are resolved like the built-in keys - environment variables, including
+	_FILE variables for keys in the file, override the file.
+
+	Example config.toml section:
+		[Newsletter]
+		ListID = 'weekly'
+		BatchSize = 500
+		Interval = '1h'
+
+	Example reading it:
+		listID, err := config.Get[string]("Newsletter.ListID")
+		batchSize, err := config.Get[int]("Newsletter.BatchSize")
+		interval, err := config.Get[time.Duration]("Newsletter.Interval")
+
+		// Whole sections decode into structs with mapstructure tags
+		type Newsletter struct {
+			ListID    string `mapstructure:"ListID"`
+			BatchSize int    `mapstructure:"BatchSize"`
+		}
+		newsletter, err := config.Get[Newsletter]("Newsletter")
+
+	Notes:
+	- Get reads the most recently loaded config, including reloads by Watch
+	- Keys are case insensitive, MOOKIE_NEWSLETTER_BATCHSIZE overrides Newsletter.BatchSize
+	- Values are converted weakly, '500' reads as an int and 'a,b' as a []string
+	- Sections read as structs get the file values, environment variables only override single keys
+*/
+
+var (
+	// ErrNotLoaded is returned by Get before a config was loaded
+	ErrNotLoaded = errors.New("config: no config loaded")
+	// ErrNotSet is returned by Get for keys that are neither in the config nor in the environment
+	ErrNotSet = errors.New("config: key not set")
+)
+
+// current is the viper instance of the most recently loaded config
+var current atomic.Pointer[viper.Viper]
+
+// Get returns the value of a key of the most recently loaded config converted to T,
+// ErrNotSet if the key isn't set
+func Get[T any](key string) (T, error) {
+	var value T
+	v := current.Load()
+	if v == nil {
+		return value, ErrNotLoaded
+	}
+	if !v.IsSet(key) {
+		return value, fmt.Errorf("%w: %s", ErrNotSet, key)
+	}
+	if err := v.UnmarshalKey(key, &value); err != nil {
+		return value, fmt.Errorf("config: invalid value of %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// GetOr returns the value of a key like Get, or fallback if the key isn't set
+func GetOr[T any](key string, fallback T) (T, error) {
+	value, err := Get[T](key)
+	if errors.Is(err, ErrNotSet) {
+		return fallback, nil
+	}
+	return value, err
+}
+
+// publish makes the config the one read by Get and fills the registered sections
+func (c *Config) publish() {
+	if c.viper != nil {
+		current.Store(c.viper)
+	}
+	c.fillSections()
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	Remote config: the TOML config is stored under a key in Consul or etcd instead of a local
+	file. Environment variables and defaults apply on top of it as with the file.
+
+	Remote URLs:
+	- consul://127.0.0.1:8500/mookie/config - Consul KV key mookie/config over HTTP
+	- etcd://127.0.0.1:2379/mookie/config - etcd v3 key /mookie/config over the JSON gateway
+	- consul+https://... and etcd+https://... use HTTPS
+
+	Example loading with a fallback to the local file:
+		remote, err := config.ParseRemote("consul://127.0.0.1:8500/mookie/config", token)
+		cfg, err := config.NewWithRemote("config.toml", remote, func(err error) {
+			log.Printf("remote config unavailable, using config.toml: %v", err)
+		})
+
+	Notes:
+	- The local file is only used when the remote can't be reached or the key doesn't exist,
+	  a remote config that fails to parse or validate is an error
+	- Watch polls the remote every PollInterval instead of watching the file, and reloads
+	  when the stored value changes
+	- The token is sent as X-Consul-Token to Consul and as Authorization to etcd
+*/
+
+// ErrRemoteUnavailable is returned when the remote can't be reached or the key doesn't exist
+var ErrRemoteUnavailable = errors.New("config: remote config unavailable")
+
+// Remote providers
+const (
+	RemoteConsul = "consul"
+	RemoteEtcd   = "etcd"
+)
+
+// Remote is a config stored under a key in Consul or etcd
+type Remote struct {
+	Provider string
+	// Endpoint is the base URL of the provider's HTTP API
+	Endpoint string
+	Key      string
+	Token    string
+	// PollInterval is how often Watch checks for changes, defaults to 30 seconds
+	PollInterval time.Duration
+	// Client sends the requests, defaults to a client with a 10 second timeout
+	Client *http.Client
+
+	mu   sync.Mutex
+	last []byte
+}
+
+// ParseRemote parses a remote URL like consul://host:port/key, see the package notes for the formats
+func ParseRemote(rawURL, token string) (*Remote, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid remote URL: %w", err)
+	}
+	provider, scheme, _ := strings.Cut(u.Scheme, "+")
+	if scheme == "" {
+		scheme = "http"
+	}
+	if provider != RemoteConsul && provider != RemoteEtcd {
+		return nil, fmt.Errorf("config: unknown remote provider %q, use consul or etcd", provider)
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("config: unknown remote scheme %q", u.Scheme)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, fmt.Errorf("config: remote URL %q needs a host and a key", rawURL)
+	}
+	if provider == RemoteEtcd {
+		// etcd keys are conventionally absolute
+		key = "/" + key
+	}
+	return &Remote{Provider: provider, Endpoint: scheme + "://" + u.Host, Key: key, Token: token}, nil
+}
+
+// String returns the remote as a URL without the token
+func (r *Remote) String() string {
+	return r.Provider + "+" + r.Endpoint + "/" + strings.TrimPrefix(r.Key, "/")
+}
+
+// NewWithRemote loads the config from the remote, or from the file at configPath if the remote
+// is unavailable - fallback is called with the reason before the file is loaded
+func NewWithRemote(configPath string, remote *Remote, fallback func(error)) (*Config, error) {
+	cfg, err := loadConfig(configPath, remote)
+	if errors.Is(err, ErrRemoteUnavailable) {
+		if fallback != nil {
+			fallback(err)
+		}
+		cfg, err = NewWithPath(configPath)
+		if err != nil {
+			return nil, err
+		}
+		// Keep watching the remote, the file is only a fallback
+		cfg.watcher.remote = remote
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", remote, err)
+	}
+	cfg.watcher = &watcher{path: configPath, remote: remote, current: cfg}
+	cfg.publish()
+	return cfg, nil
+}
+
+// Fetch returns the stored config
+func (r *Remote) Fetch(ctx context.Context) ([]byte, error) {
+	var data []byte
+	var err error
+	switch r.Provider {
+	case RemoteConsul:
+		data, err = r.fetchConsul(ctx)
+	case RemoteEtcd:
+		data, err = r.fetchEtcd(ctx)
+	default:
+		err = fmt.Errorf("config: unknown remote provider %q", r.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.last = data
+	r.mu.Unlock()
+	return data, nil
+}
+
+// changed fetches the stored config and reports whether it differs from the last fetched one
+func (r *Remote) changed(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	last := r.last
+	r.mu.Unlock()
+
+	data, err := r.Fetch(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(data, last), nil
+}
+
+// fetchConsul reads the raw value of a Consul KV key
+func (r *Remote) fetchConsul(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.Endpoint+"/v1/kv/"+r.Key+"?raw", nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Token != "" {
+		req.Header.Set("X-Consul-Token", r.Token)
+	}
+	return r.do(req)
+}
+
+// fetchEtcd reads the value of an etcd key through the v3 JSON gateway
+func (r *Remote) fetchEtcd(ctx context.Context) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(r.Key))})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Endpoint+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Token != "" {
+		req.Header.Set("Authorization", r.Token)
+	}
+	data, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Kvs []struct {
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("config: invalid etcd response: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("%w: etcd key %s not found", ErrRemoteUnavailable, r.Key)
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+// do sends the request and returns the body of a successful response
+// Network errors, missing keys and server errors are ErrRemoteUnavailable
+func (r *Remote) do(req *http.Request) ([]byte, error) {
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRemoteUnavailable, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s key %s not found", ErrRemoteUnavailable, r.Provider, r.Key)
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: %s responded with %d", ErrRemoteUnavailable, r.Provider, resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("config: %s responded with %d: %s", r.Provider, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
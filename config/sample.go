@@ -0,0 +1,142 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Sample config: WriteSample writes a config file with every key set to its default and commented
+	with its description, type, validation rules and environment variable. It is generated from the
+	desc and validate tags of the Config struct and the registered sections, so it can't go stale.
+
+	Example writing a sample config:
+		f, err := os.Create("config.sample.toml")
+		err = config.WriteSample(f)
+
+	Notes:
+	- Describe new fields with a desc tag, fields without one are written without a description
+	- NewWithPath writes the sample when the config file doesn't exist
+	- Keys whose default depends on Env are commented out, so the file doesn't pin the development values
+*/
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// WriteSample writes a commented config file with the default values to w
+func WriteSample(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("# mookie config - generated with mookie -gen-config\n")
+	buf.WriteString("# Environment variables override the file, and MOOKIE_<KEY>_FILE reads a value from a file\n")
+
+	writeTable(&buf, reflect.ValueOf(*getDefaultConfig()), "", "")
+	for _, s := range registeredSections() {
+		writeTable(&buf, s.defaults, s.name, "Registered by RegisterSection")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeTable writes the keys of a struct value, then its nested structs as tables
+func writeTable(buf *bytes.Buffer, value reflect.Value, prefix, desc string) {
+	if prefix != "" {
+		buf.WriteString("\n")
+		if desc != "" {
+			fmt.Fprintf(buf, "# %s\n", desc)
+		}
+		fmt.Fprintf(buf, "[%s]\n", prefix)
+	}
+
+	var tables []func()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" {
+			name = field.Name
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			// TOML tables must follow the keys of their parent
+			fieldValue, desc := value.Field(i), field.Tag.Get("desc")
+			tables = append(tables, func() { writeTable(buf, fieldValue, key, desc) })
+			continue
+		}
+
+		if desc := field.Tag.Get("desc"); desc != "" {
+			fmt.Fprintf(buf, "\n# %s\n", desc)
+		} else {
+			buf.WriteString("\n")
+		}
+		details := []string{typeName(field.Type)}
+		if rules := field.Tag.Get("validate"); rules != "" {
+			details = append(details, "rules "+rules)
+		}
+		if field.Tag.Get("secret") == "true" {
+			details = append(details, "secret, may be encrypted with -encrypt-value")
+		}
+		details = append(details, "env MOOKIE_"+strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+		fmt.Fprintf(buf, "# %s\n", strings.Join(details, ", "))
+		if envKeys[key] {
+			// The default follows Env, the value written is the development one
+			fmt.Fprintf(buf, "# %s = %s\n", name, tomlValue(value.Field(i)))
+			continue
+		}
+		fmt.Fprintf(buf, "%s = %s\n", name, tomlValue(value.Field(i)))
+	}
+	for _, table := range tables {
+		table()
+	}
+}
+
+// typeName describes the type of a config field
+func typeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration, e.g. '1h30m'"
+	case t.Kind() == reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "float"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "int"
+	}
+	return t.Kind().String()
+}
+
+// tomlValue formats a value as TOML
+func tomlValue(value reflect.Value) string {
+	if value.Type() == durationType {
+		return "'" + time.Duration(value.Int()).String() + "'"
+	}
+	switch value.Kind() {
+	case reflect.String:
+		s := value.String()
+		if strings.ContainsAny(s, "'\n") {
+			return strconv.Quote(s)
+		}
+		return "'" + s + "'"
+	case reflect.Float32, reflect.Float64:
+		s := strconv.FormatFloat(value.Float(), 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case reflect.Slice:
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = tomlValue(value.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value.Interface())
+}
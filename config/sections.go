@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	Config sections: code in services/ declares its own typed config sections with RegisterSection
+	instead of adding fields to the Config struct. A section is read from the table of the same
+	name in the config file, like the built-in sections.
+
+	Example declaring a section:
+		// NewsletterConfig configures the newsletter service
+		type NewsletterConfig struct {
+			ListID    string        `mapstructure:"ListID" validate:"required"`
+			BatchSize int           `mapstructure:"BatchSize" validate:"min=1"`
+			Interval  time.Duration `mapstructure:"Interval"`
+		}
+
+		// The values at registration are the defaults
+		var Newsletter = NewsletterConfig{BatchSize: 500, Interval: time.Hour}
+
+		func init() {
+			config.RegisterSection("Newsletter", &Newsletter)
+		}
+
+	Example config.toml section:
+		[Newsletter]
+		ListID = 'weekly'
+
+	Notes:
+	- Register sections before the config is loaded - in an init function or before setupDependencies
+	- Sections get everything the built-in sections get: environment variables like
+	  MOOKIE_NEWSLETTER_BATCHSIZE, _FILE variables, ${VAR} expansion, validate tags, -print-config
+	- The target is filled after the config is valid and again on every reload, before the OnChange
+	  subscribers run - read it at startup or from an OnChange subscriber if the config is watched
+	- Fields without a mapstructure tag use the field name, tag secret fields with secret:"true"
+*/
+
+// section is a config section registered by RegisterSection
+type section struct {
+	name string
+	// target points to the struct filled from the config
+	target reflect.Value
+	// defaults is a copy of the target at registration
+	defaults reflect.Value
+}
+
+var (
+	sectionsMu sync.Mutex
+	sections   []*section
+)
+
+// RegisterSection declares a config section read into target, which must be a pointer to a struct
+// The values of target at registration are the defaults, it panics for invalid or duplicate sections
+func RegisterSection(name string, target any) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config: section %s target must be a non-nil pointer to a struct, got %T", name, target))
+	}
+	if name == "" || strings.Contains(name, ".") {
+		panic(fmt.Sprintf("config: invalid section name %q", name))
+	}
+	if _, exists := reflect.TypeOf(Config{}).FieldByNameFunc(func(field string) bool {
+		return strings.EqualFold(field, name)
+	}); exists {
+		panic(fmt.Sprintf("config: section %s is a built-in config section", name))
+	}
+
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	for _, s := range sections {
+		if strings.EqualFold(s.name, name) {
+			panic(fmt.Sprintf("config: section %s registered twice", name))
+		}
+	}
+	defaults := reflect.New(value.Elem().Type()).Elem()
+	defaults.Set(value.Elem())
+	sections = append(sections, &section{name: name, target: value, defaults: defaults})
+}
+
+// registeredSections returns a copy of the registered sections
+func registeredSections() []*section {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	return append([]*section(nil), sections...)
+}
+
+// setSectionDefaults sets the defaults of the registered sections, so their keys are known to viper
+// for environment variables and _FILE variables
+func setSectionDefaults(v *viper.Viper) {
+	for _, s := range registeredSections() {
+		walkSettings(s.defaults, s.name+".", func(key string, _ reflect.StructField, value reflect.Value) {
+			v.SetDefault(key, value.Interface())
+		})
+	}
+}
+
+// decodeSections decodes the registered sections from the resolved values, without filling the targets
+func decodeSections(v *viper.Viper) (map[string]reflect.Value, error) {
+	// AllSettings includes environment variables, unlike UnmarshalKey of a section
+	settings := v.AllSettings()
+	decoded := map[string]reflect.Value{}
+	for _, s := range registeredSections() {
+		values, _ := settings[strings.ToLower(s.name)].(map[string]any)
+		sub := viper.New()
+		if err := sub.MergeConfigMap(values); err != nil {
+			return nil, err
+		}
+		value := reflect.New(s.defaults.Type())
+		value.Elem().Set(s.defaults)
+		if err := sub.Unmarshal(value.Interface()); err != nil {
+			return nil, fmt.Errorf("error reading config section %s: %w", s.name, err)
+		}
+		decoded[s.name] = value.Elem()
+	}
+	return decoded, nil
+}
+
+// fillSections copies the decoded sections of the config into the registered targets
+func (c *Config) fillSections() {
+	for _, s := range registeredSections() {
+		if value, ok := c.sections[s.name]; ok {
+			s.target.Elem().Set(value)
+		}
+	}
+}
+
+// sectionWrapper returns the decoded section as the field of a struct named after the section,
+// so validation errors and settings are reported with the section prefix, e.g. "Newsletter.BatchSize"
+func sectionWrapper(name string, value reflect.Value) reflect.Value {
+	wrapper := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Section",
+		Type: value.Type(),
+		Tag:  reflect.StructTag(fmt.Sprintf(`json:%q mapstructure:%q`, name, name)),
+	}})).Elem()
+	wrapper.Field(0).Set(value)
+	return wrapper
+}
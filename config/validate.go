@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"mookie/internal/crypto"
+	"mookie/internal/validate"
+	"net/netip"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validator checks the validate tags of the config structs
+var validator = newValidator()
+
+// newValidator creates a validator with the config specific rules
+func newValidator() *validate.Validator {
+	v := validate.New()
+	v.Register("writable", func(value reflect.Value, _ string) bool {
+		return writable(value.String())
+	}, "{field} must be a writable file path")
+	v.Register("proxies", func(value reflect.Value, _ string) bool {
+		for i := range value.Len() {
+			if _, err := ParseProxy(value.Index(i).String()); err != nil {
+				return false
+			}
+		}
+		return true
+	}, "{field} must list IPs or CIDRs")
+	return v
+}
+
+// Validate checks the config and returns a validate.Errors listing every invalid field,
+// field names are the config keys, e.g. "TLS.CertFile"
+// Rules that depend on other fields are checked in code, single field rules are validate tags
+func (c *Config) Validate() error {
+	errs := validate.Errors{}
+	if err := validator.Struct(context.Background(), c); err != nil {
+		if errs = validate.As(err); errs == nil {
+			return err
+		}
+	}
+
+	for name, value := range c.sections {
+		err := validator.Struct(context.Background(), sectionWrapper(name, value).Interface())
+		if sectionErrs := validate.As(err); sectionErrs != nil {
+			for field, msg := range sectionErrs {
+				errs.Add(field, msg)
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	errs.Check("DatabasePath", c.DatabasePath == "" || writable(databaseFile(c.DatabasePath)), "DatabasePath must be a writable file path")
+
+	if c.TLS.Enabled && !c.TLS.AutoCert {
+		errs.Check("TLS.CertFile", c.TLS.CertFile != "", "TLS.CertFile is required when TLS is enabled without AutoCert")
+		errs.Check("TLS.KeyFile", c.TLS.KeyFile != "", "TLS.KeyFile is required when TLS is enabled without AutoCert")
+	}
+	if c.TLS.Enabled && c.TLS.AutoCert {
+		errs.Check("TLS.Domains", len(c.TLS.Domains) > 0, "TLS.Domains is required with AutoCert")
+		errs.Check("TLS.CacheDir", c.TLS.CacheDir != "", "TLS.CacheDir is required with AutoCert")
+	}
+	keys, err := crypto.ParseKeys(c.Crypto.Keys)
+	if err == nil && len(keys) > 0 {
+		_, err = crypto.NewKeyring(keys)
+	}
+	errs.Check("Crypto.Keys", err == nil, "Crypto.Keys must be 32 byte keys as version:base64 with unique versions")
+	errs.Check("Metrics.Path", strings.HasPrefix(c.Metrics.Path, "/"), "Metrics.Path must start with /")
+	if c.Socket.Path != "" {
+		_, err := strconv.ParseUint(c.Socket.Mode, 8, 32)
+		errs.Check("Socket.Mode", err == nil, "Socket.Mode must be an octal file mode, e.g. 0660")
+	}
+	errs.Check("Mail.Host", c.Mail.Transport != "smtp" || c.Mail.Host != "", "Mail.Host is required with the smtp transport")
+	errs.Check("Mail.MaildirPath", c.Mail.Transport != "maildir" || c.Mail.MaildirPath != "", "Mail.MaildirPath is required with the maildir transport")
+	errs.Check("Tracing.Endpoint", !c.Tracing.Enabled || c.Tracing.Endpoint != "", "Tracing.Endpoint is required when tracing is enabled")
+	errs.Check("Search.BlevePath", !c.Search.Enabled || c.Search.Backend != "bleve" || c.Search.BlevePath != "", "Search.BlevePath is required with the bleve backend")
+	errs.Check("RateLimit.WindowSeconds", !c.RateLimit.Enabled || c.RateLimit.WindowSeconds > 0, "RateLimit.WindowSeconds must be at least 1 when rate limiting is enabled")
+	errs.Check("RateLimit.LoginWindowSeconds", c.RateLimit.LoginAttempts == 0 || c.RateLimit.LoginWindowSeconds > 0, "RateLimit.LoginWindowSeconds must be at least 1 when LoginAttempts is set")
+	if c.Captcha.Provider != "" {
+		errs.Check("Captcha.SiteKey", c.Captcha.SiteKey != "", "Captcha.SiteKey is required with a captcha provider")
+		errs.Check("Captcha.SecretKey", c.Captcha.SecretKey != "", "Captcha.SecretKey is required with a captcha provider")
+	}
+	if c.Messaging.SMSProvider == "twilio" {
+		errs.Check("Messaging.TwilioAccountSID", c.Messaging.TwilioAccountSID != "", "Messaging.TwilioAccountSID is required with the twilio SMS provider")
+		errs.Check("Messaging.TwilioAuthToken", c.Messaging.TwilioAuthToken != "", "Messaging.TwilioAuthToken is required with the twilio SMS provider")
+		errs.Check("Messaging.TwilioFrom", c.Messaging.TwilioFrom != "", "Messaging.TwilioFrom is required with the twilio SMS provider")
+	}
+	errs.Check("Messaging.VAPIDSubject", c.Messaging.VAPIDPrivateKey == "" || c.Messaging.VAPIDSubject != "", "Messaging.VAPIDSubject is required with VAPID keys")
+	if c.Payments.StripeSecretKey != "" {
+		errs.Check("Payments.StripeWebhookSecret", c.Payments.StripeWebhookSecret != "", "Payments.StripeWebhookSecret is required with a Stripe secret key")
+		errs.Check("Payments.Prices", len(c.Payments.Prices) > 0, "Payments.Prices must list at least one price with a Stripe secret key")
+	}
+	return errs.Err()
+}
+
+// ParseProxy parses a trusted proxy, an IP or a CIDR, into a prefix
+func ParseProxy(proxy string) (netip.Prefix, error) {
+	if strings.Contains(proxy, "/") {
+		prefix, err := netip.ParsePrefix(proxy)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// TrustedPrefixes returns the parsed TrustedProxies, invalid entries are rejected by Validate
+func (s ServerConfig) TrustedPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(s.TrustedProxies))
+	for _, proxy := range s.TrustedProxies {
+		if prefix, err := ParseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// databaseFile returns the file of a SQLite database path, which may be a file: URI with parameters
+func databaseFile(path string) string {
+	path = strings.TrimPrefix(path, "file:")
+	path, _, _ = strings.Cut(path, "?")
+	return path
+}
+
+// writable reports whether the file can be opened for writing, missing files are created and removed again
+func writable(path string) bool {
+	if path == ":memory:" {
+		return true
+	}
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	if errors.Is(statErr, os.ErrNotExist) {
+		os.Remove(path)
+	}
+	return true
+}
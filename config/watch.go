@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+	Config reloading: Watch re-parses the config file when it or one of its overlays changes on disk
+	or the process receives SIGHUP, and passes the new config to the functions registered with OnChange.
+
+	Example reacting to changes:
+		cfg.OnChange(func(next *config.Config) {
+			level.Set(parseLevel(next.LogLevel))
+		})
+
+		// Blocks until ctx is done
+		go cfg.Watch(ctx, func(err error) {
+			logger.Error("Failed to reload config", "error", err)
+		})
+
+	Notes:
+	- The Config a subscriber was created with doesn't change, use the one passed to the
+	  subscriber or Current for the latest values
+	- A file that fails to parse or validate is reported to the error function and the last good config stays
+	- Settings used only at startup - ports, database path, TLS, server timeouts - still need a restart
+	- Configs loaded with NewWithRemote are reloaded from the remote, which is polled instead
+	  of watching the file - this includes configs that fell back to the file at startup
+*/
+
+// reloadDelay is how long Watch waits for further file events before reloading,
+// editors often write a file in several steps
+const reloadDelay = 100 * time.Millisecond
+
+// defaultPollInterval is how often Watch checks a remote config without a PollInterval
+const defaultPollInterval = 30 * time.Second
+
+// watcher holds the subscribers and the latest config, shared by every reloaded Config
+type watcher struct {
+	path    string
+	remote  *Remote
+	mu      sync.Mutex
+	hooks   []func(*Config)
+	current *Config
+}
+
+// OnChange registers a function called with the new config after every reload
+func (c *Config) OnChange(fn func(*Config)) {
+	w := c.watch()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks = append(w.hooks, fn)
+}
+
+// Current returns the latest loaded config
+func (c *Config) Current() *Config {
+	w := c.watch()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload re-parses the config file or remote and passes the new config to the OnChange functions
+func (c *Config) Reload() (*Config, error) {
+	w := c.watch()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, err := loadConfig(w.path, w.remote)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", w.source(), err)
+	}
+	next.watcher = w
+	w.current = next
+	next.publish()
+	for _, fn := range w.hooks {
+		fn(next)
+	}
+	return next, nil
+}
+
+// Watch reloads the config when the file or remote changes or on SIGHUP until ctx is done,
+// reload errors are passed to onError
+func (c *Config) Watch(ctx context.Context, onError func(error)) error {
+	if remote := c.watch().remote; remote != nil {
+		return c.poll(ctx, remote, onError)
+	}
+
+	path := c.watch().path
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsWatcher.Close()
+
+	// Watch the directory, editors and config management often replace the file instead of writing it
+	if err := fsWatcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	// Overlays are watched even if they don't exist yet, creating or removing one changes the config
+	overlays, err := overlayCandidates(path)
+	if err != nil {
+		return err
+	}
+	files := map[string]bool{filepath.Clean(path): true}
+	for _, overlay := range overlays {
+		files[filepath.Clean(overlay)] = true
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	reload := func() {
+		if _, err := c.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			reload()
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[filepath.Clean(event.Name)] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				timer.Reset(reloadDelay)
+			}
+		case <-timer.C:
+			reload()
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// poll reloads the config when the remote value changes or on SIGHUP until ctx is done
+func (c *Config) poll(ctx context.Context, remote *Remote, onError func(error)) error {
+	interval := remote.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	reload := func() {
+		if _, err := c.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			reload()
+		case <-ticker.C:
+			changed, err := remote.changed(ctx)
+			if err != nil {
+				if onError != nil && ctx.Err() == nil {
+					onError(err)
+				}
+				continue
+			}
+			if changed {
+				reload()
+			}
+		}
+	}
+}
+
+// source returns where the config is loaded from, for error messages
+func (w *watcher) source() string {
+	if w.remote != nil {
+		return w.remote.String()
+	}
+	return w.path
+}
+
+// watch returns the watcher of the config, creating one for configs not loaded from a file
+func (c *Config) watch() *watcher {
+	if c.watcher == nil {
+		c.watcher = &watcher{current: c}
+	}
+	return c.watcher
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mookie/config"
+	"mookie/internal/db"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// doctor.go - Define environment checks run by the -doctor flag before serving,
+// and the effective config printed by the -print-config flag
+
+// checkResult is the outcome of a single doctor check
+type checkResult struct {
+	name    string
+	problem error
+}
+
+// runDoctor checks the environment and prints every problem found, returns false if any check failed
+func runDoctor(configPath string) bool {
+	var results []checkResult
+	check := func(name string, problem error) {
+		results = append(results, checkResult{name: name, problem: problem})
+	}
+
+	cfg, err := doctorConfig(configPath)
+	check("config", err)
+	if cfg != nil {
+		check("database path", checkWritableFile(cfg.DatabasePath))
+		check("database schema", checkSchema(cfg.DatabasePath))
+		if cfg.LogFile != "" {
+			check("log file", checkWritableFile(cfg.LogFile))
+		}
+		if cfg.PIDFile != "" {
+			check("pid file", checkWritableFile(cfg.PIDFile))
+		}
+		check("listen address", checkListen(cfg))
+		if cfg.TLS.Enabled && !cfg.TLS.AutoCert {
+			check("tls certificate", checkReadable(cfg.TLS.CertFile, cfg.TLS.KeyFile))
+		}
+		if !cfg.EmbedStatic {
+			check("static files", checkStatic("static"))
+		}
+	}
+	check("templates", checkTemplates("templates"))
+	check("clock", checkClock())
+
+	ok := true
+	for _, r := range results {
+		if r.problem != nil {
+			ok = false
+			fmt.Printf("✗ %s: %v\n", r.name, r.problem)
+		} else {
+			fmt.Printf("✓ %s\n", r.name)
+		}
+	}
+	return ok
+}
+
+// doctorConfig loads the config without writing a default config file
+func doctorConfig(path string) (*config.Config, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return config.NewWithPath(path)
+}
+
+// printConfig prints every config key with its resolved value and the source that won,
+// secrets are masked - remoteURL is the -config-remote flag and may be empty
+func printConfig(w io.Writer, configPath, remoteURL string) error {
+	var remote *config.Remote
+	if remoteURL != "" {
+		var err error
+		if remote, err = config.ParseRemote(remoteURL, os.Getenv("MOOKIE_CONFIG_REMOTE_TOKEN")); err != nil {
+			return err
+		}
+	}
+	settings, err := config.Explain(configPath, remote)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		source := s.Source
+		if s.Origin != "" {
+			source += " " + s.Origin
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, s.Value, source)
+	}
+	return tw.Flush()
+}
+
+// checkWritableFile checks that the file can be opened for writing, creating missing files temporarily
+func checkWritableFile(path string) error {
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	if errors.Is(statErr, fs.ErrNotExist) {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// checkReadable checks that all files exist and can be read
+func checkReadable(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			return errors.New("path not configured")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+	return nil
+}
+
+// checkSchema checks that all tables from the schema exist, missing tables are created on startup
+func checkSchema(dbPath string) error {
+	if _, err := os.Stat(dbPath); errors.Is(err, fs.ErrNotExist) {
+		// A new database gets the full schema on startup
+		return nil
+	}
+
+	database, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	var missing []string
+	for _, table := range db.Tables() {
+		var name string
+		err := database.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, table)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("tables %s are missing and will be created on startup", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkListen checks that the configured listeners are available
+func checkListen(cfg *config.Config) error {
+	var addrs []string
+	if cfg.Socket.Path == "" {
+		addrs = append(addrs, net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.Port)))
+	} else if _, err := os.Stat(filepath.Dir(cfg.Socket.Path)); err != nil {
+		return fmt.Errorf("socket directory: %w", err)
+	}
+	if cfg.TLS.Enabled && (cfg.TLS.AutoCert || cfg.TLS.RedirectHTTP) {
+		addrs = append(addrs, net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.TLS.HTTPPort)))
+	}
+
+	var problems []string
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		listener.Close()
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkStatic checks that the static folder exists and isn't empty
+func checkStatic(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("%w - enable EmbedStatic to serve the embedded files", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s is empty", dir)
+	}
+	return nil
+}
+
+// checkTemplates checks that every .templ file has been generated and the generated code is up to date
+// Templates are compiled in, so this only applies when the source tree is present
+func checkTemplates(dir string) error {
+	var stale []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".templ") {
+			return err
+		}
+		source, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		generated, err := os.Stat(strings.TrimSuffix(path, ".templ") + "_templ.go")
+		if err != nil || generated.ModTime().Before(source.ModTime()) {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("run templ generate, outdated: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// checkClock checks that the system clock is roughly sane - TLS and token expiry depend on it
+func checkClock() error {
+	earliest := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if now := time.Now(); now.Before(earliest) {
+		return fmt.Errorf("system time %s is before %s", now.Format(time.RFC3339), earliest.Format("2006-01-02"))
+	}
+	return nil
+}
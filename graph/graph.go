@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"mookie/internal/dataloader"
+	"mookie/internal/db/sqlc"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+/*
+Package graph defines the GraphQL schema and resolvers of the application.
+
+The schema lives in schema.graphql and is parsed against the Resolver at startup.
+Resolvers are backed by sqlc queries, nested fields that would otherwise cause
+N+1 queries (like User.roles) go through per-request dataloaders.
+
+To add a query:
+ 1. Add the field to the Query type in schema.graphql
+ 2. Add a method with the same name to Resolver in resolver.go
+ 3. Return a resolver struct for object types (see userResolver)
+*/
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// NewSchema parses the schema and binds it to the resolvers
+func NewSchema(db *sql.DB) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schemaSDL, &Resolver{queries: sqlc.New(db)})
+}
+
+// Loaders holds the per-request dataloaders
+type Loaders struct {
+	Roles *dataloader.Loader[int64, []string]
+}
+
+// NewLoaders creates fresh dataloaders, call once per request
+func NewLoaders(db *sql.DB) *Loaders {
+	queries := sqlc.New(db)
+	return &Loaders{
+		Roles: dataloader.New(func(ctx context.Context, ids []int64) (map[int64][]string, error) {
+			rows, err := queries.ListRolesForUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			roles := make(map[int64][]string, len(ids))
+			for _, id := range ids {
+				roles[id] = []string{}
+			}
+			for _, row := range rows {
+				roles[row.UserID] = append(roles[row.UserID], row.Role)
+			}
+			return roles, nil
+		}),
+	}
+}
+
+// contextKey is an unexported type for context keys defined in this package
+type contextKey struct{}
+
+// WithLoaders returns a copy of ctx carrying the loaders
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, loaders)
+}
+
+// loadersFrom returns the loaders stored in ctx
+func loadersFrom(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(contextKey{}).(*Loaders)
+	return loaders
+}
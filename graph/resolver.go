@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mookie/internal/db/sqlc"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver is the root resolver, its methods resolve the fields of the Query type
+type Resolver struct {
+	queries *sqlc.Queries
+}
+
+// User resolves Query.user
+func (r *Resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := strconv.ParseInt(string(args.ID), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	user, err := r.queries.GetUserByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{user: user}, nil
+}
+
+// Users resolves Query.users
+func (r *Resolver) Users(ctx context.Context, args struct {
+	First  int32
+	Offset int32
+	Search string
+}) ([]*userResolver, error) {
+	if args.First < 0 || args.First > 100 {
+		return nil, errors.New("first must be between 0 and 100")
+	}
+
+	users, err := r.queries.ListUsers(ctx, sqlc.ListUsersParams{
+		Search: args.Search,
+		Limit:  int64(args.First),
+		Offset: int64(args.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*userResolver, 0, len(users))
+	for _, user := range users {
+		resolvers = append(resolvers, &userResolver{user: user})
+	}
+	return resolvers, nil
+}
+
+// userResolver resolves the fields of the User type
+type userResolver struct {
+	user sqlc.User
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(u.user.ID, 10))
+}
+
+func (u *userResolver) Username() string {
+	return u.user.Username
+}
+
+func (u *userResolver) Email() string {
+	return u.user.Email
+}
+
+func (u *userResolver) CreatedAt() *string {
+	if !u.user.CreatedAt.Valid {
+		return nil
+	}
+	createdAt := u.user.CreatedAt.Time.Format(time.RFC3339)
+	return &createdAt
+}
+
+// Roles are batched through the roles dataloader to avoid a query per user
+func (u *userResolver) Roles(ctx context.Context) ([]string, error) {
+	loaders := loadersFrom(ctx)
+	if loaders == nil {
+		return nil, errors.New("dataloaders missing from context")
+	}
+	return loaders.Roles.Load(ctx, u.user.ID)
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mookie/internal/admin"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"mookie/internal/flags"
+	"mookie/internal/params"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+Define admin resources here. A resource maps a model to the admin CRUD pages,
+register it with the admin in setup.go.
+*/
+
+// UsersResource exposes the users table in the admin, created users are published as UserRegistered events
+func UsersResource(db *sql.DB, bus *events.Bus) admin.Resource {
+	queries := sqlc.New(db)
+
+	toRecord := func(u sqlc.User) admin.Record {
+		return admin.Record{
+			"id":         strconv.FormatInt(u.ID, 10),
+			"username":   u.Username,
+			"email":      u.Email,
+			"created_at": u.CreatedAt.Time.Format("2006-01-02 15:04"),
+		}
+	}
+
+	return admin.Resource{
+		Name:  "users",
+		Title: "Users",
+		Fields: []admin.Field{
+			{Name: "username", Label: "Username", Required: true, List: true},
+			{Name: "email", Label: "Email", Type: "email", Required: true, List: true},
+			{Name: "password", Label: "Password", Type: "password", Required: true, CreateOnly: true},
+		},
+		List: func(ctx context.Context, q *params.Query, search string) ([]admin.Record, int64, error) {
+			users, err := queries.ListUsers(ctx, sqlc.ListUsersParams{
+				Search: search,
+				Limit:  q.Limit(),
+				Offset: q.Offset(),
+			})
+			if err != nil {
+				return nil, 0, err
+			}
+			total, err := queries.CountUsers(ctx, search)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			records := make([]admin.Record, 0, len(users))
+			for _, u := range users {
+				records = append(records, toRecord(u))
+			}
+			return records, total, nil
+		},
+		Get: func(ctx context.Context, id int64) (admin.Record, error) {
+			user, err := queries.GetUserByID(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, admin.ErrNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			return toRecord(user), nil
+		},
+		Create: func(ctx context.Context, values admin.Record) error {
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(values["password"]), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			user, err := queries.CreateUser(ctx, sqlc.CreateUserParams{
+				Username: values["username"],
+				Email:    values["email"],
+				Password: string(hashedPassword),
+			})
+			if err != nil {
+				return err
+			}
+			return bus.Publish(ctx, events.UserRegistered{
+				UserID:   user.ID,
+				Username: user.Username,
+				Email:    user.Email,
+			})
+		},
+		Update: func(ctx context.Context, id int64, values admin.Record) error {
+			return queries.UpdateUser(ctx, sqlc.UpdateUserParams{
+				Username: values["username"],
+				Email:    values["email"],
+				ID:       id,
+			})
+		},
+		Delete: func(ctx context.Context, id int64) error {
+			return queries.DeleteUser(ctx, id)
+		},
+		Validate: func(ctx context.Context, values admin.Record, creating bool) map[string]string {
+			errs := make(map[string]string)
+			if creating && len(values["password"]) > 0 && len(values["password"]) < 8 {
+				errs["password"] = "Password must be at least 8 characters"
+			}
+			return errs
+		},
+	}
+}
+
+// FeatureFlagsResource exposes the feature_flags table in the admin, changes are published as FlagChanged events
+func FeatureFlagsResource(db *sql.DB, bus *events.Bus) admin.Resource {
+	queries := sqlc.New(db)
+
+	toRecord := func(f sqlc.FeatureFlag) admin.Record {
+		enabled := ""
+		if f.Enabled {
+			enabled = "true"
+		}
+		return admin.Record{
+			"id":          strconv.FormatInt(f.ID, 10),
+			"name":        f.Name,
+			"description": f.Description,
+			"enabled":     enabled,
+			"percentage":  strconv.FormatInt(f.RolloutPercentage, 10),
+			"roles":       f.Roles,
+			"user_ids":    f.UserIds,
+		}
+	}
+	toParams := func(values admin.Record) sqlc.CreateFeatureFlagParams {
+		percentage, _ := strconv.ParseInt(values["percentage"], 10, 64)
+		return sqlc.CreateFeatureFlagParams{
+			Name:              values["name"],
+			Description:       values["description"],
+			Enabled:           values["enabled"] != "",
+			RolloutPercentage: percentage,
+			Roles:             flags.JoinList(flags.SplitList(values["roles"])),
+			UserIds:           flags.JoinList(flags.SplitList(values["user_ids"])),
+		}
+	}
+
+	return admin.Resource{
+		Name:  "flags",
+		Title: "Feature flags",
+		Fields: []admin.Field{
+			{Name: "name", Label: "Name", Required: true, List: true},
+			{Name: "description", Label: "Description", List: true},
+			{Name: "enabled", Label: "Enabled", Type: "checkbox", List: true},
+			{Name: "percentage", Label: "Rollout percentage", Type: "number", Required: true, List: true},
+			{Name: "roles", Label: "Roles (comma separated)"},
+			{Name: "user_ids", Label: "User IDs (comma separated)"},
+		},
+		List: func(ctx context.Context, q *params.Query, search string) ([]admin.Record, int64, error) {
+			list, err := queries.ListFeatureFlags(ctx, sqlc.ListFeatureFlagsParams{
+				Search: search,
+				Limit:  q.Limit(),
+				Offset: q.Offset(),
+			})
+			if err != nil {
+				return nil, 0, err
+			}
+			total, err := queries.CountFeatureFlags(ctx, search)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			records := make([]admin.Record, 0, len(list))
+			for _, f := range list {
+				records = append(records, toRecord(f))
+			}
+			return records, total, nil
+		},
+		Get: func(ctx context.Context, id int64) (admin.Record, error) {
+			flag, err := queries.GetFeatureFlag(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, admin.ErrNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			return toRecord(flag), nil
+		},
+		Create: func(ctx context.Context, values admin.Record) error {
+			flag, err := queries.CreateFeatureFlag(ctx, toParams(values))
+			if err != nil {
+				return err
+			}
+			return bus.Publish(ctx, events.FlagChanged{Name: flag.Name, Enabled: flag.Enabled})
+		},
+		Update: func(ctx context.Context, id int64, values admin.Record) error {
+			old, err := queries.GetFeatureFlag(ctx, id)
+			if err != nil {
+				return err
+			}
+			p := toParams(values)
+			err = queries.UpdateFeatureFlag(ctx, sqlc.UpdateFeatureFlagParams{
+				Name:              p.Name,
+				Description:       p.Description,
+				Enabled:           p.Enabled,
+				RolloutPercentage: p.RolloutPercentage,
+				Roles:             p.Roles,
+				UserIds:           p.UserIds,
+				ID:                id,
+			})
+			if err != nil {
+				return err
+			}
+			// A renamed flag no longer exists under its old name
+			if old.Name != p.Name {
+				if err := bus.Publish(ctx, events.FlagChanged{Name: old.Name, Deleted: true}); err != nil {
+					return err
+				}
+			}
+			return bus.Publish(ctx, events.FlagChanged{Name: p.Name, Enabled: p.Enabled})
+		},
+		Delete: func(ctx context.Context, id int64) error {
+			flag, err := queries.GetFeatureFlag(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := queries.DeleteFeatureFlag(ctx, id); err != nil {
+				return err
+			}
+			return bus.Publish(ctx, events.FlagChanged{Name: flag.Name, Deleted: true})
+		},
+		Validate: func(ctx context.Context, values admin.Record, creating bool) map[string]string {
+			errs := make(map[string]string)
+			percentage, err := strconv.Atoi(values["percentage"])
+			if values["percentage"] != "" && (err != nil || percentage < 0 || percentage > 100) {
+				errs["percentage"] = "Rollout percentage must be a number from 0 to 100"
+			}
+			return errs
+		},
+	}
+}
+
+// WebhooksResource exposes the webhooks table in the admin, deliveries are listed under /admin/webhooks/{id}/deliveries
+func WebhooksResource(db *sql.DB) admin.Resource {
+	queries := sqlc.New(db)
+
+	toRecord := func(h sqlc.Webhook) admin.Record {
+		active := ""
+		if h.Active {
+			active = "true"
+		}
+		return admin.Record{
+			"id":     strconv.FormatInt(h.ID, 10),
+			"url":    h.Url,
+			"secret": h.Secret,
+			"events": h.Events,
+			"active": active,
+		}
+	}
+	toParams := func(values admin.Record) sqlc.CreateWebhookParams {
+		eventList := flags.JoinList(flags.SplitList(values["events"]))
+		if eventList == "" {
+			eventList = "*"
+		}
+		return sqlc.CreateWebhookParams{
+			Url:    values["url"],
+			Secret: values["secret"],
+			Events: eventList,
+			Active: values["active"] != "",
+		}
+	}
+
+	return admin.Resource{
+		Name:  "webhooks",
+		Title: "Webhooks",
+		Fields: []admin.Field{
+			{Name: "url", Label: "URL", Type: "url", Required: true, List: true},
+			{Name: "secret", Label: "Signing secret", Required: true, Sensitive: true},
+			{Name: "events", Label: "Events (comma separated, * for all)", List: true},
+			{Name: "active", Label: "Active", Type: "checkbox", List: true},
+		},
+		List: func(ctx context.Context, q *params.Query, search string) ([]admin.Record, int64, error) {
+			list, err := queries.ListWebhooks(ctx, sqlc.ListWebhooksParams{
+				Search: search,
+				Limit:  q.Limit(),
+				Offset: q.Offset(),
+			})
+			if err != nil {
+				return nil, 0, err
+			}
+			total, err := queries.CountWebhooks(ctx, search)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			records := make([]admin.Record, 0, len(list))
+			for _, h := range list {
+				records = append(records, toRecord(h))
+			}
+			return records, total, nil
+		},
+		Get: func(ctx context.Context, id int64) (admin.Record, error) {
+			hook, err := queries.GetWebhook(ctx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, admin.ErrNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			return toRecord(hook), nil
+		},
+		Create: func(ctx context.Context, values admin.Record) error {
+			_, err := queries.CreateWebhook(ctx, toParams(values))
+			return err
+		},
+		Update: func(ctx context.Context, id int64, values admin.Record) error {
+			p := toParams(values)
+			return queries.UpdateWebhook(ctx, sqlc.UpdateWebhookParams{
+				Url:    p.Url,
+				Secret: p.Secret,
+				Events: p.Events,
+				Active: p.Active,
+				ID:     id,
+			})
+		},
+		Delete: func(ctx context.Context, id int64) error {
+			return queries.DeleteWebhook(ctx, id)
+		},
+		Validate: func(ctx context.Context, values admin.Record, creating bool) map[string]string {
+			errs := make(map[string]string)
+			if u, err := url.Parse(values["url"]); values["url"] != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+				errs["url"] = "URL must be an absolute http or https URL"
+			}
+			return errs
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log/slog"
+	"mookie/internal/audit"
+	"mookie/internal/container"
+	"mookie/internal/params"
+	"mookie/internal/render"
+	"mookie/templates/pages"
+	"net/http"
+)
+
+// AuditLog lists audit entries for review, filtered by actor, action, object and date range
+func AuditLog(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		recorder := container.MustGet[*audit.Recorder](c, "audit")
+
+		q, err := params.Parse(r.URL.Query(), audit.ListOptions)
+		if err != nil {
+			render.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := recorder.List(r.Context(), q)
+		if err != nil {
+			logger.Error("failed to list audit entries", "error", err)
+			http.Error(w, "failed to list audit entries", http.StatusInternalServerError)
+			return
+		}
+		render.Render(w, r, pages.AuditLog(page, q.Filters), page)
+	}
+}
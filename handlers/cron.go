@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log/slog"
+	"mookie/internal/container"
+	"mookie/internal/cron"
+	"mookie/internal/params"
+	"mookie/internal/render"
+	"net/http"
+)
+
+// CronStats lists the background tasks with their run counters as JSON
+func CronStats(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		runner := container.MustGet[*cron.Runner](c, "cron")
+
+		render.JSON(w, http.StatusOK, runner.Stats())
+	}
+}
+
+// CronHistory lists the recorded runs of the background tasks as JSON, latest first
+// The runs are recorded when Cron.HistoryDays is set, ?task= filters them by task name
+func CronHistory(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		store := container.MustGet[*cron.SQLStore](c, "cron.store")
+
+		q, err := params.Parse(r.URL.Query(), params.Options{})
+		if err != nil {
+			render.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := store.History(r.Context(), r.URL.Query().Get("task"), q)
+		if err != nil {
+			logger.Error("failed to list cron history", "error", err)
+			http.Error(w, "failed to list cron history", http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, http.StatusOK, page)
+	}
+}
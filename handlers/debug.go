@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"mookie/internal/container"
+	"mookie/internal/render"
+	"mookie/templates/pages"
+	"net/http"
+)
+
+// Services lists the services registered in the container with their types and tags, served with DebugRoutes
+func Services(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		services := c.List()
+		render.Render(w, r, pages.Services(services), services)
+	}
+}
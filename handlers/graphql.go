@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"database/sql"
+	"mookie/graph"
+	"mookie/internal/container"
+	"mookie/internal/csrf"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// GraphQL serves GraphQL queries against the schema registered in the container
+// Fresh dataloaders are attached to every request so batching and caching stay per request
+func GraphQL(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		schema := container.MustGet[*graphql.Schema](c, "graphql")
+		db := container.MustGet[*sql.DB](c, "db")
+
+		ctx := graph.WithLoaders(r.Context(), graph.NewLoaders(db))
+		handler := &relay.Handler{Schema: schema}
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// GraphQLPlayground serves a GraphiQL page for exploring the schema during development
+// Queries are sent with the CSRF token of the request, so the page must be served behind the CSRF middleware
+func GraphQLPlayground(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := csrf.Token(r.Context())
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!DOCTYPE html>
+<html>
+	<head>
+		<title>GraphQL playground</title>
+		<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css"/>
+	</head>
+	<body style="margin: 0;">
+		<div id="graphiql" style="height: 100vh;"></div>
+		<script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+		<script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+		<script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+		<script>
+			const fetcher = GraphiQL.createFetcher({url: "` + endpoint + `", headers: {"` + csrf.HeaderName + `": "` + token + `"}});
+			ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, {fetcher}));
+		</script>
+	</body>
+</html>
+`))
+	}
+}
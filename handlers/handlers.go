@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"mookie/internal/container"
+	"mookie/internal/events"
+	"mookie/internal/ratelimit"
+	"mookie/internal/render"
 	ws "mookie/internal/websocket"
 	"mookie/templates/pages"
 	"github.com/gorilla/websocket"
@@ -19,15 +22,16 @@ func Front() http.HandlerFunc {
 			return
 		}
 
-		pages.Front().Render(r.Context(), w)
+		render.Render(w, r, pages.Front(), nil)
 	}
 }
 
 func PostMessage(c *container.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get dependencies
-		logger := c.MustGet("logger").(*slog.Logger)
-		hub := c.MustGet("hub").(*ws.Hub)
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		hub := container.MustGet[*ws.Hub](c, "hub")
+		bus := container.MustGet[*events.Bus](c, "events")
 		message := r.Header.Get("message")
 
 		logger.Debug("received message", "message", message)
@@ -47,19 +51,27 @@ func PostMessage(c *container.Container) http.HandlerFunc {
 		}
 
 		// Broadcast the message to all connected clients on the hub
-		hub.Broadcast(wsMessage)
+		hub.BroadcastContext(r.Context(), wsMessage)
 
-		// Respond with a 200 OK status
-		w.WriteHeader(http.StatusOK)
+		// Let other modules react to the message
+		if err := bus.Publish(r.Context(), events.MessagePosted{Message: message}); err != nil {
+			logger.Error("failed to publish message event", "error", err)
+		}
+
+		// Respond with HTML for browsers or JSON for programmatic clients
+		render.Render(w, r, pages.MessagePosted(message), map[string]string{
+			"status":  "sent",
+			"message": message,
+		})
 	}
 }
 
 func BroadcastMessage(c *container.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get dependencies
-		logger := c.MustGet("logger").(*slog.Logger)
-		hub := c.MustGet("hub").(*ws.Hub)
-		upgrader := c.MustGet("upgrader").(*websocket.Upgrader)
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		hub := container.MustGet[*ws.Hub](c, "hub")
+		upgrader := container.MustGet[*websocket.Upgrader](c, "upgrader")
 
 		// Upgrade the connection to a WebSocket connection
 		conn, err := upgrader.Upgrade(w, r, nil)
@@ -70,6 +82,9 @@ func BroadcastMessage(c *container.Container) http.HandlerFunc {
 
 		// Create a new client
 		client := ws.NewClient("", conn, hub)
+		if limiter := container.GetOr[*ratelimit.Limiter](c, "wslimit", nil); limiter != nil {
+			client.LimitMessages(limiter, r.RemoteAddr)
+		}
 
 		// Add the client to the hub
 		if err := hub.AddClient(client); err != nil {
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"mookie/config"
+	"mookie/internal/container"
+	"mookie/internal/render"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout is how long the readiness checks may take together
+const healthCheckTimeout = 5 * time.Second
+
+// HealthStatus is the response of the health endpoints
+type HealthStatus struct {
+	Status string `json:"status"`
+	// Checks holds "ok" or the failure of each check, failures only show details with ErrorDetail
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz reports that the process is up and serving requests, for liveness probes
+// It runs no checks so a failing dependency doesn't get the process restarted
+func Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+	}
+}
+
+// Readyz runs the health checks registered on the container, for readiness probes
+// It responds 503 Service Unavailable if any check fails
+func Readyz(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		cfg := container.MustGet[*config.Config](c, "config")
+
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+
+		status := HealthStatus{Status: "ok", Checks: map[string]string{}}
+		code := http.StatusOK
+		for name, err := range c.Health(ctx) {
+			if err == nil {
+				status.Checks[name] = "ok"
+				continue
+			}
+			logger.Warn("Health check failed", "check", name, "error", err)
+			status.Status = "unavailable"
+			status.Checks[name] = "failed"
+			if cfg.Current().ErrorDetail {
+				status.Checks[name] = err.Error()
+			}
+			code = http.StatusServiceUnavailable
+		}
+		render.JSON(w, code, status)
+	}
+}
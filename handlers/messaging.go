@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mookie/internal/container"
+	"mookie/internal/messaging"
+	"mookie/internal/render"
+	"net/http"
+)
+
+// PushKey is the JSON response with the VAPID public key browsers subscribe with
+type PushKey struct {
+	PublicKey string `json:"public_key"`
+}
+
+// PushPublicKey returns the VAPID public key, 404 if Web Push isn't configured
+func PushPublicKey(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		push := container.GetOr[*messaging.WebPush](c, "webpush", nil)
+		if push == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, PushKey{PublicKey: push.PublicKey()})
+	}
+}
+
+// SubscribePush stores a push subscription of the authenticated user, the body is the PushSubscription JSON
+func SubscribePush(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*messaging.Service](c, "messaging")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+		var sub messaging.Subscription
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&sub); err != nil {
+			http.Error(w, "invalid push subscription", http.StatusBadRequest)
+			return
+		}
+
+		if err := service.Subscribe(r.Context(), userID, sub); err != nil {
+			logger.Warn("failed to store push subscription", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// UnsubscribePush deletes a push subscription of the authenticated user, the body is the PushSubscription JSON
+func UnsubscribePush(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*messaging.Service](c, "messaging")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+		var sub messaging.Subscription
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&sub); err != nil {
+			http.Error(w, "invalid push subscription", http.StatusBadRequest)
+			return
+		}
+
+		removed, err := service.Unsubscribe(r.Context(), userID, sub.Endpoint)
+		if err != nil {
+			logger.Error("failed to delete push subscription", "error", err)
+			http.Error(w, "failed to delete push subscription", http.StatusInternalServerError)
+			return
+		}
+		if !removed {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"mookie/internal/auth"
+	"mookie/internal/container"
+	"mookie/internal/notifications"
+	"mookie/internal/params"
+	"mookie/internal/ratelimit"
+	"mookie/internal/render"
+	ws "mookie/internal/websocket"
+	"mookie/templates/pages"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/websocket"
+)
+
+// NotificationList is the JSON response of the notifications page
+type NotificationList struct {
+	params.Page[notifications.Notification]
+	Unread int64 `json:"unread"`
+}
+
+// Notifications lists the authenticated user's notifications
+func Notifications(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*notifications.Service](c, "notifications")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+		q, err := params.Parse(r.URL.Query(), params.Options{})
+		if err != nil {
+			render.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := service.List(r.Context(), userID, q)
+		if err != nil {
+			logger.Error("failed to list notifications", "error", err)
+			http.Error(w, "failed to list notifications", http.StatusInternalServerError)
+			return
+		}
+		unread, err := service.UnreadCount(r.Context(), userID)
+		if err != nil {
+			logger.Error("failed to count notifications", "error", err)
+			http.Error(w, "failed to count notifications", http.StatusInternalServerError)
+			return
+		}
+
+		render.Render(w, r, pages.Notifications(page, unread), NotificationList{Page: page, Unread: unread})
+	}
+}
+
+// MarkNotificationRead marks one of the authenticated user's notifications read
+func MarkNotificationRead(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*notifications.Service](c, "notifications")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		err = service.MarkRead(r.Context(), userID, id)
+		if errors.Is(err, notifications.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to mark notification read", "error", err)
+			http.Error(w, "failed to mark notification read", http.StatusInternalServerError)
+			return
+		}
+		notificationsUpdated(w, r, service, userID)
+	}
+}
+
+// MarkAllNotificationsRead marks all of the authenticated user's notifications read
+func MarkAllNotificationsRead(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*notifications.Service](c, "notifications")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := service.MarkAllRead(r.Context(), userID); err != nil {
+			logger.Error("failed to mark notifications read", "error", err)
+			http.Error(w, "failed to mark notifications read", http.StatusInternalServerError)
+			return
+		}
+		notificationsUpdated(w, r, service, userID)
+	}
+}
+
+// NotificationStream upgrades to a websocket receiving the authenticated user's notifications in real time
+func NotificationStream(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		hub := container.MustGet[*ws.Hub](c, "hub")
+		upgrader := container.MustGet[*websocket.Upgrader](c, "upgrader")
+		authenticator := container.MustResolveAs[auth.Authenticator](c)
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		// The client is upgraded for the user authenticated by the chain
+		client, err := ws.UpgradeAuthenticated(w, r, upgrader, authenticator, hub)
+		if err != nil {
+			logger.Error("failed to upgrade connection", "error", err)
+			return
+		}
+		if limiter := container.GetOr[*ratelimit.Limiter](c, "wslimit", nil); limiter != nil {
+			client.LimitMessages(limiter, r.RemoteAddr)
+		}
+		if err := hub.AddClient(client); err != nil {
+			logger.Error("failed to add client", "error", err)
+			client.Close()
+			return
+		}
+		// Notifications are sent to the user's room, joined before the client can disconnect
+		if err := hub.JoinRoom(client, notifications.Room(userID)); err != nil {
+			logger.Error("failed to join notifications room", "error", err)
+			hub.RemoveClient(client)
+			client.Close()
+			return
+		}
+		if err := client.Start(); err != nil {
+			logger.Error("failed to start client", "error", err)
+			hub.RemoveClient(client)
+		}
+	}
+}
+
+// notificationsUpdated redirects browsers back to the list and sends the unread count to API clients
+func notificationsUpdated(w http.ResponseWriter, r *http.Request, service *notifications.Service, userID int64) {
+	if render.Format(r) == render.FormatHTML {
+		render.AddFlash(w, r, render.FlashSuccess, "Notifications marked read")
+		http.Redirect(w, r, "/notifications", http.StatusSeeOther)
+		return
+	}
+	unread, err := service.UnreadCount(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "failed to count notifications", http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, http.StatusOK, map[string]int64{"unread": unread})
+}
+
+// currentUserID returns the ID of the authenticated user, responding with 401 if there is none
+func currentUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return 0, false
+	}
+	id, err := strconv.ParseInt(user.ID, 10, 64)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return 0, false
+	}
+	return id, true
+}
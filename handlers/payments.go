@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"mookie/config"
+	"mookie/internal/container"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/payments"
+	"mookie/internal/render"
+	"net/http"
+	"net/url"
+)
+
+// BillingStatus is the JSON response of the billing status endpoint
+type BillingStatus struct {
+	Active        bool                `json:"active"`
+	Subscriptions []sqlc.Subscription `json:"subscriptions"`
+	Prices        []string            `json:"prices"`
+}
+
+// Billing returns the authenticated user's subscriptions and the prices they may subscribe to
+func Billing(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*payments.Service](c, "payments")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		subs, err := service.Subscriptions(r.Context(), userID)
+		if err != nil {
+			logger.Error("failed to list subscriptions", "error", err)
+			http.Error(w, "failed to list subscriptions", http.StatusInternalServerError)
+			return
+		}
+		status := BillingStatus{Subscriptions: subs, Prices: service.Prices()}
+		for _, sub := range subs {
+			status.Active = status.Active || payments.Active(sub)
+		}
+		render.JSON(w, http.StatusOK, status)
+	}
+}
+
+// Checkout creates a Stripe checkout session for the "price" form value, or the default price,
+// browsers are redirected to the session and API clients get its URL
+func Checkout(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		cfg := container.MustGet[*config.Config](c, "config")
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*payments.Service](c, "payments")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		session, err := service.Checkout(r.Context(), userID, r.FormValue("price"),
+			absoluteURL(r, cfg.Payments.SuccessURL), absoluteURL(r, cfg.Payments.CancelURL))
+		if errors.Is(err, payments.ErrUnknownPrice) {
+			http.Error(w, "unknown price", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to create checkout session", "error", err)
+			http.Error(w, "failed to create checkout session", http.StatusBadGateway)
+			return
+		}
+		redirectToSession(w, r, session)
+	}
+}
+
+// BillingPortal creates a Stripe billing portal session for the authenticated user
+func BillingPortal(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		cfg := container.MustGet[*config.Config](c, "config")
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*payments.Service](c, "payments")
+
+		userID, ok := currentUserID(w, r)
+		if !ok {
+			return
+		}
+
+		session, err := service.Portal(r.Context(), userID, absoluteURL(r, cfg.Payments.PortalReturnURL))
+		if errors.Is(err, payments.ErrNoCustomer) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to create billing portal session", "error", err)
+			http.Error(w, "failed to create billing portal session", http.StatusBadGateway)
+			return
+		}
+		redirectToSession(w, r, session)
+	}
+}
+
+// StripeWebhook receives Stripe webhook events, rejecting payloads without a valid signature
+func StripeWebhook(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*payments.Service](c, "payments")
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		err = service.HandleWebhook(r.Context(), payload, r.Header.Get("Stripe-Signature"))
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			logger.Warn("rejected stripe webhook", "error", err)
+			http.Error(w, "invalid signature", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			// Stripe retries failed deliveries
+			logger.Error("failed to handle stripe webhook", "error", err)
+			http.Error(w, "failed to handle webhook", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// redirectToSession redirects browsers to a Stripe session and sends API clients its JSON
+func redirectToSession(w http.ResponseWriter, r *http.Request, session *payments.Session) {
+	if render.Format(r) == render.FormatHTML {
+		http.Redirect(w, r, session.URL, http.StatusSeeOther)
+		return
+	}
+	render.JSON(w, http.StatusOK, session)
+}
+
+// absoluteURL resolves a URL relative to the request's scheme and host
+func absoluteURL(r *http.Request, ref string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	base := &url.URL{Scheme: scheme, Host: r.Host, Path: "/"}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return base.String()
+	}
+	return u.String()
+}
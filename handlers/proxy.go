@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"mookie/internal/tracing"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+Proxy forwards requests to another service so internal services or legacy endpoints
+can be served under the same router and middleware stack.
+
+Example - forward /legacy/* to an internal service as /*:
+
+	mux.Handle("/legacy/", defaultChain(handlers.Proxy("http://127.0.0.1:9000", handlers.ProxyOptions{
+		StripPrefix: "/legacy",
+		SetHeaders:  map[string]string{"X-Forwarded-By": "mookie"},
+		Timeout:     10 * time.Second,
+		Logger:      logger,
+	})))
+
+Websocket upgrades are passed through to the target automatically.
+Proxy panics if the target is not a valid URL, as it's called during route setup.
+*/
+
+// ProxyOptions configures the Proxy handler
+type ProxyOptions struct {
+	// StripPrefix is removed from the request path before forwarding
+	StripPrefix string
+	// SetHeaders are set on the forwarded request, overwriting existing values
+	SetHeaders map[string]string
+	// RemoveHeaders are removed from the forwarded request
+	RemoveHeaders []string
+	// PreserveHost keeps the incoming Host header instead of using the target host
+	PreserveHost bool
+	// Timeout limits connecting to the target and waiting for response headers, 0 means no timeout
+	Timeout time.Duration
+	// Logger logs proxy errors, defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+// Proxy returns a handler that forwards requests to the target URL
+func Proxy(target string, opts ProxyOptions) http.HandlerFunc {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Scheme == "" || targetURL.Host == "" {
+		panic(fmt.Sprintf("invalid proxy target %q", target))
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Timeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: opts.Timeout}).DialContext
+		transport.ResponseHeaderTimeout = opts.Timeout
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if opts.StripPrefix != "" {
+				pr.Out.URL.Path = strings.TrimPrefix(pr.Out.URL.Path, opts.StripPrefix)
+				pr.Out.URL.RawPath = strings.TrimPrefix(pr.Out.URL.RawPath, opts.StripPrefix)
+			}
+			pr.SetURL(targetURL)
+			pr.SetXForwarded()
+			// Continue the trace in the target service
+			tracing.Inject(pr.In.Context(), pr.Out.Header)
+			if opts.PreserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+
+			for _, name := range opts.RemoveHeaders {
+				pr.Out.Header.Del(name)
+			}
+			for name, value := range opts.SetHeaders {
+				pr.Out.Header.Set(name, value)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error("proxy request failed",
+				"target", targetURL.String(),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"error", err,
+			)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return proxy.ServeHTTP
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log/slog"
+	"mookie/internal/container"
+	"mookie/internal/params"
+	"mookie/internal/render"
+	"mookie/internal/search"
+	"mookie/templates/pages"
+	"net/http"
+)
+
+// Search queries the search index, the type query parameter limits results to document types
+func Search(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		service := container.MustGet[*search.Service](c, "search")
+
+		q, err := params.Parse(r.URL.Query(), params.Options{})
+		if err != nil {
+			render.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+		text := r.URL.Query().Get("q")
+
+		page, err := service.Search(r.Context(), text, r.URL.Query()["type"], q)
+		if err != nil {
+			logger.Error("failed to search", "error", err)
+			http.Error(w, "failed to search", http.StatusInternalServerError)
+			return
+		}
+		render.Render(w, r, pages.Search(text, page), page)
+	}
+}
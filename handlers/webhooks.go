@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"mookie/internal/container"
+	"mookie/internal/params"
+	"mookie/internal/render"
+	"mookie/internal/webhooks"
+	"net/http"
+	"strconv"
+)
+
+// WebhookDeliveries lists the delivery log of a webhook as JSON
+func WebhookDeliveries(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		dispatcher := container.MustGet[*webhooks.Dispatcher](c, "webhooks")
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		q, err := params.Parse(r.URL.Query(), params.Options{})
+		if err != nil {
+			render.JSON(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := dispatcher.Deliveries(r.Context(), id, q)
+		if err != nil {
+			logger.Error("failed to list webhook deliveries", "error", err)
+			http.Error(w, "failed to list webhook deliveries", http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, http.StatusOK, page)
+	}
+}
+
+// RedeliverWebhook queues a delivery for another attempt, e.g. after it was marked dead
+func RedeliverWebhook(c *container.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get dependencies
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		dispatcher := container.MustGet[*webhooks.Dispatcher](c, "webhooks")
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		err = dispatcher.Redeliver(r.Context(), id)
+		if errors.Is(err, webhooks.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to redeliver webhook", "error", err)
+			http.Error(w, "failed to redeliver webhook", http.StatusInternalServerError)
+			return
+		}
+		render.JSON(w, http.StatusAccepted, map[string]string{"status": webhooks.StatusPending})
+	}
+}
@@ -0,0 +1,447 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"mookie/internal/csrf"
+	"mookie/internal/forms"
+	"mookie/internal/params"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/a-h/templ"
+)
+
+/*
+   Package admin provides CRUD scaffolding for a basic back office. Models are
+   registered as Resources describing their fields and data access functions,
+   and the Admin generates list/create/edit/delete handlers and pages for them.
+
+   How to use:
+   1. Create a new Admin
+   2. Register a Resource for every model (usually backed by sqlc queries)
+   3. Mount the admin on the mux behind an auth/role protected middleware chain
+
+   Example basic usage:
+       a := admin.New(logger)
+       a.Register(admin.Resource{
+           Name:  "users",
+           Title: "Users",
+           Fields: []admin.Field{
+               {Name: "username", Label: "Username", Required: true, List: true},
+               {Name: "email", Label: "Email", Type: "email", Required: true, List: true},
+           },
+           List:   listUsers,
+           Get:    getUser,
+           Create: createUser,
+           Update: updateUser,
+           Delete: deleteUser,
+       })
+
+       // All routes are mounted under /admin/
+       a.Mount(mux, "/admin", adminChain)
+
+   Routes generated for every resource:
+       GET    /admin/                      - index of resources
+       GET    /admin/{resource}            - paginated list with ?q= search
+       GET    /admin/{resource}/new        - create form
+       POST   /admin/{resource}            - create
+       GET    /admin/{resource}/{id}/edit  - edit form
+       PUT    /admin/{resource}/{id}       - update
+       DELETE /admin/{resource}/{id}       - delete
+
+   Notes:
+   - Forms use the _method field for PUT/DELETE, the mux must be wrapped with middleware.MethodOverride
+   - Required fields and email fields are validated before Create/Update
+   - Resource.Validate can add custom validation on top
+   - Records are passed around as string maps keyed by field name plus "id"
+   - OnChange hooks are called after every successful create, update and delete, e.g. for audit logging
+*/
+
+// ErrNotFound should be returned by Resource.Get when the record doesn't exist
+var ErrNotFound = errors.New("admin: record not found")
+
+// Record is a single model instance keyed by field name, the primary key is stored under "id"
+type Record map[string]string
+
+// Field describes a single editable or listed model field
+type Field struct {
+	Name  string
+	Label string
+	// Type is the HTML input type, defaults to text
+	Type     string
+	Required bool
+	// List shows the field as a column on the list page
+	List bool
+	// CreateOnly fields are only shown on the create form (e.g. passwords)
+	CreateOnly bool
+	// Sensitive values are redacted in changes passed to OnChange hooks, password fields always are
+	Sensitive bool
+}
+
+// Change actions passed to OnChange hooks
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change describes a record created, updated or deleted through the admin
+// Password and sensitive fields are redacted in Before and After
+type Change struct {
+	Resource string
+	Action   string
+	// ID is 0 for creates as Resource.Create doesn't return the new record
+	ID int64
+	// Before is the record before an update or delete, nil if it couldn't be loaded
+	Before Record
+	// After holds the submitted values of a create or update
+	After Record
+}
+
+// ChangeHook is called after a successful change
+type ChangeHook func(ctx context.Context, change Change)
+
+// Resource describes a model managed by the admin
+type Resource struct {
+	Name   string
+	Title  string
+	Fields []Field
+	// List returns a page of records matching the search and the total count of matching records
+	List func(ctx context.Context, q *params.Query, search string) ([]Record, int64, error)
+	// Get returns a single record or ErrNotFound
+	Get    func(ctx context.Context, id int64) (Record, error)
+	Create func(ctx context.Context, values Record) error
+	Update func(ctx context.Context, id int64, values Record) error
+	Delete func(ctx context.Context, id int64) error
+	// Validate optionally adds validation errors keyed by field name
+	Validate func(ctx context.Context, values Record, creating bool) map[string]string
+}
+
+// Admin holds registered resources and serves the admin pages
+type Admin struct {
+	resources []Resource
+	prefix    string
+	logger    *slog.Logger
+	hooks     []ChangeHook
+	mu        sync.RWMutex
+}
+
+// New creates a new Admin
+func New(logger *slog.Logger) *Admin {
+	return &Admin{logger: logger}
+}
+
+// Register adds a resource to the admin, replacing any resource with the same name
+func (a *Admin) Register(res Resource) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, existing := range a.resources {
+		if existing.Name == res.Name {
+			a.resources[i] = res
+			return
+		}
+	}
+	a.resources = append(a.resources, res)
+}
+
+// OnChange registers a hook called after every successful create, update and delete
+func (a *Admin) OnChange(hook ChangeHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = append(a.hooks, hook)
+}
+
+// Resources returns the registered resources
+func (a *Admin) Resources() []Resource {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return slices.Clone(a.resources)
+}
+
+// resource finds a registered resource by name
+func (a *Admin) resource(name string) (Resource, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, res := range a.resources {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return Resource{}, false
+}
+
+// Mount registers the admin routes on the mux under prefix, wrapped with chain
+func (a *Admin) Mount(mux *http.ServeMux, prefix string, chain func(http.Handler) http.Handler) {
+	a.prefix = prefix
+
+	mux.Handle("GET "+prefix+"/{$}", chain(http.HandlerFunc(a.index)))
+	mux.Handle("GET "+prefix+"/{resource}", chain(a.withResource(a.list)))
+	mux.Handle("GET "+prefix+"/{resource}/new", chain(a.withResource(a.newForm)))
+	mux.Handle("POST "+prefix+"/{resource}", chain(a.withResource(a.create)))
+	mux.Handle("GET "+prefix+"/{resource}/{id}/edit", chain(a.withResource(a.editForm)))
+	mux.Handle("PUT "+prefix+"/{resource}/{id}", chain(a.withResource(a.update)))
+	mux.Handle("DELETE "+prefix+"/{resource}/{id}", chain(a.withResource(a.delete)))
+}
+
+// resourceHandler is a handler that operates on a resolved resource
+type resourceHandler func(w http.ResponseWriter, r *http.Request, res Resource)
+
+// withResource resolves the {resource} path value and responds with 404 if it isn't registered
+func (a *Admin) withResource(h resourceHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, ok := a.resource(r.PathValue("resource"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r, res)
+	})
+}
+
+// index lists the registered resources
+func (a *Admin) index(w http.ResponseWriter, r *http.Request) {
+	a.render(w, r, indexPage(a.prefix, a.Resources()))
+}
+
+// listOptions are the pagination options used by list pages
+var listOptions = params.Options{
+	DefaultPerPage: 20,
+	MaxPerPage:     100,
+}
+
+// list renders a paginated and searchable list of records
+func (a *Admin) list(w http.ResponseWriter, r *http.Request, res Resource) {
+	q, err := params.Parse(r.URL.Query(), listOptions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	search := r.URL.Query().Get("q")
+
+	records, total, err := res.List(r.Context(), q, search)
+	if err != nil {
+		a.serverError(w, "failed to list records", err, res)
+		return
+	}
+
+	page := params.NewPage(q, records, total)
+	a.render(w, r, listPage(a.prefix, res, page, search, csrf.Token(r.Context())))
+}
+
+// newForm renders an empty create form
+func (a *Admin) newForm(w http.ResponseWriter, r *http.Request, res Resource) {
+	a.render(w, r, formPage(a.prefix, res, 0, forms.Empty(r)))
+}
+
+// create validates the submitted form and creates a record
+func (a *Admin) create(w http.ResponseWriter, r *http.Request, res Resource) {
+	f, values := submittedForm(r, res, true)
+	if f.Errors = a.validate(r.Context(), res, values, true); f.HasErrors() {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		a.render(w, r, formPage(a.prefix, res, 0, f))
+		return
+	}
+
+	if err := res.Create(r.Context(), values); err != nil {
+		a.serverError(w, "failed to create record", err, res)
+		return
+	}
+	a.changed(r.Context(), res, Change{Action: ActionCreate, After: values})
+
+	http.Redirect(w, r, a.prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+// editForm renders the edit form for an existing record
+func (a *Admin) editForm(w http.ResponseWriter, r *http.Request, res Resource) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	record, err := res.Get(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		a.serverError(w, "failed to get record", err, res)
+		return
+	}
+
+	f := forms.Empty(r)
+	for name, value := range record {
+		f.Set(name, value)
+	}
+	a.render(w, r, formPage(a.prefix, res, id, f))
+}
+
+// update validates the submitted form and updates the record
+func (a *Admin) update(w http.ResponseWriter, r *http.Request, res Resource) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	f, values := submittedForm(r, res, false)
+	if f.Errors = a.validate(r.Context(), res, values, false); f.HasErrors() {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		a.render(w, r, formPage(a.prefix, res, id, f))
+		return
+	}
+
+	before := a.current(r.Context(), res, id)
+	if err := res.Update(r.Context(), id, values); err != nil {
+		a.serverError(w, "failed to update record", err, res)
+		return
+	}
+	a.changed(r.Context(), res, Change{Action: ActionUpdate, ID: id, Before: before, After: values})
+
+	http.Redirect(w, r, a.prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+// delete removes a record
+func (a *Admin) delete(w http.ResponseWriter, r *http.Request, res Resource) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	before := a.current(r.Context(), res, id)
+	if err := res.Delete(r.Context(), id); err != nil {
+		a.serverError(w, "failed to delete record", err, res)
+		return
+	}
+	a.changed(r.Context(), res, Change{Action: ActionDelete, ID: id, Before: before})
+
+	http.Redirect(w, r, a.prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+// current loads a record before it's changed, returning nil if it can't be loaded
+func (a *Admin) current(ctx context.Context, res Resource, id int64) Record {
+	record, err := res.Get(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return record
+}
+
+// changed redacts password and sensitive fields and calls the change hooks
+func (a *Admin) changed(ctx context.Context, res Resource, change Change) {
+	change.Resource = res.Name
+	change.Before = redact(res, change.Before)
+	change.After = redact(res, change.After)
+
+	a.mu.RLock()
+	hooks := slices.Clone(a.hooks)
+	a.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, change)
+	}
+}
+
+// redact returns a copy of the record without the values of password and sensitive fields
+func redact(res Resource, record Record) Record {
+	if record == nil {
+		return nil
+	}
+	redacted := maps.Clone(record)
+	for _, field := range res.Fields {
+		if _, ok := redacted[field.Name]; ok && (field.Type == "password" || field.Sensitive) {
+			redacted[field.Name] = "[redacted]"
+		}
+	}
+	return redacted
+}
+
+// validate runs the built-in field validation followed by the resource validation
+func (a *Admin) validate(ctx context.Context, res Resource, values Record, creating bool) map[string]string {
+	errs := make(map[string]string)
+	for _, field := range res.Fields {
+		if field.CreateOnly && !creating {
+			continue
+		}
+		value := values[field.Name]
+		if field.Required && value == "" {
+			errs[field.Name] = field.Label + " is required"
+			continue
+		}
+		if field.Type == "email" && value != "" {
+			if _, err := mail.ParseAddress(value); err != nil {
+				errs[field.Name] = field.Label + " must be a valid email address"
+			}
+		}
+	}
+
+	if res.Validate != nil {
+		for name, msg := range res.Validate(ctx, values, creating) {
+			if _, exists := errs[name]; !exists {
+				errs[name] = msg
+			}
+		}
+	}
+	return errs
+}
+
+// render renders a templ component and logs render errors
+func (a *Admin) render(w http.ResponseWriter, r *http.Request, component templ.Component) {
+	if err := component.Render(r.Context(), w); err != nil {
+		a.logger.Error("failed to render admin page", "error", err)
+	}
+}
+
+// serverError logs err and responds with 500
+func (a *Admin) serverError(w http.ResponseWriter, msg string, err error, res Resource) {
+	a.logger.Error(msg, "resource", res.Name, "error", err)
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+// submittedForm reads the submitted form and the resource field values from it
+func submittedForm(r *http.Request, res Resource, creating bool) (*forms.Form, Record) {
+	f := forms.New(r)
+	values := Record{}
+	for _, field := range res.Fields {
+		if field.CreateOnly && !creating {
+			continue
+		}
+		values[field.Name] = f.Value(field.Name)
+	}
+	return f, values
+}
+
+// pathID parses the {id} path value and responds with 404 if it's invalid
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return 0, false
+	}
+	return id, true
+}
+
+// formField converts a resource field to a form field
+func formField(field Field) forms.Field {
+	return forms.Field{
+		Name:     field.Name,
+		Label:    field.Label,
+		Type:     field.Type,
+		Required: field.Required,
+	}
+}
+
+// pageURL returns the URL of a list page keeping the current search
+func pageURL(prefix string, res Resource, page int, search string) string {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(page))
+	if search != "" {
+		query.Set("q", search)
+	}
+	return prefix + "/" + res.Name + "?" + query.Encode()
+}
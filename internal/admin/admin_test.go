@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mookie/internal/auth"
+	"mookie/internal/db"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/params"
+	"mookie/middleware"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testAdmin serves an admin with a notes resource behind basic auth and the admin role, like
+// middleware.AdminChain, and returns the queries of its database
+func testAdmin(t *testing.T) (http.Handler, *sqlc.Queries, *[]Record) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	queries := sqlc.New(database)
+
+	// Roles are looked up on every request, so grants and revocations apply immediately
+	authenticator := auth.NewBasicAuthenticator(func(ctx context.Context, username string) (*auth.Credentials, error) {
+		user, err := queries.GetUserByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := queries.ListUserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Credentials{
+			User:         auth.AuthUser{ID: strconv.FormatInt(user.ID, 10), Username: user.Username, Roles: roles},
+			PasswordHash: user.Password,
+		}, nil
+	})
+	chain := func(h http.Handler) http.Handler {
+		return middleware.Chain(h, middleware.RequireRole("admin"), middleware.RequireAuth(authenticator))
+	}
+
+	notes := &[]Record{}
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	a.Register(Resource{
+		Name:   "notes",
+		Title:  "Notes",
+		Fields: []Field{{Name: "text", Label: "Text", Required: true, List: true}},
+		List: func(ctx context.Context, q *params.Query, search string) ([]Record, int64, error) {
+			return *notes, int64(len(*notes)), nil
+		},
+		Get: func(ctx context.Context, id int64) (Record, error) {
+			if id < 1 || int(id) > len(*notes) {
+				return nil, ErrNotFound
+			}
+			return (*notes)[id-1], nil
+		},
+		Create: func(ctx context.Context, values Record) error {
+			values["id"] = strconv.Itoa(len(*notes) + 1)
+			*notes = append(*notes, values)
+			return nil
+		},
+		Update: func(ctx context.Context, id int64, values Record) error {
+			values["id"] = strconv.FormatInt(id, 10)
+			(*notes)[id-1] = values
+			return nil
+		},
+		Delete: func(ctx context.Context, id int64) error {
+			*notes = slices.Delete(*notes, int(id-1), int(id))
+			return nil
+		},
+	})
+	mux := http.NewServeMux()
+	a.Mount(mux, "/admin", chain)
+	return mux, queries, notes
+}
+
+// createUser creates a user with the password "password"
+func createUser(t *testing.T, queries *sqlc.Queries, username string) sqlc.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := queries.CreateUser(context.Background(), sqlc.CreateUserParams{Username: username, Email: username + "@example.com", Password: string(hash)})
+	if err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	return user
+}
+
+// serve sends a request as username, no credentials if username is empty, and returns the status
+func serve(handler http.Handler, method, target, username string) int {
+	var body io.Reader
+	if method != http.MethodGet {
+		body = strings.NewReader(url.Values{"text": {"hello"}}.Encode())
+	}
+	r := httptest.NewRequest(method, target, body)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if username != "" {
+		r.SetBasicAuth(username, "password")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestAdmin_RequiresAdminRole(t *testing.T) {
+	handler, queries, notes := testAdmin(t)
+	createUser(t, queries, "alice")
+	*notes = append(*notes, Record{"id": "1", "text": "first"})
+
+	routes := []struct {
+		method string
+		target string
+	}{
+		{http.MethodGet, "/admin/"},
+		{http.MethodGet, "/admin/notes"},
+		{http.MethodGet, "/admin/notes/new"},
+		{http.MethodPost, "/admin/notes"},
+		{http.MethodGet, "/admin/notes/1/edit"},
+		{http.MethodPut, "/admin/notes/1"},
+		{http.MethodDelete, "/admin/notes/1"},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.target, func(t *testing.T) {
+			if code := serve(handler, route.method, route.target, ""); code != http.StatusUnauthorized {
+				t.Errorf("expected 401 without credentials, got %d", code)
+			}
+			if code := serve(handler, route.method, route.target, "alice"); code != http.StatusForbidden {
+				t.Errorf("expected 403 without the admin role, got %d", code)
+			}
+		})
+	}
+	if len(*notes) != 1 || (*notes)[0]["text"] != "first" {
+		t.Errorf("expected the notes to be unchanged, got %v", *notes)
+	}
+}
+
+func TestAdmin_GrantRevokeRole(t *testing.T) {
+	handler, queries, _ := testAdmin(t)
+	ctx := context.Background()
+	alice := createUser(t, queries, "alice")
+	bob := createUser(t, queries, "bob")
+	admin := sqlc.GrantUserRoleParams{UserID: alice.ID, Role: "admin"}
+
+	if err := queries.GrantUserRole(ctx, admin); err != nil {
+		t.Fatalf("GrantUserRole returned error: %v", err)
+	}
+	// Granting a role twice is a no-op
+	if err := queries.GrantUserRole(ctx, admin); err != nil {
+		t.Fatalf("GrantUserRole returned error on a granted role: %v", err)
+	}
+	if roles, _ := queries.ListUserRoles(ctx, alice.ID); len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("expected alice to have the admin role once, got %v", roles)
+	}
+	if code := serve(handler, http.MethodGet, "/admin/notes", "alice"); code != http.StatusOK {
+		t.Errorf("expected 200 with the admin role, got %d", code)
+	}
+	if code := serve(handler, http.MethodPost, "/admin/notes", "alice"); code != http.StatusSeeOther {
+		t.Errorf("expected the admin to create a note, got %d", code)
+	}
+	if code := serve(handler, http.MethodGet, "/admin/notes", "bob"); code != http.StatusForbidden {
+		t.Errorf("expected 403 for bob, got %d", code)
+	}
+
+	if err := queries.RevokeUserRole(ctx, sqlc.RevokeUserRoleParams{UserID: alice.ID, Role: "admin"}); err != nil {
+		t.Fatalf("RevokeUserRole returned error: %v", err)
+	}
+	if code := serve(handler, http.MethodGet, "/admin/notes", "alice"); code != http.StatusForbidden {
+		t.Errorf("expected 403 after the role was revoked, got %d", code)
+	}
+
+	// Roles of deleted users are deleted with them
+	if err := queries.GrantUserRole(ctx, sqlc.GrantUserRoleParams{UserID: bob.ID, Role: "admin"}); err != nil {
+		t.Fatalf("GrantUserRole returned error: %v", err)
+	}
+	if err := queries.DeleteUser(ctx, bob.ID); err != nil {
+		t.Fatalf("DeleteUser returned error: %v", err)
+	}
+	if roles, _ := queries.ListUserRoles(ctx, bob.ID); len(roles) != 0 {
+		t.Errorf("expected the roles of the deleted user to be deleted, got %v", roles)
+	}
+	// Roles can't be granted to users that don't exist
+	if err := queries.GrantUserRole(ctx, sqlc.GrantUserRoleParams{UserID: bob.ID, Role: "admin"}); err == nil {
+		t.Error("expected granting a role to a deleted user to fail")
+	}
+}
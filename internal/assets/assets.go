@@ -0,0 +1,189 @@
+package assets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+/*
+   Package assets fingerprints static files for cache busting. Every file gets a hashed name,
+   e.g. css/style.css is served as css/style.1a2b3c4d.css with immutable cache headers, and
+   templates link to the hashed name through the manifest.
+
+   How to use:
+   1. Build a manifest of the static files at startup, or load one written at build time
+   2. Serve the static files with the manifest's Handler
+   3. Make the manifest available to templates with WithManifest - done by the assets middleware
+   4. Link to assets with assets.URL(ctx, name) in templates
+
+   Example basic usage:
+       manifest, err := assets.Build(static.FS, "/static/")
+       mux.Handle("GET /static/", http.StripPrefix("/static/", manifest.Handler(static.FS)))
+
+   Example in a templ template:
+       <link rel="stylesheet" href={ assets.URL(ctx, "css/style.css") }/>
+
+   Example build step with esbuild bundling - writes static/manifest.json:
+       manifest, err := assets.Run(ctx, "static", "/static/",
+           assets.Esbuild("esbuild", []string{"js/app.js"}, "dist", true))
+
+   Notes:
+   - Hashed URLs are cached forever by browsers, other URLs are revalidated on every use
+   - Unknown names are linked unhashed, so templates never break on missing files
+   - A manifest built at startup doesn't see later changes to files served from disk,
+     disable fingerprinting while editing static files
+*/
+
+// ManifestFile is the name of the manifest written by Run and read by Load
+const ManifestFile = "manifest.json"
+
+// DefaultPrefix is the URL prefix used by URL when no manifest is in the context
+const DefaultPrefix = "/static/"
+
+// hashLength is the number of hex characters of the content hash in file names
+const hashLength = 8
+
+// Manifest maps static file names to their fingerprinted names
+type Manifest struct {
+	prefix    string
+	files     map[string]string
+	originals map[string]string
+}
+
+// New creates an empty manifest, linking every file unhashed under prefix
+func New(prefix string) *Manifest {
+	return &Manifest{prefix: prefix, files: map[string]string{}, originals: map[string]string{}}
+}
+
+// Build hashes every file in fsys into a manifest linking under prefix
+func Build(fsys fs.FS, prefix string) (*Manifest, error) {
+	m := New(prefix)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || name == ManifestFile {
+			return err
+		}
+		hash, err := hashFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		m.add(name, hashedName(name, hash))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load reads the manifest written by Run from fsys, the error wraps fs.ErrNotExist if there is none
+func Load(fsys fs.FS, prefix string) (*Manifest, error) {
+	data, err := fs.ReadFile(fsys, ManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var files map[string]string
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, err
+	}
+	m := New(prefix)
+	for name, hashed := range files {
+		m.add(name, hashed)
+	}
+	return m, nil
+}
+
+// WriteFile writes the manifest as JSON so it can be loaded with Load
+func (m *Manifest) WriteFile(name string) error {
+	data, err := json.MarshalIndent(m.files, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0644)
+}
+
+// URL returns the URL of the fingerprinted file, or the unhashed URL if the file isn't in the manifest
+func (m *Manifest) URL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if hashed, ok := m.files[name]; ok {
+		return m.prefix + hashed
+	}
+	return m.prefix + name
+}
+
+// Len returns the number of files in the manifest
+func (m *Manifest) Len() int {
+	return len(m.files)
+}
+
+// Handler serves the files of fsys, hashed names with immutable cache headers
+// The request path must be relative to the manifest prefix - use http.StripPrefix
+func (m *Manifest) Handler(fsys fs.FS) http.Handler {
+	files := http.FileServerFS(fsys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		original, hashed := m.originals[name]
+		if !hashed {
+			w.Header().Set("Cache-Control", "no-cache")
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + original
+		r2.URL.RawPath = ""
+		files.ServeHTTP(w, r2)
+	})
+}
+
+// add registers a file and its hashed name
+func (m *Manifest) add(name, hashed string) {
+	m.files[name] = hashed
+	m.originals[hashed] = name
+}
+
+// manifestKey is the context key of the manifest
+type manifestKey struct{}
+
+// WithManifest returns a copy of ctx carrying the manifest used by URL
+func WithManifest(ctx context.Context, m *Manifest) context.Context {
+	return context.WithValue(ctx, manifestKey{}, m)
+}
+
+// URL returns the fingerprinted URL of a static file using the manifest in ctx,
+// without one the file is linked unhashed under DefaultPrefix
+func URL(ctx context.Context, name string) string {
+	m, ok := ctx.Value(manifestKey{}).(*Manifest)
+	if !ok {
+		return DefaultPrefix + strings.TrimPrefix(name, "/")
+	}
+	return m.URL(name)
+}
+
+// hashFile returns the hex content hash of a file
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:hashLength], nil
+}
+
+// hashedName inserts the hash before the extension: css/style.css becomes css/style.1a2b3c4d.css
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
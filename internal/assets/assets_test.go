@@ -0,0 +1,110 @@
+package assets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+// testFS is a small static folder
+var testFS = fstest.MapFS{
+	"css/style.css": {Data: []byte("body { color: red; }")},
+	"logo.png":      {Data: []byte("png")},
+}
+
+func TestBuild(t *testing.T) {
+	m, err := Build(testFS, "/static/")
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	if got := m.URL("css/style.css"); !regexp.MustCompile(`^/static/css/style\.[0-9a-f]{8}\.css$`).MatchString(got) {
+		t.Errorf("URL(css/style.css) = %q, want a hashed name", got)
+	}
+	if got := m.URL("missing.js"); got != "/static/missing.js" {
+		t.Errorf("URL(missing.js) = %q, want the unhashed URL", got)
+	}
+
+	t.Run("hash changes with the content", func(t *testing.T) {
+		changed := fstest.MapFS{"css/style.css": {Data: []byte("body { color: blue; }")}}
+		other, _ := Build(changed, "/static/")
+		if other.URL("css/style.css") == m.URL("css/style.css") {
+			t.Error("different content produced the same URL")
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	m, _ := Build(testFS, "/static/")
+	handler := http.StripPrefix("/static/", m.Handler(testFS))
+
+	tests := []struct {
+		name        string
+		url         string
+		wantStatus  int
+		wantCaching string
+	}{
+		{"hashed", m.URL("css/style.css"), http.StatusOK, "public, max-age=31536000, immutable"},
+		{"unhashed", "/static/css/style.css", http.StatusOK, "no-cache"},
+		{"missing", "/static/css/style.00000000.css", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCaching {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCaching)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != "body { color: red; }" {
+				t.Errorf("body = %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestURLFromContext(t *testing.T) {
+	if got := URL(context.Background(), "css/style.css"); got != "/static/css/style.css" {
+		t.Errorf("URL without manifest = %q", got)
+	}
+
+	m, _ := Build(testFS, "/assets/")
+	ctx := WithManifest(context.Background(), m)
+	if got := URL(ctx, "/css/style.css"); got != m.URL("css/style.css") {
+		t.Errorf("URL with manifest = %q, want %q", got, m.URL("css/style.css"))
+	}
+}
+
+func TestRunAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log(1)"), 0644)
+
+	var hookRan bool
+	built, err := Run(context.Background(), dir, "/static/", func(ctx context.Context, dir string) error {
+		hookRan = true
+		return os.WriteFile(filepath.Join(dir, "bundle.js"), []byte("bundled"), 0644)
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !hookRan || built.Len() != 2 {
+		t.Fatalf("Run built %d files with hook ran = %v, want 2 files including the hook output", built.Len(), hookRan)
+	}
+
+	loaded, err := Load(os.DirFS(dir), "/static/")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	for _, name := range []string{"app.js", "bundle.js"} {
+		if loaded.URL(name) != built.URL(name) {
+			t.Errorf("loaded URL(%s) = %q, want %q", name, loaded.URL(name), built.URL(name))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Hook processes the static files in dir before they're fingerprinted, e.g. bundling or minifying
+type Hook func(ctx context.Context, dir string) error
+
+// Run runs the hooks on dir, fingerprints its files and writes the manifest into dir
+func Run(ctx context.Context, dir, prefix string, hooks ...Hook) (*Manifest, error) {
+	for _, hook := range hooks {
+		if err := hook(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+	m, err := Build(os.DirFS(dir), prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.WriteFile(filepath.Join(dir, ManifestFile)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Esbuild bundles every entry point with the esbuild binary into outDir, both relative to the static dir
+// e.g. js/app.js is bundled into dist/js/app.js, the esbuild binary must be installed separately
+func Esbuild(binary string, entries []string, outDir string, minify bool) Hook {
+	return func(ctx context.Context, dir string) error {
+		for _, entry := range entries {
+			args := []string{
+				filepath.Join(dir, entry),
+				"--bundle",
+				"--outfile=" + filepath.Join(dir, outDir, entry),
+			}
+			if minify {
+				args = append(args, "--minify")
+			}
+			cmd := exec.CommandContext(ctx, binary, args...)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("assets: esbuild %s: %w\n%s", entry, err, output)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/params"
+	"time"
+)
+
+/*
+   Package audit records who did what to which object, for compliance review. Entries are stored
+   in the audit_log table together with the request ID and client IP of the request that made them.
+
+   How to use:
+   1. Create a recorder with the database
+   2. Make it available to handlers with WithRecorder - the audit middleware also adds the request metadata
+   3. Record actions with audit.Record from handlers and services
+   4. Prune old entries periodically, e.g. from the cron runner
+
+   Example recording an action in a handler:
+       err := audit.Record(r.Context(), user.Username, "user.password_reset",
+           audit.Object{Type: "users", ID: "42"}, nil)
+
+   Example recording field changes:
+       changes := audit.Diff(map[string]string{"email": "old@example.com"}, map[string]string{"email": "new@example.com"})
+       err := audit.Record(ctx, "admin", "update", audit.Object{Type: "users", ID: "42"}, changes)
+
+   Example listing entries for review:
+       q, _ := params.Parse(r.URL.Query(), audit.ListOptions)
+       page, err := recorder.List(ctx, q)   // supports filter[actor], filter[action], filter[object_type],
+                                            // filter[object_id], filter[from] and filter[to]
+
+   Notes:
+   - audit.Record does nothing when no recorder is in the context, so code can record unconditionally
+   - filter[from] and filter[to] are dates or "YYYY-MM-DD HH:MM:SS" times in UTC, to is exclusive
+   - Never put secrets in changes, they're stored as plain JSON
+*/
+
+// Change is the old and new value of a field
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Changes are field changes keyed by field name
+type Changes map[string]Change
+
+// Object identifies the object an action was performed on
+type Object struct {
+	Type string
+	ID   string
+}
+
+// Entry is a recorded action
+type Entry struct {
+	ID         int64     `json:"id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	ObjectType string    `json:"object_type,omitempty"`
+	ObjectID   string    `json:"object_id,omitempty"`
+	Changes    Changes   `json:"changes,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListOptions are the pagination and filter options accepted by List
+var ListOptions = params.Options{
+	DefaultPerPage: 50,
+	MaxPerPage:     500,
+	AllowedFilters: []string{"actor", "action", "object_type", "object_id", "from", "to"},
+}
+
+// Recorder stores audit entries
+type Recorder struct {
+	queries *sqlc.Queries
+	now     func() time.Time
+}
+
+// New creates a recorder
+func New(db *sql.DB) *Recorder {
+	return &Recorder{queries: sqlc.New(db), now: time.Now}
+}
+
+// Record stores an action, the request ID and IP are taken from the context when present
+func (r *Recorder) Record(ctx context.Context, actor, action string, object Object, changes Changes) (*Entry, error) {
+	if changes == nil {
+		changes = Changes{}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := requestFromContext(ctx)
+	row, err := r.queries.CreateAuditEntry(ctx, sqlc.CreateAuditEntryParams{
+		Actor:      actor,
+		Action:     action,
+		ObjectType: object.Type,
+		ObjectID:   object.ID,
+		Changes:    string(data),
+		RequestID:  meta.requestID,
+		Ip:         meta.ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := fromRow(row)
+	return &entry, nil
+}
+
+// List returns a page of entries matching the query filters, newest first
+func (r *Recorder) List(ctx context.Context, q *params.Query) (params.Page[Entry], error) {
+	filter := sqlc.CountAuditEntriesParams{
+		Actor:       q.Filters["actor"],
+		Action:      q.Filters["action"],
+		ObjectType:  q.Filters["object_type"],
+		ObjectID:    q.Filters["object_id"],
+		CreatedFrom: q.Filters["from"],
+		CreatedTo:   q.Filters["to"],
+	}
+	rows, err := r.queries.ListAuditEntries(ctx, sqlc.ListAuditEntriesParams{
+		Actor:       filter.Actor,
+		Action:      filter.Action,
+		ObjectType:  filter.ObjectType,
+		ObjectID:    filter.ObjectID,
+		CreatedFrom: filter.CreatedFrom,
+		CreatedTo:   filter.CreatedTo,
+		Limit:       q.Limit(),
+		Offset:      q.Offset(),
+	})
+	if err != nil {
+		return params.Page[Entry]{}, err
+	}
+	total, err := r.queries.CountAuditEntries(ctx, filter)
+	if err != nil {
+		return params.Page[Entry]{}, err
+	}
+
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, fromRow(row))
+	}
+	return params.NewPage(q, entries, total), nil
+}
+
+// Prune deletes entries older than retention and returns how many were deleted
+func (r *Recorder) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	before := r.now().UTC().Add(-retention).Format(time.DateTime)
+	return r.queries.DeleteAuditEntriesBefore(ctx, before)
+}
+
+// Diff returns the fields whose values differ between before and after, missing fields count as empty
+func Diff(before, after map[string]string) Changes {
+	changes := Changes{}
+	for name, from := range before {
+		if to := after[name]; to != from {
+			changes[name] = Change{From: from, To: to}
+		}
+	}
+	for name, to := range after {
+		if _, ok := before[name]; !ok && to != "" {
+			changes[name] = Change{To: to}
+		}
+	}
+	return changes
+}
+
+// fromRow converts a database row to an entry
+func fromRow(row sqlc.AuditLog) Entry {
+	entry := Entry{
+		ID:         row.ID,
+		Actor:      row.Actor,
+		Action:     row.Action,
+		ObjectType: row.ObjectType,
+		ObjectID:   row.ObjectID,
+		RequestID:  row.RequestID,
+		IP:         row.Ip,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+	// Entries are written by Record, so the changes are always valid JSON
+	json.Unmarshal([]byte(row.Changes), &entry.Changes)
+	return entry
+}
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"context"
+	"mookie/internal/db"
+	"mookie/internal/params"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestRecorder returns a recorder backed by a temporary database
+func newTestRecorder(t *testing.T) *Recorder {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return New(database)
+}
+
+// list returns the entries matching the query string
+func list(t *testing.T, r *Recorder, query string) params.Page[Entry] {
+	t.Helper()
+	values, _ := url.ParseQuery(query)
+	q, err := params.Parse(values, ListOptions)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	page, err := r.List(context.Background(), q)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	return page
+}
+
+func TestRecordFromContext(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	t.Run("without recorder", func(t *testing.T) {
+		if err := Record(context.Background(), "alice", "login", Object{}, nil); err != nil {
+			t.Errorf("Record without recorder returned error: %v", err)
+		}
+	})
+
+	t.Run("with recorder and request", func(t *testing.T) {
+		ctx := WithRequest(WithRecorder(context.Background(), recorder), "req-1", "10.0.0.1")
+		changes := Changes{"email": {From: "a@example.com", To: "b@example.com"}}
+		if err := Record(ctx, "alice", "update", Object{Type: "users", ID: "7"}, changes); err != nil {
+			t.Fatalf("Record returned error: %v", err)
+		}
+
+		page := list(t, recorder, "")
+		if page.Total != 1 {
+			t.Fatalf("List returned %d entries, want 1", page.Total)
+		}
+		entry := page.Items[0]
+		if entry.Actor != "alice" || entry.ObjectID != "7" || entry.RequestID != "req-1" || entry.IP != "10.0.0.1" {
+			t.Errorf("entry = %+v", entry)
+		}
+		if !reflect.DeepEqual(entry.Changes, changes) {
+			t.Errorf("changes = %v, want %v", entry.Changes, changes)
+		}
+	})
+}
+
+func TestListFilters(t *testing.T) {
+	recorder := newTestRecorder(t)
+	ctx := context.Background()
+	recorder.Record(ctx, "alice", "create", Object{Type: "users", ID: "1"}, nil)
+	recorder.Record(ctx, "alice", "delete", Object{Type: "flags", ID: "2"}, nil)
+	recorder.Record(ctx, "bob", "create", Object{Type: "users", ID: "3"}, nil)
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"", 3},
+		{"filter[actor]=alice", 2},
+		{"filter[action]=create", 2},
+		{"filter[actor]=alice&filter[object_type]=users", 1},
+		{"filter[object_id]=3", 1},
+		{"filter[from]=2000-01-01", 3},
+		{"filter[to]=2000-01-01", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if page := list(t, recorder, tt.query); page.Total != tt.want {
+				t.Errorf("List(%q) returned %d entries, want %d", tt.query, page.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	recorder := newTestRecorder(t)
+	ctx := context.Background()
+	recorder.Record(ctx, "alice", "create", Object{}, nil)
+
+	if deleted, err := recorder.Prune(ctx, time.Hour); err != nil || deleted != 0 {
+		t.Fatalf("Prune(1h) = %d, %v, want 0 deleted", deleted, err)
+	}
+
+	recorder.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if deleted, err := recorder.Prune(ctx, time.Hour); err != nil || deleted != 1 {
+		t.Fatalf("Prune(1h) two hours later = %d, %v, want 1 deleted", deleted, err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := map[string]string{"username": "alice", "email": "a@example.com", "role": "admin"}
+	after := map[string]string{"username": "alice", "email": "b@example.com", "name": "Alice"}
+
+	want := Changes{
+		"email": {From: "a@example.com", To: "b@example.com"},
+		"role":  {From: "admin"},
+		"name":  {To: "Alice"},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package audit
+
+import "context"
+
+// recorderKey and requestKey are the context keys of the recorder and the request metadata
+type (
+	recorderKey struct{}
+	requestKey  struct{}
+)
+
+// requestMeta identifies the request an action was made in
+type requestMeta struct {
+	requestID string
+	ip        string
+}
+
+// WithRecorder returns a copy of ctx carrying the recorder used by Record
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// WithRequest returns a copy of ctx carrying the request ID and client IP stored with entries
+func WithRequest(ctx context.Context, requestID, ip string) context.Context {
+	return context.WithValue(ctx, requestKey{}, requestMeta{requestID: requestID, ip: ip})
+}
+
+// Record stores an action with the recorder in ctx, it does nothing if there is none
+func Record(ctx context.Context, actor, action string, object Object, changes Changes) error {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return nil
+	}
+	_, err := r.Record(ctx, actor, action, object, changes)
+	return err
+}
+
+// requestFromContext returns the request metadata in ctx, empty if there is none
+func requestFromContext(ctx context.Context) requestMeta {
+	meta, _ := ctx.Value(requestKey{}).(requestMeta)
+	return meta
+}
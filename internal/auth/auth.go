@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"slices"
 )
 
 // Define auth errors
@@ -15,6 +16,12 @@ var (
 type AuthUser struct {
 	ID       string
 	Username string
+	Roles    []string
+}
+
+// HasRole reports whether the user has the given role
+func (u *AuthUser) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
 }
 
 // Authenticator is the interface that all auth methods must implement
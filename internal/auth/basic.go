@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+   BasicAuthenticator authenticates requests using HTTP basic auth against bcrypt
+   password hashes. The lookup function decouples it from the storage layer.
+
+   Example:
+       authenticator := auth.NewBasicAuthenticator(func(ctx context.Context, username string) (*auth.Credentials, error) {
+           user, err := queries.GetUserByUsername(ctx, username)
+           if err != nil {
+               return nil, err
+           }
+           roles, err := queries.ListUserRoles(ctx, user.ID)
+           if err != nil {
+               return nil, err
+           }
+           return &auth.Credentials{
+               User:         auth.AuthUser{ID: strconv.FormatInt(user.ID, 10), Username: user.Username, Roles: roles},
+               PasswordHash: user.Password,
+           }, nil
+       })
+*/
+
+// Credentials holds a user and the stored password hash used to verify it
+type Credentials struct {
+	User         AuthUser
+	PasswordHash string
+}
+
+// CredentialsLookup finds the credentials for a username
+type CredentialsLookup func(ctx context.Context, username string) (*Credentials, error)
+
+// BasicAuthenticator authenticates requests with HTTP basic auth
+type BasicAuthenticator struct {
+	lookup CredentialsLookup
+}
+
+// NewBasicAuthenticator creates a new BasicAuthenticator
+func NewBasicAuthenticator(lookup CredentialsLookup) *BasicAuthenticator {
+	return &BasicAuthenticator{lookup: lookup}
+}
+
+// Authenticate checks the basic auth credentials of the request
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*AuthUser, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	creds, err := a.lookup(r.Context(), username)
+	if err != nil || creds == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(creds.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user := creds.User
+	return &user, nil
+}
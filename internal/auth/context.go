@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+// contextKey is an unexported type for context keys defined in this package
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user
+func WithUser(ctx context.Context, user *AuthUser) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user stored in ctx, or nil if there is none
+func UserFromContext(ctx context.Context) *AuthUser {
+	user, _ := ctx.Value(contextKey{}).(*AuthUser)
+	return user
+}
@@ -3,6 +3,7 @@ package cache
 
 import (
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -41,6 +42,54 @@ import (
 			// Handle unexpected error
 		}
 
+	Example counters:
+		// Counts the requests of a client in the current minute, safe for concurrent requests
+		count, err := cache.Increment("requests:"+ip, 1, time.Minute)
+		if err == nil && count > 100 {
+			// Reject the request
+		}
+
+	Example invalidating a family of keys:
+		// After a profile update, drop everything cached for the user
+		removed, err := cache.DeleteMatching("user:123:*")
+
+	Example sliding expiration:
+		// Every request keeps the session for another 30 minutes
+		if err := cache.Touch("session:"+id, 30*time.Minute); err != nil {
+			// Session expired or missing
+		}
+		// Log the user out at midnight whatever happens
+		cache.ExpireAt("session:"+id, midnight)
+
+	Example batches:
+		// Warm a page with one lock or query instead of one per key
+		items, err := cache.GetMulti([]string{"user:1", "user:2", "user:3"})
+		for key, item := range items {
+			// Missing and expired keys are left out
+		}
+		cache.SetMulti(map[string]cache.Item{"user:1": {Value: user1}, "user:2": {Value: user2}}, 5*time.Minute)
+
+	Example negative caching:
+		// Remember for a minute that the user doesn't exist instead of querying it on every request
+		item, err := cache.Get("user:" + slug)
+		if errors.Is(err, cache.ErrNegativeCached) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			user, err := queries.GetUserBySlug(ctx, slug)
+			if errors.Is(err, sql.ErrNoRows) {
+				cache.SetNotFound("user:"+slug, time.Minute)
+			}
+		}
+
+	Example remaining lifetime:
+		// Let clients cache the response as long as the server does
+		item, err := cache.Get("report:" + id)
+		if err == nil && item.TTL() != NoExpiration {
+			w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(item.TTL().Seconds())))
+		}
+
 	Notes:
 	- Set zero expiration time to disable expiration
 	- Expired items should be automatically cleaned up
@@ -48,8 +97,12 @@ import (
 
 // Define cache errors
 var (
-	ErrNotFound = errors.New("cache: key not found")
-	ErrExpired  = errors.New("cache: item has expired")
+	ErrNotFound   = errors.New("cache: key not found")
+	ErrExpired    = errors.New("cache: item has expired")
+	ErrNotInteger = errors.New("cache: value is not an integer")
+	ErrTooLarge   = errors.New("cache: item is too large")
+	// ErrNegativeCached is returned by Get for keys cached as not found with SetNotFound
+	ErrNegativeCached = errors.New("cache: key cached as not found")
 )
 
 // Item represents a cache entry
@@ -61,22 +114,183 @@ type Item struct {
 	ExpiresAt time.Time
 }
 
+// NoExpiration is the TTL of an item that never expires
+const NoExpiration time.Duration = -1
+
+// TTL returns how long the item remains valid from now, NoExpiration if it never expires
+// An item that expired since it was read has a TTL of 0
+func (i *Item) TTL() time.Duration {
+	if i.ExpiresAt.IsZero() {
+		return NoExpiration
+	}
+	return max(time.Until(i.ExpiresAt), 0)
+}
+
 // Cache defines the interface that cache implementations must satisfy
 type Cache interface {
 	// Get retrieves an item from the cache by key
 	// Returns ErrNotFound if the key doesn't exist
 	// Returns ErrExpired if the item has expired
+	// Returns ErrNegativeCached if the key was cached as not found with SetNotFound
 	Get(key string) (*Item, error)
 
 	// Set adds an item to the cache with the specified key and expiration
 	// If duration is 0, the item never expires
 	// If key already exists, the item will be overwritten
+	// Returns ErrTooLarge if the cache limits the size of items and the item is larger
 	Set(key string, value interface{}, duration time.Duration) error
 
+	// SetNotFound caches key as not found for ttl, Get returns ErrNegativeCached until it expires or
+	// the key is set - keep ttl short so created records are seen quickly
+	SetNotFound(key string, ttl time.Duration) error
+
+	// GetMulti retrieves the items of several keys at once, keyed by their key
+	// Missing, expired and negatively cached keys are left out of the map, it's empty but not nil if
+	// none is found
+	GetMulti(keys []string) (map[string]*Item, error)
+
+	// SetMulti adds several items to the cache at once, overwriting existing keys
+	// An item expires at its ExpiresAt if set, otherwise after ttl - never if ttl is 0
+	SetMulti(items map[string]Item, ttl time.Duration) error
+
 	// Delete removes an item from the cache
 	// Returns nil if the key was removed or didn't exist
 	Delete(key string) error
 
 	// Clear removes all items from the cache
 	Clear() error
+
+	// Increment adds delta to the integer stored under key and returns the new value, atomically
+	// A missing or expired key starts at 0 and expires after ttl, never if ttl is 0 - an existing key
+	// keeps its expiration. The value is stored as an int64
+	// Returns ErrNotInteger if the stored value isn't an integer
+	Increment(key string, delta int64, ttl time.Duration) (int64, error)
+
+	// Decrement subtracts delta from the integer stored under key, see Increment
+	Decrement(key string, delta int64, ttl time.Duration) (int64, error)
+
+	// Touch extends the lifetime of an item to ttl from now without setting its value again
+	// If ttl is 0, the item never expires
+	// Returns ErrNotFound if the key doesn't exist and ErrExpired if the item has expired
+	Touch(key string, ttl time.Duration) error
+
+	// ExpireAt sets the expiration of an item to t, the item never expires if t is zero
+	// Returns ErrNotFound if the key doesn't exist and ErrExpired if the item has expired
+	ExpireAt(key string, t time.Time) error
+
+	// Keys returns the sorted keys of the items matching the glob pattern, expired items and keys
+	// cached as not found excluded
+	// * matches any characters, ? one character and [...] one character of a set like [abc], [a-z] or [^0-9]
+	Keys(pattern string) ([]string, error)
+
+	// DeleteMatching removes the items matching the glob pattern and returns how many were removed,
+	// including expired items not yet cleaned up and keys cached as not found
+	DeleteMatching(pattern string) (int, error)
+}
+
+// notFound is the value of the keys cached as not found, a string so every codec stores it
+const notFound = "\x00cache:not-found\x00"
+
+// match reports whether key matches the glob pattern, with the rules of the SQLite GLOB operator
+func match(pattern, key string) bool {
+	p, k := []rune(pattern), []rune(key)
+	// star is the position of the last * in the pattern and next the key position it's retried from
+	star, next := -1, 0
+	i, j := 0, 0
+	for j < len(k) {
+		if i < len(p) {
+			switch p[i] {
+			case '*':
+				star, next = i, j
+				i++
+				continue
+			case '?':
+				i, j = i+1, j+1
+				continue
+			case '[':
+				if end, ok := matchSet(p, i, k[j]); ok {
+					i, j = end, j+1
+					continue
+				}
+			default:
+				if p[i] == k[j] {
+					i, j = i+1, j+1
+					continue
+				}
+			}
+		}
+		// Let the last * match one more character
+		if star < 0 {
+			return false
+		}
+		next++
+		i, j = star+1, next
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
+
+// matchSet reports whether r is in the set starting at p[start], e.g. [a-z], and returns the position
+// after the set - a ] right after [ or [^ is part of the set, a set without ] matches nothing
+func matchSet(p []rune, start int, r rune) (int, bool) {
+	i := start + 1
+	negate := i < len(p) && p[i] == '^'
+	if negate {
+		i++
+	}
+	found := false
+	for first := true; i < len(p) && (first || p[i] != ']'); first = false {
+		lo, hi := p[i], p[i]
+		if i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']' {
+			hi = p[i+2]
+			i += 2
+		}
+		if lo <= r && r <= hi {
+			found = true
+		}
+		i++
+	}
+	if i >= len(p) {
+		return 0, false
+	}
+	return i + 1, found != negate
+}
+
+// quoteGlob returns a glob pattern matching s literally, the special characters are put in sets
+func quoteGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// deadline returns the expiration of an item touched with ttl, zero if it never expires
+func deadline(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// integer returns a stored integer value as an int64
+func integer(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	}
+	return 0, ErrNotInteger
 }
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+/*
+	Package cache's codecs serialize the values stored by caches outside of the process memory,
+	like the SQLite cache.
+
+	Example JSON values:
+	   // Values are stored as readable JSON, shared with services in other languages
+	   cache := cache.NewSQLiteCacheWithCodec(db, cache.JSONCodec{})
+
+	Notes:
+	- GobCodec is the default, it keeps the type of the values but their types must be registered
+	  with gob.Register, except basic types and their slices
+	- JSONCodec doesn't keep the types, objects are read back as map[string]interface{}, arrays as
+	  []interface{} and numbers as int64 or float64
+	- Other formats like MessagePack are added by implementing Codec
+*/
+
+// Codec serializes cache values
+type Codec interface {
+	// Encode serializes a value
+	Encode(value interface{}) ([]byte, error)
+	// Decode deserializes a value serialized by Encode
+	Decode(data []byte) (interface{}, error)
+}
+
+// GobCodec serializes values with encoding/gob, keeping their type
+type GobCodec struct{}
+
+// Encode encodes a value as an interface so its type is kept
+func (GobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a value encoded by Encode
+func (GobCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// JSONCodec serializes values with encoding/json
+type JSONCodec struct{}
+
+// Encode encodes a value as JSON
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode decodes a JSON value, integers are decoded as int64 so counters keep working
+func (JSONCodec) Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return numbers(value), nil
+}
+
+// numbers replaces the json.Number values of a decoded JSON value with int64 or float64
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	testCases := []struct {
+		name  string
+		codec Codec
+		value interface{}
+		want  interface{}
+	}{
+		{"gob string", GobCodec{}, "hello", "hello"},
+		{"gob int", GobCodec{}, 42, 42},
+		{"gob struct", GobCodec{}, testValue{Name: "test", Count: 3}, testValue{Name: "test", Count: 3}},
+		{"json string", JSONCodec{}, "hello", "hello"},
+		{"json int", JSONCodec{}, 42, int64(42)},
+		{"json float", JSONCodec{}, 3.14, 3.14},
+		{"json bool", JSONCodec{}, true, true},
+		{"json struct", JSONCodec{}, testValue{Name: "test", Count: 3}, map[string]interface{}{"Name": "test", "Count": int64(3)}},
+		{"json slice", JSONCodec{}, []int{1, 2}, []interface{}{int64(1), int64(2)}},
+		{"json nested", JSONCodec{}, map[string][]float64{"a": {1.5}}, map[string]interface{}{"a": []interface{}{1.5}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.codec.Encode(tc.value)
+			if err != nil {
+				t.Fatalf("Encode returned error: %v", err)
+			}
+			value, err := tc.codec.Decode(data)
+			if err != nil {
+				t.Fatalf("Decode returned error: %v", err)
+			}
+			if !reflect.DeepEqual(value, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, value)
+			}
+		})
+	}
+
+	// Values the codec can't represent are errors
+	if _, err := (JSONCodec{}).Encode(make(chan int)); err == nil {
+		t.Error("expected an error encoding a channel as JSON")
+	}
+	if _, err := (JSONCodec{}).Decode([]byte("{")); err == nil {
+		t.Error("expected an error decoding invalid JSON")
+	}
+}
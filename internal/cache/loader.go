@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+	Package cache's loader wraps a cache so misses load the value from its source, like the database,
+	and store it for the next reads.
+
+	How to use:
+	1. Write a loader reading a value by key from its source
+	2. Wrap a cache with the loader and the time loaded values are kept
+	3. Read with Load, or Get without a context
+
+	Example basic usage:
+	   users := cache.WrapLoader(cache.NewMemoryCache(), func(ctx context.Context, key string) (interface{}, error) {
+		   return queries.GetUser(ctx, strings.TrimPrefix(key, "user:"))
+	   }, 5*time.Minute)
+
+	   item, err := users.Load(ctx, "user:123")
+	   if err == nil {
+		   user := item.Value.(sqlc.User)
+		   // Use user...
+	   }
+
+	Notes:
+	- Concurrent misses of a key share a single call of the loader, so the source sees one query per
+	  cold key - the waiting calls get the result of the first one, including its cancellation
+	- Loader errors are returned as is and not cached, the next read calls the loader again - call
+	  SetNotFound in the loader to cache missing records, Load then returns ErrNegativeCached
+	- Only Get and Load call the loader, the other operations go to the wrapped cache
+*/
+
+// errLoaderPanic is returned to the calls waiting for a loader that panicked
+var errLoaderPanic = errors.New("cache: loader panicked")
+
+// Loader reads the value of a key from its source
+type Loader func(ctx context.Context, key string) (interface{}, error)
+
+// LoadingCache is a cache calling a loader on misses
+type LoadingCache struct {
+	Cache
+	loader Loader
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+// loadCall is a call of the loader, shared by the concurrent misses of its key
+type loadCall struct {
+	done chan struct{}
+	item *Item
+	err  error
+}
+
+// WrapLoader creates a LoadingCache storing the values loaded on misses in c for ttl, never expiring
+// if ttl is 0
+func WrapLoader(c Cache, loader Loader, ttl time.Duration) *LoadingCache {
+	return &LoadingCache{
+		Cache:  c,
+		loader: loader,
+		ttl:    ttl,
+		calls:  make(map[string]*loadCall),
+	}
+}
+
+// Get retrieves an item from the cache, loading it on a miss
+func (c *LoadingCache) Get(key string) (*Item, error) {
+	return c.Load(context.Background(), key)
+}
+
+// Load retrieves an item from the cache, or loads and stores it
+// Any other error than ErrNegativeCached reading the cache is a miss, so the value is still loaded if
+// the cache fails
+func (c *LoadingCache) Load(ctx context.Context, key string) (*Item, error) {
+	item, err := c.Cache.Get(key)
+	if err == nil || errors.Is(err, ErrNegativeCached) {
+		return item, err
+	}
+
+	c.mu.Lock()
+	call, loading := c.calls[key]
+	if !loading {
+		call = &loadCall{done: make(chan struct{})}
+		c.calls[key] = call
+	}
+	c.mu.Unlock()
+
+	if !loading {
+		c.run(ctx, key, call)
+	} else {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	// Each caller gets its own copy of the item
+	loaded := *call.item
+	return &loaded, nil
+}
+
+// run loads the value of a shared call and releases the waiting calls, even if the loader panics
+func (c *LoadingCache) run(ctx context.Context, key string, call *loadCall) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	// Kept by the waiting calls if the loader panics
+	call.err = errLoaderPanic
+	call.item, call.err = c.load(ctx, key)
+}
+
+// load calls the loader and stores the value, storing is best effort and the value is returned anyway
+func (c *LoadingCache) load(ctx context.Context, key string) (*Item, error) {
+	value, err := c.loader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.Cache.Set(key, value, c.ttl)
+	return &Item{Value: value, ExpiresAt: deadline(c.ttl)}, nil
+}
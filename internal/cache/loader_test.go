@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadingCache_Load(t *testing.T) {
+	memory := NewMemoryCache()
+	defer memory.Close()
+	var calls atomic.Int32
+	errMissing := errors.New("missing")
+	cache := WrapLoader(memory, func(ctx context.Context, key string) (interface{}, error) {
+		calls.Add(1)
+		if key == "missing" {
+			return nil, errMissing
+		}
+		return "loaded:" + key, nil
+	}, time.Minute)
+
+	// A miss loads and stores the value
+	item, err := cache.Load(context.Background(), "key")
+	if err != nil || item.Value != "loaded:key" || item.TTL() <= 59*time.Second {
+		t.Errorf("expected the loaded value for a minute, got %+v, %v", item, err)
+	}
+	if stored, err := memory.Get("key"); err != nil || stored.Value != "loaded:key" {
+		t.Errorf("expected the loaded value stored, got %+v, %v", stored, err)
+	}
+
+	// A hit doesn't call the loader
+	if item, err := cache.Get("key"); err != nil || item.Value != "loaded:key" {
+		t.Errorf("expected the cached value, got %+v, %v", item, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 loader call, got %d", n)
+	}
+
+	// Loader errors are returned and not cached
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get("missing"); !errors.Is(err, errMissing) {
+			t.Errorf("expected the loader error, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected the loader called again after an error, got %d calls", n)
+	}
+}
+
+func TestLoadingCache_NotFound(t *testing.T) {
+	memory := NewMemoryCache()
+	defer memory.Close()
+	var calls atomic.Int32
+	var cache *LoadingCache
+	cache = WrapLoader(memory, func(ctx context.Context, key string) (interface{}, error) {
+		calls.Add(1)
+		cache.SetNotFound(key, time.Minute)
+		return nil, ErrNegativeCached
+	}, time.Minute)
+
+	// Keys cached as not found by the loader don't call it again
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Load(context.Background(), "ghost"); err != ErrNegativeCached {
+			t.Errorf("expected ErrNegativeCached, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 loader call, got %d", n)
+	}
+}
+
+func TestLoadingCache_SingleFlight(t *testing.T) {
+	memory := NewMemoryCache()
+	defer memory.Close()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := WrapLoader(memory, func(ctx context.Context, key string) (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}, 0)
+
+	// Concurrent misses of a key share one loader call
+	var wg sync.WaitGroup
+	values := make(chan interface{}, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, err := cache.Load(context.Background(), "cold")
+			if err != nil {
+				t.Errorf("Load returned error: %v", err)
+				return
+			}
+			values <- item.Value
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(values)
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 loader call, got %d", n)
+	}
+	for value := range values {
+		if value != 42 {
+			t.Errorf("expected 42, got %v", value)
+		}
+	}
+}
+
+func TestLoadingCache_Cancel(t *testing.T) {
+	memory := NewMemoryCache()
+	defer memory.Close()
+	release := make(chan struct{})
+	cache := WrapLoader(memory, func(ctx context.Context, key string) (interface{}, error) {
+		<-release
+		return "value", nil
+	}, 0)
+
+	go cache.Load(context.Background(), "slow")
+	time.Sleep(20 * time.Millisecond)
+
+	// A waiting call stops waiting when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := cache.Load(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	close(release)
+}
+
+func TestLoadingCache_Panic(t *testing.T) {
+	memory := NewMemoryCache()
+	defer memory.Close()
+	release := make(chan struct{})
+	cache := WrapLoader(memory, func(ctx context.Context, key string) (interface{}, error) {
+		<-release
+		panic("boom")
+	}, 0)
+
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		cache.Load(context.Background(), "key")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Waiting calls are released with an error when the loader panics
+	waiting := make(chan error)
+	go func() {
+		_, err := cache.Load(context.Background(), "key")
+		waiting <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if r := <-panicked; r != "boom" {
+		t.Errorf("expected the panic to reach the loading call, got %v", r)
+	}
+	if err := <-waiting; err != errLoaderPanic {
+		t.Errorf("expected errLoaderPanic, got %v", err)
+	}
+}
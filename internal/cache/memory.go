@@ -2,6 +2,10 @@
 package cache
 
 import (
+	"container/list"
+	"context"
+	"fmt"
+	"slices"
 	"sync"
 	"time"
 )
@@ -30,42 +34,153 @@ import (
 		   // Use value...
 	   }
 
+	Example bounded cache:
+	   // At most 10000 items, the least recently used item is evicted to make room
+	   cache := cache.NewMemoryCacheWithSize(10000)
+	   evicted := cache.Evictions()
+
+	Example memory budget:
+	   // About 64MB of items, a single item can't take more than 1MB
+	   cache := cache.NewMemoryCache(cache.MaxBytes(64<<20), cache.MaxItemBytes(1<<20))
+	   if err := cache.Set("report", blob, time.Hour); errors.Is(err, cache.ErrTooLarge) {
+		   // Not cached
+	   }
+
+	Example cleanup interval:
+	   // Expired items are deleted every 10 seconds, Close stops the cleanup goroutine
+	   cache := cache.NewMemoryCache(cache.CleanupInterval(10 * time.Second))
+	   defer cache.Close()
+
 	Features:
 	- Thread-safe operations
 	- Automatic cleanup of expired items
 	- Zero allocation for non-expired gets
 	- Configurable cleanup interval
+	- Change notifications with OnEvent
 	- Efficient memory usage
 
 	Notes:
 	- Uses sync.RWMutex for thread safety
-	- Cleanup runs every minute in background
+	- Cleanup runs every minute in background until Close or Stop is called, create short lived caches
+	  without cleanup or close them
 	- Safe for concurrent access
 	- Memory is released when items expire
+	- A bounded cache takes the write lock in Get to track the recently used items, keep it unbounded
+	  for small fixed key sets
+	- Sizes are estimated by walking the values, strings and byte slices are cheap but large structs and
+	  maps cost a walk on every Set when a budget or an item limit is set
 */
 
 // MemoryCache is an in-memory cache implementation
 type MemoryCache struct {
 	items map[string]Item
-	mu    sync.RWMutex
+	// maxEntries bounds the number of items, 0 for no bound
+	maxEntries int
+	// recent lists the keys from the most to the least recently used, elements by key, both nil if unbounded
+	recent    *list.List
+	elements  map[string]*list.Element
+	evictions uint64
+	// maxBytes bounds the approximate size of the items, sizes holds it by key and bytes in total,
+	// maxItemBytes bounds the size of an item - 0 for no bound, sizes is nil without maxBytes
+	maxBytes     int64
+	maxItemBytes int64
+	sizes        map[string]int64
+	bytes        int64
+	// hooks are called with the pending events by the dispatcher, woken up by notify - nil without hooks,
+	// droppedEvents counts the events not queued because MaxPendingEvents were pending
+	hooks         []EventHook
+	pending       []Event
+	notify        chan struct{}
+	droppedEvents uint64
+	// cleanupInterval is how often expired items are deleted, the cleanup goroutine doesn't run if 0
+	cleanupInterval time.Duration
+	mu              sync.RWMutex
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// DefaultCleanupInterval is how often expired items are deleted from a MemoryCache by default
+const DefaultCleanupInterval = time.Minute
+
+// Option configures a MemoryCache
+type Option func(*MemoryCache)
+
+// CleanupInterval deletes expired items every interval instead of DefaultCleanupInterval, a
+// non-positive interval disables the cleanup goroutine - expired items are then removed by Delete,
+// DeleteMatching and eviction only, Get never returns them
+func CleanupInterval(interval time.Duration) Option {
+	return func(c *MemoryCache) {
+		c.cleanupInterval = interval
+	}
+}
+
+// MaxBytes bounds the approximate memory footprint of the items to n bytes, setting an item evicts the
+// least recently used items to make room, items larger than n are rejected with ErrTooLarge
+func MaxBytes(n int64) Option {
+	return func(c *MemoryCache) {
+		c.maxBytes = max(n, 0)
+	}
+}
+
+// MaxItemBytes rejects items with an approximate memory footprint larger than n bytes with ErrTooLarge
+func MaxItemBytes(n int64) Option {
+	return func(c *MemoryCache) {
+		c.maxItemBytes = max(n, 0)
+	}
 }
 
 // NewMemoryCache creates a new MemoryCache instance
-func NewMemoryCache() *MemoryCache {
+func NewMemoryCache(opts ...Option) *MemoryCache {
+	return newMemoryCache(0, opts)
+}
+
+// NewMemoryCacheWithSize creates a new MemoryCache holding at most maxEntries items, setting a new key
+// on a full cache evicts the least recently used item
+// It panics if maxEntries is less than 1
+func NewMemoryCacheWithSize(maxEntries int, opts ...Option) *MemoryCache {
+	if maxEntries < 1 {
+		panic(fmt.Sprintf("cache: max entries %d, need at least 1", maxEntries))
+	}
+	return newMemoryCache(maxEntries, opts)
+}
+
+// newMemoryCache creates a MemoryCache bounded to maxEntries, 0 for no bound
+func newMemoryCache(maxEntries int, opts []Option) *MemoryCache {
 	cache := &MemoryCache{
-		items: make(map[string]Item),
+		items:           make(map[string]Item),
+		maxEntries:      maxEntries,
+		cleanupInterval: DefaultCleanupInterval,
+		stop:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(cache)
+	}
+	if maxEntries > 0 || cache.maxBytes > 0 {
+		cache.recent = list.New()
+		cache.elements = make(map[string]*list.Element)
+	}
+	if cache.maxBytes > 0 {
+		cache.sizes = make(map[string]int64)
 	}
 
 	// Start the cleanup goroutine
-	go cache.cleanup()
+	if cache.cleanupInterval > 0 {
+		go cache.cleanup()
+	}
 
 	return cache
 }
 
 // Get retrieves an item from the cache
 func (c *MemoryCache) Get(key string) (*Item, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	// Marking the item as recently used writes
+	if c.recent != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
 
 	item, exists := c.items[key]
 	if !exists {
@@ -76,6 +191,12 @@ func (c *MemoryCache) Get(key string) (*Item, error) {
 		return nil, ErrExpired
 	}
 
+	if c.recent != nil {
+		c.recent.MoveToFront(c.elements[key])
+	}
+	if item.Value == notFound {
+		return nil, ErrNegativeCached
+	}
 	return &item, nil
 }
 
@@ -90,20 +211,192 @@ func (c *MemoryCache) Set(key string, value interface{}, duration time.Duration)
 		expiresAt = time.Now().Add(duration)
 	}
 
-	c.items[key] = Item{
+	return c.set(key, Item{
 		Value:     value,
 		ExpiresAt: expiresAt,
+	})
+}
+
+// SetNotFound caches key as not found for ttl
+func (c *MemoryCache) SetNotFound(key string, ttl time.Duration) error {
+	return c.Set(key, notFound, ttl)
+}
+
+// GetMulti retrieves the unexpired items of several keys under a single lock
+func (c *MemoryCache) GetMulti(keys []string) (map[string]*Item, error) {
+	if c.recent != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
+
+	items := make(map[string]*Item, len(keys))
+	now := time.Now()
+	for _, key := range keys {
+		item, exists := c.items[key]
+		if !exists || (!item.ExpiresAt.IsZero() && now.After(item.ExpiresAt)) || item.Value == notFound {
+			continue
+		}
+		if c.recent != nil {
+			c.recent.MoveToFront(c.elements[key])
+		}
+		items[key] = &item
 	}
+	return items, nil
+}
+
+// SetMulti adds several items under a single lock, items without ExpiresAt expire after ttl
+// No item is added if one of them is too large
+func (c *MemoryCache) SetMulti(items map[string]Item, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	for key, item := range items {
+		if _, err := c.size(key, item.Value); err != nil {
+			return err
+		}
+	}
+	expiresAt := deadline(ttl)
+	for key, item := range items {
+		if item.ExpiresAt.IsZero() {
+			item.ExpiresAt = expiresAt
+		}
+		c.set(key, item)
+	}
 	return nil
 }
 
+// Increment adds delta to the integer stored under key and returns the new value
+func (c *MemoryCache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[key]
+	if !exists || (!item.ExpiresAt.IsZero() && time.Now().After(item.ExpiresAt)) {
+		item = Item{Value: int64(0)}
+		if ttl > 0 {
+			item.ExpiresAt = time.Now().Add(ttl)
+		}
+	}
+	value, err := integer(item.Value)
+	if err != nil {
+		return 0, err
+	}
+
+	value += delta
+	item.Value = value
+	return value, c.set(key, item)
+}
+
+// Decrement subtracts delta from the integer stored under key and returns the new value
+func (c *MemoryCache) Decrement(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Increment(key, -delta, ttl)
+}
+
+// Touch extends the lifetime of an item to ttl from now
+func (c *MemoryCache) Touch(key string, ttl time.Duration) error {
+	return c.ExpireAt(key, deadline(ttl))
+}
+
+// ExpireAt sets the expiration of an item to t, zero for never
+func (c *MemoryCache) ExpireAt(key string, t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.items[key]
+	if !exists {
+		return ErrNotFound
+	}
+	if !item.ExpiresAt.IsZero() && time.Now().After(item.ExpiresAt) {
+		return ErrExpired
+	}
+
+	item.ExpiresAt = t
+	return c.set(key, item)
+}
+
+// Keys returns the sorted keys of the unexpired items matching the glob pattern, keys cached as not found excluded
+func (c *MemoryCache) Keys(pattern string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := []string{}
+	now := time.Now()
+	for key, item := range c.items {
+		if (item.ExpiresAt.IsZero() || now.Before(item.ExpiresAt)) && item.Value != notFound && match(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// DeleteMatching removes the items matching the glob pattern, including keys cached as not found
+func (c *MemoryCache) DeleteMatching(pattern string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.items {
+		if match(pattern, key) {
+			c.remove(key, EventDelete)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// set stores an item and marks it as recently used, evicting the least recently used items of a full
+// bounded cache, the caller holds the lock
+// Returns ErrTooLarge without storing the item if it's larger than the limits
+func (c *MemoryCache) set(key string, item Item) error {
+	size, err := c.size(key, item.Value)
+	if err != nil {
+		return err
+	}
+	c.items[key] = item
+	c.emit(EventSet, key, item)
+	if c.sizes != nil {
+		c.bytes += size - c.sizes[key]
+		c.sizes[key] = size
+	}
+
+	if c.recent != nil {
+		if element, exists := c.elements[key]; exists {
+			c.recent.MoveToFront(element)
+		} else {
+			c.elements[key] = c.recent.PushFront(key)
+		}
+		// Evict the least recently used items, the new item fits alone
+		for (c.maxEntries > 0 && len(c.items) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+			c.remove(c.recent.Back().Value.(string), EventEvict)
+			c.evictions++
+		}
+	}
+	return nil
+}
+
+// size returns the approximate size of an item, 0 if the cache has no size limits
+// Returns ErrTooLarge if the item is larger than the limits
+func (c *MemoryCache) size(key string, value interface{}) (int64, error) {
+	if c.maxBytes == 0 && c.maxItemBytes == 0 {
+		return 0, nil
+	}
+	size := int64(len(key)) + sizeOf(value)
+	if (c.maxItemBytes > 0 && size > c.maxItemBytes) || (c.maxBytes > 0 && size > c.maxBytes) {
+		return 0, fmt.Errorf("%w: %s is about %d bytes", ErrTooLarge, key, size)
+	}
+	return size, nil
+}
+
 // Delete removes an item from the cache
 func (c *MemoryCache) Delete(key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	c.remove(key, EventDelete)
 	return nil
 }
 
@@ -112,22 +405,97 @@ func (c *MemoryCache) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.notify != nil {
+		for key, item := range c.items {
+			c.emit(EventDelete, key, item)
+		}
+	}
 	c.items = make(map[string]Item)
+	if c.recent != nil {
+		c.recent.Init()
+		c.elements = make(map[string]*list.Element)
+	}
+	if c.sizes != nil {
+		c.sizes = make(map[string]int64)
+		c.bytes = 0
+	}
 	return nil
 }
 
+// remove deletes an item and its recently used entry and reports it as typ, the caller holds the lock
+func (c *MemoryCache) remove(key string, typ EventType) {
+	item, exists := c.items[key]
+	if !exists {
+		return
+	}
+	c.emit(typ, key, item)
+	delete(c.items, key)
+	if c.sizes != nil {
+		c.bytes -= c.sizes[key]
+		delete(c.sizes, key)
+	}
+	if element, exists := c.elements[key]; exists {
+		c.recent.Remove(element)
+		delete(c.elements, key)
+	}
+}
+
+// Evictions returns the number of items evicted to make room in a bounded cache or a memory budget
+func (c *MemoryCache) Evictions() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.evictions
+}
+
+// Bytes returns the approximate memory footprint of the items, 0 without a MaxBytes budget
+func (c *MemoryCache) Bytes() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bytes
+}
+
+// Len returns the number of items in the cache, including expired items not yet cleaned up
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
 // cleanup removes expired items from the cache periodically
 func (c *MemoryCache) cleanup() {
-	ticker := time.NewTicker(time.Second)
+	ticker := time.NewTicker(c.cleanupInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mu.Lock()
-		for key, item := range c.items {
-			if !item.ExpiresAt.IsZero() && time.Now().After(item.ExpiresAt) {
-				delete(c.items, key)
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			for key, item := range c.items {
+				if !item.ExpiresAt.IsZero() && time.Now().After(item.ExpiresAt) {
+					c.remove(key, EventExpire)
+				}
 			}
+			c.mu.Unlock()
+		case <-c.stop:
+			return
 		}
-		c.mu.Unlock()
 	}
 }
+
+// Close stops the cleanup goroutine and the delivery of events, expired items are still not returned by Get
+// The cache stays usable, calling Close again does nothing
+func (c *MemoryCache) Close() error {
+	c.stopOnce.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		close(c.stop)
+		// The dispatcher won't deliver the queued events
+		c.pending = nil
+	})
+	return nil
+}
+
+// Stop stops the cleanup goroutine like Close, for the container lifecycle
+func (c *MemoryCache) Stop(context.Context) error {
+	return c.Close()
+}
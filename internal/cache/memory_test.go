@@ -2,8 +2,11 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -188,7 +191,8 @@ func TestMemoryCache_Types(t *testing.T) {
 }
 
 func TestMemoryCache_CleanupExpired(t *testing.T) {
-	cache := NewMemoryCache()
+	cache := NewMemoryCache(CleanupInterval(20 * time.Millisecond))
+	defer cache.Close()
 
 	t.Run("Cleanup removes expired items", func(t *testing.T) {
 		// Add items with short expiration
@@ -226,5 +230,507 @@ func TestMemoryCache_CleanupExpired(t *testing.T) {
 				t.Errorf("expected %d, got %v", i, item.Value)
 			}
 		}
+
+		// The cleanup deleted the expired items
+		if n := cache.Len(); n != 5 {
+			t.Errorf("expected 5 items after cleanup, got %d", n)
+		}
+	})
+}
+
+func TestItem_TTL(t *testing.T) {
+	testCases := []struct {
+		name string
+		item Item
+		min  time.Duration
+		max  time.Duration
+	}{
+		{"never expires", Item{}, NoExpiration, NoExpiration},
+		{"expires later", Item{ExpiresAt: time.Now().Add(time.Minute)}, 59 * time.Second, time.Minute},
+		{"expired", Item{ExpiresAt: time.Now().Add(-time.Second)}, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if ttl := tc.item.TTL(); ttl < tc.min || ttl > tc.max {
+				t.Errorf("expected a TTL between %v and %v, got %v", tc.min, tc.max, ttl)
+			}
+		})
+	}
+
+	// Items read from the cache carry their remaining lifetime
+	cache := NewMemoryCache()
+	defer cache.Close()
+	cache.Set("key", "value", time.Hour)
+	item, err := cache.Get("key")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if ttl := item.TTL(); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("expected a TTL close to an hour, got %v", ttl)
+	}
+}
+
+func TestMemoryCache_Close(t *testing.T) {
+	cache := NewMemoryCache(CleanupInterval(20 * time.Millisecond))
+
+	// Close is safe to call more than once
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("second Close returned error: %v", err)
+	}
+
+	// The cache stays usable but expired items are no longer cleaned up
+	cache.Set("key", "value", 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := cache.Get("key"); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	if n := cache.Len(); n != 1 {
+		t.Errorf("expected the expired item to remain after Close, got %d items", n)
+	}
+
+	// A non-positive interval disables the cleanup goroutine, Close still works
+	disabled := NewMemoryCache(CleanupInterval(0))
+	disabled.Set("key", "value", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if n := disabled.Len(); n != 1 {
+		t.Errorf("expected no cleanup with a 0 interval, got %d items", n)
+	}
+	if err := disabled.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+}
+
+func TestMemoryCache_Stop(t *testing.T) {
+	cache := NewMemoryCache()
+	cache.Set("key", "value", 50*time.Millisecond)
+
+	// Stop is safe to call more than once
+	if err := cache.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if err := cache.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop returned error: %v", err)
+	}
+
+	// Expired items are still not returned without the cleanup goroutine
+	time.Sleep(100 * time.Millisecond)
+	if _, err := cache.Get("key"); err != ErrExpired && err != ErrNotFound {
+		t.Errorf("expected ErrExpired or ErrNotFound, got %v", err)
+	}
+}
+
+func TestMemoryCache_MaxEntries(t *testing.T) {
+	cache := NewMemoryCacheWithSize(3)
+	defer cache.Stop(context.Background())
+
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	cache.Set("c", 3, 0)
+
+	// Get and Set mark items as recently used, b is the least recently used
+	cache.Get("a")
+	cache.Set("c", 30, 0)
+	cache.Set("d", 4, 0)
+
+	if _, err := cache.Get("b"); err != ErrNotFound {
+		t.Errorf("expected the least recently used item to be evicted, got %v", err)
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, err := cache.Get(key); err != nil {
+			t.Errorf("expected %s to remain, got %v", key, err)
+		}
+	}
+	if n := cache.Len(); n != 3 {
+		t.Errorf("expected 3 items, got %d", n)
+	}
+	if n := cache.Evictions(); n != 1 {
+		t.Errorf("expected 1 eviction, got %d", n)
+	}
+
+	// Deleted and cleared items free their slots without evictions
+	cache.Delete("a")
+	cache.Set("e", 5, 0)
+	cache.Clear()
+	for i := 0; i < 3; i++ {
+		cache.Set(fmt.Sprintf("key_%d", i), i, 0)
+	}
+	if n := cache.Evictions(); n != 1 {
+		t.Errorf("expected no more evictions, got %d", n)
+	}
+
+	// An unbounded key space stays bounded
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("ip_%d", i), i, 0)
+	}
+	if n := cache.Len(); n != 3 {
+		t.Errorf("expected 3 items, got %d", n)
+	}
+}
+
+func TestMemoryCache_MaxEntriesConcurrent(t *testing.T) {
+	cache := NewMemoryCacheWithSize(10)
+	defer cache.Stop(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("key_%d_%d", n, j%20)
+				cache.Set(key, j, time.Millisecond)
+				cache.Get(key)
+				if j%10 == 0 {
+					cache.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := cache.Len(); n > 10 {
+		t.Errorf("expected at most 10 items, got %d", n)
+	}
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	cache := NewMemoryCache(MaxBytes(300), MaxItemBytes(200))
+	defer cache.Stop(context.Background())
+
+	// Items larger than the item limit are rejected and the previous value is kept
+	cache.Set("blob", strings.Repeat("a", 50), 0)
+	if err := cache.Set("blob", strings.Repeat("a", 250), 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if item, err := cache.Get("blob"); err != nil || len(item.Value.(string)) != 50 {
+		t.Errorf("expected the previous value to be kept, got %+v, %v", item, err)
+	}
+	if err := cache.SetMulti(map[string]Item{"small": {Value: "a"}, "large": {Value: strings.Repeat("a", 250)}}, 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := cache.Get("small"); err != ErrNotFound {
+		t.Errorf("expected no item of the batch to be set, got %v", err)
+	}
+
+	// Setting items past the budget evicts the least recently used
+	cache.Set("a", strings.Repeat("a", 99), 0)
+	cache.Set("b", strings.Repeat("b", 99), 0)
+	cache.Get("blob")
+	cache.Set("c", strings.Repeat("c", 99), 0)
+	if _, err := cache.Get("a"); err != ErrNotFound {
+		t.Errorf("expected the least recently used item evicted, got %v", err)
+	}
+	for _, key := range []string{"blob", "b", "c"} {
+		if _, err := cache.Get(key); err != nil {
+			t.Errorf("expected %s to be kept, got %v", key, err)
+		}
+	}
+	if n := cache.Bytes(); n != 54+100+100 {
+		t.Errorf("expected 254 bytes, got %d", n)
+	}
+	if n := cache.Evictions(); n != 1 {
+		t.Errorf("expected 1 eviction, got %d", n)
+	}
+
+	// Replacing an item accounts for its new size
+	cache.Set("c", "c", 0)
+	cache.Delete("b")
+	if n := cache.Bytes(); n != 54+2 {
+		t.Errorf("expected 56 bytes, got %d", n)
+	}
+	cache.Clear()
+	if n := cache.Bytes(); n != 0 {
+		t.Errorf("expected 0 bytes after Clear, got %d", n)
+	}
+}
+
+func TestMemoryCache_Increment(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+	testIncrement(t, cache)
+}
+
+// testIncrement checks the counters of a Cache implementation
+func testIncrement(t *testing.T, cache Cache) {
+	t.Run("Increment and Decrement", func(t *testing.T) {
+		if n, err := cache.Increment("count", 5, 0); err != nil || n != 5 {
+			t.Errorf("expected a missing key to start at 0, got %d, %v", n, err)
+		}
+		if n, _ := cache.Increment("count", 2, 0); n != 7 {
+			t.Errorf("expected 7, got %d", n)
+		}
+		if n, _ := cache.Decrement("count", 10, 0); n != -3 {
+			t.Errorf("expected -3, got %d", n)
+		}
+		if item, _ := cache.Get("count"); item.Value != int64(-3) {
+			t.Errorf("expected the counter to be stored as int64, got %#v", item.Value)
+		}
+
+		// Integers set with Set can be incremented
+		cache.Set("int", 41, 0)
+		if n, err := cache.Increment("int", 1, 0); err != nil || n != 42 {
+			t.Errorf("expected 42, got %d, %v", n, err)
+		}
+		cache.Set("string", "value", 0)
+		if _, err := cache.Increment("string", 1, 0); err != ErrNotInteger {
+			t.Errorf("expected ErrNotInteger, got %v", err)
+		}
 	})
+
+	t.Run("Expiration", func(t *testing.T) {
+		cache.Increment("window", 1, 50*time.Millisecond)
+		// An existing counter keeps its expiration
+		cache.Increment("window", 1, time.Hour)
+		time.Sleep(100 * time.Millisecond)
+		if _, err := cache.Get("window"); err != ErrExpired && err != ErrNotFound {
+			t.Errorf("expected the counter to expire, got %v", err)
+		}
+		// An expired counter starts again
+		if n, _ := cache.Increment("window", 1, time.Minute); n != 1 {
+			t.Errorf("expected the expired counter to restart at 1, got %d", n)
+		}
+	})
+
+	t.Run("Concurrent increments", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					if _, err := cache.Increment("concurrent", 1, time.Minute); err != nil {
+						t.Errorf("Increment returned error: %v", err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if item, _ := cache.Get("concurrent"); item.Value != int64(200) {
+			t.Errorf("expected no lost increments, got %v", item.Value)
+		}
+	})
+}
+
+func TestMemoryCache_Touch(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+	testTouch(t, cache)
+}
+
+// testTouch checks the expiration changes of a Cache implementation
+func testTouch(t *testing.T, cache Cache) {
+	t.Run("Touch extends the lifetime", func(t *testing.T) {
+		cache.Set("session", "data", 50*time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+		if err := cache.Touch("session", 100*time.Millisecond); err != nil {
+			t.Fatalf("Touch returned error: %v", err)
+		}
+		time.Sleep(40 * time.Millisecond)
+		item, err := cache.Get("session")
+		if err != nil || item.Value != "data" {
+			t.Errorf("expected the touched item to be kept, got %+v, %v", item, err)
+		}
+
+		// A zero ttl removes the expiration
+		cache.Touch("session", 0)
+		if item, _ := cache.Get("session"); !item.ExpiresAt.IsZero() {
+			t.Errorf("expected no expiration, got %v", item.ExpiresAt)
+		}
+	})
+
+	t.Run("ExpireAt sets a deadline", func(t *testing.T) {
+		cache.Set("deadline", "data", 0)
+		if err := cache.ExpireAt("deadline", time.Now().Add(30*time.Millisecond)); err != nil {
+			t.Fatalf("ExpireAt returned error: %v", err)
+		}
+		if _, err := cache.Get("deadline"); err != nil {
+			t.Errorf("expected the item before its deadline, got %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if _, err := cache.Get("deadline"); err != ErrExpired && err != ErrNotFound {
+			t.Errorf("expected the item to expire at its deadline, got %v", err)
+		}
+	})
+
+	t.Run("Missing and expired items", func(t *testing.T) {
+		if err := cache.Touch("missing", time.Minute); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		cache.Set("expired", "data", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		if err := cache.ExpireAt("expired", time.Now().Add(time.Minute)); err != ErrExpired && err != ErrNotFound {
+			t.Errorf("expected an expired item not to be revived, got %v", err)
+		}
+	})
+}
+
+// matchCases are glob patterns with keys and whether they match, shared with the SQLite GLOB test
+var matchCases = []struct {
+	pattern string
+	key     string
+	want    bool
+}{
+	{"user:123:*", "user:123:profile", true},
+	{"user:123:*", "user:123:", true},
+	{"user:123:*", "user:1234:profile", false},
+	{"user:*:profile", "user:42:profile", true},
+	{"user:*:profile", "user:42:settings", false},
+	{"*", "", true},
+	{"*", "anything/with/slashes", true},
+	{"a*b*c", "aXXbYYbZZc", true},
+	{"a*b*c", "aXXbYY", false},
+	{"?", "é", true},
+	{"h?llo", "hello", true},
+	{"h?llo", "hllo", false},
+	{"h[ae]llo", "hallo", true},
+	{"h[ae]llo", "hillo", false},
+	{"item:[0-9]", "item:7", true},
+	{"item:[0-9]", "item:x", false},
+	{"item:[^0-9]", "item:x", true},
+	{"item:[^0-9]", "item:7", false},
+	{"[]]", "]", true},
+	{"[a-]", "-", true},
+	{"[abc", "a", false},
+	{"User:*", "user:1", false},
+	{"a[*][?][[]b", "a*?[b", true},
+	{"a[*][?][[]b", "aXY[b", false},
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range matchCases {
+		if got := match(tc.pattern, tc.key); got != tc.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tc.pattern, tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryCache_Keys(t *testing.T) {
+	cache := NewMemoryCacheWithSize(100)
+	defer cache.Stop(context.Background())
+	testKeys(t, cache)
+}
+
+// testKeys checks the pattern operations of a Cache implementation
+func testKeys(t *testing.T, cache Cache) {
+	cache.Set("user:123:profile", "profile", 0)
+	cache.Set("user:123:settings", "settings", 0)
+	cache.Set("user:124:profile", "other", 0)
+	cache.Set("user:123:expired", "expired", 10*time.Millisecond)
+	cache.SetNotFound("user:123:avatar", time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	keys, err := cache.Keys("user:123:*")
+	if err != nil || !reflect.DeepEqual(keys, []string{"user:123:profile", "user:123:settings"}) {
+		t.Errorf("expected the sorted unexpired keys of user 123 without the ones cached as not found, got %v, %v", keys, err)
+	}
+	if keys, _ := cache.Keys("nothing:*"); keys == nil || len(keys) != 0 {
+		t.Errorf("expected an empty list, got %#v", keys)
+	}
+
+	removed, err := cache.DeleteMatching("user:123:*")
+	if err != nil || removed != 4 {
+		t.Errorf("expected 4 items removed including the expired one and the one cached as not found, got %d, %v", removed, err)
+	}
+	if _, err := cache.Get("user:123:avatar"); err != ErrNotFound {
+		t.Errorf("expected the key cached as not found to be removed, got %v", err)
+	}
+	if _, err := cache.Get("user:123:profile"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after DeleteMatching, got %v", err)
+	}
+	if _, err := cache.Get("user:124:profile"); err != nil {
+		t.Errorf("expected other users to be kept, got %v", err)
+	}
+}
+
+func TestMemoryCache_Multi(t *testing.T) {
+	cache := NewMemoryCacheWithSize(1000)
+	defer cache.Stop(context.Background())
+	testMulti(t, cache)
+}
+
+// testMulti checks the batch operations of a Cache implementation
+func testMulti(t *testing.T, cache Cache) {
+	fixed := time.Now().Add(time.Hour).Truncate(time.Second)
+	err := cache.SetMulti(map[string]Item{
+		"a": {Value: "one"},
+		"b": {Value: int64(2)},
+		"c": {Value: "fixed", ExpiresAt: fixed},
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("SetMulti returned error: %v", err)
+	}
+	cache.Set("expired", "value", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	items, err := cache.GetMulti([]string{"a", "b", "c", "expired", "missing"})
+	if err != nil {
+		t.Fatalf("GetMulti returned error: %v", err)
+	}
+	if len(items) != 3 || items["a"].Value != "one" || items["b"].Value != int64(2) {
+		t.Errorf("expected the 3 unexpired items, got %v", items)
+	}
+	// Items without ExpiresAt expire after the ttl, the others keep theirs
+	if ttl := items["a"].TTL(); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Errorf("expected a TTL close to a minute, got %v", ttl)
+	}
+	if c := items["c"]; c == nil || !c.ExpiresAt.Equal(fixed) {
+		t.Errorf("expected the item to expire at %v, got %+v", fixed, c)
+	}
+
+	if items, _ := cache.GetMulti(nil); items == nil || len(items) != 0 {
+		t.Errorf("expected an empty map, got %#v", items)
+	}
+
+	// Large batches are read completely
+	batch := map[string]Item{}
+	keys := []string{}
+	for i := 0; i < 600; i++ {
+		key := fmt.Sprintf("batch:%d", i)
+		batch[key] = Item{Value: i}
+		keys = append(keys, key)
+	}
+	if err := cache.SetMulti(batch, 0); err != nil {
+		t.Fatalf("SetMulti returned error: %v", err)
+	}
+	if items, err := cache.GetMulti(keys); err != nil || len(items) != 600 {
+		t.Errorf("expected 600 items, got %d, %v", len(items), err)
+	}
+}
+
+func TestMemoryCache_NotFound(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+	testNotFound(t, cache)
+}
+
+// testNotFound checks the negative caching of a Cache implementation
+func testNotFound(t *testing.T, cache Cache) {
+	if err := cache.SetNotFound("user:ghost", 30*time.Millisecond); err != nil {
+		t.Fatalf("SetNotFound returned error: %v", err)
+	}
+	if _, err := cache.Get("user:ghost"); err != ErrNegativeCached {
+		t.Errorf("expected ErrNegativeCached, got %v", err)
+	}
+	cache.Set("user:real", "real", 0)
+	items, err := cache.GetMulti([]string{"user:ghost", "user:real"})
+	if err != nil || len(items) != 1 || items["user:real"] == nil {
+		t.Errorf("expected only the found item, got %v, %v", items, err)
+	}
+
+	// The negative entry expires
+	time.Sleep(50 * time.Millisecond)
+	if _, err := cache.Get("user:ghost"); err != ErrExpired && err != ErrNotFound {
+		t.Errorf("expected ErrExpired or ErrNotFound, got %v", err)
+	}
+
+	// Setting the key replaces the negative entry
+	cache.SetNotFound("user:new", time.Minute)
+	cache.Set("user:new", "created", 0)
+	if item, err := cache.Get("user:new"); err != nil || item.Value != "created" {
+		t.Errorf("expected the created item, got %+v, %v", item, err)
+	}
 }
@@ -0,0 +1,112 @@
+package cache
+
+/*
+	Package cache's notifications tell other components about the changes of a memory cache, e.g. to
+	push invalidations to browsers or drop the items of another cache.
+
+	Example basic usage:
+	   cache.OnEvent(func(e cache.Event) {
+		   if e.Type != cache.EventSet {
+			   hub.Broadcast([]byte("invalidate:" + e.Key))
+		   }
+	   })
+
+	Notes:
+	- Hooks run in a separate goroutine after the change, in the order of the changes, so they may
+	  use the cache but can see a newer state than the event
+	- A slow hook delays the next events but not the cache, events queue up until it returns - at most
+	  MaxPendingEvents, further events are dropped and counted by DroppedEvents
+	- Expired items are reported when the cleanup removes them, not when Get finds them expired
+	- Clear reports a delete for every item
+	- No events are delivered after Close or Stop
+*/
+
+// EventType is the kind of change of a cache event
+type EventType string
+
+// Event types
+const (
+	// EventSet is an item set, including by Increment, Touch and ExpireAt
+	EventSet EventType = "set"
+	// EventDelete is an item removed by Delete, DeleteMatching or Clear
+	EventDelete EventType = "delete"
+	// EventExpire is an expired item removed by the cleanup
+	EventExpire EventType = "expire"
+	// EventEvict is an item evicted to make room
+	EventEvict EventType = "evict"
+)
+
+// Event is a change of a memory cache item
+type Event struct {
+	Type EventType
+	Key  string
+	// Item is the item set, or the item removed
+	Item Item
+}
+
+// EventHook is called with the events of a memory cache
+type EventHook func(event Event)
+
+// MaxPendingEvents bounds the events queued for slow hooks, later events are dropped until they caught up
+const MaxPendingEvents = 10000
+
+// OnEvent adds a hook called with every change of the cache
+func (c *MemoryCache) OnEvent(hook EventHook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+	if c.notify == nil {
+		c.notify = make(chan struct{}, 1)
+		go c.dispatch()
+	}
+}
+
+// emit queues an event for the hooks, the caller holds the lock
+func (c *MemoryCache) emit(typ EventType, key string, item Item) {
+	if c.notify == nil {
+		return
+	}
+	// The dispatcher is gone after Close
+	select {
+	case <-c.stop:
+		return
+	default:
+	}
+	if len(c.pending) >= MaxPendingEvents {
+		c.droppedEvents++
+		return
+	}
+	c.pending = append(c.pending, Event{Type: typ, Key: key, Item: item})
+	// Wake up the dispatcher, unless it's already woken up
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch delivers the queued events to the hooks until the cache is closed
+func (c *MemoryCache) dispatch() {
+	for {
+		select {
+		case <-c.notify:
+			c.mu.Lock()
+			events, hooks := c.pending, c.hooks
+			c.pending = nil
+			c.mu.Unlock()
+			for _, event := range events {
+				for _, hook := range hooks {
+					hook(event)
+				}
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// DroppedEvents returns the number of events dropped because MaxPendingEvents were queued for the hooks
+func (c *MemoryCache) DroppedEvents() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.droppedEvents
+}
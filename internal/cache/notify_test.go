@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder collects the events of a cache
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) hook(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, string(e.Type)+":"+e.Key)
+}
+
+// wait returns the recorded events once n were recorded, failing the test after a second
+func (r *eventRecorder) wait(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		if len(r.events) >= n {
+			events := append([]string(nil), r.events...)
+			r.mu.Unlock()
+			return events
+		}
+		r.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.Fatalf("expected %d events, got %v", n, r.events)
+	return nil
+}
+
+func TestMemoryCache_OnEvent(t *testing.T) {
+	cache := NewMemoryCacheWithSize(2)
+	defer cache.Stop(context.Background())
+	recorder := &eventRecorder{}
+	cache.OnEvent(recorder.hook)
+
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 30*time.Millisecond)
+	cache.Increment("a", 1, 0)
+	cache.Set("c", 3, 0)
+	cache.Delete("a")
+	// Missing keys aren't reported
+	cache.Delete("missing")
+	events := recorder.wait(t, 6)
+	want := []string{"set:a", "set:b", "set:a", "set:c", "evict:b", "delete:a"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+
+	// Clear reports every item, in no particular order
+	cache.Set("d", 4, 0)
+	cache.Clear()
+	events = recorder.wait(t, 9)[6:]
+	if events[0] != "set:d" || len(events) != 3 || !slices.Contains(events, "delete:c") || !slices.Contains(events, "delete:d") {
+		t.Errorf("expected d set then c and d deleted, got %v", events)
+	}
+}
+
+func TestMemoryCache_OnEventExpire(t *testing.T) {
+	cache := NewMemoryCache(CleanupInterval(10 * time.Millisecond))
+	defer cache.Stop(context.Background())
+	recorder := &eventRecorder{}
+	cache.OnEvent(recorder.hook)
+
+	cache.Set("short", "value", 5*time.Millisecond)
+	if events := recorder.wait(t, 2); !reflect.DeepEqual(events, []string{"set:short", "expire:short"}) {
+		t.Errorf("expected the item set then expired, got %v", events)
+	}
+}
+
+func TestMemoryCache_OnEventReentrant(t *testing.T) {
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+	second := NewMemoryCache()
+	defer second.Stop(context.Background())
+
+	// Hooks may use the cache, e.g. to drop the items of a second tier
+	recorder := &eventRecorder{}
+	cache.OnEvent(func(e Event) {
+		if e.Type == EventDelete {
+			second.Delete(e.Key)
+		}
+		cache.Get(e.Key)
+		recorder.hook(e)
+	})
+
+	second.Set("key", "value", 0)
+	cache.Set("key", "value", 0)
+	cache.Delete("key")
+	recorder.wait(t, 2)
+	if _, err := second.Get("key"); err != ErrNotFound {
+		t.Errorf("expected the item dropped from the second cache, got %v", err)
+	}
+}
+
+func TestMemoryCache_OnEventBacklog(t *testing.T) {
+	cache := NewMemoryCache(CleanupInterval(0))
+	defer cache.Stop(context.Background())
+	release := make(chan struct{})
+	recorder := &eventRecorder{}
+	cache.OnEvent(func(e Event) {
+		<-release
+		recorder.hook(e)
+	})
+
+	// The hook blocks on the first event, at most MaxPendingEvents queue up behind it
+	cache.Set("first", 0, 0)
+	time.Sleep(20 * time.Millisecond)
+	for i := range MaxPendingEvents + 10 {
+		cache.Set("key", i, 0)
+	}
+	if dropped := cache.DroppedEvents(); dropped != 10 {
+		t.Errorf("expected 10 dropped events, got %d", dropped)
+	}
+	close(release)
+	recorder.wait(t, MaxPendingEvents+1)
+
+	// Nothing is queued once the cache is closed
+	cache.Close()
+	cache.Set("closed", 1, 0)
+	cache.mu.RLock()
+	pending := len(cache.pending)
+	cache.mu.RUnlock()
+	if pending != 0 {
+		t.Errorf("expected no pending events after Close, got %d", pending)
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+	Package cache's prefixed view lets several features share a cache without key collisions.
+
+	Example basic usage:
+	   sessions := cache.WithPrefix(shared, "sessions:")
+	   reports := cache.WithPrefix(shared, "reports:")
+
+	   // Stored as "sessions:abc" in the shared cache
+	   sessions.Set("abc", session, 30*time.Minute)
+
+	   // Removes the reports only, the sessions are kept
+	   reports.Clear()
+
+	Notes:
+	- Keys and patterns are given and returned without the prefix
+	- Clear removes the items of the prefix only, Clear the shared cache to remove everything
+	- Views can be nested, the prefixes are joined
+*/
+
+// PrefixedCache is a view of a cache prefixing all keys
+type PrefixedCache struct {
+	cache  Cache
+	prefix string
+	// pattern matches the prefix literally in glob patterns
+	pattern string
+}
+
+// WithPrefix returns a view of c storing its items under keys starting with prefix
+func WithPrefix(c Cache, prefix string) *PrefixedCache {
+	return &PrefixedCache{cache: c, prefix: prefix, pattern: quoteGlob(prefix)}
+}
+
+// Get retrieves an item from the cache
+func (c *PrefixedCache) Get(key string) (*Item, error) {
+	return c.cache.Get(c.prefix + key)
+}
+
+// Set adds an item to the cache
+func (c *PrefixedCache) Set(key string, value interface{}, duration time.Duration) error {
+	return c.cache.Set(c.prefix+key, value, duration)
+}
+
+// SetNotFound caches key as not found for ttl
+func (c *PrefixedCache) SetNotFound(key string, ttl time.Duration) error {
+	return c.cache.SetNotFound(c.prefix+key, ttl)
+}
+
+// GetMulti retrieves the unexpired items of several keys
+func (c *PrefixedCache) GetMulti(keys []string) (map[string]*Item, error) {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	found, err := c.cache.GetMulti(prefixed)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]*Item, len(found))
+	for key, item := range found {
+		items[strings.TrimPrefix(key, c.prefix)] = item
+	}
+	return items, nil
+}
+
+// SetMulti adds several items to the cache
+func (c *PrefixedCache) SetMulti(items map[string]Item, ttl time.Duration) error {
+	prefixed := make(map[string]Item, len(items))
+	for key, item := range items {
+		prefixed[c.prefix+key] = item
+	}
+	return c.cache.SetMulti(prefixed, ttl)
+}
+
+// Delete removes an item from the cache
+func (c *PrefixedCache) Delete(key string) error {
+	return c.cache.Delete(c.prefix + key)
+}
+
+// Clear removes the items of the prefix
+func (c *PrefixedCache) Clear() error {
+	_, err := c.cache.DeleteMatching(c.pattern + "*")
+	return err
+}
+
+// Increment adds delta to the integer stored under key and returns the new value
+func (c *PrefixedCache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.cache.Increment(c.prefix+key, delta, ttl)
+}
+
+// Decrement subtracts delta from the integer stored under key and returns the new value
+func (c *PrefixedCache) Decrement(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.cache.Decrement(c.prefix+key, delta, ttl)
+}
+
+// Touch extends the lifetime of an item to ttl from now
+func (c *PrefixedCache) Touch(key string, ttl time.Duration) error {
+	return c.cache.Touch(c.prefix+key, ttl)
+}
+
+// ExpireAt sets the expiration of an item to t, zero for never
+func (c *PrefixedCache) ExpireAt(key string, t time.Time) error {
+	return c.cache.ExpireAt(c.prefix+key, t)
+}
+
+// Keys returns the sorted keys of the unexpired items matching the glob pattern, without the prefix
+func (c *PrefixedCache) Keys(pattern string) ([]string, error) {
+	keys, err := c.cache.Keys(c.pattern + pattern)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, c.prefix)
+	}
+	return keys, nil
+}
+
+// DeleteMatching removes the items matching the glob pattern
+func (c *PrefixedCache) DeleteMatching(pattern string) (int, error) {
+	return c.cache.DeleteMatching(c.pattern + pattern)
+}
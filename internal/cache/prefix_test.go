@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPrefixedCache(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+	sessions := WithPrefix(shared, "sessions:")
+	reports := WithPrefix(shared, "reports:")
+
+	sessions.Set("abc", "session", 0)
+	reports.Set("abc", "report", 0)
+	if item, err := shared.Get("sessions:abc"); err != nil || item.Value != "session" {
+		t.Errorf("expected the item under the prefixed key, got %+v, %v", item, err)
+	}
+	if item, err := reports.Get("abc"); err != nil || item.Value != "report" {
+		t.Errorf("expected the item of the view, got %+v, %v", item, err)
+	}
+
+	items, err := sessions.GetMulti([]string{"abc", "missing"})
+	if err != nil || len(items) != 1 || items["abc"].Value != "session" {
+		t.Errorf("expected the item keyed without the prefix, got %v, %v", items, err)
+	}
+
+	// Clear removes the items of the prefix only
+	if err := reports.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if _, err := reports.Get("abc"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after Clear, got %v", err)
+	}
+	if _, err := sessions.Get("abc"); err != nil {
+		t.Errorf("expected the other prefix to be kept, got %v", err)
+	}
+
+	// Views can be nested
+	nested := WithPrefix(sessions, "admin:")
+	nested.Set("xyz", "admin", 0)
+	if _, err := shared.Get("sessions:admin:xyz"); err != nil {
+		t.Errorf("expected the prefixes joined, got %v", err)
+	}
+	if keys, _ := sessions.Keys("*"); !reflect.DeepEqual(keys, []string{"abc", "admin:xyz"}) {
+		t.Errorf("expected the keys without the prefix, got %v", keys)
+	}
+}
+
+func TestPrefixedCache_GlobPrefix(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+
+	// Glob characters of the prefix are matched literally
+	view := WithPrefix(shared, "a*[b]?:")
+	view.Set("key", "value", 0)
+	shared.Set("axx[b]y:key", "other", 0)
+	if keys, _ := view.Keys("*"); !reflect.DeepEqual(keys, []string{"key"}) {
+		t.Errorf("expected only the key of the view, got %v", keys)
+	}
+	if removed, _ := view.DeleteMatching("*"); removed != 1 {
+		t.Errorf("expected 1 item removed, got %d", removed)
+	}
+	if _, err := shared.Get("axx[b]y:key"); err != nil {
+		t.Errorf("expected the other key to be kept, got %v", err)
+	}
+}
+
+func TestPrefixedCache_Increment(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+	testIncrement(t, WithPrefix(shared, "counters:"))
+}
+
+func TestPrefixedCache_Touch(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+	testTouch(t, WithPrefix(shared, "sessions:"))
+}
+
+func TestPrefixedCache_Keys(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+	shared.Set("user:123:other", "outside the view", 0)
+	testKeys(t, WithPrefix(shared, "view:"))
+}
+
+func TestPrefixedCache_Multi(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testMulti(t, WithPrefix(cache, "batch:"))
+}
+
+func TestPrefixedCache_NotFound(t *testing.T) {
+	shared := NewMemoryCache()
+	defer shared.Stop(context.Background())
+	testNotFound(t, WithPrefix(shared, "users:"))
+}
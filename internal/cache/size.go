@@ -0,0 +1,83 @@
+package cache
+
+import "reflect"
+
+// sizeOf returns the approximate memory footprint of a value in bytes, following pointers, slices and
+// maps - shared values are counted once per value
+func sizeOf(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+	return valueSize(reflect.ValueOf(value), map[uintptr]bool{})
+}
+
+// valueSize returns the approximate size of v, seen holds the addresses of the pointers already counted
+func valueSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() {
+			return int64(v.Type().Size())
+		}
+		return int64(v.Type().Size()) + elementsSize(v, seen)
+	case reflect.Array:
+		return elementsSize(v, seen)
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		if v.IsNil() || seen[v.Pointer()] {
+			return size
+		}
+		seen[v.Pointer()] = true
+		iter := v.MapRange()
+		for iter.Next() {
+			size += valueSize(iter.Key(), seen) + valueSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.Pointer:
+		size := int64(v.Type().Size())
+		if v.IsNil() || seen[v.Pointer()] {
+			return size
+		}
+		seen[v.Pointer()] = true
+		return size + valueSize(v.Elem(), seen)
+	case reflect.Interface:
+		size := int64(v.Type().Size())
+		if v.IsNil() {
+			return size
+		}
+		return size + valueSize(v.Elem(), seen)
+	case reflect.Struct:
+		size := int64(0)
+		for i := 0; i < v.NumField(); i++ {
+			size += valueSize(v.Field(i), seen)
+		}
+		return size
+	case reflect.Invalid:
+		return 0
+	default:
+		// Numbers, booleans, channels and functions
+		return int64(v.Type().Size())
+	}
+}
+
+// elementsSize returns the approximate size of the elements of a slice or array
+func elementsSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	switch v.Type().Elem().Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		// Fixed size elements, not walked one by one
+		return int64(v.Len()) * int64(v.Type().Elem().Size())
+	}
+	size := int64(0)
+	for i := 0; i < v.Len(); i++ {
+		size += valueSize(v.Index(i), seen)
+	}
+	return size
+}
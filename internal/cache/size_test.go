@@ -0,0 +1,40 @@
+package cache
+
+import "testing"
+
+func TestSizeOf(t *testing.T) {
+	shared := &testValue{Name: "shared"}
+	testCases := []struct {
+		name  string
+		value interface{}
+		min   int64
+		max   int64
+	}{
+		{"nil", nil, 0, 0},
+		{"string", "hello", 5, 5},
+		{"bytes", make([]byte, 1<<20), 1 << 20, 1 << 20},
+		{"int", 42, 8, 8},
+		{"ints", make([]int64, 1000), 8000, 8100},
+		{"struct", testValue{Name: "hello", Count: 1}, 16 + 5 + 8, 16 + 5 + 8},
+		{"pointer", &testValue{Name: "hello"}, 8 + 29, 8 + 29},
+		{"strings", []string{"abc", "defg"}, 24 + 2*16 + 7, 24 + 2*16 + 7},
+		{"map", map[string]int{"a": 1, "b": 2}, 2 * (16 + 1 + 8), 2*(16+1+8) + 16},
+		{"shared pointers", []*testValue{shared, shared}, 24 + 2*8 + 30, 24 + 2*8 + 30},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if size := sizeOf(tc.value); size < tc.min || size > tc.max {
+				t.Errorf("expected a size between %d and %d, got %d", tc.min, tc.max, size)
+			}
+		})
+	}
+
+	// Cycles don't loop forever
+	type node struct{ Next *node }
+	cycle := &node{}
+	cycle.Next = cycle
+	if size := sizeOf(cycle); size <= 0 {
+		t.Errorf("expected a positive size, got %d", size)
+	}
+}
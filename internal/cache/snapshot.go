@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+	Package cache's snapshots save the items of a memory cache to a file and load them back, so a
+	single instance keeps a warm cache across restarts without a shared backend.
+
+	Example basic usage:
+	   // At startup
+	   loaded, err := cache.LoadSnapshot("cache.snapshot")
+
+	   // Periodically and at shutdown
+	   saved, err := cache.SaveSnapshot("cache.snapshot")
+
+	Notes:
+	- Values are encoded with GobCodec, values of types not registered with gob are skipped
+	- Expired items aren't saved or loaded, the others keep their expiration
+	- A bounded cache keeps its most recently used items, the order of use is saved
+	- The file is replaced atomically, a crash while saving keeps the previous snapshot
+*/
+
+// snapshotItem is an item of a snapshot file, with its value encoded by GobCodec
+type snapshotItem struct {
+	Key       string
+	Value     []byte
+	ExpiresAt time.Time
+}
+
+// SaveSnapshot writes the unexpired items to the file at path and returns how many were saved
+func (c *MemoryCache) SaveSnapshot(path string) (int, error) {
+	items := c.snapshot()
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("cache: creating snapshot: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := gob.NewEncoder(file).Encode(items); err != nil {
+		file.Close()
+		return 0, fmt.Errorf("cache: writing snapshot: %w", err)
+	}
+	// Flush the file before the rename, a crash mustn't leave an empty snapshot in place of the last one
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return 0, fmt.Errorf("cache: writing snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("cache: writing snapshot: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return 0, fmt.Errorf("cache: replacing snapshot: %w", err)
+	}
+	return len(items), nil
+}
+
+// LoadSnapshot adds the unexpired items of the file at path and returns how many were loaded,
+// overwriting the items with the same keys - a missing file loads nothing
+func (c *MemoryCache) LoadSnapshot(path string) (int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cache: opening snapshot: %w", err)
+	}
+	defer file.Close()
+
+	var items []snapshotItem
+	if err := gob.NewDecoder(file).Decode(&items); err != nil {
+		return 0, fmt.Errorf("cache: reading snapshot: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Items are saved from the least to the most recently used, skip those a bounded cache would evict
+	if c.maxEntries > 0 && len(items) > c.maxEntries {
+		items = items[len(items)-c.maxEntries:]
+	}
+	loaded := 0
+	now := time.Now()
+	for _, item := range items {
+		if !item.ExpiresAt.IsZero() && now.After(item.ExpiresAt) {
+			continue
+		}
+		value, err := (GobCodec{}).Decode(item.Value)
+		if err != nil {
+			continue
+		}
+		// Items larger than the limits of the cache are skipped
+		if c.set(item.Key, Item{Value: value, ExpiresAt: item.ExpiresAt}) == nil {
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// snapshot returns the unexpired items with an encodable value, from the least to the most recently used
+func (c *MemoryCache) snapshot() []snapshotItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.items))
+	if c.recent != nil {
+		for element := c.recent.Back(); element != nil; element = element.Prev() {
+			keys = append(keys, element.Value.(string))
+		}
+	} else {
+		for key := range c.items {
+			keys = append(keys, key)
+		}
+	}
+
+	items := make([]snapshotItem, 0, len(keys))
+	now := time.Now()
+	for _, key := range keys {
+		item := c.items[key]
+		if !item.ExpiresAt.IsZero() && now.After(item.ExpiresAt) {
+			continue
+		}
+		value, err := (GobCodec{}).Encode(item.Value)
+		if err != nil {
+			continue
+		}
+		items = append(items, snapshotItem{Key: key, Value: value, ExpiresAt: item.ExpiresAt})
+	}
+	return items
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_Snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+	cache.Set("string", "value", time.Hour)
+	cache.Set("struct", testValue{Name: "kept", Count: 1}, 0)
+	cache.Set("expired", "value", 10*time.Millisecond)
+	cache.Set("unregistered", struct{ Name string }{"skipped"}, 0)
+	time.Sleep(20 * time.Millisecond)
+
+	saved, err := cache.SaveSnapshot(path)
+	if err != nil || saved != 2 {
+		t.Fatalf("expected 2 items saved, got %d, %v", saved, err)
+	}
+
+	restored := NewMemoryCache()
+	defer restored.Stop(context.Background())
+	loaded, err := restored.LoadSnapshot(path)
+	if err != nil || loaded != 2 {
+		t.Fatalf("expected 2 items loaded, got %d, %v", loaded, err)
+	}
+	item, err := restored.Get("string")
+	if err != nil || item.Value != "value" || item.TTL() <= 59*time.Minute {
+		t.Errorf("expected the item with its expiration, got %+v, %v", item, err)
+	}
+	if item, err := restored.Get("struct"); err != nil || !reflect.DeepEqual(item.Value, testValue{Name: "kept", Count: 1}) {
+		t.Errorf("expected the registered struct, got %+v, %v", item, err)
+	}
+
+	// No temporary file is left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the snapshot file, got %d entries", len(entries))
+	}
+}
+
+func TestMemoryCache_SnapshotBounded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	cache := NewMemoryCacheWithSize(3)
+	defer cache.Stop(context.Background())
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	cache.Set("c", 3, 0)
+	cache.Get("a")
+	if _, err := cache.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot returned error: %v", err)
+	}
+
+	// A smaller cache keeps the most recently used items, in their order of use
+	restored := NewMemoryCacheWithSize(2)
+	defer restored.Stop(context.Background())
+	if loaded, err := restored.LoadSnapshot(path); err != nil || loaded != 2 {
+		t.Fatalf("expected 2 items loaded, got %d, %v", loaded, err)
+	}
+	if _, err := restored.Get("b"); err != ErrNotFound {
+		t.Errorf("expected the least recently used item skipped, got %v", err)
+	}
+	restored.Set("d", 4, 0)
+	if _, err := restored.Get("c"); err != ErrNotFound {
+		t.Errorf("expected c evicted before a, got %v", err)
+	}
+	if _, err := restored.Get("a"); err != nil {
+		t.Errorf("expected a kept, got %v", err)
+	}
+	if n := restored.Evictions(); n != 1 {
+		t.Errorf("expected 1 eviction, loading doesn't evict, got %d", n)
+	}
+}
+
+func TestMemoryCache_SnapshotErrors(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewMemoryCache()
+	defer cache.Stop(context.Background())
+
+	// A missing snapshot loads nothing
+	if loaded, err := cache.LoadSnapshot(filepath.Join(dir, "missing")); err != nil || loaded != 0 {
+		t.Errorf("expected nothing loaded, got %d, %v", loaded, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt")
+	os.WriteFile(corrupt, []byte("not a snapshot"), 0o600)
+	if _, err := cache.LoadSnapshot(corrupt); err == nil {
+		t.Error("expected an error loading a corrupt snapshot")
+	}
+
+	if _, err := cache.SaveSnapshot(filepath.Join(dir, "missing", "cache.snapshot")); err == nil {
+		t.Error("expected an error saving to a missing directory")
+	}
+}
@@ -0,0 +1,363 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mookie/internal/db/sqlc"
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+	Package cache's SQLite implementation stores items in the cache_items table
+	of the application database, so cached data survives restarts without Redis.
+
+	How to use:
+	1. Create a new SQLite cache with the application database
+	2. Register the types of the stored values with gob
+	3. Store and retrieve items like with the memory cache
+
+	Example basic usage:
+	   gob.Register(&Report{})
+	   cache := cache.NewSQLiteCache(db)
+
+	   cache.Set("report:2024", report, 24*time.Hour)
+
+	   item, err := cache.Get("report:2024")
+	   if err == nil {
+		   report := item.Value.(*Report)
+		   // Use report...
+	   }
+
+	Notes:
+	- Values are encoded with encoding/gob by default, basic types and their slices work as is, other
+	  types like maps and structs must be registered with gob.Register and only exported fields are
+	  stored - NewSQLiteCacheWithCodec selects another codec like JSONCodec
+	- Every call is a query, keep hot data like rate limits in the memory cache
+	- Cleanup deletes expired items every minute in background until Stop is called
+	- Safe for concurrent access and shared by instances using the same database
+*/
+
+// sqliteCleanupInterval is how often expired items are deleted from the table
+const sqliteCleanupInterval = time.Minute
+
+// sqliteBatchSize is the number of keys read by a query of GetMulti, under the SQLite variable limit
+const sqliteBatchSize = 500
+
+// SQLiteCache is a cache implementation stored in the application database
+type SQLiteCache struct {
+	db       *sql.DB
+	queries  *sqlc.Queries
+	codec    Codec
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSQLiteCache creates a new SQLiteCache instance backed by the application database, storing
+// values with gob
+func NewSQLiteCache(db *sql.DB) *SQLiteCache {
+	return NewSQLiteCacheWithCodec(db, GobCodec{})
+}
+
+// NewSQLiteCacheWithCodec creates a new SQLiteCache instance storing values with codec
+// Caches sharing the database must use the same codec
+func NewSQLiteCacheWithCodec(db *sql.DB, codec Codec) *SQLiteCache {
+	cache := &SQLiteCache{
+		db:      db,
+		queries: sqlc.New(db),
+		codec:   codec,
+		stop:    make(chan struct{}),
+	}
+
+	// Start the cleanup goroutine
+	go cache.cleanup()
+
+	return cache
+}
+
+// Get retrieves an item from the cache
+func (c *SQLiteCache) Get(key string) (*Item, error) {
+	row, err := c.queries.GetCacheItem(context.Background(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if row.ExpiresAt.Valid && time.Now().After(row.ExpiresAt.Time) {
+		return nil, ErrExpired
+	}
+
+	value, err := c.decode(key, row.Value)
+	if err != nil {
+		return nil, err
+	}
+	if value == notFound {
+		return nil, ErrNegativeCached
+	}
+	item := &Item{Value: value}
+	if row.ExpiresAt.Valid {
+		item.ExpiresAt = row.ExpiresAt.Time
+	}
+	return item, nil
+}
+
+// Set adds an item to the cache, the value must be encodable with gob
+func (c *SQLiteCache) Set(key string, value interface{}, duration time.Duration) error {
+	encoded, err := c.encode(key, value)
+	if err != nil {
+		return err
+	}
+
+	return c.queries.SetCacheItem(context.Background(), sqlc.SetCacheItemParams{
+		Key:       key,
+		Value:     encoded,
+		ExpiresAt: expiresIn(duration),
+	})
+}
+
+// SetNotFound caches key as not found for ttl
+func (c *SQLiteCache) SetNotFound(key string, ttl time.Duration) error {
+	return c.Set(key, notFound, ttl)
+}
+
+// GetMulti retrieves the unexpired items of several keys with a query per 500 keys
+func (c *SQLiteCache) GetMulti(keys []string) (map[string]*Item, error) {
+	items := make(map[string]*Item, len(keys))
+	now := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	for batch := range slices.Chunk(keys, sqliteBatchSize) {
+		rows, err := c.queries.ListCacheItems(context.Background(), sqlc.ListCacheItemsParams{Now: now, Keys: batch})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			value, err := c.decode(row.Key, row.Value)
+			if err != nil {
+				return nil, err
+			}
+			if value == notFound {
+				continue
+			}
+			item := &Item{Value: value}
+			if row.ExpiresAt.Valid {
+				item.ExpiresAt = row.ExpiresAt.Time
+			}
+			items[row.Key] = item
+		}
+	}
+	return items, nil
+}
+
+// SetMulti adds several items in a single transaction, items without ExpiresAt expire after ttl
+func (c *SQLiteCache) SetMulti(items map[string]Item, ttl time.Duration) error {
+	ctx := context.Background()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := c.queries.WithTx(tx)
+	for key, item := range items {
+		encoded, err := c.encode(key, item.Value)
+		if err != nil {
+			return err
+		}
+		expiresAt := expiresIn(ttl)
+		if !item.ExpiresAt.IsZero() {
+			expiresAt = sql.NullTime{Time: item.ExpiresAt.UTC(), Valid: true}
+		}
+		if err := queries.SetCacheItem(ctx, sqlc.SetCacheItemParams{Key: key, Value: encoded, ExpiresAt: expiresAt}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Increment adds delta to the integer stored under key and returns the new value
+// The read and write run in an immediate transaction, so increments of other connections and
+// instances sharing the database wait for it
+func (c *SQLiteCache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	ctx := context.Background()
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// database/sql starts deferred transactions, which take the write lock only when writing
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+
+	queries := sqlc.New(conn)
+	value := int64(0)
+	expiresAt := expiresIn(ttl)
+	row, err := queries.GetCacheItem(ctx, key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	// A missing or expired key starts at 0
+	if err == nil && (!row.ExpiresAt.Valid || time.Now().Before(row.ExpiresAt.Time)) {
+		stored, err := c.decode(key, row.Value)
+		if err != nil {
+			return 0, err
+		}
+		if value, err = integer(stored); err != nil {
+			return 0, err
+		}
+		expiresAt = row.ExpiresAt
+	}
+
+	value += delta
+	encoded, err := c.encode(key, value)
+	if err != nil {
+		return 0, err
+	}
+	if err := queries.SetCacheItem(ctx, sqlc.SetCacheItemParams{Key: key, Value: encoded, ExpiresAt: expiresAt}); err != nil {
+		return 0, err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return 0, err
+	}
+	committed = true
+	return value, nil
+}
+
+// Decrement subtracts delta from the integer stored under key and returns the new value
+func (c *SQLiteCache) Decrement(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Increment(key, -delta, ttl)
+}
+
+// Delete removes an item from the cache
+func (c *SQLiteCache) Delete(key string) error {
+	return c.queries.DeleteCacheItem(context.Background(), key)
+}
+
+// Clear removes all items from the cache
+func (c *SQLiteCache) Clear() error {
+	return c.queries.ClearCache(context.Background())
+}
+
+// Len returns the number of items in the cache, including expired items not yet cleaned up
+func (c *SQLiteCache) Len() int {
+	count, err := c.queries.CountCacheItems(context.Background())
+	if err != nil {
+		return 0
+	}
+	return int(count)
+}
+
+// Touch extends the lifetime of an item to ttl from now
+func (c *SQLiteCache) Touch(key string, ttl time.Duration) error {
+	return c.ExpireAt(key, deadline(ttl))
+}
+
+// ExpireAt sets the expiration of an item to t, zero for never
+func (c *SQLiteCache) ExpireAt(key string, t time.Time) error {
+	ctx := context.Background()
+	expiresAt := sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+	updated, err := c.queries.SetCacheItemExpiration(ctx, sqlc.SetCacheItemExpirationParams{
+		ExpiresAt: expiresAt,
+		Key:       key,
+		Now:       sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil || updated > 0 {
+		return err
+	}
+
+	// Not updated, the item is missing or expired
+	if _, err := c.queries.GetCacheItem(ctx, key); errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return ErrExpired
+}
+
+// Keys returns the sorted keys of the unexpired items matching the glob pattern
+func (c *SQLiteCache) Keys(pattern string) ([]string, error) {
+	// Keys cached as not found are compared encoded, the codecs encode a value the same way every time
+	encodedNotFound, err := c.encode("", notFound)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.queries.ListCacheKeys(context.Background(), sqlc.ListCacheKeysParams{
+		Pattern:  pattern,
+		Now:      sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		NotFound: encodedNotFound,
+	})
+	if keys == nil && err == nil {
+		keys = []string{}
+	}
+	return keys, err
+}
+
+// DeleteMatching removes the items matching the glob pattern
+func (c *SQLiteCache) DeleteMatching(pattern string) (int, error) {
+	removed, err := c.queries.DeleteCacheItemsMatching(context.Background(), pattern)
+	return int(removed), err
+}
+
+// encode encodes a value with the codec of the cache
+func (c *SQLiteCache) encode(key string, value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("cache: encoding %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// decode decodes a value encoded by encode
+func (c *SQLiteCache) decode(key string, data []byte) (interface{}, error) {
+	value, err := c.codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("cache: decoding %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// expiresIn returns the expiration of an item stored for duration, null if 0 as it never expires
+func expiresIn(duration time.Duration) sql.NullTime {
+	if duration <= 0 {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Now().Add(duration).UTC(), Valid: true}
+}
+
+// cleanup deletes expired items from the table periodically
+func (c *SQLiteCache) cleanup() {
+	ticker := time.NewTicker(sqliteCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// deleteExpired deletes the expired items, errors are retried on the next cleanup
+func (c *SQLiteCache) deleteExpired() {
+	c.queries.DeleteExpiredCacheItems(context.Background(), sql.NullTime{Time: time.Now().UTC(), Valid: true})
+}
+
+// Stop stops the cleanup goroutine, expired items are still not returned by Get
+func (c *SQLiteCache) Stop(context.Context) error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
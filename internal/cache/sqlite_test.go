@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"mookie/internal/db"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testValue is a custom type stored in the SQLite cache
+type testValue struct {
+	Name  string
+	Count int
+}
+
+func init() {
+	gob.Register(testValue{})
+	gob.Register(map[string]int{})
+}
+
+// newTestSQLiteCache returns a SQLite cache backed by a temporary database and the database path
+func newTestSQLiteCache(t *testing.T) (*SQLiteCache, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	cache := NewSQLiteCache(database)
+	t.Cleanup(func() {
+		cache.Stop(context.Background())
+		database.Close()
+	})
+	return cache, path
+}
+
+func TestSQLiteCache_BasicOperations(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+
+	if err := cache.Set("key1", "value1", 0); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	item, err := cache.Get("key1")
+	if err != nil || item.Value != "value1" || !item.ExpiresAt.IsZero() {
+		t.Errorf("expected value1 without expiration, got %+v, %v", item, err)
+	}
+
+	// Set overwrites an existing key
+	cache.Set("key1", "value2", time.Minute)
+	if item, _ := cache.Get("key1"); item.Value != "value2" || item.ExpiresAt.IsZero() {
+		t.Errorf("expected the overwritten value with expiration, got %+v", item)
+	}
+
+	if _, err := cache.Get("nonexistent"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	cache.Delete("key1")
+	if _, err := cache.Get("key1"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+
+	cache.Set("key2", "value", 0)
+	cache.Set("key3", "value", 0)
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("expected an empty cache after Clear, got %d items", n)
+	}
+}
+
+func TestSQLiteCache_Expiration(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	cache.Set("short", "value", 50*time.Millisecond)
+	cache.Set("permanent", "value", 0)
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := cache.Get("short"); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+
+	// The cleanup deletes expired items only
+	cache.deleteExpired()
+	if _, err := cache.Get("short"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after cleanup, got %v", err)
+	}
+	if _, err := cache.Get("permanent"); err != nil {
+		t.Errorf("expected the permanent item to remain, got %v", err)
+	}
+}
+
+func TestSQLiteCache_Types(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+
+	testCases := []struct {
+		key   string
+		value interface{}
+	}{
+		{"string", "hello"},
+		{"int", 42},
+		{"float", 3.14},
+		{"bool", true},
+		{"struct", testValue{Name: "test", Count: 3}},
+		{"slice", []int{1, 2, 3}},
+		{"map", map[string]int{"one": 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			if err := cache.Set(tc.key, tc.value, 0); err != nil {
+				t.Fatalf("Set returned error: %v", err)
+			}
+			item, err := cache.Get(tc.key)
+			if err != nil {
+				t.Fatalf("Get returned error: %v", err)
+			}
+			if !reflect.DeepEqual(item.Value, tc.value) {
+				t.Errorf("expected %v, got %v", tc.value, item.Value)
+			}
+		})
+	}
+
+	// Unregistered types can't be stored
+	type unregistered struct{ Name string }
+	if err := cache.Set("unregistered", unregistered{"test"}, 0); err == nil {
+		t.Error("expected an error storing an unregistered type")
+	}
+}
+
+func TestSQLiteCache_Restart(t *testing.T) {
+	cache, path := newTestSQLiteCache(t)
+	cache.Set("key", testValue{Name: "kept"}, time.Hour)
+
+	// Items are kept by a new cache on the same database
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer database.Close()
+	restarted := NewSQLiteCache(database)
+	defer restarted.Stop(context.Background())
+
+	item, err := restarted.Get("key")
+	if err != nil || item.Value != (testValue{Name: "kept"}) {
+		t.Errorf("expected the item to survive a restart, got %+v, %v", item, err)
+	}
+}
+
+func TestSQLiteCache_JSONCodec(t *testing.T) {
+	cache, path := newTestSQLiteCache(t)
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer database.Close()
+	jsonCache := NewSQLiteCacheWithCodec(database, JSONCodec{})
+	defer jsonCache.Stop(context.Background())
+
+	// Values are stored as JSON and read back without registered types
+	jsonCache.Set("key", testValue{Name: "json", Count: 1}, 0)
+	var stored string
+	if err := cache.db.QueryRow(`SELECT value FROM cache_items WHERE key = 'key'`).Scan(&stored); err != nil {
+		t.Fatalf("QueryRow returned error: %v", err)
+	}
+	if stored != `{"Name":"json","Count":1}` {
+		t.Errorf("expected the value stored as JSON, got %s", stored)
+	}
+	item, err := jsonCache.Get("key")
+	if err != nil || !reflect.DeepEqual(item.Value, map[string]interface{}{"Name": "json", "Count": int64(1)}) {
+		t.Errorf("expected the value as a map, got %+v, %v", item, err)
+	}
+
+	testIncrement(t, jsonCache)
+}
+
+func TestSQLiteCache_Increment(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testIncrement(t, cache)
+}
+
+func TestSQLiteCache_Touch(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testTouch(t, cache)
+}
+
+func TestSQLiteCache_Keys(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testKeys(t, cache)
+}
+
+func TestMatch_SQLiteGlob(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+
+	// The memory cache matches keys like the GLOB operator of the SQLite cache
+	for _, tc := range matchCases {
+		var got bool
+		if err := cache.db.QueryRow(`SELECT ? GLOB ?`, tc.key, tc.pattern).Scan(&got); err != nil {
+			t.Fatalf("GLOB returned error: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("%q GLOB %q = %v, want %v", tc.key, tc.pattern, got, tc.want)
+		}
+	}
+}
+
+func TestSQLiteCache_Multi(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testMulti(t, cache)
+}
+
+func TestSQLiteCache_NotFound(t *testing.T) {
+	cache, _ := newTestSQLiteCache(t)
+	testNotFound(t, cache)
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+	Package cache's tiered implementation puts a fast local cache in front of a shared one,
+	so reads are served from memory while the data is kept by the shared backend.
+
+	How to use:
+	1. Create the local cache, usually a memory cache
+	2. Create the shared cache, e.g. the SQLite cache of the application database
+	3. Combine them with the time items are kept locally
+
+	Example basic usage:
+	   local := cache.NewMemoryCacheWithSize(10000)
+	   shared := cache.NewSQLiteCache(db)
+	   cache := cache.NewTieredCache(local, shared, 30*time.Second)
+
+	   // Stored in both caches, locally for at most 30 seconds
+	   cache.Set("report:2024", report, time.Hour)
+
+	   // Read from memory, or from the database and kept in memory for the next reads
+	   item, err := cache.Get("report:2024")
+
+	Notes:
+	- Writes go to the shared cache first, then to the local one
+	- Items are kept locally for at most the local TTL, so instances see changes of other instances
+	  after that time - keep it short for data that changes often
+	- Increment, Decrement, Touch and ExpireAt run on the shared cache and drop the local item, the next
+	  Get reads it again
+	- Items read from the local cache carry their local expiration, at most the local TTL from when
+	  they were stored
+	- Keys cached as not found are read from the shared cache, they're kept locally only when set
+	  through the tiered cache
+	- Keys lists the shared cache only
+	- The caches aren't stopped by the tiered cache, stop them separately
+*/
+
+// TieredCache is a cache reading through a local cache in front of a shared cache
+type TieredCache struct {
+	l1 Cache
+	l2 Cache
+	// l1TTL is the longest time an item is kept in l1
+	l1TTL time.Duration
+}
+
+// NewTieredCache creates a new TieredCache reading through l1 in front of l2, items read from or
+// written to l2 are kept in l1 for at most l1TTL
+// It panics if l1TTL isn't positive
+func NewTieredCache(l1, l2 Cache, l1TTL time.Duration) *TieredCache {
+	if l1TTL <= 0 {
+		panic(fmt.Sprintf("cache: tiered cache L1 TTL %v, need a positive duration", l1TTL))
+	}
+	return &TieredCache{l1: l1, l2: l2, l1TTL: l1TTL}
+}
+
+// Get retrieves an item from l1, or from l2 and back-fills l1
+func (c *TieredCache) Get(key string) (*Item, error) {
+	item, err := c.l1.Get(key)
+	if err == nil || errors.Is(err, ErrNegativeCached) {
+		return item, err
+	}
+
+	item, err = c.l2.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	// Back-filling is best effort, the item is read from l2 again if it fails
+	if expiresAt, ok := c.localExpiry(item.ExpiresAt); ok {
+		c.l1.Set(key, item.Value, time.Until(expiresAt))
+	}
+	return item, nil
+}
+
+// Set adds an item to l2 and l1
+func (c *TieredCache) Set(key string, value interface{}, duration time.Duration) error {
+	if err := c.l2.Set(key, value, duration); err != nil {
+		return err
+	}
+	return c.l1.Set(key, value, c.localTTL(duration))
+}
+
+// SetNotFound caches key as not found in l2 and l1
+func (c *TieredCache) SetNotFound(key string, ttl time.Duration) error {
+	return c.Set(key, notFound, ttl)
+}
+
+// GetMulti retrieves the items found in l1, then the others from l2 and back-fills l1 with them
+func (c *TieredCache) GetMulti(keys []string) (map[string]*Item, error) {
+	items, err := c.l1.GetMulti(keys)
+	if err != nil {
+		return nil, err
+	}
+	missing := []string{}
+	for _, key := range keys {
+		if _, found := items[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return items, nil
+	}
+
+	shared, err := c.l2.GetMulti(missing)
+	if err != nil {
+		return nil, err
+	}
+	backfill := make(map[string]Item, len(shared))
+	for key, item := range shared {
+		items[key] = item
+		if expiresAt, ok := c.localExpiry(item.ExpiresAt); ok {
+			backfill[key] = Item{Value: item.Value, ExpiresAt: expiresAt}
+		}
+	}
+	c.l1.SetMulti(backfill, 0)
+	return items, nil
+}
+
+// SetMulti adds several items to l2 and l1
+func (c *TieredCache) SetMulti(items map[string]Item, ttl time.Duration) error {
+	if err := c.l2.SetMulti(items, ttl); err != nil {
+		return err
+	}
+
+	local := make(map[string]Item, len(items))
+	for key, item := range items {
+		expiresAt := item.ExpiresAt
+		if expiresAt.IsZero() {
+			expiresAt = deadline(ttl)
+		}
+		if expiresAt, ok := c.localExpiry(expiresAt); ok {
+			local[key] = Item{Value: item.Value, ExpiresAt: expiresAt}
+		}
+	}
+	return c.l1.SetMulti(local, 0)
+}
+
+// Delete removes an item from l2 and l1
+func (c *TieredCache) Delete(key string) error {
+	return errors.Join(c.l2.Delete(key), c.l1.Delete(key))
+}
+
+// Clear removes all items from l2 and l1
+func (c *TieredCache) Clear() error {
+	return errors.Join(c.l2.Clear(), c.l1.Clear())
+}
+
+// Increment adds delta to the integer stored under key in l2 and returns the new value
+func (c *TieredCache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	value, err := c.l2.Increment(key, delta, ttl)
+	if err != nil {
+		return 0, err
+	}
+	return value, c.l1.Delete(key)
+}
+
+// Decrement subtracts delta from the integer stored under key in l2 and returns the new value
+func (c *TieredCache) Decrement(key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.Increment(key, -delta, ttl)
+}
+
+// Touch extends the lifetime of an item in l2 to ttl from now
+func (c *TieredCache) Touch(key string, ttl time.Duration) error {
+	return c.ExpireAt(key, deadline(ttl))
+}
+
+// ExpireAt sets the expiration of an item in l2 to t, zero for never
+func (c *TieredCache) ExpireAt(key string, t time.Time) error {
+	if err := c.l2.ExpireAt(key, t); err != nil {
+		return err
+	}
+	return c.l1.Delete(key)
+}
+
+// Keys returns the sorted keys of the unexpired items of l2 matching the glob pattern
+func (c *TieredCache) Keys(pattern string) ([]string, error) {
+	return c.l2.Keys(pattern)
+}
+
+// DeleteMatching removes the items matching the glob pattern from l2 and l1, returning how many were
+// removed from l2
+func (c *TieredCache) DeleteMatching(pattern string) (int, error) {
+	removed, err := c.l2.DeleteMatching(pattern)
+	if err != nil {
+		return 0, err
+	}
+	_, err = c.l1.DeleteMatching(pattern)
+	return removed, err
+}
+
+// localTTL returns the duration an item stored for duration is kept in l1
+func (c *TieredCache) localTTL(duration time.Duration) time.Duration {
+	if duration <= 0 {
+		return c.l1TTL
+	}
+	return min(duration, c.l1TTL)
+}
+
+// localExpiry returns when an item expiring at expiresAt expires in l1, false if it already expired
+func (c *TieredCache) localExpiry(expiresAt time.Time) (time.Time, bool) {
+	now := time.Now()
+	local := now.Add(c.l1TTL)
+	if expiresAt.IsZero() || expiresAt.After(local) {
+		return local, true
+	}
+	return expiresAt, expiresAt.After(now)
+}
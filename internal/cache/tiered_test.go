@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestTieredCache returns a tiered cache over two memory caches and its caches
+func newTestTieredCache(t *testing.T, l1TTL time.Duration) (*TieredCache, *MemoryCache, *MemoryCache) {
+	t.Helper()
+	l1, l2 := NewMemoryCache(), NewMemoryCache()
+	t.Cleanup(func() {
+		l1.Stop(context.Background())
+		l2.Stop(context.Background())
+	})
+	return NewTieredCache(l1, l2, l1TTL), l1, l2
+}
+
+func TestTieredCache_ReadThrough(t *testing.T) {
+	cache, l1, l2 := newTestTieredCache(t, 50*time.Millisecond)
+
+	// Items set through the tiered cache are in both caches, kept shorter in l1
+	cache.Set("key", "value", time.Hour)
+	local, err := l1.Get("key")
+	if err != nil || local.TTL() > 50*time.Millisecond {
+		t.Errorf("expected the item in l1 for at most the L1 TTL, got %+v, %v", local, err)
+	}
+	if shared, err := l2.Get("key"); err != nil || shared.TTL() <= 59*time.Minute {
+		t.Errorf("expected the item in l2 for an hour, got %+v, %v", shared, err)
+	}
+
+	// Items only in l2 are read and back-filled into l1
+	l2.Set("shared", "value", 0)
+	item, err := cache.Get("shared")
+	if err != nil || item.Value != "value" || item.TTL() != NoExpiration {
+		t.Errorf("expected the l2 item, got %+v, %v", item, err)
+	}
+	if local, err := l1.Get("shared"); err != nil || local.TTL() == NoExpiration {
+		t.Errorf("expected the item back-filled with the L1 TTL, got %+v, %v", local, err)
+	}
+
+	// Changes of another instance are seen once the l1 item expires
+	l2.Set("shared", "changed", 0)
+	if item, _ := cache.Get("shared"); item.Value != "value" {
+		t.Errorf("expected the l1 item before the L1 TTL, got %v", item.Value)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if item, _ := cache.Get("shared"); item.Value != "changed" {
+		t.Errorf("expected the l2 item after the L1 TTL, got %v", item.Value)
+	}
+
+	// Items expiring before the L1 TTL aren't kept longer in l1
+	l2.Set("short", "value", 10*time.Millisecond)
+	cache.Get("short")
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.Get("short"); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+
+	// Delete removes the item from both caches
+	cache.Delete("key")
+	if _, err := l1.Get("key"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound in l1, got %v", err)
+	}
+	if _, err := l2.Get("key"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound in l2, got %v", err)
+	}
+}
+
+func TestTieredCache_GetMulti(t *testing.T) {
+	cache, l1, l2 := newTestTieredCache(t, time.Minute)
+	l1.Set("a", "local", 0)
+	l2.Set("a", "shared", 0)
+	l2.Set("b", "shared", 0)
+
+	items, err := cache.GetMulti([]string{"a", "b", "missing"})
+	if err != nil || len(items) != 2 || items["a"].Value != "local" || items["b"].Value != "shared" {
+		t.Errorf("expected a from l1 and b from l2, got %v, %v", items, err)
+	}
+	if _, err := l1.Get("b"); err != nil {
+		t.Errorf("expected b back-filled into l1, got %v", err)
+	}
+}
+
+func TestTieredCache_Increment(t *testing.T) {
+	cache, _, _ := newTestTieredCache(t, time.Minute)
+	testIncrement(t, cache)
+}
+
+func TestTieredCache_Touch(t *testing.T) {
+	cache, _, _ := newTestTieredCache(t, time.Minute)
+	testTouch(t, cache)
+}
+
+func TestTieredCache_Keys(t *testing.T) {
+	cache, _, _ := newTestTieredCache(t, time.Minute)
+	testKeys(t, cache)
+}
+
+func TestTieredCache_Multi(t *testing.T) {
+	// Longer than the items of the test so they keep their expiration in l1
+	cache, _, _ := newTestTieredCache(t, 2*time.Hour)
+	testMulti(t, cache)
+}
+
+func TestTieredCache_NotFound(t *testing.T) {
+	cache, _, l2 := newTestTieredCache(t, time.Minute)
+	testNotFound(t, cache)
+
+	// Keys cached as not found by another instance are seen
+	l2.SetNotFound("other", time.Minute)
+	if _, err := cache.Get("other"); err != ErrNegativeCached {
+		t.Errorf("expected ErrNegativeCached, got %v", err)
+	}
+}
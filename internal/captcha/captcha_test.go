@@ -0,0 +1,96 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// fakeProvider accepts the token "pass" for the secret "secret"
+func fakeProvider(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("remoteip") != "192.0.2.1" {
+			t.Errorf("remoteip = %q", r.Form.Get("remoteip"))
+		}
+		if r.Form.Get("secret") == "secret" && r.Form.Get("response") == "pass" {
+			json.NewEncoder(w).Encode(verifyResponse{Success: true})
+			return
+		}
+		json.NewEncoder(w).Encode(verifyResponse{ErrorCodes: []string{"invalid-input-response"}})
+	}))
+}
+
+func TestVerify(t *testing.T) {
+	server := fakeProvider(t)
+	defer server.Close()
+
+	v, err := New(Turnstile, "site", "secret", Options{VerifyURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(context.Background(), "pass", "192.0.2.1"); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+
+	err = v.Verify(context.Background(), "fail", "192.0.2.1")
+	if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), "invalid-input-response") {
+		t.Errorf("invalid token: err = %v", err)
+	}
+	if err := v.Verify(context.Background(), "", ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("missing token: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	server := fakeProvider(t)
+	defer server.Close()
+
+	for _, name := range []string{Turnstile, HCaptcha, ReCaptcha} {
+		v, _ := New(name, "site", "secret", Options{VerifyURL: server.URL})
+		form := url.Values{v.FieldName(): {"pass"}}
+		r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = "192.0.2.1:1234"
+		if err := v.VerifyRequest(r); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestProviderUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	v, _ := New(HCaptcha, "site", "secret", Options{VerifyURL: server.URL})
+	err := v.Verify(context.Background(), "pass", "")
+	if err == nil || errors.Is(err, ErrInvalid) {
+		t.Errorf("err = %v, want a non ErrInvalid error", err)
+	}
+}
+
+func TestUnknownProvider(t *testing.T) {
+	if _, err := New("nope", "", "", Options{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestWidget(t *testing.T) {
+	v, _ := New(HCaptcha, "site-key", "secret", Options{})
+	var b strings.Builder
+	if err := Widget(v).Render(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`src="https://js.hcaptcha.com/1/api.js"`, `class="h-captcha"`, `data-sitekey="site-key"`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("widget %s missing %s", b.String(), want)
+		}
+	}
+}
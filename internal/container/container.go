@@ -1,8 +1,14 @@
 package container
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 /*
@@ -13,7 +19,8 @@ import (
    1. Create a new Container
    2. Register services with unique names
    3. Retrieve services using Get (with error handling) or MustGet (panics on error)
-   4. Type assert retrieved services to their concrete types
+   4. Type assert retrieved services to their concrete types, or use the generic Get[T] and MustGet[T]
+      which do it for you
 
    Example basic usage:
        // Create container
@@ -34,6 +41,10 @@ import (
        // Get service with panic on error and assert type (*slog.Logger in this case)
        logger := container.MustGet("logger").(*slog.Logger)
 
+       // The same with the generic helpers, a wrong type names the expected and actual type
+       logger := container.MustGet[*slog.Logger](c, "logger")
+       db, err := container.Get[*sql.DB](c, "db")
+
    Example in web application:
        func main() {
            container := container.New()
@@ -51,39 +62,478 @@ import (
    Notes:
    - Thread-safe
    - Services are stored as interface{} (any) which supports any dependency type
-   - Type assertion required when retrieving services
+   - Type assertion required when retrieving services with the methods, Get[T] and MustGet[T] return
+     an error instead of panicking on a wrong type (MustGet[T] panics with that error)
    - Register will overwrite existing services with same name
-   - MustGet panics if service not found
+   - MustGet panics if service not found, GetOr[T] returns a fallback for optional services, e.g.
+     limiter := container.GetOr[*ratelimit.Limiter](c, "wslimit", nil)
+   - ResolveAs[I] finds the one service implementing an interface without its name - see resolve.go
+   - Instrument sets functions called on registrations, lookups and factory builds, e.g. to log slow
+     factories - see instrument.go
+   - List describes the registered services, their types and whether factory services are built - see list.go
+
+   Lazy services:
+   RegisterFactory defers building a service until the first Get and keeps the result, so expensive
+   services are only built when used. The factory gets the container to look up its dependencies,
+   which may be factories themselves.
+
+       container.RegisterFactory("stripe", func(c *container.Container) (any, error) {
+           cfg := container.MustGet[*config.Config](c, "config")
+           return stripe.NewClient(cfg.Payments.StripeSecretKey)
+       })
+
+   - The factory runs once, concurrent Gets wait for it - a failed factory runs again on the next Get
+   - Factories depending on each other in a cycle return an error naming the cycle, e.g.
+     "dependency cycle between services: mailer -> queue -> mailer", also when the services in the
+     cycle are built by different goroutines
+   - Look up dependencies with the container passed to the factory, a cycle through a container
+     captured from outside the factory can't be detected and deadlocks
+   - Register and RegisterFactory replace each other for the same name
+
+   Transient services:
+   RegisterTransient registers a factory that runs on every Get, for services that must not be
+   shared, e.g. an SMTP connection per job. RegisterFactory services are singletons built once.
+
+       container.RegisterTransient("smtp", func(c *container.Container) (any, error) {
+           cfg := container.MustGet[*config.Config](c, "config")
+           return smtp.Dial(net.JoinHostPort(cfg.Mail.Host, strconv.Itoa(cfg.Mail.Port)))
+       })
+       client := container.MustGet[*smtp.Client](c, "smtp")   // a new connection, close it when done
+
+   - The caller owns every instance, StartAll and Shutdown don't see transient services
+   - Decorators and tags apply to every instance, Tagged builds new instances
+
+   Tagged services:
+   Register and RegisterFactory take optional tags, Tagged returns every service with a tag, so a
+   subsystem can discover services contributed by other packages without a central list.
+
+       c.Register("cron.webhooks", cron.Task{Name: "webhooks", Run: dispatcher.Run, Every: time.Minute}, "cron.task")
+       c.Register("cron.search", cron.Task{Name: "search", Run: search.Run, Every: time.Hour}, "cron.task")
+
+       tasks, err := container.Tagged[cron.Task](c, "cron.task")
+
+   - Services are returned in the order they were tagged, factory services are built
+   - Tags belong to the name, registering a name again adds its tags to the previous ones
+
+   Overrides and freezing:
+   Override replaces a registered service, e.g. with a fake in tests, and Freeze stops any further
+   registration, so a service registered by accident at runtime fails loudly instead of replacing a
+   service handlers already hold.
+
+       c := newContainer()              // registers the real services
+       c.Override("mail", fakeMailer)   // in a test, before Freeze
+       c.Freeze()                       // main freezes after the setup
+
+   - Register, RegisterFactory and Override panic with ErrFrozen after Freeze
+   - Override panics if the name isn't registered, to catch typos in tests
+   - The override keeps the tags and lifecycle position of the name, hooks set with SetHooks for the
+     replaced service are removed
+
+   Decorators:
+   Decorate wraps a registered service, so cross-cutting wrappers are layered on without changing the
+   code that registered it. Decorators apply in the order they were added, the last is the outermost.
+
+       c.Decorate("cache", func(existing any) any {
+           return &loggingCache{Cache: existing.(cache.Cache), logger: logger}
+       })
+
+   - Decorating a factory service wraps it when it is built, it stays lazy
+   - Keep the type users look up, e.g. wrap a cache.Cache in another cache.Cache, and the methods
+     Starter and Stopper need, the lifecycle uses the decorated service
+   - Register, RegisterFactory and Override drop the decorators of a factory service not built yet
+   - Decorate panics like Override if the service isn't registered or the container is frozen
 */
 
+// ErrNotFound is returned by Get when no service is registered with the name, also wrapped in the error
+// of a factory looking up a missing dependency - see NotRegistered
+var ErrNotFound = errors.New("not found")
+
+// notRegisteredError is the error of Get for a name no service is registered with
+type notRegisteredError struct {
+	name string
+}
+
+func (e *notRegisteredError) Error() string {
+	return fmt.Sprintf("service %s %v", e.name, ErrNotFound)
+}
+
+func (e *notRegisteredError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// NotRegistered reports whether err is the error of looking up name when no service is registered with it,
+// unlike errors.Is(err, ErrNotFound) it's false for a factory of name failing on a missing dependency
+func NotRegistered(err error, name string) bool {
+	var missing *notRegisteredError
+	return errors.As(err, &missing) && missing.name == name
+}
+
+// ErrFrozen is the panic value of Register, RegisterFactory and Override after Freeze
+var ErrFrozen = errors.New("container is frozen")
+
 // Container is a dependency injection container
 type Container struct {
-	services map[string]any
-	mu       sync.RWMutex
+	*registry
+	// resolving are the factories being built, set on the container passed to a factory
+	resolving []string
+	// builder is the innermost singleton factory being built, transient factories hold no lock
+	builder string
+}
+
+// registry holds the services shared by a container and the containers passed to factories
+type registry struct {
+	services  map[string]any
+	factories map[string]*factory
+	// order is the names of the services in the order they were registered or built - see lifecycle.go
+	order []string
+	hooks map[string]Hooks
+	// deps maps a service to the services it depends on, recorded by factory lookups and DependsOn - see lifecycle.go
+	deps map[string][]string
+	// waiting maps a factory being built to the factory it waits for, to detect cycles across goroutines
+	waiting map[string]string
+	// checks are the health checks run by Health - see health.go
+	checks map[string]func(context.Context) error
+	// tags maps a tag to the names of the services with the tag in the order they were tagged
+	tags map[string][]string
+	// lazy are the names registered with RegisterFactory, also once built - see List
+	lazy map[string]bool
+	// transients are built on every Get - see RegisterTransient
+	transients map[string]func(*Container) (any, error)
+	// decorators wrap factory services when they are built - see Decorate
+	decorators map[string][]func(any) any
+	// instrumentation is called on registrations, lookups and builds - see instrument.go
+	instrumentation Instrumentation
+	// frozen rejects registrations after Freeze
+	frozen bool
+	mu     sync.RWMutex
+}
+
+// factory builds a service registered with RegisterFactory on the first Get
+type factory struct {
+	build func(*Container) (any, error)
+	mu    sync.Mutex
 }
 
 // New creates a new dependency container
 func New() *Container {
 	return &Container{
-		services: make(map[string]any),
+		registry: &registry{
+			services:   make(map[string]any),
+			factories:  make(map[string]*factory),
+			hooks:      make(map[string]Hooks),
+			deps:       make(map[string][]string),
+			waiting:    make(map[string]string),
+			checks:     make(map[string]func(context.Context) error),
+			tags:       make(map[string][]string),
+			lazy:       make(map[string]bool),
+			transients: make(map[string]func(*Container) (any, error)),
+			decorators: make(map[string][]func(any) any),
+		},
 	}
 }
 
-// Register a service by name
-func (c *Container) Register(name string, service any) {
+// Register a service by name with optional tags
+func (c *Container) Register(name string, service any, tags ...string) {
+	c.locked(func() {
+		c.checkFrozen("register", name)
+		delete(c.factories, name)
+		delete(c.transients, name)
+		delete(c.lazy, name)
+		delete(c.decorators, name)
+		c.store(name, service)
+		c.tag(name, tags)
+	})
+	c.registered(name)
+}
+
+// Override replaces a registered service, e.g. with a fake in tests
+// It panics if the service isn't registered or the container is frozen
+func (c *Container) Override(name string, service any) {
+	c.locked(func() {
+		c.checkFrozen("override", name)
+		_, exists := c.services[name]
+		_, lazy := c.factories[name]
+		_, transient := c.transients[name]
+		if !exists && !lazy && !transient {
+			panic(fmt.Errorf("override %s: service not registered", name))
+		}
+		delete(c.factories, name)
+		delete(c.transients, name)
+		delete(c.lazy, name)
+		delete(c.decorators, name)
+		delete(c.hooks, name)
+		c.store(name, service)
+	})
+	c.registered(name)
+}
+
+// Decorate wraps a registered service, e.g. with logging or instrumentation, without changing the
+// code that registered it - factory services are wrapped when they are built, transient services on every Get
+// It panics if the service isn't registered or the container is frozen
+func (c *Container) Decorate(name string, decorate func(existing any) any) {
+	var service any
+	var exists, deferred bool
+	c.locked(func() {
+		c.checkFrozen("decorate", name)
+		_, lazy := c.factories[name]
+		_, transient := c.transients[name]
+		if lazy || transient {
+			c.decorators[name] = append(c.decorators[name], decorate)
+			deferred = true
+			return
+		}
+		service, exists = c.services[name]
+	})
+	if deferred {
+		return
+	}
+	if !exists {
+		panic(fmt.Errorf("decorate %s: service not registered", name))
+	}
+
+	// The decorator runs without the lock so it may look up other services
+	decorated := decorate(service)
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.services[name] = decorated
+}
+
+// Freeze rejects further registrations, Register, RegisterFactory, RegisterTransient, Override and
+// Decorate panic afterwards
+func (c *Container) Freeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frozen = true
+}
+
+// Frozen reports whether Freeze was called
+func (c *Container) Frozen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.frozen
+}
+
+// locked runs fn holding the lock
+func (c *Container) locked(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn()
+}
+
+// checkFrozen panics if the container is frozen, the caller holds the lock
+func (c *Container) checkFrozen(action, name string) {
+	if c.frozen {
+		panic(fmt.Errorf("%s %s: %w", action, name, ErrFrozen))
+	}
+}
+
+// store sets a service and records when it was first registered, the caller holds the lock
+func (c *Container) store(name string, service any) {
+	if _, exists := c.services[name]; !exists && !slices.Contains(c.order, name) {
+		c.order = append(c.order, name)
+	}
 	c.services[name] = service
 }
 
+// unorder removes a service from the lifecycle order, a factory service takes its place when it is built
+// The caller holds the lock
+func (c *Container) unorder(name string) {
+	c.order = slices.DeleteFunc(c.order, func(n string) bool { return n == name })
+}
+
+// wait records that the factory being built waits for the factory name, or returns the cycle it would close
+// A top level Get isn't part of a build and can't close a cycle
+func (c *Container) wait(name string) []string {
+	// A factory in this chain of builds
+	if i := slices.Index(c.resolving, name); i >= 0 {
+		return append(slices.Clone(c.resolving[i:]), name)
+	}
+	if c.builder == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A factory built by another goroutine that waits, maybe indirectly, for this build
+	builder := c.builder
+	cycle := []string{builder, name}
+	for next := name; ; {
+		var ok bool
+		if next, ok = c.waiting[next]; !ok {
+			break
+		}
+		cycle = append(cycle, next)
+		if next == builder {
+			return cycle
+		}
+	}
+	c.waiting[builder] = name
+	return nil
+}
+
+// done removes what the factory being built waited for
+func (c *Container) done() {
+	if c.builder == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.waiting, c.builder)
+}
+
+// RegisterFactory registers a service by name with optional tags that is built by build on the first Get
+func (c *Container) RegisterFactory(name string, build func(*Container) (any, error), tags ...string) {
+	c.locked(func() {
+		c.checkFrozen("register", name)
+		delete(c.services, name)
+		delete(c.transients, name)
+		delete(c.decorators, name)
+		c.unorder(name)
+		c.factories[name] = &factory{build: build}
+		c.lazy[name] = true
+		c.tag(name, tags)
+	})
+	c.registered(name)
+}
+
+// tag adds the tags to a service, the caller holds the lock
+func (c *Container) tag(name string, tags []string) {
+	for _, tag := range tags {
+		if !slices.Contains(c.tags[tag], name) {
+			c.tags[tag] = append(c.tags[tag], name)
+		}
+	}
+}
+
+// Tagged returns the services with a tag in the order they were tagged
+func (c *Container) Tagged(tag string) ([]any, error) {
+	c.mu.RLock()
+	names := slices.Clone(c.tags[tag])
+	c.mu.RUnlock()
+
+	services := make([]any, 0, len(names))
+	for _, name := range names {
+		service, err := c.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
 // Get a service by name
-func (c *Container) Get(name string) (any, error) {
+func (c *Container) Get(name string) (service any, err error) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	service, exists := c.services[name]
+	f, lazy := c.factories[name]
+	transient, isTransient := c.transients[name]
+	onGet := c.instrumentation.OnGet
+	c.mu.RUnlock()
+
+	if onGet != nil {
+		start := time.Now()
+		defer func() { onGet(name, time.Since(start), err) }()
+	}
+
+	if exists || lazy || isTransient {
+		c.dependency(name)
+	}
+	switch {
+	case exists:
+		return service, nil
+	case lazy:
+		return c.build(name, f)
+	case isTransient:
+		return c.buildTransient(name, transient)
+	}
+	return nil, &notRegisteredError{name: name}
+}
+
+// buildTransient runs the factory of a transient service, without sharing or registering the result
+func (c *Container) buildTransient(name string, build func(*Container) (any, error)) (any, error) {
+	if cycle := c.wait(name); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle between services: %s", strings.Join(cycle, " -> "))
+	}
+	c.done()
+
+	scoped := &Container{registry: c.registry, resolving: append(slices.Clip(c.resolving), name), builder: c.builder}
+	return c.timeBuild(name, func() (any, error) {
+		service, err := build(scoped)
+		if err != nil {
+			return nil, fmt.Errorf("error creating service %s: %w", name, err)
+		}
+		return c.decorate(name, service), nil
+	})
+}
+
+// decorate applies the queued decorators of a factory or transient service
+func (c *Container) decorate(name string, service any) any {
+	c.mu.RLock()
+	decorators := slices.Clone(c.decorators[name])
+	c.mu.RUnlock()
+	for _, decorate := range decorators {
+		service = decorate(service)
+	}
+	return service
+}
+
+// RegisterTransient registers a service by name with optional tags that is built by build on every
+// Get, for services that must not be shared - RegisterFactory builds a service once and shares it
+func (c *Container) RegisterTransient(name string, build func(*Container) (any, error), tags ...string) {
+	c.locked(func() {
+		c.checkFrozen("register", name)
+		delete(c.services, name)
+		delete(c.factories, name)
+		delete(c.lazy, name)
+		delete(c.decorators, name)
+		c.unorder(name)
+		c.transients[name] = build
+		c.tag(name, tags)
+	})
+	c.registered(name)
+}
+
+// build runs the factory of a service once and registers the result
+func (c *Container) build(name string, f *factory) (any, error) {
+	if cycle := c.wait(name); cycle != nil {
+		return nil, fmt.Errorf("dependency cycle between services: %s", strings.Join(cycle, " -> "))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c.done()
 
+	// Another Get may have built it while this one waited
+	c.mu.RLock()
 	service, exists := c.services[name]
-	if !exists {
-		return nil, fmt.Errorf("service %s not found", name)
+	current := c.factories[name]
+	c.mu.RUnlock()
+	if exists {
+		return service, nil
+	}
+	if current != f {
+		// Replaced while waiting
+		return c.Get(name)
+	}
+
+	scoped := &Container{registry: c.registry, resolving: append(slices.Clip(c.resolving), name), builder: name}
+	service, err := c.timeBuild(name, func() (any, error) {
+		service, err := f.build(scoped)
+		if err != nil {
+			return nil, fmt.Errorf("error creating service %s: %w", name, err)
+		}
+		return c.decorate(name, service), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.factories[name] == f {
+		delete(c.factories, name)
+		delete(c.decorators, name)
+		c.store(name, service)
 	}
 	return service, nil
 }
@@ -96,3 +546,59 @@ func (c *Container) MustGet(name string) any {
 	}
 	return service
 }
+
+// Get a service by name as type T
+// It returns an error naming the expected and actual type if the service isn't a T
+func Get[T any](c *Container, name string) (T, error) {
+	var zero T
+	service, err := c.Get(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := service.(T)
+	if !ok {
+		return zero, fmt.Errorf("service %s is %T, not %s", name, service, reflect.TypeFor[T]())
+	}
+	return typed, nil
+}
+
+// MustGet a service by name as type T, panics if it's missing or not a T
+func MustGet[T any](c *Container, name string) T {
+	service, err := Get[T](c, name)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+// GetOr a service by name as type T, or fallback if no service is registered with the name
+// Use it for optional services, a service of the wrong type or a failing factory still panics, also
+// when the factory fails on a missing dependency
+func GetOr[T any](c *Container, name string, fallback T) T {
+	service, err := Get[T](c, name)
+	if NotRegistered(err, name) {
+		return fallback
+	}
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+// Tagged returns the services with a tag as type T in the order they were tagged
+// It returns an error naming the expected and actual type if a service isn't a T
+func Tagged[T any](c *Container, tag string) ([]T, error) {
+	c.mu.RLock()
+	names := slices.Clone(c.tags[tag])
+	c.mu.RUnlock()
+
+	services := make([]T, 0, len(names))
+	for _, name := range names {
+		service, err := Get[T](c, name)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
@@ -2,7 +2,14 @@
 package container
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestContainer_Register(t *testing.T) {
@@ -141,3 +148,580 @@ func TestContainer_MultipleServices(t *testing.T) {
 		t.Errorf("got %v, want test", s)
 	}
 }
+
+func TestGet_Typed(t *testing.T) {
+	c := New()
+	c.Register("string", "string service")
+
+	s, err := Get[string](c, "string")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s != "string service" {
+		t.Errorf("got %v, want string service", s)
+	}
+
+	// A wrong type names the expected and actual type
+	_, err = Get[int](c, "string")
+	if err == nil || err.Error() != "service string is string, not int" {
+		t.Errorf("Get[int]() error = %v, want a type mismatch", err)
+	}
+
+	if _, err := Get[string](c, "missing"); err == nil {
+		t.Error("Get() on a missing service returned no error")
+	}
+}
+
+func TestGet_Interface(t *testing.T) {
+	c := New()
+	c.Register("stringer", time.Second)
+
+	s, err := Get[fmt.Stringer](c, "stringer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.String() != "1s" {
+		t.Errorf("got %v, want 1s", s)
+	}
+
+	_, err = Get[fmt.Stringer](New(), "stringer")
+	if err == nil {
+		t.Error("Get() on a missing service returned no error")
+	}
+}
+
+func TestMustGet_Typed(t *testing.T) {
+	c := New()
+	c.Register("int", 42)
+
+	if i := MustGet[int](c, "int"); i != 42 {
+		t.Errorf("got %v, want 42", i)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("MustGet() did not panic on a wrong type")
+		}
+		if err, ok := r.(error); !ok || err.Error() != "service int is int, not *string" {
+			t.Errorf("MustGet() panicked with %v, want a type mismatch", r)
+		}
+	}()
+	MustGet[*string](c, "int")
+}
+
+func TestContainer_RegisterFactory(t *testing.T) {
+	c := New()
+	calls := 0
+	c.RegisterFactory("lazy", func(*Container) (any, error) {
+		calls++
+		return "lazy service", nil
+	})
+
+	// Not built until the first Get
+	if calls != 0 {
+		t.Fatalf("factory ran %d times before Get", calls)
+	}
+
+	for i := 0; i < 3; i++ {
+		s, err := c.Get("lazy")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s != "lazy service" {
+			t.Errorf("got %v, want lazy service", s)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("factory ran %d times, want 1", calls)
+	}
+}
+
+func TestContainer_FactoryDependencies(t *testing.T) {
+	c := New()
+	c.Register("name", "db")
+	c.RegisterFactory("pool", func(c *Container) (any, error) {
+		return "pool for " + MustGet[string](c, "name"), nil
+	})
+	c.RegisterFactory("client", func(c *Container) (any, error) {
+		pool, err := Get[string](c, "pool")
+		if err != nil {
+			return nil, err
+		}
+		return "client using " + pool, nil
+	})
+
+	if s := MustGet[string](c, "client"); s != "client using pool for db" {
+		t.Errorf("got %v, want client using pool for db", s)
+	}
+}
+
+func TestContainer_FactoryCycle(t *testing.T) {
+	c := New()
+	c.RegisterFactory("a", func(c *Container) (any, error) { return c.Get("b") })
+	c.RegisterFactory("b", func(c *Container) (any, error) { return c.Get("a") })
+
+	_, err := c.Get("a")
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Get() error = %v, want the cycle a -> b -> a", err)
+	}
+}
+
+func TestContainer_FactorySelfCycle(t *testing.T) {
+	c := New()
+	c.RegisterFactory("a", func(c *Container) (any, error) { return c.Get("a") })
+
+	_, err := c.Get("a")
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle between services: a -> a") {
+		t.Errorf("Get() error = %v, want the cycle a -> a", err)
+	}
+}
+
+func TestContainer_FactoryCycleLongPath(t *testing.T) {
+	c := New()
+	c.RegisterFactory("root", func(c *Container) (any, error) { return c.Get("a") })
+	c.RegisterFactory("a", func(c *Container) (any, error) { return c.Get("b") })
+	c.RegisterFactory("b", func(c *Container) (any, error) { return c.Get("c") })
+	c.RegisterFactory("c", func(c *Container) (any, error) { return c.Get("a") })
+
+	// The path starts at the first service of the cycle
+	_, err := c.Get("root")
+	if err == nil || !strings.Contains(err.Error(), ": a -> b -> c -> a") {
+		t.Errorf("Get() error = %v, want the cycle a -> b -> c -> a", err)
+	}
+}
+
+func TestContainer_FactoryCycleAcrossGoroutines(t *testing.T) {
+	c := New()
+	var started sync.WaitGroup
+	var startedA, startedB sync.Once
+	started.Add(2)
+	// Each factory waits until both are being built, so each goroutine holds one and waits for the other
+	// A factory runs again after the other goroutine gave up, it only counts once
+	c.RegisterFactory("a", func(c *Container) (any, error) {
+		startedA.Do(started.Done)
+		started.Wait()
+		return c.Get("b")
+	})
+	c.RegisterFactory("b", func(c *Container) (any, error) {
+		startedB.Do(started.Done)
+		started.Wait()
+		return c.Get("a")
+	})
+
+	errs := make(chan error, 2)
+	for _, name := range []string{"a", "b"} {
+		go func() {
+			_, err := c.Get(name)
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err == nil || !strings.Contains(err.Error(), "dependency cycle between services") {
+				t.Errorf("Get() error = %v, want a dependency cycle", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Get() deadlocked on a cycle across goroutines")
+		}
+	}
+}
+
+func TestContainer_FactoryError(t *testing.T) {
+	c := New()
+	fail := true
+	c.RegisterFactory("flaky", func(*Container) (any, error) {
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return "flaky service", nil
+	})
+
+	if _, err := c.Get("flaky"); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("Get() error = %v, want the factory error", err)
+	}
+
+	// A failed factory runs again
+	fail = false
+	if s, err := c.Get("flaky"); err != nil || s != "flaky service" {
+		t.Errorf("Get() = %v, %v, want flaky service", s, err)
+	}
+}
+
+func TestContainer_FactoryConcurrent(t *testing.T) {
+	c := New()
+	var calls atomic.Int32
+	c.RegisterFactory("slow", func(*Container) (any, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return new(int), nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]*int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = MustGet[*int](c, "slow")
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("factory ran %d times, want 1", calls.Load())
+	}
+	for _, r := range results {
+		if r != results[0] {
+			t.Fatal("concurrent Gets returned different instances")
+		}
+	}
+}
+
+func TestContainer_RegisterReplacesFactory(t *testing.T) {
+	c := New()
+	c.RegisterFactory("service", func(*Container) (any, error) { return "lazy", nil })
+	c.Register("service", "eager")
+
+	if s := c.MustGet("service"); s != "eager" {
+		t.Errorf("got %v, want eager", s)
+	}
+}
+
+func TestContainer_Tagged(t *testing.T) {
+	c := New()
+	c.Register("first", "first task", "task")
+	c.Register("other", "not a task")
+	c.RegisterFactory("second", func(*Container) (any, error) { return "second task", nil }, "task", "lazy")
+	c.Register("third", "third task", "task")
+
+	// Registering again adds tags without reordering
+	c.Register("first", "first task", "task", "eager")
+
+	tasks, err := Tagged[string](c, "task")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(tasks, ","); got != "first task,second task,third task" {
+		t.Errorf("got %v, want first task,second task,third task", got)
+	}
+
+	services, err := c.Tagged("eager")
+	if err != nil || len(services) != 1 || services[0] != "first task" {
+		t.Errorf("Tagged(eager) = %v, %v, want first task", services, err)
+	}
+
+	if services, err := c.Tagged("missing"); err != nil || len(services) != 0 {
+		t.Errorf("Tagged(missing) = %v, %v, want no services", services, err)
+	}
+}
+
+func TestContainer_TaggedErrors(t *testing.T) {
+	c := New()
+	c.Register("number", 42, "task")
+	c.RegisterFactory("broken", func(*Container) (any, error) { return nil, errors.New("unavailable") }, "broken")
+
+	_, err := Tagged[string](c, "task")
+	if err == nil || err.Error() != "service number is int, not string" {
+		t.Errorf("Tagged[string]() error = %v, want a type mismatch", err)
+	}
+
+	_, err = c.Tagged("broken")
+	if err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Tagged() error = %v, want the factory error", err)
+	}
+}
+
+func TestContainer_Override(t *testing.T) {
+	c := New()
+	c.Register("mail", "smtp mailer", "notifier")
+	c.RegisterFactory("lazy", func(*Container) (any, error) { return "real", nil })
+	c.SetHooks("mail", Hooks{OnStop: func(context.Context) error { return errors.New("closed the real mailer") }})
+
+	c.Override("mail", "fake mailer")
+	c.Override("lazy", "fake")
+
+	if s := MustGet[string](c, "mail"); s != "fake mailer" {
+		t.Errorf("got %v, want fake mailer", s)
+	}
+	if s := MustGet[string](c, "lazy"); s != "fake" {
+		t.Errorf("got %v, want fake", s)
+	}
+	// The override keeps the tags, the hooks of the replaced service are removed
+	if notifiers, _ := Tagged[string](c, "notifier"); len(notifiers) != 1 || notifiers[0] != "fake mailer" {
+		t.Errorf("Tagged() = %v, want fake mailer", notifiers)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v, want the hooks removed", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Override() of an unregistered service did not panic")
+		}
+	}()
+	c.Override("missing", "fake")
+}
+
+func TestContainer_Freeze(t *testing.T) {
+	c := New()
+	c.Register("mail", "smtp mailer")
+	c.Freeze()
+
+	if !c.Frozen() {
+		t.Fatal("Frozen() = false after Freeze")
+	}
+	// Lookups still work
+	if s := c.MustGet("mail"); s != "smtp mailer" {
+		t.Errorf("got %v, want smtp mailer", s)
+	}
+
+	for name, register := range map[string]func(){
+		"Register":          func() { c.Register("other", "service") },
+		"RegisterFactory":   func() { c.RegisterFactory("other", func(*Container) (any, error) { return nil, nil }) },
+		"Override":          func() { c.Override("mail", "fake mailer") },
+		"Decorate":          func() { c.Decorate("mail", func(existing any) any { return existing }) },
+		"RegisterTransient": func() { c.RegisterTransient("other", func(*Container) (any, error) { return nil, nil }) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrFrozen) {
+					t.Errorf("%s() panicked with %v, want ErrFrozen", name, err)
+				}
+				// The panic must not leave the container locked
+				if s, err := c.Get("mail"); err != nil || s != "smtp mailer" {
+					t.Errorf("Get() after %s() = %v, %v, want smtp mailer", name, s, err)
+				}
+			}()
+			register()
+		})
+	}
+}
+
+func TestContainer_FreezeFactories(t *testing.T) {
+	c := New()
+	c.RegisterFactory("lazy", func(*Container) (any, error) { return "built", nil })
+	c.Freeze()
+
+	// Factories may still build their services after Freeze
+	if s, err := c.Get("lazy"); err != nil || s != "built" {
+		t.Errorf("Get() = %v, %v, want built", s, err)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	c := New()
+	c.Register("cache", "memory cache")
+	c.RegisterFactory("broken", func(*Container) (any, error) { return nil, errors.New("unavailable") })
+	c.RegisterFactory("mailer", func(c *Container) (any, error) { return c.Get("smtp") })
+
+	if s := GetOr(c, "cache", "fallback"); s != "memory cache" {
+		t.Errorf("got %v, want memory cache", s)
+	}
+	// Missing services return the fallback
+	if s := GetOr(c, "metrics", "fallback"); s != "fallback" {
+		t.Errorf("got %v, want fallback", s)
+	}
+	if p := GetOr[*int](c, "metrics", nil); p != nil {
+		t.Errorf("got %v, want nil", p)
+	}
+	if _, err := c.Get("metrics"); !errors.Is(err, ErrNotFound) || !NotRegistered(err, "metrics") {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Get("mailer"); !errors.Is(err, ErrNotFound) || NotRegistered(err, "mailer") {
+		t.Errorf("Get() error = %v, want a missing dependency of a registered service", err)
+	}
+
+	// A wrong type or a failing factory is a bug, not a missing service
+	for name, get := range map[string]func(){
+		"wrong type":      func() { GetOr(c, "cache", 0) },
+		"failing factory": func() { GetOr(c, "broken", "fallback") },
+		// The factory is registered, its dependency is missing
+		"missing dependency": func() { GetOr(c, "mailer", "fallback") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("GetOr() did not panic")
+				}
+			}()
+			get()
+		})
+	}
+}
+
+func TestContainer_Decorate(t *testing.T) {
+	c := New()
+	c.Register("cache", "cache", "store")
+	c.Decorate("cache", func(existing any) any { return "instrumented " + existing.(string) })
+	c.Decorate("cache", func(existing any) any { return "logging " + existing.(string) })
+
+	// The last decorator is the outermost
+	if s := MustGet[string](c, "cache"); s != "logging instrumented cache" {
+		t.Errorf("got %v, want logging instrumented cache", s)
+	}
+	// Tags are kept
+	if stores, _ := Tagged[string](c, "store"); len(stores) != 1 || stores[0] != "logging instrumented cache" {
+		t.Errorf("Tagged() = %v, want the decorated service", stores)
+	}
+}
+
+func TestContainer_DecorateFactory(t *testing.T) {
+	c := New()
+	calls := 0
+	c.RegisterFactory("db", func(*Container) (any, error) {
+		calls++
+		return "db", nil
+	})
+	c.Decorate("db", func(existing any) any { return "logging " + existing.(string) })
+
+	// The factory stays lazy
+	if calls != 0 {
+		t.Fatalf("factory ran %d times before Get", calls)
+	}
+	if s := MustGet[string](c, "db"); s != "logging db" {
+		t.Errorf("got %v, want logging db", s)
+	}
+	if s := MustGet[string](c, "db"); s != "logging db" || calls != 1 {
+		t.Errorf("got %v after %d calls, want logging db built once", s, calls)
+	}
+}
+
+func TestContainer_DecorateDropped(t *testing.T) {
+	c := New()
+	c.RegisterFactory("db", func(*Container) (any, error) { return "db", nil })
+	c.Decorate("db", func(existing any) any { return "logging " + existing.(string) })
+	c.Override("db", "fake db")
+
+	if s := MustGet[string](c, "db"); s != "fake db" {
+		t.Errorf("got %v, want fake db", s)
+	}
+}
+
+func TestContainer_DecoratePanics(t *testing.T) {
+	for name, freeze := range map[string]bool{"missing": false, "frozen": true} {
+		t.Run(name, func(t *testing.T) {
+			c := New()
+			c.Register("cache", "cache")
+			target := "missing"
+			if freeze {
+				c.Freeze()
+				target = "cache"
+			}
+
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Decorate() did not panic")
+				}
+			}()
+			c.Decorate(target, func(existing any) any { return existing })
+		})
+	}
+}
+
+func TestContainer_RegisterTransient(t *testing.T) {
+	c := New()
+	calls := 0
+	c.RegisterTransient("conn", func(*Container) (any, error) {
+		calls++
+		return &calls, nil
+	}, "conns")
+	c.Decorate("conn", func(existing any) any { return fmt.Sprint("conn ", *existing.(*int)) })
+
+	// Every Get builds and decorates a new instance
+	for i := 1; i <= 3; i++ {
+		if s := MustGet[string](c, "conn"); s != fmt.Sprint("conn ", i) {
+			t.Errorf("got %v, want conn %d", s, i)
+		}
+	}
+	if conns, _ := Tagged[string](c, "conns"); len(conns) != 1 || conns[0] != "conn 4" {
+		t.Errorf("Tagged() = %v, want a new instance", conns)
+	}
+
+	// Transient services are never built by List or started by StartAll
+	list := c.List()
+	if len(list) != 1 || !list[0].Transient || list[0].Built {
+		t.Errorf("List() = %+v, want one transient service", list)
+	}
+	if err := c.StartAll(context.Background()); err != nil || calls != 4 {
+		t.Errorf("StartAll() = %v after %d calls, want no builds", err, calls)
+	}
+}
+
+func TestContainer_TransientDependencies(t *testing.T) {
+	c := New()
+	c.RegisterFactory("pool", func(*Container) (any, error) { return new(int), nil })
+	c.RegisterTransient("conn", func(c *Container) (any, error) {
+		return MustGet[*int](c, "pool"), nil
+	})
+
+	// Transient services share their singleton dependencies
+	if MustGet[*int](c, "conn") != MustGet[*int](c, "conn") {
+		t.Error("transient services got different singleton dependencies")
+	}
+
+	c.RegisterTransient("loop", func(c *Container) (any, error) { return c.Get("loop") })
+	if _, err := c.Get("loop"); err == nil || !strings.Contains(err.Error(), "loop -> loop") {
+		t.Errorf("Get() error = %v, want the cycle loop -> loop", err)
+	}
+
+	c.RegisterTransient("broken", func(*Container) (any, error) { return nil, errors.New("unavailable") })
+	if _, err := c.Get("broken"); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Get() error = %v, want the factory error", err)
+	}
+}
+
+func TestContainer_TransientReplaced(t *testing.T) {
+	c := New()
+	c.RegisterTransient("conn", func(*Container) (any, error) { return "transient", nil })
+	c.Override("conn", "fake")
+	if s := c.MustGet("conn"); s != "fake" {
+		t.Errorf("got %v, want fake", s)
+	}
+
+	c.RegisterTransient("conn", func(*Container) (any, error) { return "transient", nil })
+	c.Register("conn", "eager")
+	if s := c.MustGet("conn"); s != "eager" {
+		t.Errorf("got %v, want eager", s)
+	}
+}
+
+func TestContainer_TransientCycleAcrossGoroutines(t *testing.T) {
+	c := New()
+	var started sync.WaitGroup
+	var startedA, startedB sync.Once
+	started.Add(2)
+	// a waits for b through the transient t, which holds no lock itself
+	c.RegisterFactory("a", func(c *Container) (any, error) {
+		startedA.Do(started.Done)
+		started.Wait()
+		return c.Get("t")
+	})
+	c.RegisterTransient("t", func(c *Container) (any, error) { return c.Get("b") })
+	c.RegisterFactory("b", func(c *Container) (any, error) {
+		startedB.Do(started.Done)
+		started.Wait()
+		return c.Get("a")
+	})
+
+	errs := make(chan error, 2)
+	for _, name := range []string{"a", "b"} {
+		go func() {
+			_, err := c.Get(name)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err == nil || !strings.Contains(err.Error(), "dependency cycle between services") {
+				t.Errorf("Get() error = %v, want a dependency cycle", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Get() deadlocked on a cycle through a transient service")
+		}
+	}
+}
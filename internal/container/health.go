@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+	Health checks: services register a check that returns an error when they can't serve requests,
+	Health runs them all, e.g. for a readiness endpoint probed by an orchestrator.
+
+	Example:
+		c.RegisterHealthCheck("db", db.PingContext)
+
+		results := c.Health(ctx)
+		for name, err := range results {
+			if err != nil {
+				log.Printf("%s is unhealthy: %v", name, err)
+			}
+		}
+
+	Notes:
+	- Checks run concurrently, keep them fast and pass a context with a timeout to Health
+	- A panicking check is reported as unhealthy
+	- Registering a check with the same name replaces it
+*/
+
+// RegisterHealthCheck registers a check run by Health, it returns an error when the service is unhealthy
+func (c *Container) RegisterHealthCheck(name string, check func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Health runs the health checks concurrently and returns their results by name, nil for healthy checks
+func (c *Container) Health(ctx context.Context) map[string]error {
+	c.mu.RLock()
+	checks := make(map[string]func(context.Context) error, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := runCheck(ctx, check)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runCheck runs a health check and turns a panic into an error
+func runCheck(ctx context.Context, check func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("health check panicked: %v", r)
+		}
+	}()
+	return check(ctx)
+}
@@ -0,0 +1,54 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContainer_Health(t *testing.T) {
+	c := New()
+	c.RegisterHealthCheck("db", func(context.Context) error { return nil })
+	c.RegisterHealthCheck("cache", func(context.Context) error { return errors.New("cache down") })
+	c.RegisterHealthCheck("hub", func(context.Context) error { panic("boom") })
+
+	results := c.Health(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if err := results["db"]; err != nil {
+		t.Errorf("db error = %v, want nil", err)
+	}
+	if err := results["cache"]; err == nil || err.Error() != "cache down" {
+		t.Errorf("cache error = %v, want cache down", err)
+	}
+	// A panicking check is unhealthy
+	if err := results["hub"]; err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("hub error = %v, want the panic", err)
+	}
+}
+
+func TestContainer_HealthContext(t *testing.T) {
+	c := New()
+	c.RegisterHealthCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Health(ctx)["slow"]; !errors.Is(err, context.Canceled) {
+		t.Errorf("slow error = %v, want context.Canceled", err)
+	}
+}
+
+func TestContainer_HealthReplace(t *testing.T) {
+	c := New()
+	c.RegisterHealthCheck("db", func(context.Context) error { return errors.New("old") })
+	c.RegisterHealthCheck("db", func(context.Context) error { return nil })
+
+	if err := c.Health(context.Background())["db"]; err != nil {
+		t.Errorf("db error = %v, want the replaced check", err)
+	}
+}
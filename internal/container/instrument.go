@@ -0,0 +1,73 @@
+package container
+
+import "time"
+
+/*
+	Instrumentation: optional functions the container calls on registrations, lookups and factory
+	builds, so slow lazy services and lookups of missing services can be logged or exported as metrics.
+
+	Example:
+		c.Instrument(container.Instrumentation{
+			OnBuild: func(name string, d time.Duration, err error) {
+				if d > 100*time.Millisecond {
+					logger.Warn("Slow service build", "service", name, "duration", d)
+				}
+			},
+			OnGet: func(name string, d time.Duration, err error) {
+				if container.NotRegistered(err, name) {
+					misses.Inc(name)
+				}
+			},
+		})
+
+	Notes:
+	- Any function may be nil, Instrument replaces the previous instrumentation
+	- The functions run without the container lock and may use the container, a Get in OnGet is
+	  reported too so avoid infinite recursion
+	- OnGet includes the build of a factory service, OnBuild only the factory and its decorators
+	- Lookups inside a factory are reported, also the lookups of Tagged, GetOr and the generic getters
+	- List doesn't look up services by name and isn't reported, ResolveAs reports the factories it builds
+*/
+
+// Instrumentation are the functions the container calls on registrations, lookups and builds
+type Instrumentation struct {
+	// OnRegister is called after Register, RegisterFactory, RegisterTransient or Override succeeded
+	OnRegister func(name string)
+	// OnGet is called after every Get with how long it took and its error, see NotRegistered for missing services
+	OnGet func(name string, d time.Duration, err error)
+	// OnBuild is called after a factory or transient service was built with how long it took and its error
+	OnBuild func(name string, d time.Duration, err error)
+}
+
+// Instrument sets the functions the container calls on registrations, lookups and builds
+func (c *Container) Instrument(instrumentation Instrumentation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instrumentation = instrumentation
+}
+
+// instrumented returns the current instrumentation
+func (c *Container) instrumented() Instrumentation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.instrumentation
+}
+
+// registered reports a registration to OnRegister
+func (c *Container) registered(name string) {
+	if onRegister := c.instrumented().OnRegister; onRegister != nil {
+		onRegister(name)
+	}
+}
+
+// timeBuild runs build and reports how long it took to OnBuild
+func (c *Container) timeBuild(name string, build func() (any, error)) (any, error) {
+	onBuild := c.instrumented().OnBuild
+	if onBuild == nil {
+		return build()
+	}
+	start := time.Now()
+	service, err := build()
+	onBuild(name, time.Since(start), err)
+	return service, err
+}
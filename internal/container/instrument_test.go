@@ -0,0 +1,86 @@
+package container
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInstrument(t *testing.T) {
+	c := New()
+	var registered, gets, builds []string
+	var buildErr error
+	c.Instrument(Instrumentation{
+		OnRegister: func(name string) {
+			registered = append(registered, name)
+		},
+		OnGet: func(name string, d time.Duration, err error) {
+			if NotRegistered(err, name) {
+				name += " (missing)"
+			}
+			gets = append(gets, name)
+		},
+		OnBuild: func(name string, d time.Duration, err error) {
+			if d < 10*time.Millisecond {
+				t.Errorf("build of %s took %v, want at least 10ms", name, d)
+			}
+			builds = append(builds, name)
+			buildErr = err
+		},
+	})
+
+	c.Register("config", "config")
+	c.RegisterFactory("db", func(c *Container) (any, error) {
+		MustGet[string](c, "config")
+		time.Sleep(10 * time.Millisecond)
+		return "db", nil
+	})
+	c.RegisterTransient("conn", func(*Container) (any, error) {
+		time.Sleep(10 * time.Millisecond)
+		return nil, errors.New("refused")
+	})
+	c.Override("config", "test config")
+
+	c.MustGet("db")
+	c.MustGet("db")
+	c.Get("conn")
+	c.Get("mail")
+
+	if want := []string{"config", "db", "conn", "config"}; !slices.Equal(registered, want) {
+		t.Errorf("registered = %v, want %v", registered, want)
+	}
+	// The factory's lookup is reported before the Get building it returns
+	if want := []string{"config", "db", "db", "conn", "mail (missing)"}; !slices.Equal(gets, want) {
+		t.Errorf("gets = %v, want %v", gets, want)
+	}
+	// The factory service is built once
+	if want := []string{"db", "conn"}; !slices.Equal(builds, want) {
+		t.Errorf("builds = %v, want %v", builds, want)
+	}
+	if buildErr == nil {
+		t.Error("OnBuild got no error for the failing transient service")
+	}
+}
+
+func TestInstrument_NoRegisterOnPanic(t *testing.T) {
+	c := New()
+	var registered []string
+	c.Instrument(Instrumentation{OnRegister: func(name string) {
+		registered = append(registered, name)
+	}})
+
+	func() {
+		defer func() { recover() }()
+		c.Override("missing", "service")
+	}()
+	if len(registered) != 0 {
+		t.Errorf("registered = %v after a failed Override, want none", registered)
+	}
+
+	// The hook may use the container
+	c.Instrument(Instrumentation{OnRegister: func(name string) {
+		c.MustGet(name)
+	}})
+	c.Register("logger", "logger")
+}
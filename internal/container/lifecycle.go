@@ -0,0 +1,184 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+/*
+	Lifecycle: services that run in the background or hold resources implement Starter or Stopper,
+	StartAll starts them and Shutdown stops them, so main starts and tears down everything in one place.
+
+	Services start in the order they were registered - factory services when they are built - and
+	after their dependencies, and stop in reverse order, so a service is stopped before what it uses.
+	The services a factory looks up are its dependencies, DependsOn declares the dependencies of
+	services registered with Register, which the container can't see.
+
+	Example:
+		c.Register("db", db)
+		c.SetHooks("db", container.Hooks{OnStop: func(context.Context) error { return db.Close() }})
+		c.Register("events", bus)   // *events.Bus implements Stopper
+		c.Register("cron", runner)
+		c.DependsOn("cron", "db")   // the cron tasks query the database
+
+		if err := c.StartAll(ctx); err != nil {
+			log.Fatal(err)
+		}
+		...
+		err := c.Shutdown(shutdownCtx)   // stops the cron runner and the bus, then closes the database
+
+	Notes:
+	- Use SetHooks for types whose methods don't match, e.g. *sql.DB or *cron.Runner, hooks replace
+	  the Start and Stop methods of the service
+	- If a service fails to start, the services already started are stopped and StartAll returns the error
+	- Shutdown stops every service, also when StartAll wasn't called, and returns all errors joined
+	- Factory services built after StartAll aren't started, start them in the factory instead
+	- Dependencies are kept when a service is registered again or overridden
+	- A cycle of declared dependencies doesn't fail, one dependency in the cycle is ignored
+*/
+
+// Starter is implemented by services that start background work
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by services that stop background work or release resources
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Hooks are the lifecycle functions of a service set with SetHooks, either may be nil
+type Hooks struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// SetHooks sets the functions StartAll and Shutdown run for the service name instead of its methods
+func (c *Container) SetHooks(name string, hooks Hooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks[name] = hooks
+}
+
+// DependsOn declares that the service name uses the services deps, so it starts after and stops before them
+// Lookups by factories are recorded automatically, declare the dependencies of services built outside the container
+func (c *Container) DependsOn(name string, deps ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addDeps(name, deps)
+}
+
+// dependency records that the factory being built looks up name
+func (c *Container) dependency(name string) {
+	if len(c.resolving) == 0 {
+		return
+	}
+	dependent := c.resolving[len(c.resolving)-1]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addDeps(dependent, []string{name})
+}
+
+// addDeps adds dependencies of a service, the caller holds the lock
+func (c *Container) addDeps(name string, deps []string) {
+	for _, dep := range deps {
+		if dep != name && !slices.Contains(c.deps[name], dep) {
+			c.deps[name] = append(c.deps[name], dep)
+		}
+	}
+}
+
+// StartAll starts the services in dependency and registration order
+func (c *Container) StartAll(ctx context.Context) error {
+	names, hooks := c.lifecycle()
+	for i, name := range names {
+		if hooks[i].OnStart == nil {
+			continue
+		}
+		if err := hooks[i].OnStart(ctx); err != nil {
+			err = fmt.Errorf("error starting service %s: %w", name, err)
+			return errors.Join(err, stop(ctx, names[:i], hooks[:i]))
+		}
+	}
+	return nil
+}
+
+// Shutdown stops the services in reverse dependency and registration order, a service before its dependencies
+func (c *Container) Shutdown(ctx context.Context) error {
+	names, hooks := c.lifecycle()
+	return stop(ctx, names, hooks)
+}
+
+// lifecycle returns the services in start order with their hooks
+func (c *Container) lifecycle() ([]string, []Hooks) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var names []string
+	var hooks []Hooks
+	for _, name := range c.startOrder() {
+		service, exists := c.services[name]
+		if !exists {
+			continue
+		}
+		h, ok := c.hooks[name]
+		if !ok {
+			if starter, ok := service.(Starter); ok {
+				h.OnStart = starter.Start
+			}
+			if stopper, ok := service.(Stopper); ok {
+				h.OnStop = stopper.Stop
+			}
+		}
+		names = append(names, name)
+		hooks = append(hooks, h)
+	}
+	return names, hooks
+}
+
+// startOrder returns the registered services after their dependencies, otherwise in registration order
+// Dependencies through services without a place in the order, e.g. transient services, count too
+// The caller holds the lock
+func (c *Container) startOrder() []string {
+	ordered := make(map[string]bool, len(c.order))
+	for _, name := range c.order {
+		ordered[name] = true
+	}
+
+	visited := make(map[string]bool, len(c.order))
+	names := make([]string, 0, len(c.order))
+	var visit func(name string)
+	visit = func(name string) {
+		// Marked before the dependencies so a cycle ends here
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range c.deps[name] {
+			visit(dep)
+		}
+		if ordered[name] {
+			names = append(names, name)
+		}
+	}
+	for _, name := range c.order {
+		visit(name)
+	}
+	return names
+}
+
+// stop runs the stop hooks in reverse order and joins their errors
+func stop(ctx context.Context, names []string, hooks []Hooks) error {
+	var errs []error
+	for i := range slices.Backward(names) {
+		if hooks[i].OnStop == nil {
+			continue
+		}
+		if err := hooks[i].OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("error stopping service %s: %w", names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
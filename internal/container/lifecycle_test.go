@@ -0,0 +1,184 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recorder records the lifecycle calls of a service
+type recorder struct {
+	name     string
+	calls    *[]string
+	startErr error
+	stopErr  error
+}
+
+func (r *recorder) Start(context.Context) error {
+	*r.calls = append(*r.calls, "start "+r.name)
+	return r.startErr
+}
+
+func (r *recorder) Stop(context.Context) error {
+	*r.calls = append(*r.calls, "stop "+r.name)
+	return r.stopErr
+}
+
+func TestContainer_StartAllShutdown(t *testing.T) {
+	var calls []string
+	c := New()
+	c.Register("db", &recorder{name: "db", calls: &calls})
+	c.Register("config", "not a service with a lifecycle")
+	c.Register("cache", &recorder{name: "cache", calls: &calls})
+
+	if err := c.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := "start db,start cache,stop cache,stop db"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestContainer_LifecycleFactoryOrder(t *testing.T) {
+	var calls []string
+	c := New()
+	// The client is registered first but built after its dependency
+	c.RegisterFactory("client", func(c *Container) (any, error) {
+		c.MustGet("pool")
+		return &recorder{name: "client", calls: &calls}, nil
+	})
+	c.RegisterFactory("pool", func(*Container) (any, error) {
+		return &recorder{name: "pool", calls: &calls}, nil
+	})
+	c.RegisterFactory("unused", func(*Container) (any, error) {
+		return &recorder{name: "unused", calls: &calls}, nil
+	})
+	c.MustGet("client")
+
+	if err := c.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := "start pool,start client,stop client,stop pool"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestContainer_SetHooks(t *testing.T) {
+	var calls []string
+	c := New()
+	c.Register("db", &recorder{name: "db", calls: &calls})
+	c.SetHooks("db", Hooks{OnStop: func(context.Context) error {
+		calls = append(calls, "close db")
+		return nil
+	}})
+
+	if err := c.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	// Hooks replace the methods of the service
+	if got := strings.Join(calls, ","); got != "close db" {
+		t.Errorf("got %v, want close db", got)
+	}
+}
+
+func TestContainer_StartAllError(t *testing.T) {
+	var calls []string
+	c := New()
+	c.Register("db", &recorder{name: "db", calls: &calls})
+	c.Register("hub", &recorder{name: "hub", calls: &calls, startErr: errors.New("port in use")})
+	c.Register("cron", &recorder{name: "cron", calls: &calls})
+
+	err := c.StartAll(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "error starting service hub: port in use") {
+		t.Fatalf("StartAll() error = %v, want the hub error", err)
+	}
+
+	// The services started before the failure are stopped, later ones aren't started
+	want := "start db,start hub,stop db"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestContainer_ShutdownErrors(t *testing.T) {
+	var calls []string
+	c := New()
+	c.Register("db", &recorder{name: "db", calls: &calls, stopErr: errors.New("busy")})
+	c.Register("cache", &recorder{name: "cache", calls: &calls, stopErr: errors.New("locked")})
+
+	err := c.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "service db: busy") || !strings.Contains(err.Error(), "service cache: locked") {
+		t.Errorf("Shutdown() error = %v, want both errors", err)
+	}
+	// Every service is stopped even if one fails
+	if got := strings.Join(calls, ","); got != "stop cache,stop db" {
+		t.Errorf("got %v, want stop cache,stop db", got)
+	}
+}
+
+func TestContainer_DependsOn(t *testing.T) {
+	var calls []string
+	c := New()
+	// Registered before the database it uses
+	c.Register("cron", &recorder{name: "cron", calls: &calls})
+	c.Register("hub", &recorder{name: "hub", calls: &calls})
+	c.Register("db", &recorder{name: "db", calls: &calls})
+	c.DependsOn("cron", "db")
+
+	if err := c.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() error = %v", err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := "start db,start cron,start hub,stop hub,stop cron,stop db"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestContainer_LifecycleRecordedDependencies(t *testing.T) {
+	var calls []string
+	c := New()
+	c.Register("queue", "replaced by a factory below")
+	c.Register("db", &recorder{name: "db", calls: &calls})
+	// The queue takes the place where it's built, after the db it looks up through a transient service
+	c.RegisterFactory("queue", func(c *Container) (any, error) {
+		c.MustGet("conn")
+		return &recorder{name: "queue", calls: &calls}, nil
+	})
+	c.RegisterTransient("conn", func(c *Container) (any, error) {
+		return c.MustGet("db"), nil
+	})
+	c.MustGet("queue")
+	// A cycle ignores the dependency closing it, b -> a
+	c.Register("a", &recorder{name: "a", calls: &calls})
+	c.Register("b", &recorder{name: "b", calls: &calls})
+	c.DependsOn("a", "b")
+	c.DependsOn("b", "a")
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := "stop a,stop b,stop queue,stop db"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
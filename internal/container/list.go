@@ -0,0 +1,54 @@
+package container
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ServiceInfo describes a registered service, returned by List
+type ServiceInfo struct {
+	Name string `json:"name"`
+	// Type is the concrete type, empty for a factory service that isn't built yet
+	Type string `json:"type"`
+	// Lazy is set for services registered with RegisterFactory
+	Lazy bool `json:"lazy"`
+	// Transient is set for services registered with RegisterTransient, they are never built by List
+	Transient bool `json:"transient"`
+	// Built is set once the service exists, always for services registered with Register
+	Built bool     `json:"built"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// List describes the registered services sorted by name, e.g. for a debug page
+// It doesn't build factory services
+func (c *Container) List() []ServiceInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tags := map[string][]string{}
+	for tag, names := range c.tags {
+		for _, name := range names {
+			tags[name] = append(tags[name], tag)
+		}
+	}
+
+	list := make([]ServiceInfo, 0, len(c.services)+len(c.factories)+len(c.transients))
+	for name, service := range c.services {
+		list = append(list, ServiceInfo{Name: name, Type: fmt.Sprintf("%T", service), Lazy: c.lazy[name], Built: true})
+	}
+	for name := range c.factories {
+		list = append(list, ServiceInfo{Name: name, Lazy: true})
+	}
+	for name := range c.transients {
+		list = append(list, ServiceInfo{Name: name, Transient: true})
+	}
+	for i := range list {
+		list[i].Tags = tags[list[i].Name]
+		slices.Sort(list[i].Tags)
+	}
+	slices.SortFunc(list, func(a, b ServiceInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
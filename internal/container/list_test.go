@@ -0,0 +1,41 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContainer_List(t *testing.T) {
+	c := New()
+	c.Register("logger", "text logger")
+	c.Register("task", func() error { return nil }, "cron.task", "b")
+	c.RegisterFactory("client", func(*Container) (any, error) { return 42, nil })
+	c.RegisterFactory("pool", func(*Container) (any, error) { return "pool", nil }, "cron.task")
+	c.MustGet("client")
+
+	want := []ServiceInfo{
+		{Name: "client", Type: "int", Lazy: true, Built: true},
+		{Name: "logger", Type: "string", Built: true},
+		{Name: "pool", Lazy: true, Tags: []string{"cron.task"}},
+		{Name: "task", Type: "func() error", Built: true, Tags: []string{"b", "cron.task"}},
+	}
+	if got := c.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+
+	// List doesn't build factory services
+	if c.List()[2].Built {
+		t.Error("List() built the pool factory")
+	}
+}
+
+func TestContainer_ListReplaced(t *testing.T) {
+	c := New()
+	c.RegisterFactory("client", func(*Container) (any, error) { return 42, nil })
+	c.Register("client", "eager")
+
+	want := []ServiceInfo{{Name: "client", Type: "string", Built: true}}
+	if got := c.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
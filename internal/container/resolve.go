@@ -0,0 +1,80 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+/*
+	Resolving by type: ResolveAs finds the one service implementing an interface, so consumers can
+	depend on cache.Cache or auth.Authenticator without knowing the name it was registered with.
+
+	Example:
+		authenticator, err := container.ResolveAs[auth.Authenticator](c)
+		// Or panic if no service or several are an auth.Authenticator
+		authenticator := container.MustResolveAs[auth.Authenticator](c)
+
+	Notes:
+	- Exactly one service must match, several matching services are an error naming them - get one
+	  by name then
+	- Factory services not built yet are built to learn their type, a failing factory fails ResolveAs -
+	  transient services aren't considered, they would be built on every call
+	- The factories being built by the caller are skipped, so a factory may resolve the services it needs
+	- Concrete types match too, e.g. ResolveAs[*sql.DB]
+*/
+
+// ResolveAs returns the one registered service that is an I, usually an interface
+// It returns ErrNotFound if no service matches and an error naming the services if several match
+func ResolveAs[I any](c *Container) (I, error) {
+	var zero I
+	c.mu.RLock()
+	var names []string
+	var matches []I
+	for name, service := range c.services {
+		if typed, ok := service.(I); ok {
+			names = append(names, name)
+			matches = append(matches, typed)
+		}
+	}
+	var lazy []string
+	for name := range c.factories {
+		if !slices.Contains(c.resolving, name) {
+			lazy = append(lazy, name)
+		}
+	}
+	c.mu.RUnlock()
+
+	// Built factories move to the services, each is counted once
+	slices.Sort(lazy)
+	for _, name := range lazy {
+		service, err := c.Get(name)
+		if err != nil {
+			return zero, err
+		}
+		if typed, ok := service.(I); ok {
+			names = append(names, name)
+			matches = append(matches, typed)
+		}
+	}
+
+	typeName := reflect.TypeFor[I]().String()
+	switch len(matches) {
+	case 0:
+		return zero, fmt.Errorf("service implementing %s %w", typeName, ErrNotFound)
+	case 1:
+		return matches[0], nil
+	}
+	slices.Sort(names)
+	return zero, fmt.Errorf("services %s all implement %s, get one by name", strings.Join(names, ", "), typeName)
+}
+
+// MustResolveAs returns the one registered service that is an I, panics if none or several match
+func MustResolveAs[I any](c *Container) I {
+	service, err := ResolveAs[I](c)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
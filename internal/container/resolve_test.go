@@ -0,0 +1,83 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveAs(t *testing.T) {
+	c := New()
+	c.Register("timeout", time.Second)
+	c.Register("name", "not a stringer")
+	c.RegisterFactory("lazy", func(*Container) (any, error) { return errors.New("lazy"), nil })
+	c.RegisterTransient("conn", func(*Container) (any, error) { return errors.New("conn"), nil })
+
+	s, err := ResolveAs[fmt.Stringer](c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.String() != "1s" {
+		t.Errorf("got %v, want 1s", s)
+	}
+
+	// Concrete types match too
+	if d := MustResolveAs[time.Duration](c); d != time.Second {
+		t.Errorf("got %v, want 1s", d)
+	}
+
+	// The factory is built to learn its type, the transient service isn't considered
+	if e, err := ResolveAs[error](c); err != nil || e.Error() != "lazy" {
+		t.Errorf("ResolveAs() = %v, %v, want the factory service", e, err)
+	}
+	if built := c.List(); !slices.ContainsFunc(built, func(s ServiceInfo) bool { return s.Name == "lazy" && s.Built }) {
+		t.Errorf("expected the factory to be built, got %+v", built)
+	}
+}
+
+func TestResolveAsInFactory(t *testing.T) {
+	c := New()
+	c.RegisterFactory("timeout", func(*Container) (any, error) { return time.Second, nil })
+	// The factory resolving a service isn't built again to learn its own type
+	c.RegisterFactory("client", func(c *Container) (any, error) {
+		return MustResolveAs[time.Duration](c), nil
+	})
+
+	if d := MustGet[time.Duration](c, "client"); d != time.Second {
+		t.Errorf("got %v, want 1s", d)
+	}
+}
+
+func TestResolveAsErrors(t *testing.T) {
+	c := New()
+	if _, err := ResolveAs[fmt.Stringer](c); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolveAs() error = %v, want ErrNotFound", err)
+	}
+
+	c.Register("timeout", time.Second)
+	c.Register("interval", time.Minute)
+	_, err := ResolveAs[fmt.Stringer](c)
+	if err == nil || !strings.Contains(err.Error(), "services interval, timeout all implement fmt.Stringer") {
+		t.Errorf("ResolveAs() error = %v, want the ambiguous services", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("MustResolveAs() did not panic on ambiguous services")
+		}
+	}()
+	MustResolveAs[fmt.Stringer](c)
+}
+
+func TestResolveAsFailingFactory(t *testing.T) {
+	c := New()
+	c.Register("timeout", time.Second)
+	c.RegisterFactory("broken", func(*Container) (any, error) { return nil, errors.New("unavailable") })
+
+	if _, err := ResolveAs[time.Duration](c); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("ResolveAs() error = %v, want the factory error", err)
+	}
+}
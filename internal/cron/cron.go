@@ -1,6 +1,14 @@
 package cron
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,31 +19,31 @@ import (
 
    How to use:
    1. Create a new Runner
-   2. Add tasks to the Runner (functions that implement CronFunc)
-   3. Start the Runner with a specified interval
+   2. Add tasks to the Runner (functions that implement CronFunc) with a unique name and how often each runs
+   3. Start the Runner
    4. Stop the Runner when done
 
    Example basic usage:
        // Create and start runner
        runner := cron.NewRunner()
-       runner.Add(func() error {
+       runner.Add("hello", func(ctx context.Context) error {
            fmt.Println("Task running...")
            return nil
-       })
-       go runner.Start(time.Minute)
+       }, time.Minute)
+       go runner.Start()
 
    Example with dependencies:
        // Create task with database dependency
        func SaveMetrics(db *sql.DB) cron.CronFunc {
-           return func() error {
-               return db.Exec("INSERT INTO metrics...")
+           return func(ctx context.Context) error {
+               return db.ExecContext(ctx, "INSERT INTO metrics...")
            }
        }
 
        // Use in application
        runner := cron.NewRunner()
-       runner.Add(SaveMetrics(db))
-       go runner.Start(time.Minute * 5)
+       runner.Add("save_metrics", SaveMetrics(db), time.Minute * 5)
+       go runner.Start()
 
        // Cleanup on shutdown
        defer runner.Stop()
@@ -43,71 +51,840 @@ import (
    Example multiple tasks:
        runner := cron.NewRunner()
 
-       // Add multiple tasks
-       runner.Add(CleanupOldRecords(db))
-       runner.Add(UpdateCache(cache))
-       runner.Add(SendMetrics(metrics))
+       // Add multiple tasks, each on its own schedule
+       runner.Add("cleanup", CleanupOldRecords(db), time.Hour)
+       runner.Add("update_cache", UpdateCache(cache), time.Second * 30)
+       runner.Add("send_metrics", SendMetrics(metrics), time.Minute)
+
+       go runner.Start()
+
+   Example overlap policies:
+       // A backup taking longer than an hour runs again right after it finished
+       runner.Add("backup", Backup(db), time.Hour, cron.WithOverlap(cron.OverlapQueue))
+       // Independent pings start on time even if a previous one hangs
+       runner.Add("ping", Ping(client), time.Minute, cron.WithOverlap(cron.OverlapConcurrent))
+
+   Example timeout:
+       // A sync hanging on a request is cancelled after 30 seconds and reported as ErrTimeout
+       runner.Add("sync", Sync(api), time.Minute, cron.WithTimeout(30 * time.Second))
+
+   Example retries:
+       // A failed sync is tried again after 1, 2 and 4 seconds before the failure is reported
+       runner.Add("sync", Sync(api), time.Minute, cron.WithRetry(4, time.Second))
+
+   Example pipeline:
+       // Every night the export is written, then compressed, then uploaded - a failed step stops the
+       // pipeline until the next night, upload runs only once both export and report succeeded
+       runner.Add("export", Export(db), 24*time.Hour)
+       runner.Add("report", Report(db), 24*time.Hour)
+       runner.Add("compress", Compress(dir), 0, cron.WithAfter("export"))
+       runner.Add("upload", Upload(bucket), 0, cron.WithAfter("compress", "report"))
+
+   Example jitter:
+       // Instances of the application sync at a random moment within 10 seconds after every minute
+       runner.Add("sync", Sync(api), time.Minute, cron.WithJitter(10 * time.Second))
+
+   Example one-shot jobs:
+       // Runs once in 15 minutes, cancel() drops it or cancels its context if it's running
+       cancel := runner.RunAfter(15*time.Minute, func(ctx context.Context) error {
+           return tokens.DeleteExpired(ctx)
+       })
+       runner.RunAt(post.PublishAt, PublishPost(db, post.ID))
+
+   Example catching up after downtime:
+       // The last successful runs are kept in the database, see store.go
+       runner := cron.NewRunner(cron.Persist(cron.NewSQLStore(db)))
+       runner.Add("backup", Backup(db), 24*time.Hour, cron.WithCatchUp())
+
+   Example running every tick on one instance of a cluster:
+       store := cron.NewSQLStore(db)
+       runner := cron.NewRunner(cron.Persist(store), cron.Lock(store))
+
+   Example managing tasks at runtime:
+       err := runner.Pause("send_metrics")    // skips its runs until Resume
+       err = runner.Resume("send_metrics")
+       err = runner.Remove("cleanup")         // cancels a running cleanup
+
+       for _, task := range runner.List() {
+           fmt.Println(task.Name, task.Every, task.Paused, task.LastRun, task.LastError)
+       }
+
+   Example metrics:
+       // Counters of every task since it was added
+       for _, s := range runner.Stats() {
+           fmt.Println(s.Name, s.Runs, s.Failures, s.LastDuration, s.LastError)
+       }
+
+       // Export every run, e.g. to Prometheus
+       runner := cron.NewRunner(cron.OnRun(func(run cron.RunInfo) {
+           duration.Observe(run.Duration, run.Name)
+       }))
 
-       // Run all tasks every 30 seconds
-       go runner.Start(time.Second * 30)
+       // Keep a history of the runs in the database, see store.go
+       runner := cron.NewRunner(cron.Record(cron.NewSQLStore(db)))
 
    Notes:
-   - Tasks run sequentially in the order they were added
-   - All tasks share the same interval
+   - Every task has its own ticker, tasks run concurrently with each other so a slow task doesn't
+     delay the others - Workers bounds how many run at the same time
+   - A task due while it's still running is skipped by default, WithOverlap queues one more run or
+     runs it concurrently instead
+   - Tasks added after Start are scheduled right away, adding a name again replaces the task
+   - Pause doesn't interrupt a running task, Remove cancels its context
    - Thread-safe
-   - Supports graceful shutdown
+   - Supports graceful shutdown, Stop cancels the context passed to running tasks and Start returns
+     once they finished - pass the context on to queries and requests so long tasks abort
    - Tasks should be idempotent
-   - Error handling must be implemented in the task
-   - Start() is blocking and should typically run in a goroutine
+   - Returned errors and panics are logged, with the stack for panics, and passed to the OnError
+     callback - a panicking task keeps running on its schedule
+   - A run with its retries counts as one run for Stats, OnRun and the history, the worker is released
+     while waiting for a retry
+   - Errors returned after Stop or Remove cancelled the context aren't reported, and such a run doesn't
+     count as a success for the Store, Stats or the history
+   - One-shot jobs count as tasks for Stop, Workers, logging, OnError and OnRun, they don't need Start
+     and aren't listed - pending jobs are kept in memory and lost on restart
+   - Stats are kept in memory and reset when a task is added again
+   - Tasks running after others run on the instance that ran the upstream tasks with Lock, they don't
+     catch up and the interval passed to Add is ignored
+
+   Example bounded concurrency:
+       // At most 2 tasks run at the same time, the others wait for a free worker
+       runner := cron.NewRunner(cron.Workers(2))
+
+   Example error reporting:
+       runner := cron.NewRunner(
+           cron.Logging(logger),
+           cron.OnError(func(name string, err error) { failures.Inc(name) }),
+       )
 */
 
+// ErrNotFound is returned by Remove, Pause and Resume when no task has the name
+var ErrNotFound = errors.New("not found")
+
+// ErrTimeout is reported for runs that took longer than the timeout of their task, see WithTimeout
+var ErrTimeout = errors.New("cron: task timed out")
+
+// errCancelled is returned by call for runs that Stop or Remove cancelled, they neither succeeded nor failed
+var errCancelled = errors.New("cron: run cancelled")
+
 // CronFunc is a function type that can be run on a schedule
-type CronFunc func() error
+// ctx is cancelled by Stop, a long running task should return when it's done
+type CronFunc func(ctx context.Context) error
+
+// Task is a named CronFunc with how often it runs, e.g. to contribute tasks as services
+type Task struct {
+	Name    string
+	Run     CronFunc
+	Every   time.Duration
+	Overlap Overlap
+	// Jitter is the most every run is delayed by at random, see WithJitter
+	Jitter time.Duration
+	// CatchUp runs the task on startup if a run was missed, see WithCatchUp
+	CatchUp bool
+	// Timeout is how long a run may take before its context is cancelled, 0 for no limit, see WithTimeout
+	Timeout time.Duration
+	// MaxAttempts of a failed run and RetryBase, the delay after the first failed attempt, see WithRetry
+	MaxAttempts int
+	RetryBase   time.Duration
+	// After are the tasks that have to succeed before the task runs, see WithAfter
+	After []string
+}
+
+// Overlap is what happens when a task is due while it's still running
+type Overlap int
+
+const (
+	// OverlapSkip skips the run, the default
+	OverlapSkip Overlap = iota
+	// OverlapQueue runs the task again right after the running one finished, runs due meanwhile are
+	// combined into one
+	OverlapQueue
+	// OverlapConcurrent starts another run next to the running one
+	OverlapConcurrent
+)
+
+// String returns the name of the policy
+func (o Overlap) String() string {
+	switch o {
+	case OverlapSkip:
+		return "skip"
+	case OverlapQueue:
+		return "queue"
+	case OverlapConcurrent:
+		return "concurrent"
+	}
+	return fmt.Sprintf("Overlap(%d)", int(o))
+}
+
+// MarshalText encodes the policy as its name, e.g. in the JSON of List
+func (o Overlap) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// TaskOption configures a task added with Add
+type TaskOption func(*Task)
+
+// WithOverlap sets what happens when the task is due while it's still running, OverlapSkip by default
+func WithOverlap(policy Overlap) TaskOption {
+	return func(t *Task) {
+		t.Overlap = policy
+	}
+}
+
+// WithJitter delays every run of the task by a random duration below max, so instances of the
+// application or tasks with the same interval don't all run at the same moment
+// Keep it below the interval, ticks during the delay are dropped. It panics if max is negative
+func WithJitter(max time.Duration) TaskOption {
+	if max < 0 {
+		panic(fmt.Sprintf("cron: negative jitter %v", max))
+	}
+	return func(t *Task) {
+		t.Jitter = max
+	}
+}
+
+// WithTimeout cancels the context of a run after d and reports the run as failed with ErrTimeout, so
+// a hung request can't hold the task forever - the task has to pass its context on to return
+// It panics if d is negative, 0 means no limit
+func WithTimeout(d time.Duration) TaskOption {
+	if d < 0 {
+		panic(fmt.Sprintf("cron: negative timeout %v", d))
+	}
+	return func(t *Task) {
+		t.Timeout = d
+	}
+}
+
+// WithRetry retries a failed run up to maxAttempts in total with exponential backoff: base, 2x, 4x, ...
+// Only the last error is reported to OnError, keep the backoff below the interval - the overlap policy
+// applies to ticks due while retrying. It panics if maxAttempts is less than 1 or base is negative
+func WithRetry(maxAttempts int, base time.Duration) TaskOption {
+	if maxAttempts < 1 || base < 0 {
+		panic(fmt.Sprintf("cron: retry with %d attempts and base %v", maxAttempts, base))
+	}
+	return func(t *Task) {
+		t.MaxAttempts = maxAttempts
+		t.RetryBase = base
+	}
+}
+
+// WithAfter runs the task after the tasks named upstream succeeded instead of on its own interval,
+// e.g. to compress an export once it's written - with several upstream tasks it runs once all of them
+// succeeded since its last run. A failed upstream run skips the task until the next successful one
+func WithAfter(upstream ...string) TaskOption {
+	return func(t *Task) {
+		t.After = upstream
+	}
+}
+
+// WithCatchUp runs the task once when the runner starts if its last successful run recorded by the
+// Store of Persist is an interval or more ago, e.g. for daily reports missed while the process was down
+func WithCatchUp() TaskOption {
+	return func(t *Task) {
+		t.CatchUp = true
+	}
+}
+
+// ParseSchedules parses intervals of tasks by name written as name:interval, e.g. "search:30s" - for
+// schedules set in the config. An interval of 0 is valid, e.g. to disable a task
+func ParseSchedules(values []string) (map[string]time.Duration, error) {
+	schedules := make(map[string]time.Duration, len(values))
+	for _, value := range values {
+		name, interval, ok := strings.Cut(value, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("cron: schedule %q must be name:interval", value)
+		}
+		every, err := time.ParseDuration(interval)
+		if err != nil || every < 0 {
+			return nil, fmt.Errorf("cron: invalid interval %q of task %s", interval, name)
+		}
+		schedules[name] = every
+	}
+	return schedules, nil
+}
+
+// TaskInfo describes a task, returned by List
+type TaskInfo struct {
+	Name    string        `json:"name"`
+	Every   time.Duration `json:"every"`
+	Overlap Overlap       `json:"overlap"`
+	Jitter  time.Duration `json:"jitter"`
+	Timeout time.Duration `json:"timeout"`
+	// MaxAttempts is 0 or 1 for tasks that aren't retried
+	MaxAttempts int `json:"max_attempts"`
+	// After are the tasks the task runs after
+	After []string `json:"after,omitempty"`
+	// Paused is set between Pause and Resume
+	Paused bool `json:"paused"`
+	// Running is set while the task runs or waits for a worker
+	Running bool `json:"running"`
+	// LastRun is when the last run started, zero if the task hasn't run yet
+	LastRun time.Time `json:"last_run"`
+	// LastError is the error of the last run, empty if it succeeded
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Stats are the counters of a task since it was added, returned by Stats
+type Stats struct {
+	Name string `json:"name"`
+	// Runs is the number of finished runs, Failures of those that returned an error or panicked
+	Runs     int64 `json:"runs"`
+	Failures int64 `json:"failures"`
+	// LastRun is when the last run started and LastDuration how long it took, zero if the task hasn't run yet
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	// LastError is the error of the last run, empty if it succeeded
+	LastError string `json:"last_error,omitempty"`
+}
+
+// RunInfo describes a finished run, passed to OnRun and recorded by a History
+type RunInfo struct {
+	Name      string        `json:"name"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	// Error is empty if the run succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Option configures a Runner
+type Option func(*Runner)
+
+// Logging logs failed and panicking tasks to logger instead of slog.Default()
+func Logging(logger *slog.Logger) Option {
+	return func(r *Runner) {
+		r.logger = logger
+	}
+}
+
+// OnError calls fn with the name and error of every failed or panicking task, e.g. to count failures
+func OnError(fn func(name string, err error)) Option {
+	return func(r *Runner) {
+		r.onError = fn
+	}
+}
+
+// OnRun calls fn after every run of a task, e.g. to count runs and time them
+func OnRun(fn func(run RunInfo)) Option {
+	return func(r *Runner) {
+		r.onRun = fn
+	}
+}
+
+// Workers limits how many tasks run at the same time to n, tasks due while all workers are busy
+// wait for one - by default every task runs as soon as it's due
+// It panics if n is less than 1
+func Workers(n int) Option {
+	if n < 1 {
+		panic(fmt.Sprintf("cron: %d workers, need at least 1", n))
+	}
+	return func(r *Runner) {
+		r.workers = make(chan struct{}, n)
+	}
+}
 
 // Runner runs tasks on a schedule
 type Runner struct {
-	tasks    []CronFunc
-	stop     chan struct{}
-	mu       sync.RWMutex
-	stopOnce sync.Once
+	tasks   map[string]*entry
+	running bool
+	logger  *slog.Logger
+	onError func(string, error)
+	onRun   func(RunInfo)
+	// workers holds a value for every running task when the number of workers is bounded, nil otherwise
+	workers chan struct{}
+	// store records the last successful runs, nil unless Persist is set - see store.go
+	store Store
+	// locker grants the leases of tasks on a cluster, nil unless Lock is set
+	locker Locker
+	// history records every run, nil unless Record is set
+	history History
+	// jitter returns the delay of a run with the Jitter of its task
+	jitter func(max time.Duration) time.Duration
+	// ctx is passed to the tasks and cancelled by Stop
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+}
+
+// entry is a task with its state, guarded by the lock of the Runner
+type entry struct {
+	Task
+	paused bool
+	// oneShot is set for jobs run with RunAfter and RunAt, their runs aren't recorded in the Store
+	oneShot bool
+	// runs is the number of runs started and not finished, pending is set for a queued run
+	runs    int
+	pending bool
+	// finished and failures count the finished runs for Stats
+	finished     int64
+	failures     int64
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastErr      error
+	// succeeded holds the upstream tasks of WithAfter that succeeded since the last run
+	succeeded map[string]bool
+	// ctx is passed to the runs of a scheduled task, cancel stops its ticker and cancels its run
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewRunner creates a new Runner
-func NewRunner() *Runner {
-	return &Runner{
-		stop: make(chan struct{}),
+func NewRunner(opts ...Option) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Runner{
+		tasks:  make(map[string]*entry),
+		logger: slog.Default(),
+		jitter: randomJitter,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
-// Add adds a task to the Runner
-func (r *Runner) Add(task CronFunc) {
+// Add adds a task to the Runner that runs every interval, replacing a task with the same name
+// It panics if every isn't positive for a task without WithAfter, or if WithAfter creates a cycle
+func (r *Runner) Add(name string, task CronFunc, every time.Duration, opts ...TaskOption) {
+	e := &entry{Task: Task{Name: name, Run: task, Every: every}}
+	for _, opt := range opts {
+		opt(&e.Task)
+	}
+	if every <= 0 && len(e.After) == 0 {
+		panic(fmt.Sprintf("cron: non-positive interval %v for task %s", every, name))
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.tasks = append(r.tasks, task)
+	if r.cycle(name, e.After) {
+		panic(fmt.Sprintf("cron: task %s runs after itself", name))
+	}
+	if old, exists := r.tasks[name]; exists && old.cancel != nil {
+		old.cancel()
+	}
+	r.tasks[name] = e
+	if r.running {
+		r.schedule(e)
+	}
 }
 
-// Start starts the Runner and runs tasks on the specified interval
-// Usually called in a goroutine for example: go runner.Start(time.Minute)
-func (r *Runner) Start(runEvery time.Duration) {
-	ticker := time.NewTicker(runEvery)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			r.mu.RLock()
-			for _, task := range r.tasks {
-				task()
+// Remove removes a task, cancelling its context if it's running
+func (r *Runner) Remove(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, exists := r.tasks[name]
+	if !exists {
+		return fmt.Errorf("cron task %s %w", name, ErrNotFound)
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	delete(r.tasks, name)
+	return nil
+}
+
+// Pause skips the runs of a task until Resume, a running task isn't interrupted
+func (r *Runner) Pause(name string) error {
+	return r.setPaused(name, true)
+}
+
+// Resume runs a paused task again on its next tick
+func (r *Runner) Resume(name string) error {
+	return r.setPaused(name, false)
+}
+
+// setPaused pauses or resumes a task
+func (r *Runner) setPaused(name string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, exists := r.tasks[name]
+	if !exists {
+		return fmt.Errorf("cron task %s %w", name, ErrNotFound)
+	}
+	e.paused = paused
+	return nil
+}
+
+// List describes the tasks sorted by name
+func (r *Runner) List() []TaskInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]TaskInfo, 0, len(r.tasks))
+	for _, e := range r.tasks {
+		info := TaskInfo{Name: e.Name, Every: e.Every, Overlap: e.Overlap, Jitter: e.Jitter, Timeout: e.Timeout, MaxAttempts: e.MaxAttempts, After: e.After, Paused: e.paused, Running: e.runs > 0, LastRun: e.lastRun}
+		if e.lastErr != nil {
+			info.LastError = e.lastErr.Error()
+		}
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b TaskInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// Stats returns the counters of the tasks sorted by name
+func (r *Runner) Stats() []Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make([]Stats, 0, len(r.tasks))
+	for _, e := range r.tasks {
+		s := Stats{Name: e.Name, Runs: e.finished, Failures: e.failures, LastRun: e.lastRun, LastDuration: e.lastDuration}
+		if e.lastErr != nil {
+			s.LastError = e.lastErr.Error()
+		}
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b Stats) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stats
+}
+
+// Start starts the tickers of the tasks and blocks until Stop is called and the running tasks finished
+// Usually called in a goroutine for example: go runner.Start()
+func (r *Runner) Start() {
+	r.mu.Lock()
+	if !r.running {
+		r.running = true
+		for _, e := range r.tasks {
+			r.schedule(e)
+		}
+	}
+	r.mu.Unlock()
+
+	<-r.ctx.Done()
+	r.wg.Wait()
+}
+
+// cycle reports whether the task name would run after itself with the upstream tasks after, the caller
+// holds the lock
+func (r *Runner) cycle(name string, after []string) bool {
+	seen := map[string]bool{}
+	var reaches func(upstream []string) bool
+	reaches = func(upstream []string) bool {
+		for _, u := range upstream {
+			if u == name {
+				return true
 			}
-			r.mu.RUnlock()
-		case <-r.stop:
+			if seen[u] {
+				continue
+			}
+			seen[u] = true
+			if e, exists := r.tasks[u]; exists && reaches(e.After) {
+				return true
+			}
+		}
+		return false
+	}
+	return reaches(after)
+}
+
+// schedule runs a task on its own ticker until Stop or Remove, the caller holds the lock
+// A task running after others has no ticker, downstream runs it once they succeeded
+func (r *Runner) schedule(e *entry) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	e.ctx, e.cancel = ctx, cancel
+	if len(e.After) > 0 {
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(e.Every)
+		defer ticker.Stop()
+		if r.missed(ctx, e) && sleep(ctx, r.jitter(e.Jitter)) && r.lease(ctx, e) {
+			r.due(ctx, e)
+		}
+		for {
+			select {
+			case <-ticker.C:
+				if !sleep(ctx, r.jitter(e.Jitter)) {
+					return
+				}
+				if r.lease(ctx, e) {
+					r.due(ctx, e)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// randomJitter returns a random delay below max, 0 without jitter
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// sleep waits for d, it returns false if ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// due starts a run of a task unless it's paused or its overlap policy holds it back
+func (r *Runner) due(ctx context.Context, e *entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.paused {
+		return
+	}
+	if e.runs > 0 {
+		switch e.Overlap {
+		case OverlapSkip:
+			r.logger.Debug("Cron task still running, skipped", "task", e.Name)
 			return
+		case OverlapQueue:
+			e.pending = true
+			return
+		}
+	}
+	e.runs++
+	// The ticker goroutine holds a count of the wait group, adding to it is safe
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx, e)
+	}()
+}
+
+// run runs a task, again while a run is queued, recording and reporting its errors
+func (r *Runner) run(ctx context.Context, e *entry) {
+	defer func() {
+		r.mu.Lock()
+		e.runs--
+		e.pending = false
+		r.mu.Unlock()
+	}()
+
+	for {
+		if !r.runOnce(ctx, e) {
+			return
+		}
+		r.mu.Lock()
+		queued := e.pending
+		e.pending = false
+		r.mu.Unlock()
+		if !queued {
+			return
+		}
+	}
+}
+
+// runOnce runs a task with its retries, it returns false if the task was removed or stopped before or while it ran
+func (r *Runner) runOnce(ctx context.Context, e *entry) bool {
+	start := time.Now()
+	started, err := r.attempt(ctx, e)
+	if !started {
+		return false
+	}
+	attempts := 1
+	for ; err != nil && !errors.Is(err, errCancelled) && attempts < e.MaxAttempts; attempts++ {
+		delay := e.RetryBase << (attempts - 1)
+		r.logger.Warn("Cron task failed, retrying", "task", e.Name, "attempt", attempts, "delay", delay, "error", err)
+		if !sleep(ctx, delay) {
+			break
+		}
+		if started, next := r.attempt(ctx, e); started {
+			err = next
+		} else {
+			break
+		}
+	}
+	if errors.Is(err, errCancelled) {
+		r.logger.Debug("Cron task cancelled", "task", e.Name)
+		return false
+	}
+	if err != nil {
+		if attempts > 1 {
+			err = fmt.Errorf("cron: task %s failed after %d attempts: %w", e.Name, attempts, err)
+		}
+		r.logger.Error("Cron task failed", "task", e.Name, "error", err)
+	}
+	duration := time.Since(start)
+
+	r.mu.Lock()
+	e.finished++
+	if err != nil {
+		e.failures++
+	}
+	e.lastRun = start
+	e.lastDuration = duration
+	e.lastErr = err
+	r.mu.Unlock()
+	if err != nil && r.onError != nil {
+		r.onError(e.Name, err)
+	}
+	if r.onRun != nil || (r.history != nil && !e.oneShot) {
+		run := RunInfo{Name: e.Name, StartedAt: start, Duration: duration}
+		if err != nil {
+			run.Error = err.Error()
+		}
+		if r.onRun != nil {
+			r.onRun(run)
+		}
+		if r.history != nil && !e.oneShot {
+			if err := r.history.RecordRun(context.WithoutCancel(ctx), run); err != nil {
+				r.logger.Error("Failed to record cron run history", "task", e.Name, "error", err)
+			}
+		}
+	}
+	if err == nil && r.store != nil && !e.oneShot {
+		// Recorded also when the runner is stopping right after the run
+		if err := r.store.SetLastSuccess(context.WithoutCancel(ctx), e.Name, start); err != nil {
+			r.logger.Error("Failed to record cron run", "task", e.Name, "error", err)
+		}
+	}
+	if err == nil && !e.oneShot && ctx.Err() == nil {
+		r.downstream(e.Name)
+	}
+	return true
+}
+
+// downstream runs the tasks after name whose upstream tasks all succeeded since their last run
+func (r *Runner) downstream(name string) {
+	r.mu.Lock()
+	var ready []*entry
+	for _, e := range r.tasks {
+		if !slices.Contains(e.After, name) || e.ctx == nil || e.ctx.Err() != nil {
+			continue
+		}
+		if e.succeeded == nil {
+			e.succeeded = map[string]bool{}
+		}
+		e.succeeded[name] = true
+		if len(e.succeeded) == len(e.After) {
+			clear(e.succeeded)
+			ready = append(ready, e)
+		}
+	}
+	r.mu.Unlock()
+	for _, e := range ready {
+		r.due(e.ctx, e)
+	}
+}
+
+// attempt runs a task once it gets a worker, it returns false if the task was removed or stopped first
+func (r *Runner) attempt(ctx context.Context, e *entry) (bool, error) {
+	// Wait for a free worker
+	if r.workers != nil {
+		select {
+		case r.workers <- struct{}{}:
+			defer func() { <-r.workers }()
+		case <-ctx.Done():
+			return false, nil
 		}
 	}
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	return true, r.call(ctx, e.Task)
 }
 
-// Stop stops the Runner
+// lease takes the lease of a task for this tick from the Locker, it returns true without a Locker
+func (r *Runner) lease(ctx context.Context, e *entry) bool {
+	if r.locker == nil {
+		return true
+	}
+	acquired, err := r.locker.Acquire(ctx, e.Name, time.Now().Add(e.Every-e.Every/10))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to acquire cron lease, skipping the run", "task", e.Name, "error", err)
+		}
+		return false
+	}
+	if !acquired {
+		r.logger.Debug("Cron task running on another instance, skipped", "task", e.Name)
+	}
+	return acquired
+}
+
+// missed reports whether a task catching up missed a run, according to the Store
+func (r *Runner) missed(ctx context.Context, e *entry) bool {
+	if !e.CatchUp || r.store == nil {
+		return false
+	}
+	last, err := r.store.LastSuccess(ctx, e.Name)
+	if err != nil {
+		r.logger.Error("Failed to look up the last cron run", "task", e.Name, "error", err)
+		return false
+	}
+	return !last.IsZero() && time.Since(last) >= e.Every
+}
+
+// call runs a task with its timeout, a panic is logged and returned as an error
+// A task returning because Stop or Remove cancelled its context returns errCancelled, whatever it returned
+func (r *Runner) call(ctx context.Context, t Task) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("cron: task %s panicked: %v", t.Name, rec)
+			r.logger.Error("Cron task panicked", "task", t.Name, "error", err, "stack", string(debug.Stack()))
+		}
+	}()
+	runCtx := ctx
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeoutCause(ctx, t.Timeout, ErrTimeout)
+		defer cancel()
+	}
+	err = t.Run(runCtx)
+	if ctx.Err() != nil && (err == nil || errors.Is(err, ctx.Err())) {
+		return errCancelled
+	}
+	// Also a run that ignored its context and finished late timed out
+	if errors.Is(context.Cause(runCtx), ErrTimeout) {
+		err = fmt.Errorf("cron: task %s timed out after %v: %w", t.Name, t.Timeout, ErrTimeout)
+	}
+	return err
+}
+
+// oneShotName is the task name of jobs run with RunAfter and RunAt in logs and OnError
+const oneShotName = "one-shot"
+
+// RunAfter runs task once after d, it returns a function that drops the job or cancels the context of
+// the running job
+func (r *Runner) RunAfter(d time.Duration, task CronFunc) (cancel func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, cancel := context.WithCancel(r.ctx)
+	// Stopped, Start may be waiting for the wait group already
+	if ctx.Err() != nil {
+		return cancel
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		if sleep(ctx, d) {
+			r.runOnce(ctx, &entry{Task: Task{Name: oneShotName, Run: task}, oneShot: true})
+		}
+	}()
+	return cancel
+}
+
+// RunAt runs task once at t, right away if t is in the past, see RunAfter
+func (r *Runner) RunAt(t time.Time, task CronFunc) (cancel func()) {
+	return r.RunAfter(time.Until(t), task)
+}
+
+// Stop stops the Runner and cancels the context of the running tasks
 func (r *Runner) Stop() {
-	r.stopOnce.Do(func() {
-		close(r.stop)
-	})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel()
 }
@@ -1,6 +1,14 @@
 package cron
 
 import (
+	"fmt"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -12,15 +20,15 @@ func TestRunner_Add(t *testing.T) {
 	
 	t.Run("add single task", func(t *testing.T) {
 		var executed bool
-		task := func() error {
+		task := func(context.Context) error {
 			executed = true
 			return nil
 		}
 		
-		runner.Add(task)
+		runner.Add("task", task, time.Minute)
 		
 		// Run once manually to verify task was added
-		runner.tasks[0]()
+		runner.tasks["task"].Run(context.Background())
 		
 		if !executed {
 			t.Error("task was not executed")
@@ -33,15 +41,15 @@ func TestRunner_Add(t *testing.T) {
 		
 		// Add 3 tasks
 		for i := 0; i < 3; i++ {
-			runner.Add(func() error {
+			runner.Add(fmt.Sprint(i), func(context.Context) error {
 				atomic.AddInt32(&count, 1)
 				return nil
-			})
+			}, time.Minute)
 		}
 		
 		// Run all tasks manually
 		for _, task := range runner.tasks {
-			task()
+			task.Run(context.Background())
 		}
 		
 		if atomic.LoadInt32(&count) != 3 {
@@ -55,13 +63,13 @@ func TestRunner_Start(t *testing.T) {
 		runner := NewRunner()
 		var count int32
 		
-		runner.Add(func() error {
+		runner.Add("count", func(context.Context) error {
 			atomic.AddInt32(&count, 1)
 			return nil
-		})
+		}, 100*time.Millisecond)
 		
 		// Start runner with 100ms interval
-		go runner.Start(100 * time.Millisecond)
+		go runner.Start()
 		
 		// Wait for ~3 executions
 		time.Sleep(350 * time.Millisecond)
@@ -73,38 +81,102 @@ func TestRunner_Start(t *testing.T) {
 		}
 	})
 	
-	t.Run("multiple tasks execute in order", func(t *testing.T) {
+	t.Run("tasks run on their own interval", func(t *testing.T) {
 		runner := NewRunner()
-		var sequence []int
-		var mu sync.Mutex
+		var fast, slow int32
 		
-		// Add tasks that record their execution order
-		for i := 0; i < 3; i++ {
-			taskNum := i
-			runner.Add(func() error {
-				mu.Lock()
-				sequence = append(sequence, taskNum)
-				mu.Unlock()
-				return nil
-			})
+		runner.Add("fast", func(context.Context) error {
+			atomic.AddInt32(&fast, 1)
+			return nil
+		}, 50*time.Millisecond)
+		runner.Add("slow", func(context.Context) error {
+			atomic.AddInt32(&slow, 1)
+			return nil
+		}, 200*time.Millisecond)
+		
+		go runner.Start()
+		time.Sleep(330 * time.Millisecond)
+		runner.Stop()
+		
+		if n := atomic.LoadInt32(&fast); n < 4 || n > 7 {
+			t.Errorf("expected ~6 executions of the fast task, got %d", n)
+		}
+		if n := atomic.LoadInt32(&slow); n != 1 {
+			t.Errorf("expected 1 execution of the slow task, got %d", n)
 		}
+	})
+	
+	t.Run("tasks added after start are scheduled", func(t *testing.T) {
+		runner := NewRunner()
+		var count int32
+		
+		go runner.Start()
+		time.Sleep(20 * time.Millisecond)
+		runner.Add("late", func(context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		}, 50*time.Millisecond)
+		time.Sleep(80 * time.Millisecond)
+		runner.Stop()
+		
+		if atomic.LoadInt32(&count) != 1 {
+			t.Errorf("expected 1 execution, got %d", count)
+		}
+	})
+	
+	t.Run("start returns after stop", func(t *testing.T) {
+		runner := NewRunner()
+		runner.Add("slow", func(context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}, 10*time.Millisecond)
 		
-		go runner.Start(100 * time.Millisecond)
-		time.Sleep(150 * time.Millisecond) // Wait for one execution
+		done := make(chan struct{})
+		go func() {
+			runner.Start()
+			close(done)
+		}()
+		time.Sleep(30 * time.Millisecond)
 		runner.Stop()
 		
-		if len(sequence) != 3 {
-			t.Errorf("expected 3 task executions, got %d", len(sequence))
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Start did not return after Stop")
 		}
+	})
+	
+	t.Run("stop cancels running tasks", func(t *testing.T) {
+		runner := NewRunner()
+		cancelled := make(chan error, 1)
+		runner.Add("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return ctx.Err()
+		}, 10*time.Millisecond)
 		
-		// Verify execution order
-		for i := 0; i < len(sequence); i++ {
-			if sequence[i] != i {
-				t.Errorf("tasks executed out of order, got %v", sequence)
-				break
+		go runner.Start()
+		time.Sleep(30 * time.Millisecond)
+		runner.Stop()
+		
+		select {
+		case err := <-cancelled:
+			if err != context.Canceled {
+				t.Errorf("expected context.Canceled, got %v", err)
 			}
+		case <-time.After(time.Second):
+			t.Fatal("the task's context was not cancelled by Stop")
 		}
 	})
+	
+	t.Run("non-positive interval panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		NewRunner().Add("zero", func(context.Context) error { return nil }, 0)
+	})
 }
 
 func TestRunner_Stop(t *testing.T) {
@@ -112,12 +184,12 @@ func TestRunner_Stop(t *testing.T) {
 		runner := NewRunner()
 		var count int32
 		
-		runner.Add(func() error {
+		runner.Add("count", func(context.Context) error {
 			atomic.AddInt32(&count, 1)
 			return nil
-		})
+		}, 100*time.Millisecond)
 		
-		go runner.Start(100 * time.Millisecond)
+		go runner.Start()
 		time.Sleep(250 * time.Millisecond) // Allow some executions
 		runner.Stop()
 		
@@ -133,7 +205,7 @@ func TestRunner_Stop(t *testing.T) {
 	t.Run("multiple stops are safe", func(t *testing.T) {
 		runner := NewRunner()
 		
-		go runner.Start(100 * time.Millisecond)
+		go runner.Start()
 		time.Sleep(50 * time.Millisecond)
 		
 		// Multiple stops should not panic
@@ -152,7 +224,7 @@ func TestRunner_Concurrent(t *testing.T) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				runner.Add(func() error { return nil })
+				runner.Add(fmt.Sprint(i), func(context.Context) error { return nil }, 50*time.Millisecond)
 			}()
 		}
 		
@@ -165,7 +237,7 @@ func TestRunner_Concurrent(t *testing.T) {
 	
 	t.Run("concurrent start/stop", func(t *testing.T) {
 		runner := NewRunner()
-		runner.Add(func() error { return nil })
+		runner.Add("noop", func(context.Context) error { return nil }, 50*time.Millisecond)
 		
 		var wg sync.WaitGroup
 		// Start and stop concurrently multiple times
@@ -173,7 +245,7 @@ func TestRunner_Concurrent(t *testing.T) {
 			wg.Add(2)
 			go func() {
 				defer wg.Done()
-				go runner.Start(50 * time.Millisecond)
+				go runner.Start()
 			}()
 			go func() {
 				defer wg.Done()
@@ -186,14 +258,627 @@ func TestRunner_Concurrent(t *testing.T) {
 	})
 }
 
+func TestRunner_Errors(t *testing.T) {
+	t.Run("errors and panics are reported", func(t *testing.T) {
+		var logs bytes.Buffer
+		var mu sync.Mutex
+		var errs []error
+		runner := NewRunner(
+			Logging(slog.New(slog.NewTextHandler(&logs, nil))),
+			OnError(func(name string, err error) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}),
+		)
+		var panics int32
+		runner.Add("panic", func(context.Context) error {
+			atomic.AddInt32(&panics, 1)
+			panic("boom")
+		}, 50*time.Millisecond)
+		runner.Add("fail", func(context.Context) error {
+			return errors.New("failed")
+		}, 100*time.Millisecond)
+		
+		go runner.Start()
+		time.Sleep(130 * time.Millisecond)
+		runner.Stop()
+		
+		// The panicking task keeps running on its schedule
+		if n := atomic.LoadInt32(&panics); n < 2 {
+			t.Errorf("expected the panicking task to run again, ran %d times", n)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		var panicked, failed bool
+		for _, err := range errs {
+			panicked = panicked || strings.Contains(err.Error(), "task panic panicked: boom")
+			failed = failed || err.Error() == "failed"
+		}
+		if !panicked || !failed {
+			t.Errorf("expected the panic and the error to be reported, got %v", errs)
+		}
+		if !strings.Contains(logs.String(), "Cron task panicked") || !strings.Contains(logs.String(), "stack=") {
+			t.Errorf("expected the panic to be logged with its stack, got %s", logs.String())
+		}
+	})
+	
+	t.Run("cancellation on stop isn't reported", func(t *testing.T) {
+		var reported int32
+		runner := NewRunner(
+			Logging(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))),
+			OnError(func(string, error) { atomic.AddInt32(&reported, 1) }),
+		)
+		runner.Add("wait", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, 10*time.Millisecond)
+		
+		done := make(chan struct{})
+		go func() {
+			runner.Start()
+			close(done)
+		}()
+		time.Sleep(30 * time.Millisecond)
+		runner.Stop()
+		<-done
+		
+		if n := atomic.LoadInt32(&reported); n != 0 {
+			t.Errorf("expected no reported errors, got %d", n)
+		}
+	})
+}
+
+func TestRunner_Manage(t *testing.T) {
+	t.Run("pause, resume and remove", func(t *testing.T) {
+		runner := NewRunner()
+		var count int32
+		runner.Add("count", func(context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		}, 20*time.Millisecond)
+		go runner.Start()
+		defer runner.Stop()
+		
+		if err := runner.Pause("count"); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		time.Sleep(70 * time.Millisecond)
+		if n := atomic.LoadInt32(&count); n != 0 {
+			t.Errorf("expected no executions while paused, got %d", n)
+		}
+		
+		if err := runner.Resume("count"); err != nil {
+			t.Fatalf("Resume() error = %v", err)
+		}
+		time.Sleep(70 * time.Millisecond)
+		if n := atomic.LoadInt32(&count); n < 2 {
+			t.Errorf("expected executions after resume, got %d", n)
+		}
+		
+		if err := runner.Remove("count"); err != nil {
+			t.Fatalf("Remove() error = %v", err)
+		}
+		time.Sleep(10 * time.Millisecond) // Let a run started before Remove finish
+		removed := atomic.LoadInt32(&count)
+		time.Sleep(70 * time.Millisecond)
+		if atomic.LoadInt32(&count) != removed {
+			t.Error("removed task continued to execute")
+		}
+		
+		for _, err := range []error{runner.Remove("count"), runner.Pause("count"), runner.Resume("count")} {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound for a removed task, got %v", err)
+			}
+		}
+	})
+	
+	t.Run("adding a name again replaces the task", func(t *testing.T) {
+		runner := NewRunner()
+		var first, second int32
+		runner.Add("task", func(context.Context) error {
+			atomic.AddInt32(&first, 1)
+			return nil
+		}, 20*time.Millisecond)
+		go runner.Start()
+		defer runner.Stop()
+		time.Sleep(30 * time.Millisecond)
+		
+		runner.Add("task", func(context.Context) error {
+			atomic.AddInt32(&second, 1)
+			return nil
+		}, 20*time.Millisecond)
+		replaced := atomic.LoadInt32(&first)
+		time.Sleep(50 * time.Millisecond)
+		
+		if atomic.LoadInt32(&first) != replaced || atomic.LoadInt32(&second) == 0 {
+			t.Errorf("expected only the new task to run, got %d and %d runs", first, second)
+		}
+	})
+	
+	t.Run("list", func(t *testing.T) {
+		runner := NewRunner(Logging(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))))
+		runner.Add("b_fail", func(context.Context) error {
+			return errors.New("failed")
+		}, 20*time.Millisecond)
+		runner.Add("a_idle", func(context.Context) error { return nil }, time.Hour)
+		runner.Pause("a_idle")
+		go runner.Start()
+		time.Sleep(30 * time.Millisecond)
+		runner.Stop()
+		
+		list := runner.List()
+		if len(list) != 2 || list[0].Name != "a_idle" || list[1].Name != "b_fail" {
+			t.Fatalf("expected the tasks sorted by name, got %+v", list)
+		}
+		if !list[0].Paused || list[0].Every != time.Hour || !list[0].LastRun.IsZero() {
+			t.Errorf("unexpected state of the idle task: %+v", list[0])
+		}
+		if list[1].LastRun.IsZero() || list[1].LastError != "failed" {
+			t.Errorf("unexpected state of the failing task: %+v", list[1])
+		}
+	})
+}
+
+func TestRunner_Workers(t *testing.T) {
+	for _, workers := range []int32{1, 2} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			runner := NewRunner(Workers(int(workers)))
+			var running, most int32
+			for i := 0; i < 3; i++ {
+				runner.Add(fmt.Sprint(i), func(context.Context) error {
+					n := atomic.AddInt32(&running, 1)
+					for {
+						m := atomic.LoadInt32(&most)
+						if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+							break
+						}
+					}
+					time.Sleep(30 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+					return nil
+				}, 10*time.Millisecond)
+			}
+			
+			go runner.Start()
+			time.Sleep(150 * time.Millisecond)
+			runner.Stop()
+			
+			if m := atomic.LoadInt32(&most); m != workers {
+				t.Errorf("expected at most %d tasks running at once, got %d", workers, m)
+			}
+		})
+	}
+}
+
+func TestRunner_Overlap(t *testing.T) {
+	// The task takes 50ms and is due every 40ms, the second tick comes while the first run is running
+	tests := []struct {
+		policy Overlap
+		// wantGap is whether the second run waits for the next tick after the first finished
+		wantGap bool
+		// wantParallel is whether runs overlap
+		wantParallel bool
+	}{
+		{policy: OverlapSkip, wantGap: true},
+		{policy: OverlapQueue},
+		{policy: OverlapConcurrent, wantParallel: true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			runner := NewRunner(Logging(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))))
+			var mu sync.Mutex
+			var starts, ends []time.Time
+			var running, most int32
+			runner.Add("slow", func(context.Context) error {
+				mu.Lock()
+				starts = append(starts, time.Now())
+				mu.Unlock()
+				if n := atomic.AddInt32(&running, 1); n > atomic.LoadInt32(&most) {
+					atomic.StoreInt32(&most, n)
+				}
+				time.Sleep(50 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				mu.Lock()
+				ends = append(ends, time.Now())
+				mu.Unlock()
+				return nil
+			}, 40*time.Millisecond, WithOverlap(tt.policy))
+			
+			go runner.Start()
+			time.Sleep(150 * time.Millisecond)
+			runner.Stop()
+			
+			mu.Lock()
+			defer mu.Unlock()
+			if len(starts) < 2 || len(ends) < 1 {
+				t.Fatalf("expected at least 2 runs, got %d", len(starts))
+			}
+			if parallel := atomic.LoadInt32(&most) > 1; parallel != tt.wantParallel {
+				t.Errorf("runs overlapped = %v, want %v", parallel, tt.wantParallel)
+			}
+			if !tt.wantParallel {
+				gap := starts[1].Sub(ends[0])
+				if hasGap := gap > 15*time.Millisecond; hasGap != tt.wantGap {
+					t.Errorf("second run started %v after the first finished, want a gap = %v", gap, tt.wantGap)
+				}
+			}
+			if info := runner.List()[0]; info.Overlap != tt.policy {
+				t.Errorf("List() overlap = %v, want %v", info.Overlap, tt.policy)
+			}
+		})
+	}
+}
+
+func TestRunner_Timeout(t *testing.T) {
+	var mu sync.Mutex
+	errs := map[string]error{}
+	runner := NewRunner(
+		Logging(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		OnError(func(name string, err error) {
+			mu.Lock()
+			errs[name] = err
+			mu.Unlock()
+		}),
+	)
+	// Returns once its context is cancelled, like a request
+	runner.Add("hung", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 50*time.Millisecond, WithTimeout(20*time.Millisecond))
+	// Ignores its context and finishes late
+	runner.Add("late", func(ctx context.Context) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}, 50*time.Millisecond, WithTimeout(10*time.Millisecond))
+	runner.Add("fast", func(ctx context.Context) error {
+		return nil
+	}, 50*time.Millisecond, WithTimeout(time.Second))
+
+	go runner.Start()
+	time.Sleep(90 * time.Millisecond)
+	runner.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, name := range []string{"hung", "late"} {
+		if !errors.Is(errs[name], ErrTimeout) {
+			t.Errorf("expected %s to time out, got %v", name, errs[name])
+		}
+	}
+	if errs["fast"] != nil {
+		t.Errorf("expected fast to succeed, got %v", errs["fast"])
+	}
+	for _, s := range runner.Stats() {
+		if s.Name == "hung" && (s.Runs != 1 || s.LastDuration >= 50*time.Millisecond) {
+			t.Errorf("expected the hung run to be cancelled after the timeout, got %+v", s)
+		}
+	}
+}
+
+func TestRunner_Retry(t *testing.T) {
+	var logs bytes.Buffer
+	var mu sync.Mutex
+	var errs []error
+	runner := NewRunner(
+		Logging(slog.New(slog.NewTextHandler(&logs, nil))),
+		OnError(func(name string, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}),
+	)
+	var flaky, broken int32
+	// Succeeds on the third attempt
+	runner.Add("flaky", func(context.Context) error {
+		if atomic.AddInt32(&flaky, 1) < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	}, 100*time.Millisecond, WithRetry(3, 5*time.Millisecond))
+	runner.Add("broken", func(context.Context) error {
+		atomic.AddInt32(&broken, 1)
+		return errors.New("broken")
+	}, 100*time.Millisecond, WithRetry(3, 5*time.Millisecond))
+
+	go runner.Start()
+	time.Sleep(150 * time.Millisecond)
+	runner.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	if n := atomic.LoadInt32(&flaky); n != 3 {
+		t.Errorf("expected the flaky task to succeed on the third attempt, ran %d times", n)
+	}
+	if n := atomic.LoadInt32(&broken); n != 3 {
+		t.Errorf("expected the broken task to be tried 3 times, ran %d times", n)
+	}
+
+	// Only the final failure is reported
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || errs[0].Error() != "cron: task broken failed after 3 attempts: broken" {
+		t.Errorf("expected the final failure of the broken task, got %v", errs)
+	}
+	if strings.Count(logs.String(), "Cron task failed, retrying") != 4 {
+		t.Errorf("expected every retry to be logged, got %s", logs.String())
+	}
+	for _, s := range runner.Stats() {
+		if s.Runs != 1 {
+			t.Errorf("expected a run with its retries to count once, got %+v", s)
+		}
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := ParseSchedules([]string{"search:30s", "audit_prune:0", "report:1h30m"})
+	if err != nil {
+		t.Fatalf("ParseSchedules returned error: %v", err)
+	}
+	want := map[string]time.Duration{"search": 30 * time.Second, "audit_prune": 0, "report": 90 * time.Minute}
+	if len(schedules) != len(want) {
+		t.Errorf("expected %v, got %v", want, schedules)
+	}
+	for name, every := range want {
+		if schedules[name] != every {
+			t.Errorf("expected %s every %v, got %v", name, every, schedules[name])
+		}
+	}
+
+	for _, value := range []string{"search", ":30s", "search:soon", "search:-1m"} {
+		if _, err := ParseSchedules([]string{value}); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestRunner_After(t *testing.T) {
+	t.Run("tasks run after their upstream tasks succeeded", func(t *testing.T) {
+		var mu sync.Mutex
+		var order []string
+		record := func(name string, err error) CronFunc {
+			return func(context.Context) error {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return err
+			}
+		}
+		runner := NewRunner(Logging(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		runner.Add("export", record("export", nil), 100*time.Millisecond)
+		runner.Add("report", record("report", nil), 200*time.Millisecond)
+		runner.Add("compress", record("compress", nil), 0, WithAfter("export"))
+		runner.Add("upload", record("upload", nil), 0, WithAfter("compress", "report"))
+		runner.Add("broken", record("broken", errors.New("failed")), 100*time.Millisecond)
+		runner.Add("skipped", record("skipped", nil), 0, WithAfter("broken"))
+
+		go runner.Start()
+		time.Sleep(250 * time.Millisecond)
+		runner.Stop()
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		count := map[string]int{}
+		for _, name := range order {
+			count[name]++
+		}
+		if count["export"] != 2 || count["compress"] != 2 {
+			t.Errorf("expected compress to run after every export, got %v", order)
+		}
+		// Upload waits for report, which runs once
+		if count["upload"] != 1 || slices.Index(order, "upload") < slices.Index(order, "report") {
+			t.Errorf("expected upload to run once after compress and report, got %v", order)
+		}
+		if count["skipped"] != 0 {
+			t.Errorf("expected no runs after a failed upstream task, got %v", order)
+		}
+	})
+
+	t.Run("cycles panic", func(t *testing.T) {
+		runner := NewRunner()
+		runner.Add("a", func(context.Context) error { return nil }, 0, WithAfter("c"))
+		runner.Add("b", func(context.Context) error { return nil }, 0, WithAfter("a"))
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic adding a cycle")
+			}
+		}()
+		runner.Add("c", func(context.Context) error { return nil }, 0, WithAfter("b"))
+	})
+}
+
+func TestRunner_Jitter(t *testing.T) {
+	runner := NewRunner()
+	// The largest possible delay
+	runner.jitter = func(max time.Duration) time.Duration { return max }
+	started := time.Now()
+	first := make(chan time.Duration, 1)
+	runner.Add("jittered", func(context.Context) error {
+		select {
+		case first <- time.Since(started):
+		default:
+		}
+		return nil
+	}, 20*time.Millisecond, WithJitter(40*time.Millisecond))
+	go runner.Start()
+	defer runner.Stop()
+	
+	select {
+	case d := <-first:
+		if d < 60*time.Millisecond {
+			t.Errorf("expected the first run after the interval and jitter, ran after %v", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("task did not run")
+	}
+	
+	if info := runner.List()[0]; info.Jitter != 40*time.Millisecond {
+		t.Errorf("List() jitter = %v, want 40ms", info.Jitter)
+	}
+}
+
+func TestRunner_OneShot(t *testing.T) {
+	t.Run("run after and at", func(t *testing.T) {
+		runner := NewRunner()
+		defer runner.Stop()
+		ran := make(chan time.Duration, 2)
+		started := time.Now()
+		
+		runner.RunAfter(30*time.Millisecond, func(context.Context) error {
+			ran <- time.Since(started)
+			return nil
+		})
+		runner.RunAt(started.Add(-time.Second), func(context.Context) error {
+			ran <- time.Since(started)
+			return nil
+		})
+		
+		// The job in the past runs first, right away
+		if d := <-ran; d > 20*time.Millisecond {
+			t.Errorf("expected the past job to run right away, ran after %v", d)
+		}
+		if d := <-ran; d < 30*time.Millisecond {
+			t.Errorf("expected the delayed job after 30ms, ran after %v", d)
+		}
+		if len(runner.List()) != 0 {
+			t.Error("one-shot jobs should not be listed")
+		}
+	})
+	
+	t.Run("cancel drops the job", func(t *testing.T) {
+		runner := NewRunner()
+		defer runner.Stop()
+		var count int32
+		cancel := runner.RunAfter(20*time.Millisecond, func(context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		})
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		if atomic.LoadInt32(&count) != 0 {
+			t.Error("cancelled job ran")
+		}
+	})
+	
+	t.Run("stop cancels pending and running jobs", func(t *testing.T) {
+		var reported int32
+		runner := NewRunner(OnError(func(string, error) { atomic.AddInt32(&reported, 1) }))
+		var pending int32
+		runner.RunAfter(time.Hour, func(context.Context) error {
+			atomic.AddInt32(&pending, 1)
+			return nil
+		})
+		running := make(chan struct{})
+		runner.RunAfter(0, func(ctx context.Context) error {
+			close(running)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		<-running
+		
+		done := make(chan struct{})
+		go func() {
+			runner.Start()
+			close(done)
+		}()
+		runner.Stop()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Start did not return after Stop")
+		}
+		if atomic.LoadInt32(&pending) != 0 || atomic.LoadInt32(&reported) != 0 {
+			t.Error("expected the pending job dropped and the cancelled job not reported")
+		}
+		
+		// Jobs after Stop never run
+		runner.RunAfter(0, func(context.Context) error {
+			atomic.AddInt32(&pending, 1)
+			return nil
+		})
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&pending) != 0 {
+			t.Error("job added after Stop ran")
+		}
+	})
+	
+	t.Run("errors are reported", func(t *testing.T) {
+		failed := make(chan string, 1)
+		runner := NewRunner(
+			Logging(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))),
+			OnError(func(name string, err error) { failed <- name }),
+		)
+		defer runner.Stop()
+		runner.RunAfter(0, func(context.Context) error { return errors.New("failed") })
+		select {
+		case name := <-failed:
+			if name != "one-shot" {
+				t.Errorf("OnError name = %q, want one-shot", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("error was not reported")
+		}
+	})
+}
+
+func TestRunner_Stats(t *testing.T) {
+	var mu sync.Mutex
+	var runs []RunInfo
+	runner := NewRunner(
+		Logging(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		OnRun(func(run RunInfo) {
+			mu.Lock()
+			runs = append(runs, run)
+			mu.Unlock()
+		}),
+	)
+	var calls int32
+	runner.Add("flaky", func(context.Context) error {
+		// Every other run fails
+		if atomic.AddInt32(&calls, 1)%2 == 0 {
+			return errors.New("failed")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, 50*time.Millisecond)
+	runner.Add("idle", func(context.Context) error { return nil }, time.Hour)
+
+	go runner.Start()
+	time.Sleep(180 * time.Millisecond)
+	runner.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	stats := runner.Stats()
+	if len(stats) != 2 || stats[0].Name != "flaky" || stats[1].Name != "idle" {
+		t.Fatalf("expected the stats of both tasks sorted by name, got %+v", stats)
+	}
+	flaky := stats[0]
+	if flaky.Runs != 3 || flaky.Failures != 1 {
+		t.Errorf("expected 3 runs and 1 failure, got %d runs and %d failures", flaky.Runs, flaky.Failures)
+	}
+	if flaky.LastDuration < 10*time.Millisecond || flaky.LastError != "" || flaky.LastRun.IsZero() {
+		t.Errorf("expected the last successful run, got %+v", flaky)
+	}
+	if stats[1].Runs != 0 || !stats[1].LastRun.IsZero() {
+		t.Errorf("expected no runs of the idle task, got %+v", stats[1])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 3 || runs[1].Error != "failed" || runs[0].Name != "flaky" || runs[0].Duration < 10*time.Millisecond {
+		t.Errorf("expected OnRun to be called for every run, got %+v", runs)
+	}
+}
+
 func BenchmarkRunner(b *testing.B) {
 	b.Run("task addition", func(b *testing.B) {
 		runner := NewRunner()
-		task := func() error { return nil }
+		task := func(context.Context) error { return nil }
 		
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			runner.Add(task)
+			runner.Add("task", task, time.Minute)
 		}
 	})
 	
@@ -201,14 +886,14 @@ func BenchmarkRunner(b *testing.B) {
 		runner := NewRunner()
 		var count int32
 		
-		runner.Add(func() error {
+		runner.Add("count", func(context.Context) error {
 			atomic.AddInt32(&count, 1)
 			return nil
-		})
+		}, time.Minute)
 		
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			runner.tasks[0]()
+			runner.tasks["count"].Run(context.Background())
 		}
 	})
 }
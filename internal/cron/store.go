@@ -0,0 +1,180 @@
+package cron
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/params"
+	"os"
+	"time"
+)
+
+/*
+	Persistence: with a Store the runner records the last successful run of every named task, so
+	tasks added with WithCatchUp run once on startup if a run was due while the process was down.
+
+	Example:
+		runner := cron.NewRunner(cron.Persist(cron.NewSQLStore(db)))
+		runner.Add("daily_report", SendDailyReport(db, mailer), 24*time.Hour, cron.WithCatchUp())
+
+	Notes:
+	- A task catches up at most once, however many runs it missed
+	- A task without a recorded run doesn't catch up, it waits for its first tick
+	- Failed runs and one-shot jobs aren't recorded
+	- The times are kept in the cron_runs table
+
+	Distributed locking: with a Locker, instances of the application sharing a database take a lease
+	on a task before running it, so every tick runs on one instance of the cluster.
+
+	Example:
+		store := cron.NewSQLStore(db)
+		runner := cron.NewRunner(cron.Persist(store), cron.Lock(store))
+
+	Notes:
+	- The instance taking the lease runs the task, the lease lasts 90% of the interval so the next
+	  tick of any instance can take it again - an instance that's down is taken over within a tick
+	- A run taking longer than the lease may overlap a run on another instance
+	- If the lease can't be looked up, e.g. the database is down, the run is skipped
+	- One-shot jobs run on the instance that scheduled them
+	- The leases are kept in the cron_leases table, the clocks of the instances should be in sync
+
+	Run history: with a History, every run of a task is recorded with its start, duration and error.
+
+	Example:
+		store := cron.NewSQLStore(db)
+		runner := cron.NewRunner(cron.Record(store))
+
+		page, err := store.History(ctx, "backup", q)  // latest runs first, "" for all tasks
+		_, err = store.PruneHistory(ctx, 7*24*time.Hour)
+
+	Notes:
+	- One-shot jobs aren't recorded
+	- The runs are kept in the cron_history table until pruned
+*/
+
+// Store persists when named tasks last succeeded
+type Store interface {
+	// LastSuccess returns when the task last succeeded, the zero time if it never did
+	LastSuccess(ctx context.Context, name string) (time.Time, error)
+	// SetLastSuccess records a successful run of the task started at t
+	SetLastSuccess(ctx context.Context, name string, t time.Time) error
+}
+
+// Persist records the last successful run of the tasks in store, needed for WithCatchUp
+func Persist(store Store) Option {
+	return func(r *Runner) {
+		r.store = store
+	}
+}
+
+// Locker grants leases on task names, so one instance of a cluster runs a task per tick
+type Locker interface {
+	// Acquire takes the lease on name until expires, it returns false if another instance holds it
+	Acquire(ctx context.Context, name string, expires time.Time) (bool, error)
+}
+
+// Lock runs named tasks only while holding their lease from locker, for instances sharing a database
+func Lock(locker Locker) Option {
+	return func(r *Runner) {
+		r.locker = locker
+	}
+}
+
+// History records the runs of tasks
+type History interface {
+	RecordRun(ctx context.Context, run RunInfo) error
+}
+
+// Record records every run of a named task in history
+func Record(history History) Option {
+	return func(r *Runner) {
+		r.history = history
+	}
+}
+
+// SQLStore keeps the last successful runs, the leases and the run history in the application database
+// It implements Store, Locker and History
+type SQLStore struct {
+	queries *sqlc.Queries
+	// holder identifies this instance in the leases
+	holder string
+}
+
+// NewSQLStore creates a Store, Locker and History backed by the application database
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{queries: sqlc.New(db), holder: newHolder()}
+}
+
+// newHolder returns an ID unique to this instance - the host and process with a random suffix
+func newHolder() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Acquire takes the lease on the task name until expires if it's free, expired or held by this instance
+func (s *SQLStore) Acquire(ctx context.Context, name string, expires time.Time) (bool, error) {
+	inserted, err := s.queries.InsertCronLease(ctx, sqlc.InsertCronLeaseParams{Name: name, Holder: s.holder, ExpiresAt: expires.UTC()})
+	if err != nil || inserted == 1 {
+		return inserted == 1, err
+	}
+	// Another instance may hold the lease, only an expired lease is taken over
+	taken, err := s.queries.TakeOverCronLease(ctx, sqlc.TakeOverCronLeaseParams{
+		Holder:    s.holder,
+		ExpiresAt: expires.UTC(),
+		Name:      name,
+		Now:       time.Now().UTC(),
+	})
+	return taken == 1, err
+}
+
+// LastSuccess returns when the task last succeeded, the zero time if it never did
+func (s *SQLStore) LastSuccess(ctx context.Context, name string) (time.Time, error) {
+	t, err := s.queries.GetCronLastSuccess(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return t, err
+}
+
+// SetLastSuccess records a successful run of the task started at t
+func (s *SQLStore) SetLastSuccess(ctx context.Context, name string, t time.Time) error {
+	return s.queries.SetCronLastSuccess(ctx, sqlc.SetCronLastSuccessParams{Name: name, LastSuccessAt: t.UTC()})
+}
+
+// RecordRun adds a run to the cron_history table
+func (s *SQLStore) RecordRun(ctx context.Context, run RunInfo) error {
+	return s.queries.CreateCronHistory(ctx, sqlc.CreateCronHistoryParams{
+		Name:       run.Name,
+		StartedAt:  run.StartedAt.UTC(),
+		DurationMs: run.Duration.Milliseconds(),
+		Error:      run.Error,
+	})
+}
+
+// History returns a page of the recorded runs of the task name, of all tasks if name is empty, latest first
+func (s *SQLStore) History(ctx context.Context, name string, q *params.Query) (params.Page[sqlc.CronHistory], error) {
+	rows, err := s.queries.ListCronHistory(ctx, sqlc.ListCronHistoryParams{
+		Name:   name,
+		Limit:  q.Limit(),
+		Offset: q.Offset(),
+	})
+	if err != nil {
+		return params.Page[sqlc.CronHistory]{}, err
+	}
+	total, err := s.queries.CountCronHistory(ctx, name)
+	if err != nil {
+		return params.Page[sqlc.CronHistory]{}, err
+	}
+	return params.NewPage(q, rows, total), nil
+}
+
+// PruneHistory deletes the runs started more than retention ago and returns how many were deleted
+func (s *SQLStore) PruneHistory(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.queries.DeleteCronHistoryBefore(ctx, time.Now().UTC().Add(-retention))
+}
@@ -0,0 +1,234 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"mookie/internal/db"
+	"mookie/internal/params"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store backed by a temporary database
+func newTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return NewSQLStore(database)
+}
+
+func TestSQLStore(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	last, err := store.LastSuccess(ctx, "backup")
+	if err != nil || !last.IsZero() {
+		t.Fatalf("LastSuccess() = %v, %v, want zero time for a task that never ran", last, err)
+	}
+
+	for _, at := range []time.Time{time.Now().Add(-time.Hour), time.Now()} {
+		if err := store.SetLastSuccess(ctx, "backup", at); err != nil {
+			t.Fatalf("SetLastSuccess() error = %v", err)
+		}
+		last, err := store.LastSuccess(ctx, "backup")
+		if err != nil || !last.Equal(at) {
+			t.Errorf("LastSuccess() = %v, %v, want %v", last, err, at)
+		}
+	}
+}
+
+func TestRunner_CatchUp(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	store.SetLastSuccess(ctx, "missed", time.Now().Add(-2*time.Hour))
+	store.SetLastSuccess(ctx, "recent", time.Now().Add(-time.Minute))
+
+	runner := NewRunner(Persist(store))
+	var missed, recent, never, noCatchUp int32
+	count := func(n *int32) CronFunc {
+		return func(context.Context) error {
+			atomic.AddInt32(n, 1)
+			return nil
+		}
+	}
+	runner.Add("missed", count(&missed), time.Hour, WithCatchUp())
+	runner.Add("recent", count(&recent), time.Hour, WithCatchUp())
+	runner.Add("never", count(&never), time.Hour, WithCatchUp())
+	store.SetLastSuccess(ctx, "no_catch_up", time.Now().Add(-2*time.Hour))
+	runner.Add("no_catch_up", count(&noCatchUp), time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		runner.Start()
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	runner.Stop()
+	<-done
+
+	if missed != 1 || recent != 0 || never != 0 || noCatchUp != 0 {
+		t.Errorf("runs on startup: missed %d, recent %d, never %d, no catch up %d, want only missed once",
+			missed, recent, never, noCatchUp)
+	}
+
+	// The catch-up run was recorded
+	last, _ := store.LastSuccess(ctx, "missed")
+	if time.Since(last) > time.Minute {
+		t.Errorf("LastSuccess() = %v after the catch-up run, want now", last)
+	}
+}
+
+func TestRunner_StopMidRun(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	before := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	for _, name := range []string{"aborts", "ignores"} {
+		store.SetLastSuccess(ctx, name, before)
+	}
+
+	runner := NewRunner(Persist(store), Record(store))
+	started := make(chan struct{}, 2)
+	// One task returns the context error, the other returns nil once cancelled
+	runner.Add("aborts", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}, time.Hour, WithCatchUp())
+	runner.Add("ignores", func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil
+	}, time.Hour, WithCatchUp())
+
+	done := make(chan struct{})
+	go func() {
+		runner.Start()
+		close(done)
+	}()
+	<-started
+	<-started
+	runner.Stop()
+	<-done
+
+	// A run cancelled by Stop neither succeeded nor failed
+	for _, name := range []string{"aborts", "ignores"} {
+		if last, err := store.LastSuccess(ctx, name); err != nil || !last.Equal(before) {
+			t.Errorf("LastSuccess(%s) = %v, %v after a cancelled run, want %v", name, last, err, before)
+		}
+	}
+	for _, stats := range runner.Stats() {
+		if stats.Runs != 0 || stats.Failures != 0 || !stats.LastRun.IsZero() {
+			t.Errorf("expected no runs of %s in the stats, got %+v", stats.Name, stats)
+		}
+	}
+	q, _ := params.Parse(url.Values{}, params.Options{})
+	if history, err := store.History(ctx, "", q); err != nil || history.Total != 0 {
+		t.Errorf("History() = %+v, %v, want no runs", history, err)
+	}
+}
+
+func TestSQLStore_Acquire(t *testing.T) {
+	first := newTestStore(t)
+	// A second instance sharing the database
+	second := &SQLStore{queries: first.queries, holder: newHolder()}
+	ctx := context.Background()
+
+	if ok, err := first.Acquire(ctx, "backup", time.Now().Add(time.Hour)); err != nil || !ok {
+		t.Fatalf("Acquire() = %v, %v on a free lease, want true", ok, err)
+	}
+	if ok, err := first.Acquire(ctx, "backup", time.Now().Add(time.Hour)); err != nil || !ok {
+		t.Errorf("Acquire() = %v, %v by the holder, want true", ok, err)
+	}
+	if ok, err := second.Acquire(ctx, "backup", time.Now().Add(time.Hour)); err != nil || ok {
+		t.Errorf("Acquire() = %v, %v on a held lease, want false", ok, err)
+	}
+	if ok, err := second.Acquire(ctx, "other", time.Now().Add(time.Hour)); err != nil || !ok {
+		t.Errorf("Acquire() = %v, %v on another task, want true", ok, err)
+	}
+
+	// An expired lease is taken over
+	first.Acquire(ctx, "report", time.Now().Add(-time.Second))
+	if ok, err := second.Acquire(ctx, "report", time.Now().Add(time.Hour)); err != nil || !ok {
+		t.Errorf("Acquire() = %v, %v on an expired lease, want true", ok, err)
+	}
+	if ok, _ := first.Acquire(ctx, "report", time.Now().Add(time.Hour)); ok {
+		t.Error("Acquire() = true after the lease was taken over, want false")
+	}
+}
+
+func TestRunner_Lock(t *testing.T) {
+	store := newTestStore(t)
+	var runs int32
+	count := func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+
+	// Two instances sharing the database run the task every tick between them
+	runners := []*Runner{
+		NewRunner(Lock(store)),
+		NewRunner(Lock(&SQLStore{queries: store.queries, holder: newHolder()})),
+	}
+	done := make(chan struct{}, len(runners))
+	for _, runner := range runners {
+		runner.Add("report", count, 100*time.Millisecond)
+		go func() {
+			runner.Start()
+			done <- struct{}{}
+		}()
+	}
+	time.Sleep(550 * time.Millisecond)
+	for _, runner := range runners {
+		runner.Stop()
+		<-done
+	}
+
+	if runs < 4 || runs > 6 {
+		t.Errorf("runs = %d across two instances, want 5, one per tick", runs)
+	}
+}
+
+func TestSQLStore_History(t *testing.T) {
+	store := newTestStore(t)
+	runner := NewRunner(Record(store))
+	runner.Add("report", func(context.Context) error { return nil }, 50*time.Millisecond)
+	runner.Add("fail", func(context.Context) error { return errors.New("failed") }, 50*time.Millisecond)
+	runner.RunAfter(0, func(context.Context) error { return nil })
+
+	done := make(chan struct{})
+	go func() {
+		runner.Start()
+		close(done)
+	}()
+	time.Sleep(120 * time.Millisecond)
+	runner.Stop()
+	<-done
+
+	ctx := context.Background()
+	q, _ := params.Parse(url.Values{}, params.Options{})
+	all, err := store.History(ctx, "", q)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	// Two runs of each task, the one-shot job isn't recorded
+	if all.Total != 4 {
+		t.Errorf("History() total = %d, want 4", all.Total)
+	}
+	fail, _ := store.History(ctx, "fail", q)
+	if fail.Total != 2 || fail.Items[0].Name != "fail" || fail.Items[0].Error != "failed" {
+		t.Errorf("History(fail) = %+v, want its 2 failed runs", fail)
+	}
+
+	// Runs started before the retention period are deleted
+	store.RecordRun(ctx, RunInfo{Name: "report", StartedAt: time.Now().Add(-48 * time.Hour)})
+	pruned, err := store.PruneHistory(ctx, 24*time.Hour)
+	if err != nil || pruned != 1 {
+		t.Errorf("PruneHistory() = %d, %v, want 1 deleted", pruned, err)
+	}
+}
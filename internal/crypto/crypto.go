@@ -0,0 +1,354 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+   Package crypto encrypts and signs application data with versioned keys, so keys can be
+   rotated without losing access to data encrypted with older keys.
+
+   Every key has a version. New data is always encrypted and signed with the highest version,
+   and the version is stored in the output so older data is decrypted with the key it was
+   encrypted with. Separate encryption and signing keys are derived from every configured key.
+
+   How to use:
+   1. Generate a key with `openssl rand -base64 32` and add it to the config as "1:<key>"
+   2. Create a keyring from the configured keys with ParseKeys and NewKeyring
+   3. Encrypt sensitive values before storing them, sign values that must not be tampered with
+
+   Example basic usage:
+       keys, err := crypto.ParseKeys([]string{"1:3q2+7w...="})
+       keyring, err := crypto.NewKeyring(keys)
+
+       sealed, err := keyring.EncryptString("secret", nil)   // "v1:..."
+       plain, err := keyring.DecryptString(sealed, nil)
+
+   Example encrypting a column before it hits SQLite:
+       apiTokens := keyring.Column("integrations", "api_token")
+       stored, err := apiTokens.Encrypt(token)
+       err = queries.UpdateIntegration(ctx, sqlc.UpdateIntegrationParams{ApiToken: stored, ...})
+       token, err := apiTokens.Decrypt(row.ApiToken)
+
+   Example storing API keys or remember-me tokens - only a keyed hash is stored:
+       token, err := crypto.RandomToken(32)   // Give the token to the user
+       lookup := keyring.Hash(token)          // Store the hash, "v1.<base64 MAC>"
+
+       // Look up with the hash of every key version, so hashes stored before a rotation match
+       row, err := queries.GetAPIKeyByHashes(ctx, keyring.Hashes(token))   // WHERE hash IN (...)
+       if keyring.NeedsRotation(row.Hash) {
+           err = queries.UpdateAPIKeyHash(ctx, row.ID, keyring.Hash(token))
+       }
+
+   Example secure cookie value:
+       value, err := keyring.EncryptString(sessionID, []byte("cookie:session"))
+       http.SetCookie(w, &http.Cookie{Name: "session", Value: value, HttpOnly: true, Secure: true})
+
+   Example rotation:
+       // Config: Keys = ["1:<old key>", "2:<new key>"] - new data uses version 2
+       if keyring.NeedsRotation(stored) {
+           plain, _ := apiTokens.Decrypt(stored)
+           stored, _ = apiTokens.Encrypt(plain)   // Re-encrypted with version 2
+       }
+
+   Notes:
+   - Encryption is AES-256-GCM with a random nonce, so encrypting a value twice gives different outputs
+   - Additional data binds a ciphertext to its use: a value encrypted for one column or cookie
+     can't be decrypted as another
+   - Keep old keys configured until all data is re-encrypted, removing a key makes its data unreadable
+   - Hash and Sign use the highest key version, look hashes up with Hashes and re-hash old ones
+     while the value is at hand, a hash can only be recomputed from the value
+*/
+
+var (
+	// ErrNoKeys is returned when a keyring is created without keys
+	ErrNoKeys = errors.New("crypto: no keys")
+	// ErrUnknownVersion is returned when data was encrypted with a key that isn't in the keyring
+	ErrUnknownVersion = errors.New("crypto: unknown key version")
+	// ErrInvalid is returned when data is malformed or was tampered with
+	ErrInvalid = errors.New("crypto: invalid data")
+)
+
+// KeySize is the size of keys in bytes
+const KeySize = 32
+
+// Key is a versioned key
+type Key struct {
+	Version int
+	Secret  []byte
+}
+
+// derivedKey holds the keys derived from a configured key
+type derivedKey struct {
+	aead    cipher.AEAD
+	signing []byte
+	hashing []byte
+}
+
+// Keyring encrypts and signs with the highest key version and decrypts with any key
+type Keyring struct {
+	keys    map[int]derivedKey
+	current int
+}
+
+// ParseKeys parses keys in the "version:base64" format used in the config
+func ParseKeys(values []string) ([]Key, error) {
+	keys := make([]Key, 0, len(values))
+	for _, value := range values {
+		version, encoded, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("crypto: key must be version:base64")
+		}
+		v, err := strconv.Atoi(version)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("crypto: invalid key version %q", version)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: key %d: %w", v, err)
+		}
+		keys = append(keys, Key{Version: v, Secret: secret})
+	}
+	return keys, nil
+}
+
+// GenerateKey returns a random key
+func GenerateKey(version int) (Key, error) {
+	secret := make([]byte, KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	return Key{Version: version, Secret: secret}, nil
+}
+
+// String returns the key in the "version:base64" config format
+func (k Key) String() string {
+	return strconv.Itoa(k.Version) + ":" + base64.StdEncoding.EncodeToString(k.Secret)
+}
+
+// NewKeyring creates a keyring, keys must be KeySize bytes with unique versions
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	k := &Keyring{keys: make(map[int]derivedKey, len(keys))}
+	for _, key := range keys {
+		if len(key.Secret) != KeySize {
+			return nil, fmt.Errorf("crypto: key %d must be %d bytes, got %d", key.Version, KeySize, len(key.Secret))
+		}
+		if _, ok := k.keys[key.Version]; ok {
+			return nil, fmt.Errorf("crypto: duplicate key version %d", key.Version)
+		}
+		block, err := aes.NewCipher(derive(key.Secret, "encryption"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[key.Version] = derivedKey{aead: aead, signing: derive(key.Secret, "signing"), hashing: derive(key.Secret, "hashing")}
+		k.current = max(k.current, key.Version)
+	}
+	return k, nil
+}
+
+// Version returns the key version used for new data
+func (k *Keyring) Version() int {
+	return k.current
+}
+
+// Versions returns all key versions in ascending order
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keys))
+	for v := range k.keys {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Encrypt encrypts plaintext with the current key, additionalData must be given again to decrypt
+// The output is "v<version>:<base64 nonce and ciphertext>"
+func (k *Keyring) Encrypt(plaintext, additionalData []byte) (string, error) {
+	aead := k.keys[k.current].aead
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return "v" + strconv.Itoa(k.current) + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt with any key of the keyring
+func (k *Keyring) Decrypt(data string, additionalData []byte) ([]byte, error) {
+	version, encoded, err := splitVersion(data, ":")
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return nil, ErrInvalid
+	}
+	nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+	plaintext, err := key.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts a string with the current key
+func (k *Keyring) EncryptString(plaintext string, additionalData []byte) (string, error) {
+	return k.Encrypt([]byte(plaintext), additionalData)
+}
+
+// DecryptString decrypts data encrypted by EncryptString
+func (k *Keyring) DecryptString(data string, additionalData []byte) (string, error) {
+	plaintext, err := k.Decrypt(data, additionalData)
+	return string(plaintext), err
+}
+
+// NeedsRotation reports whether encrypted or signed data uses an older key than the current one
+func (k *Keyring) NeedsRotation(data string) bool {
+	version, _, err := splitVersion(data, ":")
+	if err != nil {
+		version, _, err = splitVersion(data, ".")
+	}
+	return err == nil && version != k.current
+}
+
+// Sign returns an HMAC-SHA256 signature of data with the current key: "v<version>.<base64 MAC>"
+func (k *Keyring) Sign(data []byte) string {
+	return "v" + strconv.Itoa(k.current) + "." + base64.RawURLEncoding.EncodeToString(mac(k.keys[k.current].signing, data))
+}
+
+// Verify reports whether signature is a valid signature of data by any key of the keyring
+func (k *Keyring) Verify(data []byte, signature string) bool {
+	version, encoded, err := splitVersion(signature, ".")
+	if err != nil {
+		return false
+	}
+	if _, ok := k.keys[version]; !ok {
+		return false
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sum, mac(k.keys[version].signing, data))
+}
+
+// SignValue returns value with its signature appended: "<value>|<signature>"
+func (k *Keyring) SignValue(value string) string {
+	return value + "|" + k.Sign([]byte(value))
+}
+
+// VerifyValue returns the value of a string created by SignValue if its signature is valid
+func (k *Keyring) VerifyValue(signed string) (string, error) {
+	i := strings.LastIndex(signed, "|")
+	if i < 0 || !k.Verify([]byte(signed[:i]), signed[i+1:]) {
+		return "", ErrInvalid
+	}
+	return signed[:i], nil
+}
+
+// Hash returns a deterministic keyed hash of value with the current key, for storing and
+// looking up secrets such as API keys and remember-me tokens without storing them
+// The output is "v<version>.<base64 MAC>", look it up with Hashes
+func (k *Keyring) Hash(value string) string {
+	return k.hash(k.current, value)
+}
+
+// Hashes returns the hashes of value with every key version, newest first, to look up values
+// hashed before a rotation
+func (k *Keyring) Hashes(value string) []string {
+	versions := k.Versions()
+	hashes := make([]string, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		hashes = append(hashes, k.hash(versions[i], value))
+	}
+	return hashes
+}
+
+// hash returns the keyed hash of value with the hashing key of a version
+func (k *Keyring) hash(version int, value string) string {
+	return "v" + strconv.Itoa(version) + "." + base64.RawURLEncoding.EncodeToString(mac(k.keys[version].hashing, []byte(value)))
+}
+
+// Column returns a helper encrypting the values of a database column, bound to the table and column
+func (k *Keyring) Column(table, column string) Column {
+	return Column{keyring: k, additionalData: []byte("column:" + table + "." + column)}
+}
+
+// Column encrypts and decrypts the values of a database column
+type Column struct {
+	keyring        *Keyring
+	additionalData []byte
+}
+
+// Encrypt encrypts a value to store in the column, empty values are stored empty
+func (c Column) Encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return c.keyring.EncryptString(value, c.additionalData)
+}
+
+// Decrypt decrypts a value read from the column
+func (c Column) Decrypt(stored string) (string, error) {
+	if stored == "" {
+		return "", nil
+	}
+	return c.keyring.DecryptString(stored, c.additionalData)
+}
+
+// RandomToken returns a URL safe random token of n random bytes
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// mac returns the HMAC-SHA256 of data with key
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// derive derives a purpose specific key from a configured key
+func derive(secret []byte, purpose string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte("mookie " + purpose))
+	return h.Sum(nil)
+}
+
+// splitVersion splits "v<version><sep><rest>"
+func splitVersion(data, sep string) (int, string, error) {
+	prefix, rest, ok := strings.Cut(data, sep)
+	if !ok || !strings.HasPrefix(prefix, "v") {
+		return 0, "", ErrInvalid
+	}
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil {
+		return 0, "", ErrInvalid
+	}
+	return version, rest, nil
+}
@@ -0,0 +1,203 @@
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKeyring returns a keyring with the given key versions
+func testKeyring(t *testing.T, versions ...int) (*Keyring, []Key) {
+	t.Helper()
+	var keys []Key
+	for _, v := range versions {
+		key, err := GenerateKey(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	k, err := NewKeyring(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, keys
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k, _ := testKeyring(t, 1)
+
+	sealed, err := k.EncryptString("secret", []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, "v1:") || strings.Contains(sealed, "secret") {
+		t.Errorf("sealed = %q", sealed)
+	}
+	again, _ := k.EncryptString("secret", []byte("ad"))
+	if again == sealed {
+		t.Error("expected a random nonce per encryption")
+	}
+
+	plain, err := k.DecryptString(sealed, []byte("ad"))
+	if err != nil || plain != "secret" {
+		t.Fatalf("decrypt = %q, %v", plain, err)
+	}
+
+	if _, err := k.DecryptString(sealed, []byte("other")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong additional data: err = %v, want ErrInvalid", err)
+	}
+	last := "A"
+	if strings.HasSuffix(sealed, "A") {
+		last = "B"
+	}
+	tampered := sealed[:len(sealed)-1] + last
+	if _, err := k.DecryptString(tampered, []byte("ad")); !errors.Is(err, ErrInvalid) {
+		t.Errorf("tampered: err = %v, want ErrInvalid", err)
+	}
+	if _, err := k.DecryptString("garbage", nil); !errors.Is(err, ErrInvalid) {
+		t.Errorf("garbage: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old, keys := testKeyring(t, 1)
+	sealed, _ := old.EncryptString("secret", nil)
+	signature := old.Sign([]byte("data"))
+
+	next, err := GenerateKey(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewKeyring(append(keys, next))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Version() != 2 {
+		t.Errorf("version = %d, want 2", rotated.Version())
+	}
+
+	plain, err := rotated.DecryptString(sealed, nil)
+	if err != nil || plain != "secret" {
+		t.Errorf("old data: %q, %v", plain, err)
+	}
+	if !rotated.NeedsRotation(sealed) || !rotated.NeedsRotation(signature) {
+		t.Error("expected old data to need rotation")
+	}
+	if !rotated.Verify([]byte("data"), signature) {
+		t.Error("expected the old signature to verify")
+	}
+
+	resealed, _ := rotated.EncryptString(plain, nil)
+	if !strings.HasPrefix(resealed, "v2:") || rotated.NeedsRotation(resealed) {
+		t.Errorf("resealed = %q", resealed)
+	}
+	if _, err := old.DecryptString(resealed, nil); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("err = %v, want ErrUnknownVersion", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	k, _ := testKeyring(t, 3)
+	other, _ := testKeyring(t, 3)
+
+	signature := k.Sign([]byte("data"))
+	if !strings.HasPrefix(signature, "v3.") {
+		t.Errorf("signature = %q", signature)
+	}
+	if !k.Verify([]byte("data"), signature) {
+		t.Error("expected the signature to verify")
+	}
+	if k.Verify([]byte("tampered"), signature) || other.Verify([]byte("data"), signature) {
+		t.Error("expected the signature to fail")
+	}
+
+	signed := k.SignValue("user|42")
+	if value, err := k.VerifyValue(signed); err != nil || value != "user|42" {
+		t.Errorf("verify value = %q, %v", value, err)
+	}
+	if _, err := k.VerifyValue("user|43" + signed[len("user|42"):]); !errors.Is(err, ErrInvalid) {
+		t.Errorf("tampered value: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	k, _ := testKeyring(t, 1)
+	if k.Hash("token") != k.Hash("token") {
+		t.Error("expected hashes to be deterministic")
+	}
+	if k.Hash("token") == k.Hash("other") {
+		t.Error("expected different values to hash differently")
+	}
+	if hash := k.Hash("token"); !strings.HasPrefix(hash, "v1.") || hash == k.Sign([]byte("token")) {
+		t.Errorf("hash = %q, want a v1 hash distinct from the signature", hash)
+	}
+}
+
+func TestHashRotation(t *testing.T) {
+	old, keys := testKeyring(t, 1)
+	stored := old.Hash("token")
+
+	next, err := GenerateKey(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewKeyring(append(keys, next))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The current hash changes, the hashes of every version still find the stored one
+	if rotated.Hash("token") == stored || !strings.HasPrefix(rotated.Hash("token"), "v2.") {
+		t.Errorf("hash = %q, want a v2 hash", rotated.Hash("token"))
+	}
+	hashes := rotated.Hashes("token")
+	if len(hashes) != 2 || hashes[0] != rotated.Hash("token") || hashes[1] != stored {
+		t.Errorf("hashes = %q, want the v2 and v1 hashes", hashes)
+	}
+	if !rotated.NeedsRotation(stored) || rotated.NeedsRotation(hashes[0]) {
+		t.Error("expected only the v1 hash to need rotation")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	k, _ := testKeyring(t, 1)
+	tokens := k.Column("integrations", "api_token")
+
+	stored, err := tokens.Encrypt("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := tokens.Decrypt(stored); err != nil || value != "abc" {
+		t.Errorf("decrypt = %q, %v", value, err)
+	}
+	if _, err := k.Column("integrations", "other").Decrypt(stored); !errors.Is(err, ErrInvalid) {
+		t.Errorf("other column: err = %v, want ErrInvalid", err)
+	}
+	if stored, _ := tokens.Encrypt(""); stored != "" {
+		t.Errorf("empty value stored as %q", stored)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key, _ := GenerateKey(7)
+	keys, err := ParseKeys([]string{key.String()})
+	if err != nil || len(keys) != 1 || keys[0].Version != 7 || string(keys[0].Secret) != string(key.Secret) {
+		t.Fatalf("parse = %v, %v", keys, err)
+	}
+
+	for _, bad := range []string{"nokey", "x:AAAA", "0:AAAA", "1:!!!"} {
+		if _, err := ParseKeys([]string{bad}); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	if _, err := NewKeyring([]Key{{Version: 1, Secret: []byte("short")}}); err == nil {
+		t.Error("expected short keys to be rejected")
+	}
+	if _, err := NewKeyring([]Key{key, key}); err == nil {
+		t.Error("expected duplicate versions to be rejected")
+	}
+	if _, err := NewKeyring(nil); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("err = %v, want ErrNoKeys", err)
+	}
+}
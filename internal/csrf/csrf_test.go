@@ -0,0 +1,63 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	token, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cookie  string
+		header  string
+		field   string
+		wantErr error
+	}{
+		{name: "matching header", cookie: token, header: token, wantErr: nil},
+		{name: "matching form field", cookie: token, field: token, wantErr: nil},
+		{name: "missing cookie", header: token, wantErr: ErrMissingToken},
+		{name: "missing submitted token", cookie: token, wantErr: ErrMissingToken},
+		{name: "mismatched token", cookie: token, header: "other", wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.field != "" {
+				form.Set(FieldName, tt.field)
+			}
+			r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(HeaderName, tt.header)
+			}
+
+			if err := Verify(r); err != tt.wantErr {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if Token(r.Context()) != "" {
+		t.Error("expected empty token without middleware")
+	}
+
+	ctx := WithToken(r.Context(), "abc")
+	if Token(ctx) != "abc" {
+		t.Errorf("got %q, want abc", Token(ctx))
+	}
+}
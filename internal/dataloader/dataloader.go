@@ -0,0 +1,200 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+   Package dataloader batches and caches individual loads into a single batch call,
+   avoiding N+1 queries when resolving nested GraphQL fields or rendering lists.
+
+   How to use:
+   1. Write a BatchFunc that loads many keys at once (e.g. WHERE id IN (...))
+   2. Create a Loader per request - loaders cache results for their whole lifetime
+   3. Call Load from concurrent resolvers, loads within the wait window are batched
+
+   Example basic usage:
+       loader := dataloader.New(func(ctx context.Context, ids []int64) (map[int64][]string, error) {
+           rows, err := queries.ListRolesForUsers(ctx, ids)
+           if err != nil {
+               return nil, err
+           }
+           roles := make(map[int64][]string)
+           for _, row := range rows {
+               roles[row.UserID] = append(roles[row.UserID], row.Role)
+           }
+           return roles, nil
+       })
+
+       // Concurrent calls are combined into a single BatchFunc call
+       roles, err := loader.Load(ctx, userID)
+
+   Notes:
+   - Thread-safe
+   - Keys missing from the batch result return the zero value and ErrNotFound
+   - A batch is dispatched after the wait window or when it reaches the max batch size
+   - Results (including errors) are cached per key, create a new Loader per request
+*/
+
+// ErrNotFound is returned when the batch function didn't return a value for a key
+var ErrNotFound = errors.New("dataloader: key not found")
+
+// Default options
+const (
+	DefaultWait     = 2 * time.Millisecond
+	DefaultMaxBatch = 100
+)
+
+// BatchFunc loads values for many keys at once
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Option configures a Loader
+type Option func(*options)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// WithWait sets how long the loader waits to collect keys before dispatching a batch
+func WithWait(d time.Duration) Option {
+	return func(o *options) {
+		o.wait = d
+	}
+}
+
+// WithMaxBatch sets the maximum number of keys in a single batch
+func WithMaxBatch(n int) Option {
+	return func(o *options) {
+		o.maxBatch = n
+	}
+}
+
+// result holds the outcome of loading a single key
+type result[V any] struct {
+	value V
+	err   error
+	done  chan struct{}
+}
+
+// batch is a group of keys waiting to be dispatched
+type batch[K comparable, V any] struct {
+	keys    []K
+	results map[K]*result[V]
+	timer   *time.Timer
+}
+
+// Loader batches and caches loads
+type Loader[K comparable, V any] struct {
+	fn      BatchFunc[K, V]
+	opts    options
+	cache   map[K]*result[V]
+	pending *batch[K, V]
+	mu      sync.Mutex
+}
+
+// New creates a new Loader
+func New[K comparable, V any](fn BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{wait: DefaultWait, maxBatch: DefaultMaxBatch}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Loader[K, V]{
+		fn:    fn,
+		opts:  o,
+		cache: make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value for key, batching it with other concurrent loads
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	res, cached := l.cache[key]
+	if !cached {
+		res = &result[V]{done: make(chan struct{})}
+		l.cache[key] = res
+		l.enqueue(ctx, key, res)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany loads multiple keys, returning values and errors in key order
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, []error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}()
+	}
+	wg.Wait()
+	return values, errs
+}
+
+// Clear removes a key from the cache so the next Load fetches it again
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// enqueue adds the key to the pending batch, must be called with the lock held
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, res *result[V]) {
+	if l.pending == nil {
+		b := &batch[K, V]{results: make(map[K]*result[V])}
+		b.timer = time.AfterFunc(l.opts.wait, func() {
+			l.mu.Lock()
+			if l.pending == b {
+				l.pending = nil
+			}
+			l.mu.Unlock()
+			l.dispatch(ctx, b)
+		})
+		l.pending = b
+	}
+
+	b := l.pending
+	b.keys = append(b.keys, key)
+	b.results[key] = res
+
+	// Dispatch early when the batch is full
+	if len(b.keys) >= l.opts.maxBatch && b.timer.Stop() {
+		l.pending = nil
+		go l.dispatch(ctx, b)
+	}
+}
+
+// dispatch runs the batch function and resolves the results of the batch
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	// Use a context that isn't cancelled with the request that happened to start the batch
+	values, err := l.fn(context.WithoutCancel(ctx), b.keys)
+
+	for key, res := range b.results {
+		switch {
+		case err != nil:
+			res.err = err
+		default:
+			value, ok := values[key]
+			if !ok {
+				res.err = ErrNotFound
+			}
+			res.value = value
+		}
+		close(res.done)
+	}
+}
@@ -0,0 +1,91 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLoader_Batching(t *testing.T) {
+	var calls int32
+	loader := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		atomic.AddInt32(&calls, 1)
+		values := make(map[int]string)
+		for _, k := range keys {
+			if k != 404 {
+				values[k] = "value"
+			}
+		}
+		return values, nil
+	})
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			value, err := loader.Load(ctx, key)
+			if err != nil || value != "value" {
+				t.Errorf("Load(%d) = %q, %v", key, value, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected 1 batch call, got %d", calls)
+	}
+
+	t.Run("results are cached", func(t *testing.T) {
+		loader.Load(ctx, 3)
+		if atomic.LoadInt32(&calls) != 1 {
+			t.Errorf("expected cached result, got %d batch calls", calls)
+		}
+	})
+
+	t.Run("missing keys return ErrNotFound", func(t *testing.T) {
+		_, err := loader.Load(ctx, 404)
+		if err != ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	var calls int32
+	loader := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		atomic.AddInt32(&calls, 1)
+		if len(keys) > 2 {
+			t.Errorf("batch exceeded max size: %d", len(keys))
+		}
+		values := make(map[int]int)
+		for _, k := range keys {
+			values[k] = k * 2
+		}
+		return values, nil
+	}, WithMaxBatch(2))
+
+	values, errs := loader.LoadMany(context.Background(), []int{1, 2, 3, 4, 5})
+	for i, v := range values {
+		if errs[i] != nil || v != (i+1)*2 {
+			t.Errorf("got %d, %v for key %d", v, errs[i], i+1)
+		}
+	}
+	if atomic.LoadInt32(&calls) < 3 {
+		t.Errorf("expected at least 3 batch calls, got %d", calls)
+	}
+}
+
+func TestLoader_Error(t *testing.T) {
+	batchErr := errors.New("db down")
+	loader := New(func(ctx context.Context, keys []string) (map[string]int, error) {
+		return nil, batchErr
+	})
+
+	if _, err := loader.Load(context.Background(), "a"); err != batchErr {
+		t.Errorf("expected batch error, got %v", err)
+	}
+}
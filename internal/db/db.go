@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"regexp"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -29,14 +31,38 @@ import (
    Notes:
    - Automatically executes embedded schema.sql on connection
    - Creates database file if it doesn't exist
+   - Enables foreign keys on every connection, so ON DELETE CASCADE applies
    - Compatible with SQLC generated code
 */
 
 //go:embed schema.sql
 var ddl string
 
+// Schema returns the embedded database schema
+func Schema() string {
+	return ddl
+}
+
+// tableName matches the table name of CREATE TABLE statements in the schema
+var tableName = regexp.MustCompile(`(?i)CREATE TABLE IF NOT EXISTS\s+(\w+)`)
+
+// Tables returns the names of the tables defined in the embedded schema
+func Tables() []string {
+	var tables []string
+	for _, match := range tableName.FindAllStringSubmatch(ddl, -1) {
+		tables = append(tables, match[1])
+	}
+	return tables
+}
+
+// Open opens the SQLite database at dbPath and applies the embedded schema
 func Open(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	return OpenDriver("sqlite3", dbPath)
+}
+
+// OpenDriver is Open with a custom registered driver name - e.g. a driver wrapped for tracing
+func OpenDriver(driverName, dbPath string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, withForeignKeys(dbPath))
 	if err != nil {
 		return nil, err
 	}
@@ -47,3 +73,12 @@ func Open(dbPath string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// withForeignKeys adds the go-sqlite3 parameter enabling foreign keys to dbPath - the pragma is per
+// connection, so setting it with an Exec would only cover one connection of the pool
+func withForeignKeys(dbPath string) string {
+	if strings.Contains(dbPath, "?") {
+		return dbPath + "&_foreign_keys=1"
+	}
+	return dbPath + "?_foreign_keys=1"
+}
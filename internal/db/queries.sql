@@ -14,3 +14,377 @@ WHERE username = ? LIMIT 1;
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = ?;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR username LIKE '%' || sqlc.arg(search) || '%'
+   OR email LIKE '%' || sqlc.arg(search) || '%'
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR username LIKE '%' || sqlc.arg(search) || '%'
+   OR email LIKE '%' || sqlc.arg(search) || '%';
+
+-- name: UpdateUser :exec
+UPDATE users
+SET username = ?, email = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GrantUserRole :exec
+INSERT OR IGNORE INTO user_roles (user_id, role)
+VALUES (?, ?);
+
+-- name: RevokeUserRole :exec
+DELETE FROM user_roles
+WHERE user_id = ? AND role = ?;
+
+-- name: ListUserRoles :many
+SELECT role FROM user_roles
+WHERE user_id = ?
+ORDER BY role;
+
+-- name: ListRolesForUsers :many
+SELECT user_id, role FROM user_roles
+WHERE user_id IN (sqlc.slice(user_ids))
+ORDER BY user_id, role;
+
+-- name: GetFeatureFlag :one
+SELECT * FROM feature_flags
+WHERE id = ? LIMIT 1;
+
+-- name: GetFeatureFlagByName :one
+SELECT * FROM feature_flags
+WHERE name = ? LIMIT 1;
+
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR name LIKE '%' || sqlc.arg(search) || '%'
+   OR description LIKE '%' || sqlc.arg(search) || '%'
+ORDER BY name
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountFeatureFlags :one
+SELECT COUNT(*) FROM feature_flags
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR name LIKE '%' || sqlc.arg(search) || '%'
+   OR description LIKE '%' || sqlc.arg(search) || '%';
+
+-- name: CreateFeatureFlag :one
+INSERT INTO feature_flags (name, description, enabled, rollout_percentage, roles, user_ids)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateFeatureFlag :exec
+UPDATE feature_flags
+SET name = ?, description = ?, enabled = ?, rollout_percentage = ?, roles = ?, user_ids = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags
+WHERE id = ?;
+
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, type, title, body, link)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = ?;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+WHERE id = ? AND user_id = ?;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND read_at IS NULL;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = ? LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR url LIKE '%' || sqlc.arg(search) || '%'
+   OR events LIKE '%' || sqlc.arg(search) || '%'
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountWebhooks :one
+SELECT COUNT(*) FROM webhooks
+WHERE CAST(sqlc.arg(search) AS TEXT) = ''
+   OR url LIKE '%' || sqlc.arg(search) || '%'
+   OR events LIKE '%' || sqlc.arg(search) || '%';
+
+-- name: ListActiveWebhooks :many
+SELECT * FROM webhooks
+WHERE active = TRUE
+ORDER BY id;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, active)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: UpdateWebhook :exec
+UPDATE webhooks
+SET url = ?, secret = ?, events = ?, active = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = ? LIMIT 1;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT sqlc.embed(webhook_deliveries), webhooks.url, webhooks.secret
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= sqlc.arg(now)
+ORDER BY webhook_deliveries.next_attempt_at
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, response_body = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RetryWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE webhook_id = ?;
+
+-- name: UpdateWebhookDeliveryPayload :exec
+UPDATE webhook_deliveries
+SET payload = ?
+WHERE id = ?;
+
+-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (endpoint) DO UPDATE SET user_id = excluded.user_id, p256dh = excluded.p256dh, auth = excluded.auth
+RETURNING *;
+
+-- name: ListPushSubscriptionsByUser :many
+SELECT * FROM push_subscriptions
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE user_id = ? AND endpoint = ?;
+
+-- name: DeletePushSubscriptionByEndpoint :exec
+DELETE FROM push_subscriptions
+WHERE endpoint = ?;
+
+-- name: CreateOutboundMessage :one
+INSERT INTO outbound_messages (channel, recipient, title, body, link, next_attempt_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListDueOutboundMessages :many
+SELECT * FROM outbound_messages
+WHERE status = 'pending' AND next_attempt_at <= sqlc.arg(now)
+ORDER BY next_attempt_at
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateOutboundMessage :exec
+UPDATE outbound_messages
+SET status = ?, attempts = ?, next_attempt_at = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListUsersUpdatedSince :many
+SELECT * FROM users
+WHERE updated_at >= CAST(sqlc.arg(since) AS TEXT)
+ORDER BY id;
+
+-- name: ListFeatureFlagsUpdatedSince :many
+SELECT * FROM feature_flags
+WHERE updated_at >= CAST(sqlc.arg(since) AS TEXT)
+ORDER BY id;
+
+-- name: CreateAuditEntry :one
+INSERT INTO audit_log (actor, action, object_type, object_id, changes, request_id, ip)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListAuditEntries :many
+SELECT * FROM audit_log
+WHERE (CAST(sqlc.arg(actor) AS TEXT) = '' OR actor = CAST(sqlc.arg(actor) AS TEXT))
+  AND (CAST(sqlc.arg(action) AS TEXT) = '' OR action = CAST(sqlc.arg(action) AS TEXT))
+  AND (CAST(sqlc.arg(object_type) AS TEXT) = '' OR object_type = CAST(sqlc.arg(object_type) AS TEXT))
+  AND (CAST(sqlc.arg(object_id) AS TEXT) = '' OR object_id = CAST(sqlc.arg(object_id) AS TEXT))
+  AND (CAST(sqlc.arg(created_from) AS TEXT) = '' OR created_at >= CAST(sqlc.arg(created_from) AS TEXT))
+  AND (CAST(sqlc.arg(created_to) AS TEXT) = '' OR created_at < CAST(sqlc.arg(created_to) AS TEXT))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountAuditEntries :one
+SELECT COUNT(*) FROM audit_log
+WHERE (CAST(sqlc.arg(actor) AS TEXT) = '' OR actor = CAST(sqlc.arg(actor) AS TEXT))
+  AND (CAST(sqlc.arg(action) AS TEXT) = '' OR action = CAST(sqlc.arg(action) AS TEXT))
+  AND (CAST(sqlc.arg(object_type) AS TEXT) = '' OR object_type = CAST(sqlc.arg(object_type) AS TEXT))
+  AND (CAST(sqlc.arg(object_id) AS TEXT) = '' OR object_id = CAST(sqlc.arg(object_id) AS TEXT))
+  AND (CAST(sqlc.arg(created_from) AS TEXT) = '' OR created_at >= CAST(sqlc.arg(created_from) AS TEXT))
+  AND (CAST(sqlc.arg(created_to) AS TEXT) = '' OR created_at < CAST(sqlc.arg(created_to) AS TEXT));
+
+-- name: DeleteAuditEntriesBefore :execrows
+DELETE FROM audit_log
+WHERE created_at < CAST(sqlc.arg(before) AS TEXT);
+
+-- name: GetCustomerByUser :one
+SELECT * FROM customers
+WHERE user_id = ? LIMIT 1;
+
+-- name: GetCustomerByStripeID :one
+SELECT * FROM customers
+WHERE stripe_customer_id = ? LIMIT 1;
+
+-- name: CreateCustomer :one
+INSERT INTO customers (user_id, stripe_customer_id)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions (user_id, stripe_subscription_id, stripe_customer_id, status, price_id, current_period_end, cancel_at_period_end)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (stripe_subscription_id) DO UPDATE
+SET status = excluded.status,
+    price_id = excluded.price_id,
+    current_period_end = excluded.current_period_end,
+    cancel_at_period_end = excluded.cancel_at_period_end,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetActiveSubscriptionByUser :one
+SELECT * FROM subscriptions
+WHERE user_id = ? AND status IN ('active', 'trialing')
+ORDER BY current_period_end DESC
+LIMIT 1;
+
+-- name: ListSubscriptionsByUser :many
+SELECT * FROM subscriptions
+WHERE user_id = ?
+ORDER BY id DESC;
+
+-- name: GetCronLastSuccess :one
+SELECT last_success_at FROM cron_runs
+WHERE name = ? LIMIT 1;
+
+-- name: SetCronLastSuccess :exec
+INSERT INTO cron_runs (name, last_success_at)
+VALUES (?, ?)
+ON CONFLICT (name) DO UPDATE
+SET last_success_at = excluded.last_success_at;
+
+-- name: InsertCronLease :execrows
+INSERT OR IGNORE INTO cron_leases (name, holder, expires_at)
+VALUES (?, ?, ?);
+
+-- name: TakeOverCronLease :execrows
+UPDATE cron_leases
+SET holder = sqlc.arg(holder), expires_at = sqlc.arg(expires_at)
+WHERE name = sqlc.arg(name) AND (expires_at <= sqlc.arg(now) OR holder = sqlc.arg(holder));
+
+-- name: CreateCronHistory :exec
+INSERT INTO cron_history (name, started_at, duration_ms, error)
+VALUES (?, ?, ?, ?);
+
+-- name: ListCronHistory :many
+SELECT * FROM cron_history
+WHERE CAST(sqlc.arg(name) AS TEXT) = '' OR name = CAST(sqlc.arg(name) AS TEXT)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountCronHistory :one
+SELECT COUNT(*) FROM cron_history
+WHERE CAST(sqlc.arg(name) AS TEXT) = '' OR name = CAST(sqlc.arg(name) AS TEXT);
+
+-- name: DeleteCronHistoryBefore :execrows
+DELETE FROM cron_history
+WHERE started_at < sqlc.arg(before);
+
+-- name: GetCacheItem :one
+SELECT * FROM cache_items
+WHERE key = ? LIMIT 1;
+
+-- name: ListCacheItems :many
+SELECT * FROM cache_items
+WHERE (expires_at IS NULL OR expires_at > sqlc.arg(now)) AND key IN (sqlc.slice(keys));
+
+-- name: SetCacheItem :exec
+INSERT INTO cache_items (key, value, expires_at)
+VALUES (?, ?, ?)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value, expires_at = excluded.expires_at;
+
+-- name: DeleteCacheItem :exec
+DELETE FROM cache_items
+WHERE key = ?;
+
+-- name: ClearCache :exec
+DELETE FROM cache_items;
+
+-- name: CountCacheItems :one
+SELECT COUNT(*) FROM cache_items;
+
+-- name: DeleteExpiredCacheItems :execrows
+DELETE FROM cache_items
+WHERE expires_at IS NOT NULL AND expires_at <= sqlc.arg(now);
+
+-- name: SetCacheItemExpiration :execrows
+UPDATE cache_items
+SET expires_at = sqlc.arg(expires_at)
+WHERE key = sqlc.arg(key) AND (expires_at IS NULL OR expires_at > sqlc.arg(now));
+
+-- name: ListCacheKeys :many
+SELECT key FROM cache_items
+WHERE key GLOB sqlc.arg(pattern) AND (expires_at IS NULL OR expires_at > sqlc.arg(now)) AND value != sqlc.arg(not_found)
+ORDER BY key;
+
+-- name: DeleteCacheItemsMatching :execrows
+DELETE FROM cache_items
+WHERE key GLOB sqlc.arg(pattern);
@@ -6,8 +6,113 @@ package sqlc
 
 import (
 	"database/sql"
+	"time"
 )
 
+type AuditLog struct {
+	ID         int64        `db:"id" json:"id"`
+	Actor      string       `db:"actor" json:"actor"`
+	Action     string       `db:"action" json:"action"`
+	ObjectType string       `db:"object_type" json:"object_type"`
+	ObjectID   string       `db:"object_id" json:"object_id"`
+	Changes    string       `db:"changes" json:"changes"`
+	RequestID  string       `db:"request_id" json:"request_id"`
+	Ip         string       `db:"ip" json:"ip"`
+	CreatedAt  sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+type CacheItem struct {
+	Key       string       `db:"key" json:"key"`
+	Value     []byte       `db:"value" json:"value"`
+	ExpiresAt sql.NullTime `db:"expires_at" json:"expires_at"`
+}
+
+type CronHistory struct {
+	ID         int64     `db:"id" json:"id"`
+	Name       string    `db:"name" json:"name"`
+	StartedAt  time.Time `db:"started_at" json:"started_at"`
+	DurationMs int64     `db:"duration_ms" json:"duration_ms"`
+	Error      string    `db:"error" json:"error"`
+}
+
+type CronLease struct {
+	Name      string    `db:"name" json:"name"`
+	Holder    string    `db:"holder" json:"holder"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type CronRun struct {
+	Name          string    `db:"name" json:"name"`
+	LastSuccessAt time.Time `db:"last_success_at" json:"last_success_at"`
+}
+
+type Customer struct {
+	ID               int64        `db:"id" json:"id"`
+	UserID           int64        `db:"user_id" json:"user_id"`
+	StripeCustomerID string       `db:"stripe_customer_id" json:"stripe_customer_id"`
+	CreatedAt        sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+type FeatureFlag struct {
+	ID                int64        `db:"id" json:"id"`
+	Name              string       `db:"name" json:"name"`
+	Description       string       `db:"description" json:"description"`
+	Enabled           bool         `db:"enabled" json:"enabled"`
+	RolloutPercentage int64        `db:"rollout_percentage" json:"rollout_percentage"`
+	Roles             string       `db:"roles" json:"roles"`
+	UserIds           string       `db:"user_ids" json:"user_ids"`
+	CreatedAt         sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt         sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+type Notification struct {
+	ID        int64        `db:"id" json:"id"`
+	UserID    int64        `db:"user_id" json:"user_id"`
+	Type      string       `db:"type" json:"type"`
+	Title     string       `db:"title" json:"title"`
+	Body      string       `db:"body" json:"body"`
+	Link      string       `db:"link" json:"link"`
+	ReadAt    sql.NullTime `db:"read_at" json:"read_at"`
+	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+type OutboundMessage struct {
+	ID            int64        `db:"id" json:"id"`
+	Channel       string       `db:"channel" json:"channel"`
+	Recipient     string       `db:"recipient" json:"recipient"`
+	Title         string       `db:"title" json:"title"`
+	Body          string       `db:"body" json:"body"`
+	Link          string       `db:"link" json:"link"`
+	Status        string       `db:"status" json:"status"`
+	Attempts      int64        `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time    `db:"next_attempt_at" json:"next_attempt_at"`
+	Error         string       `db:"error" json:"error"`
+	CreatedAt     sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt     sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+type PushSubscription struct {
+	ID        int64        `db:"id" json:"id"`
+	UserID    int64        `db:"user_id" json:"user_id"`
+	Endpoint  string       `db:"endpoint" json:"endpoint"`
+	P256dh    string       `db:"p256dh" json:"p256dh"`
+	Auth      string       `db:"auth" json:"auth"`
+	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+type Subscription struct {
+	ID                   int64        `db:"id" json:"id"`
+	UserID               int64        `db:"user_id" json:"user_id"`
+	StripeSubscriptionID string       `db:"stripe_subscription_id" json:"stripe_subscription_id"`
+	StripeCustomerID     string       `db:"stripe_customer_id" json:"stripe_customer_id"`
+	Status               string       `db:"status" json:"status"`
+	PriceID              string       `db:"price_id" json:"price_id"`
+	CurrentPeriodEnd     time.Time    `db:"current_period_end" json:"current_period_end"`
+	CancelAtPeriodEnd    bool         `db:"cancel_at_period_end" json:"cancel_at_period_end"`
+	CreatedAt            sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt            sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
 type User struct {
 	ID        int64        `db:"id" json:"id"`
 	Username  string       `db:"username" json:"username"`
@@ -16,3 +121,34 @@ type User struct {
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
 	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
 }
+
+type UserRole struct {
+	UserID    int64        `db:"user_id" json:"user_id"`
+	Role      string       `db:"role" json:"role"`
+	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+}
+
+type Webhook struct {
+	ID        int64        `db:"id" json:"id"`
+	Url       string       `db:"url" json:"url"`
+	Secret    string       `db:"secret" json:"secret"`
+	Events    string       `db:"events" json:"events"`
+	Active    bool         `db:"active" json:"active"`
+	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64        `db:"id" json:"id"`
+	WebhookID      int64        `db:"webhook_id" json:"webhook_id"`
+	Event          string       `db:"event" json:"event"`
+	Payload        string       `db:"payload" json:"payload"`
+	Status         string       `db:"status" json:"status"`
+	Attempts       int64        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time    `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus int64        `db:"response_status" json:"response_status"`
+	ResponseBody   string       `db:"response_body" json:"response_body"`
+	Error          string       `db:"error" json:"error"`
+	CreatedAt      sql.NullTime `db:"created_at" json:"created_at"`
+	UpdatedAt      sql.NullTime `db:"updated_at" json:"updated_at"`
+}
@@ -6,13 +6,88 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	ClearCache(ctx context.Context) error
+	CountAuditEntries(ctx context.Context, arg CountAuditEntriesParams) (int64, error)
+	CountCacheItems(ctx context.Context) (int64, error)
+	CountCronHistory(ctx context.Context, name string) (int64, error)
+	CountFeatureFlags(ctx context.Context, search string) (int64, error)
+	CountNotifications(ctx context.Context, userID int64) (int64, error)
+	CountUnreadNotifications(ctx context.Context, userID int64) (int64, error)
+	CountUsers(ctx context.Context, search string) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, webhookID int64) (int64, error)
+	CountWebhooks(ctx context.Context, search string) (int64, error)
+	CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error)
+	CreateCronHistory(ctx context.Context, arg CreateCronHistoryParams) error
+	CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error)
+	CreateFeatureFlag(ctx context.Context, arg CreateFeatureFlagParams) (FeatureFlag, error)
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	CreateOutboundMessage(ctx context.Context, arg CreateOutboundMessageParams) (OutboundMessage, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteAuditEntriesBefore(ctx context.Context, before string) (int64, error)
+	DeleteCacheItem(ctx context.Context, key string) error
+	DeleteCacheItemsMatching(ctx context.Context, pattern string) (int64, error)
+	DeleteCronHistoryBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteExpiredCacheItems(ctx context.Context, now sql.NullTime) (int64, error)
+	DeleteFeatureFlag(ctx context.Context, id int64) error
+	DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (int64, error)
+	DeletePushSubscriptionByEndpoint(ctx context.Context, endpoint string) error
 	DeleteUser(ctx context.Context, id int64) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetActiveSubscriptionByUser(ctx context.Context, userID int64) (Subscription, error)
+	GetCacheItem(ctx context.Context, key string) (CacheItem, error)
+	GetCronLastSuccess(ctx context.Context, name string) (time.Time, error)
+	GetCustomerByStripeID(ctx context.Context, stripeCustomerID string) (Customer, error)
+	GetCustomerByUser(ctx context.Context, userID int64) (Customer, error)
+	GetFeatureFlag(ctx context.Context, id int64) (FeatureFlag, error)
+	GetFeatureFlagByName(ctx context.Context, name string) (FeatureFlag, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error
+	InsertCronLease(ctx context.Context, arg InsertCronLeaseParams) (int64, error)
+	ListActiveWebhooks(ctx context.Context) ([]Webhook, error)
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	ListCacheItems(ctx context.Context, arg ListCacheItemsParams) ([]CacheItem, error)
+	ListCacheKeys(ctx context.Context, arg ListCacheKeysParams) ([]string, error)
+	ListCronHistory(ctx context.Context, arg ListCronHistoryParams) ([]CronHistory, error)
+	ListDueOutboundMessages(ctx context.Context, arg ListDueOutboundMessagesParams) ([]OutboundMessage, error)
+	ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]ListDueWebhookDeliveriesRow, error)
+	ListFeatureFlags(ctx context.Context, arg ListFeatureFlagsParams) ([]FeatureFlag, error)
+	ListFeatureFlagsUpdatedSince(ctx context.Context, since string) ([]FeatureFlag, error)
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListPushSubscriptionsByUser(ctx context.Context, userID int64) ([]PushSubscription, error)
+	ListRolesForUsers(ctx context.Context, userIds []int64) ([]ListRolesForUsersRow, error)
+	ListSubscriptionsByUser(ctx context.Context, userID int64) ([]Subscription, error)
+	ListUserRoles(ctx context.Context, userID int64) ([]string, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListUsersUpdatedSince(ctx context.Context, since string) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error)
+	MarkAllNotificationsRead(ctx context.Context, userID int64) error
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error)
+	RetryWebhookDelivery(ctx context.Context, arg RetryWebhookDeliveryParams) (int64, error)
+	RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) error
+	SetCacheItem(ctx context.Context, arg SetCacheItemParams) error
+	SetCacheItemExpiration(ctx context.Context, arg SetCacheItemExpirationParams) (int64, error)
+	SetCronLastSuccess(ctx context.Context, arg SetCronLastSuccessParams) error
+	TakeOverCronLease(ctx context.Context, arg TakeOverCronLeaseParams) (int64, error)
+	UpdateFeatureFlag(ctx context.Context, arg UpdateFeatureFlagParams) error
+	UpdateOutboundMessage(ctx context.Context, arg UpdateOutboundMessageParams) error
+	UpdateUser(ctx context.Context, arg UpdateUserParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) error
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error
+	UpdateWebhookDeliveryPayload(ctx context.Context, arg UpdateWebhookDeliveryPayloadParams) error
+	UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error)
+	UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) (Subscription, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,8 +7,357 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
+	"strings"
+	"time"
 )
 
+const clearCache = `-- name: ClearCache :exec
+DELETE FROM cache_items
+`
+
+func (q *Queries) ClearCache(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearCache)
+	return err
+}
+
+const countAuditEntries = `-- name: CountAuditEntries :one
+SELECT COUNT(*) FROM audit_log
+WHERE (CAST(?1 AS TEXT) = '' OR actor = CAST(?1 AS TEXT))
+  AND (CAST(?2 AS TEXT) = '' OR action = CAST(?2 AS TEXT))
+  AND (CAST(?3 AS TEXT) = '' OR object_type = CAST(?3 AS TEXT))
+  AND (CAST(?4 AS TEXT) = '' OR object_id = CAST(?4 AS TEXT))
+  AND (CAST(?5 AS TEXT) = '' OR created_at >= CAST(?5 AS TEXT))
+  AND (CAST(?6 AS TEXT) = '' OR created_at < CAST(?6 AS TEXT))
+`
+
+type CountAuditEntriesParams struct {
+	Actor       string `db:"actor" json:"actor"`
+	Action      string `db:"action" json:"action"`
+	ObjectType  string `db:"object_type" json:"object_type"`
+	ObjectID    string `db:"object_id" json:"object_id"`
+	CreatedFrom string `db:"created_from" json:"created_from"`
+	CreatedTo   string `db:"created_to" json:"created_to"`
+}
+
+func (q *Queries) CountAuditEntries(ctx context.Context, arg CountAuditEntriesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuditEntries,
+		arg.Actor,
+		arg.Action,
+		arg.ObjectType,
+		arg.ObjectID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countCacheItems = `-- name: CountCacheItems :one
+SELECT COUNT(*) FROM cache_items
+`
+
+func (q *Queries) CountCacheItems(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCacheItems)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countCronHistory = `-- name: CountCronHistory :one
+SELECT COUNT(*) FROM cron_history
+WHERE CAST(?1 AS TEXT) = '' OR name = CAST(?1 AS TEXT)
+`
+
+func (q *Queries) CountCronHistory(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCronHistory, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFeatureFlags = `-- name: CountFeatureFlags :one
+SELECT COUNT(*) FROM feature_flags
+WHERE CAST(?1 AS TEXT) = ''
+   OR name LIKE '%' || ?1 || '%'
+   OR description LIKE '%' || ?1 || '%'
+`
+
+func (q *Queries) CountFeatureFlags(ctx context.Context, search string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeatureFlags, search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countNotifications = `-- name: CountNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = ?
+`
+
+func (q *Queries) CountNotifications(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = ? AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+WHERE CAST(?1 AS TEXT) = ''
+   OR username LIKE '%' || ?1 || '%'
+   OR email LIKE '%' || ?1 || '%'
+`
+
+func (q *Queries) CountUsers(ctx context.Context, search string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers, search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWebhookDeliveries = `-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE webhook_id = ?
+`
+
+func (q *Queries) CountWebhookDeliveries(ctx context.Context, webhookID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWebhookDeliveries, webhookID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWebhooks = `-- name: CountWebhooks :one
+SELECT COUNT(*) FROM webhooks
+WHERE CAST(?1 AS TEXT) = ''
+   OR url LIKE '%' || ?1 || '%'
+   OR events LIKE '%' || ?1 || '%'
+`
+
+func (q *Queries) CountWebhooks(ctx context.Context, search string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWebhooks, search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :one
+INSERT INTO audit_log (actor, action, object_type, object_id, changes, request_id, ip)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, actor, "action", object_type, object_id, changes, request_id, ip, created_at
+`
+
+type CreateAuditEntryParams struct {
+	Actor      string `db:"actor" json:"actor"`
+	Action     string `db:"action" json:"action"`
+	ObjectType string `db:"object_type" json:"object_type"`
+	ObjectID   string `db:"object_id" json:"object_id"`
+	Changes    string `db:"changes" json:"changes"`
+	RequestID  string `db:"request_id" json:"request_id"`
+	Ip         string `db:"ip" json:"ip"`
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEntry,
+		arg.Actor,
+		arg.Action,
+		arg.ObjectType,
+		arg.ObjectID,
+		arg.Changes,
+		arg.RequestID,
+		arg.Ip,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.ObjectType,
+		&i.ObjectID,
+		&i.Changes,
+		&i.RequestID,
+		&i.Ip,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createCronHistory = `-- name: CreateCronHistory :exec
+INSERT INTO cron_history (name, started_at, duration_ms, error)
+VALUES (?, ?, ?, ?)
+`
+
+type CreateCronHistoryParams struct {
+	Name       string    `db:"name" json:"name"`
+	StartedAt  time.Time `db:"started_at" json:"started_at"`
+	DurationMs int64     `db:"duration_ms" json:"duration_ms"`
+	Error      string    `db:"error" json:"error"`
+}
+
+func (q *Queries) CreateCronHistory(ctx context.Context, arg CreateCronHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, createCronHistory,
+		arg.Name,
+		arg.StartedAt,
+		arg.DurationMs,
+		arg.Error,
+	)
+	return err
+}
+
+const createCustomer = `-- name: CreateCustomer :one
+INSERT INTO customers (user_id, stripe_customer_id)
+VALUES (?, ?)
+RETURNING id, user_id, stripe_customer_id, created_at
+`
+
+type CreateCustomerParams struct {
+	UserID           int64  `db:"user_id" json:"user_id"`
+	StripeCustomerID string `db:"stripe_customer_id" json:"stripe_customer_id"`
+}
+
+func (q *Queries) CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, createCustomer, arg.UserID, arg.StripeCustomerID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createFeatureFlag = `-- name: CreateFeatureFlag :one
+INSERT INTO feature_flags (name, description, enabled, rollout_percentage, roles, user_ids)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, name, description, enabled, rollout_percentage, roles, user_ids, created_at, updated_at
+`
+
+type CreateFeatureFlagParams struct {
+	Name              string `db:"name" json:"name"`
+	Description       string `db:"description" json:"description"`
+	Enabled           bool   `db:"enabled" json:"enabled"`
+	RolloutPercentage int64  `db:"rollout_percentage" json:"rollout_percentage"`
+	Roles             string `db:"roles" json:"roles"`
+	UserIds           string `db:"user_ids" json:"user_ids"`
+}
+
+func (q *Queries) CreateFeatureFlag(ctx context.Context, arg CreateFeatureFlagParams) (FeatureFlag, error) {
+	row := q.db.QueryRowContext(ctx, createFeatureFlag,
+		arg.Name,
+		arg.Description,
+		arg.Enabled,
+		arg.RolloutPercentage,
+		arg.Roles,
+		arg.UserIds,
+	)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercentage,
+		&i.Roles,
+		&i.UserIds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :one
+INSERT INTO notifications (user_id, type, title, body, link)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, user_id, type, title, body, link, read_at, created_at
+`
+
+type CreateNotificationParams struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Type   string `db:"type" json:"type"`
+	Title  string `db:"title" json:"title"`
+	Body   string `db:"body" json:"body"`
+	Link   string `db:"link" json:"link"`
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, createNotification,
+		arg.UserID,
+		arg.Type,
+		arg.Title,
+		arg.Body,
+		arg.Link,
+	)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Type,
+		&i.Title,
+		&i.Body,
+		&i.Link,
+		&i.ReadAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOutboundMessage = `-- name: CreateOutboundMessage :one
+INSERT INTO outbound_messages (channel, recipient, title, body, link, next_attempt_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, channel, recipient, title, body, link, status, attempts, next_attempt_at, error, created_at, updated_at
+`
+
+type CreateOutboundMessageParams struct {
+	Channel       string    `db:"channel" json:"channel"`
+	Recipient     string    `db:"recipient" json:"recipient"`
+	Title         string    `db:"title" json:"title"`
+	Body          string    `db:"body" json:"body"`
+	Link          string    `db:"link" json:"link"`
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+}
+
+func (q *Queries) CreateOutboundMessage(ctx context.Context, arg CreateOutboundMessageParams) (OutboundMessage, error) {
+	row := q.db.QueryRowContext(ctx, createOutboundMessage,
+		arg.Channel,
+		arg.Recipient,
+		arg.Title,
+		arg.Body,
+		arg.Link,
+		arg.NextAttemptAt,
+	)
+	var i OutboundMessage
+	err := row.Scan(
+		&i.ID,
+		&i.Channel,
+		&i.Recipient,
+		&i.Title,
+		&i.Body,
+		&i.Link,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (username, email, password)
 VALUES (?, ?, ?)
@@ -35,6 +384,177 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	return i, err
 }
 
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, active)
+VALUES (?, ?, ?, ?)
+RETURNING id, url, secret, events, active, created_at, updated_at
+`
+
+type CreateWebhookParams struct {
+	Url    string `db:"url" json:"url"`
+	Secret string `db:"secret" json:"secret"`
+	Events string `db:"events" json:"events"`
+	Active bool   `db:"active" json:"active"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.Active,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+VALUES (?, ?, ?, ?)
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, response_body, error, created_at, updated_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID     int64     `db:"webhook_id" json:"webhook_id"`
+	Event         string    `db:"event" json:"event"`
+	Payload       string    `db:"payload" json:"payload"`
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.Event,
+		arg.Payload,
+		arg.NextAttemptAt,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteAuditEntriesBefore = `-- name: DeleteAuditEntriesBefore :execrows
+DELETE FROM audit_log
+WHERE created_at < CAST(?1 AS TEXT)
+`
+
+func (q *Queries) DeleteAuditEntriesBefore(ctx context.Context, before string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAuditEntriesBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCacheItem = `-- name: DeleteCacheItem :exec
+DELETE FROM cache_items
+WHERE key = ?
+`
+
+func (q *Queries) DeleteCacheItem(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteCacheItem, key)
+	return err
+}
+
+const deleteCacheItemsMatching = `-- name: DeleteCacheItemsMatching :execrows
+DELETE FROM cache_items
+WHERE key GLOB ?1
+`
+
+func (q *Queries) DeleteCacheItemsMatching(ctx context.Context, pattern string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCacheItemsMatching, pattern)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCronHistoryBefore = `-- name: DeleteCronHistoryBefore :execrows
+DELETE FROM cron_history
+WHERE started_at < ?1
+`
+
+func (q *Queries) DeleteCronHistoryBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCronHistoryBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredCacheItems = `-- name: DeleteExpiredCacheItems :execrows
+DELETE FROM cache_items
+WHERE expires_at IS NOT NULL AND expires_at <= ?1
+`
+
+func (q *Queries) DeleteExpiredCacheItems(ctx context.Context, now sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredCacheItems, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFeatureFlag = `-- name: DeleteFeatureFlag :exec
+DELETE FROM feature_flags
+WHERE id = ?
+`
+
+func (q *Queries) DeleteFeatureFlag(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFeatureFlag, id)
+	return err
+}
+
+const deletePushSubscription = `-- name: DeletePushSubscription :execrows
+DELETE FROM push_subscriptions
+WHERE user_id = ? AND endpoint = ?
+`
+
+type DeletePushSubscriptionParams struct {
+	UserID   int64  `db:"user_id" json:"user_id"`
+	Endpoint string `db:"endpoint" json:"endpoint"`
+}
+
+func (q *Queries) DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePushSubscription, arg.UserID, arg.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePushSubscriptionByEndpoint = `-- name: DeletePushSubscriptionByEndpoint :exec
+DELETE FROM push_subscriptions
+WHERE endpoint = ?
+`
+
+func (q *Queries) DeletePushSubscriptionByEndpoint(ctx context.Context, endpoint string) error {
+	_, err := q.db.ExecContext(ctx, deletePushSubscriptionByEndpoint, endpoint)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = ?
@@ -45,6 +565,143 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = ?
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhook, id)
+	return err
+}
+
+const getActiveSubscriptionByUser = `-- name: GetActiveSubscriptionByUser :one
+SELECT id, user_id, stripe_subscription_id, stripe_customer_id, status, price_id, current_period_end, cancel_at_period_end, created_at, updated_at FROM subscriptions
+WHERE user_id = ? AND status IN ('active', 'trialing')
+ORDER BY current_period_end DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveSubscriptionByUser(ctx context.Context, userID int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getActiveSubscriptionByUser, userID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StripeSubscriptionID,
+		&i.StripeCustomerID,
+		&i.Status,
+		&i.PriceID,
+		&i.CurrentPeriodEnd,
+		&i.CancelAtPeriodEnd,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCacheItem = `-- name: GetCacheItem :one
+SELECT "key", value, expires_at FROM cache_items
+WHERE key = ? LIMIT 1
+`
+
+func (q *Queries) GetCacheItem(ctx context.Context, key string) (CacheItem, error) {
+	row := q.db.QueryRowContext(ctx, getCacheItem, key)
+	var i CacheItem
+	err := row.Scan(&i.Key, &i.Value, &i.ExpiresAt)
+	return i, err
+}
+
+const getCronLastSuccess = `-- name: GetCronLastSuccess :one
+SELECT last_success_at FROM cron_runs
+WHERE name = ? LIMIT 1
+`
+
+func (q *Queries) GetCronLastSuccess(ctx context.Context, name string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getCronLastSuccess, name)
+	var last_success_at time.Time
+	err := row.Scan(&last_success_at)
+	return last_success_at, err
+}
+
+const getCustomerByStripeID = `-- name: GetCustomerByStripeID :one
+SELECT id, user_id, stripe_customer_id, created_at FROM customers
+WHERE stripe_customer_id = ? LIMIT 1
+`
+
+func (q *Queries) GetCustomerByStripeID(ctx context.Context, stripeCustomerID string) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerByStripeID, stripeCustomerID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCustomerByUser = `-- name: GetCustomerByUser :one
+SELECT id, user_id, stripe_customer_id, created_at FROM customers
+WHERE user_id = ? LIMIT 1
+`
+
+func (q *Queries) GetCustomerByUser(ctx context.Context, userID int64) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerByUser, userID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFeatureFlag = `-- name: GetFeatureFlag :one
+SELECT id, name, description, enabled, rollout_percentage, roles, user_ids, created_at, updated_at FROM feature_flags
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetFeatureFlag(ctx context.Context, id int64) (FeatureFlag, error) {
+	row := q.db.QueryRowContext(ctx, getFeatureFlag, id)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercentage,
+		&i.Roles,
+		&i.UserIds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getFeatureFlagByName = `-- name: GetFeatureFlagByName :one
+SELECT id, name, description, enabled, rollout_percentage, roles, user_ids, created_at, updated_at FROM feature_flags
+WHERE name = ? LIMIT 1
+`
+
+func (q *Queries) GetFeatureFlagByName(ctx context.Context, name string) (FeatureFlag, error) {
+	row := q.db.QueryRowContext(ctx, getFeatureFlagByName, name)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercentage,
+		&i.Roles,
+		&i.UserIds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getUserByID = `-- name: GetUserByID :one
 SELECT id, username, email, password, created_at, updated_at FROM users
 WHERE id = ? LIMIT 1
@@ -82,3 +739,1251 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 	)
 	return i, err
 }
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, response_body, error, created_at, updated_at FROM webhook_deliveries
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.ResponseBody,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const grantUserRole = `-- name: GrantUserRole :exec
+INSERT OR IGNORE INTO user_roles (user_id, role)
+VALUES (?, ?)
+`
+
+type GrantUserRoleParams struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Role   string `db:"role" json:"role"`
+}
+
+func (q *Queries) GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, grantUserRole, arg.UserID, arg.Role)
+	return err
+}
+
+const insertCronLease = `-- name: InsertCronLease :execrows
+INSERT OR IGNORE INTO cron_leases (name, holder, expires_at)
+VALUES (?, ?, ?)
+`
+
+type InsertCronLeaseParams struct {
+	Name      string    `db:"name" json:"name"`
+	Holder    string    `db:"holder" json:"holder"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) InsertCronLease(ctx context.Context, arg InsertCronLeaseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertCronLease, arg.Name, arg.Holder, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listActiveWebhooks = `-- name: ListActiveWebhooks :many
+SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE active = TRUE
+ORDER BY id
+`
+
+func (q *Queries) ListActiveWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, actor, "action", object_type, object_id, changes, request_id, ip, created_at FROM audit_log
+WHERE (CAST(?1 AS TEXT) = '' OR actor = CAST(?1 AS TEXT))
+  AND (CAST(?2 AS TEXT) = '' OR action = CAST(?2 AS TEXT))
+  AND (CAST(?3 AS TEXT) = '' OR object_type = CAST(?3 AS TEXT))
+  AND (CAST(?4 AS TEXT) = '' OR object_id = CAST(?4 AS TEXT))
+  AND (CAST(?5 AS TEXT) = '' OR created_at >= CAST(?5 AS TEXT))
+  AND (CAST(?6 AS TEXT) = '' OR created_at < CAST(?6 AS TEXT))
+ORDER BY id DESC
+LIMIT ?8 OFFSET ?7
+`
+
+type ListAuditEntriesParams struct {
+	Actor       string `db:"actor" json:"actor"`
+	Action      string `db:"action" json:"action"`
+	ObjectType  string `db:"object_type" json:"object_type"`
+	ObjectID    string `db:"object_id" json:"object_id"`
+	CreatedFrom string `db:"created_from" json:"created_from"`
+	CreatedTo   string `db:"created_to" json:"created_to"`
+	Offset      int64  `db:"offset" json:"offset"`
+	Limit       int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries,
+		arg.Actor,
+		arg.Action,
+		arg.ObjectType,
+		arg.ObjectID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Actor,
+			&i.Action,
+			&i.ObjectType,
+			&i.ObjectID,
+			&i.Changes,
+			&i.RequestID,
+			&i.Ip,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCacheItems = `-- name: ListCacheItems :many
+SELECT "key", value, expires_at FROM cache_items
+WHERE (expires_at IS NULL OR expires_at > ?1) AND key IN (/*SLICE:keys*/?)
+`
+
+type ListCacheItemsParams struct {
+	Now  sql.NullTime `db:"now" json:"now"`
+	Keys []string     `db:"keys" json:"keys"`
+}
+
+func (q *Queries) ListCacheItems(ctx context.Context, arg ListCacheItemsParams) ([]CacheItem, error) {
+	query := listCacheItems
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.Now)
+	if len(arg.Keys) > 0 {
+		for _, v := range arg.Keys {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:keys*/?", strings.Repeat(",?", len(arg.Keys))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:keys*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CacheItem
+	for rows.Next() {
+		var i CacheItem
+		if err := rows.Scan(&i.Key, &i.Value, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCacheKeys = `-- name: ListCacheKeys :many
+SELECT key FROM cache_items
+WHERE key GLOB ?1 AND (expires_at IS NULL OR expires_at > ?2) AND value != ?3
+ORDER BY key
+`
+
+type ListCacheKeysParams struct {
+	Pattern  string       `db:"pattern" json:"pattern"`
+	Now      sql.NullTime `db:"now" json:"now"`
+	NotFound []byte       `db:"not_found" json:"not_found"`
+}
+
+func (q *Queries) ListCacheKeys(ctx context.Context, arg ListCacheKeysParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listCacheKeys, arg.Pattern, arg.Now, arg.NotFound)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCronHistory = `-- name: ListCronHistory :many
+SELECT id, name, started_at, duration_ms, error FROM cron_history
+WHERE CAST(?1 AS TEXT) = '' OR name = CAST(?1 AS TEXT)
+ORDER BY id DESC
+LIMIT ?3 OFFSET ?2
+`
+
+type ListCronHistoryParams struct {
+	Name   string `db:"name" json:"name"`
+	Offset int64  `db:"offset" json:"offset"`
+	Limit  int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListCronHistory(ctx context.Context, arg ListCronHistoryParams) ([]CronHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listCronHistory, arg.Name, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CronHistory
+	for rows.Next() {
+		var i CronHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartedAt,
+			&i.DurationMs,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueOutboundMessages = `-- name: ListDueOutboundMessages :many
+SELECT id, channel, recipient, title, body, link, status, attempts, next_attempt_at, error, created_at, updated_at FROM outbound_messages
+WHERE status = 'pending' AND next_attempt_at <= ?1
+ORDER BY next_attempt_at
+LIMIT ?2
+`
+
+type ListDueOutboundMessagesParams struct {
+	Now   time.Time `db:"now" json:"now"`
+	Limit int64     `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListDueOutboundMessages(ctx context.Context, arg ListDueOutboundMessagesParams) ([]OutboundMessage, error) {
+	rows, err := q.db.QueryContext(ctx, listDueOutboundMessages, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboundMessage
+	for rows.Next() {
+		var i OutboundMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.Channel,
+			&i.Recipient,
+			&i.Title,
+			&i.Body,
+			&i.Link,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.Error,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueWebhookDeliveries = `-- name: ListDueWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.response_body, webhook_deliveries.error, webhook_deliveries.created_at, webhook_deliveries.updated_at, webhooks.url, webhooks.secret
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= ?1
+ORDER BY webhook_deliveries.next_attempt_at
+LIMIT ?2
+`
+
+type ListDueWebhookDeliveriesParams struct {
+	Now   time.Time `db:"now" json:"now"`
+	Limit int64     `db:"limit" json:"limit"`
+}
+
+type ListDueWebhookDeliveriesRow struct {
+	WebhookDelivery WebhookDelivery `db:"webhook_delivery" json:"webhook_delivery"`
+	Url             string          `db:"url" json:"url"`
+	Secret          string          `db:"secret" json:"secret"`
+}
+
+func (q *Queries) ListDueWebhookDeliveries(ctx context.Context, arg ListDueWebhookDeliveriesParams) ([]ListDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueWebhookDeliveries, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i ListDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.WebhookDelivery.ID,
+			&i.WebhookDelivery.WebhookID,
+			&i.WebhookDelivery.Event,
+			&i.WebhookDelivery.Payload,
+			&i.WebhookDelivery.Status,
+			&i.WebhookDelivery.Attempts,
+			&i.WebhookDelivery.NextAttemptAt,
+			&i.WebhookDelivery.ResponseStatus,
+			&i.WebhookDelivery.ResponseBody,
+			&i.WebhookDelivery.Error,
+			&i.WebhookDelivery.CreatedAt,
+			&i.WebhookDelivery.UpdatedAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlags = `-- name: ListFeatureFlags :many
+SELECT id, name, description, enabled, rollout_percentage, roles, user_ids, created_at, updated_at FROM feature_flags
+WHERE CAST(?1 AS TEXT) = ''
+   OR name LIKE '%' || ?1 || '%'
+   OR description LIKE '%' || ?1 || '%'
+ORDER BY name
+LIMIT ?3 OFFSET ?2
+`
+
+type ListFeatureFlagsParams struct {
+	Search string `db:"search" json:"search"`
+	Offset int64  `db:"offset" json:"offset"`
+	Limit  int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListFeatureFlags(ctx context.Context, arg ListFeatureFlagsParams) ([]FeatureFlag, error) {
+	rows, err := q.db.QueryContext(ctx, listFeatureFlags, arg.Search, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeatureFlag
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Enabled,
+			&i.RolloutPercentage,
+			&i.Roles,
+			&i.UserIds,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlagsUpdatedSince = `-- name: ListFeatureFlagsUpdatedSince :many
+SELECT id, name, description, enabled, rollout_percentage, roles, user_ids, created_at, updated_at FROM feature_flags
+WHERE updated_at >= CAST(?1 AS TEXT)
+ORDER BY id
+`
+
+func (q *Queries) ListFeatureFlagsUpdatedSince(ctx context.Context, since string) ([]FeatureFlag, error) {
+	rows, err := q.db.QueryContext(ctx, listFeatureFlagsUpdatedSince, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeatureFlag
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Enabled,
+			&i.RolloutPercentage,
+			&i.Roles,
+			&i.UserIds,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT id, user_id, type, title, body, link, read_at, created_at FROM notifications
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListNotificationsParams struct {
+	UserID int64 `db:"user_id" json:"user_id"`
+	Limit  int64 `db:"limit" json:"limit"`
+	Offset int64 `db:"offset" json:"offset"`
+}
+
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, listNotifications, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.Title,
+			&i.Body,
+			&i.Link,
+			&i.ReadAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPushSubscriptionsByUser = `-- name: ListPushSubscriptionsByUser :many
+SELECT id, user_id, endpoint, p256dh, auth, created_at FROM push_subscriptions
+WHERE user_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListPushSubscriptionsByUser(ctx context.Context, userID int64) ([]PushSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listPushSubscriptionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PushSubscription
+	for rows.Next() {
+		var i PushSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Endpoint,
+			&i.P256dh,
+			&i.Auth,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRolesForUsers = `-- name: ListRolesForUsers :many
+SELECT user_id, role FROM user_roles
+WHERE user_id IN (/*SLICE:user_ids*/?)
+ORDER BY user_id, role
+`
+
+type ListRolesForUsersRow struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Role   string `db:"role" json:"role"`
+}
+
+func (q *Queries) ListRolesForUsers(ctx context.Context, userIds []int64) ([]ListRolesForUsersRow, error) {
+	query := listRolesForUsers
+	var queryParams []interface{}
+	if len(userIds) > 0 {
+		for _, v := range userIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:user_ids*/?", strings.Repeat(",?", len(userIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:user_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRolesForUsersRow
+	for rows.Next() {
+		var i ListRolesForUsersRow
+		if err := rows.Scan(&i.UserID, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsByUser = `-- name: ListSubscriptionsByUser :many
+SELECT id, user_id, stripe_subscription_id, stripe_customer_id, status, price_id, current_period_end, cancel_at_period_end, created_at, updated_at FROM subscriptions
+WHERE user_id = ?
+ORDER BY id DESC
+`
+
+func (q *Queries) ListSubscriptionsByUser(ctx context.Context, userID int64) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.StripeSubscriptionID,
+			&i.StripeCustomerID,
+			&i.Status,
+			&i.PriceID,
+			&i.CurrentPeriodEnd,
+			&i.CancelAtPeriodEnd,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserRoles = `-- name: ListUserRoles :many
+SELECT role FROM user_roles
+WHERE user_id = ?
+ORDER BY role
+`
+
+func (q *Queries) ListUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, email, password, created_at, updated_at FROM users
+WHERE CAST(?1 AS TEXT) = ''
+   OR username LIKE '%' || ?1 || '%'
+   OR email LIKE '%' || ?1 || '%'
+ORDER BY id DESC
+LIMIT ?3 OFFSET ?2
+`
+
+type ListUsersParams struct {
+	Search string `db:"search" json:"search"`
+	Offset int64  `db:"offset" json:"offset"`
+	Limit  int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Search, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Password,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersUpdatedSince = `-- name: ListUsersUpdatedSince :many
+SELECT id, username, email, password, created_at, updated_at FROM users
+WHERE updated_at >= CAST(?1 AS TEXT)
+ORDER BY id
+`
+
+func (q *Queries) ListUsersUpdatedSince(ctx context.Context, since string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersUpdatedSince, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Password,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, response_body, error, created_at, updated_at FROM webhook_deliveries
+WHERE webhook_id = ?
+ORDER BY id DESC
+LIMIT ? OFFSET ?
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int64 `db:"webhook_id" json:"webhook_id"`
+	Limit     int64 `db:"limit" json:"limit"`
+	Offset    int64 `db:"offset" json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.Error,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE CAST(?1 AS TEXT) = ''
+   OR url LIKE '%' || ?1 || '%'
+   OR events LIKE '%' || ?1 || '%'
+ORDER BY id DESC
+LIMIT ?3 OFFSET ?2
+`
+
+type ListWebhooksParams struct {
+	Search string `db:"search" json:"search"`
+	Offset int64  `db:"offset" json:"offset"`
+	Limit  int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooks, arg.Search, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	return err
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+WHERE id = ? AND user_id = ?
+`
+
+type MarkNotificationReadParams struct {
+	ID     int64 `db:"id" json:"id"`
+	UserID int64 `db:"user_id" json:"user_id"`
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markNotificationRead, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retryWebhookDelivery = `-- name: RetryWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type RetryWebhookDeliveryParams struct {
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	ID            int64     `db:"id" json:"id"`
+}
+
+func (q *Queries) RetryWebhookDelivery(ctx context.Context, arg RetryWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retryWebhookDelivery, arg.NextAttemptAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRole = `-- name: RevokeUserRole :exec
+DELETE FROM user_roles
+WHERE user_id = ? AND role = ?
+`
+
+type RevokeUserRoleParams struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Role   string `db:"role" json:"role"`
+}
+
+func (q *Queries) RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRole, arg.UserID, arg.Role)
+	return err
+}
+
+const setCacheItem = `-- name: SetCacheItem :exec
+INSERT INTO cache_items (key, value, expires_at)
+VALUES (?, ?, ?)
+ON CONFLICT (key) DO UPDATE
+SET value = excluded.value, expires_at = excluded.expires_at
+`
+
+type SetCacheItemParams struct {
+	Key       string       `db:"key" json:"key"`
+	Value     []byte       `db:"value" json:"value"`
+	ExpiresAt sql.NullTime `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) SetCacheItem(ctx context.Context, arg SetCacheItemParams) error {
+	_, err := q.db.ExecContext(ctx, setCacheItem, arg.Key, arg.Value, arg.ExpiresAt)
+	return err
+}
+
+const setCacheItemExpiration = `-- name: SetCacheItemExpiration :execrows
+UPDATE cache_items
+SET expires_at = ?1
+WHERE key = ?2 AND (expires_at IS NULL OR expires_at > ?3)
+`
+
+type SetCacheItemExpirationParams struct {
+	ExpiresAt sql.NullTime `db:"expires_at" json:"expires_at"`
+	Key       string       `db:"key" json:"key"`
+	Now       sql.NullTime `db:"now" json:"now"`
+}
+
+func (q *Queries) SetCacheItemExpiration(ctx context.Context, arg SetCacheItemExpirationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCacheItemExpiration, arg.ExpiresAt, arg.Key, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCronLastSuccess = `-- name: SetCronLastSuccess :exec
+INSERT INTO cron_runs (name, last_success_at)
+VALUES (?, ?)
+ON CONFLICT (name) DO UPDATE
+SET last_success_at = excluded.last_success_at
+`
+
+type SetCronLastSuccessParams struct {
+	Name          string    `db:"name" json:"name"`
+	LastSuccessAt time.Time `db:"last_success_at" json:"last_success_at"`
+}
+
+func (q *Queries) SetCronLastSuccess(ctx context.Context, arg SetCronLastSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, setCronLastSuccess, arg.Name, arg.LastSuccessAt)
+	return err
+}
+
+const takeOverCronLease = `-- name: TakeOverCronLease :execrows
+UPDATE cron_leases
+SET holder = ?1, expires_at = ?2
+WHERE name = ?3 AND (expires_at <= ?4 OR holder = ?1)
+`
+
+type TakeOverCronLeaseParams struct {
+	Holder    string    `db:"holder" json:"holder"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	Name      string    `db:"name" json:"name"`
+	Now       time.Time `db:"now" json:"now"`
+}
+
+func (q *Queries) TakeOverCronLease(ctx context.Context, arg TakeOverCronLeaseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, takeOverCronLease,
+		arg.Holder,
+		arg.ExpiresAt,
+		arg.Name,
+		arg.Now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeatureFlag = `-- name: UpdateFeatureFlag :exec
+UPDATE feature_flags
+SET name = ?, description = ?, enabled = ?, rollout_percentage = ?, roles = ?, user_ids = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateFeatureFlagParams struct {
+	Name              string `db:"name" json:"name"`
+	Description       string `db:"description" json:"description"`
+	Enabled           bool   `db:"enabled" json:"enabled"`
+	RolloutPercentage int64  `db:"rollout_percentage" json:"rollout_percentage"`
+	Roles             string `db:"roles" json:"roles"`
+	UserIds           string `db:"user_ids" json:"user_ids"`
+	ID                int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateFeatureFlag(ctx context.Context, arg UpdateFeatureFlagParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeatureFlag,
+		arg.Name,
+		arg.Description,
+		arg.Enabled,
+		arg.RolloutPercentage,
+		arg.Roles,
+		arg.UserIds,
+		arg.ID,
+	)
+	return err
+}
+
+const updateOutboundMessage = `-- name: UpdateOutboundMessage :exec
+UPDATE outbound_messages
+SET status = ?, attempts = ?, next_attempt_at = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateOutboundMessageParams struct {
+	Status        string    `db:"status" json:"status"`
+	Attempts      int64     `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	Error         string    `db:"error" json:"error"`
+	ID            int64     `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateOutboundMessage(ctx context.Context, arg UpdateOutboundMessageParams) error {
+	_, err := q.db.ExecContext(ctx, updateOutboundMessage,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :exec
+UPDATE users
+SET username = ?, email = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateUserParams struct {
+	Username string `db:"username" json:"username"`
+	Email    string `db:"email" json:"email"`
+	ID       int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.ExecContext(ctx, updateUser, arg.Username, arg.Email, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	Password string `db:"password" json:"password"`
+	ID       int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.Password, arg.ID)
+	return err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :exec
+UPDATE webhooks
+SET url = ?, secret = ?, events = ?, active = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateWebhookParams struct {
+	Url    string `db:"url" json:"url"`
+	Secret string `db:"secret" json:"secret"`
+	Events string `db:"events" json:"events"`
+	Active bool   `db:"active" json:"active"`
+	ID     int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.Active,
+		arg.ID,
+	)
+	return err
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, response_body = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status         string    `db:"status" json:"status"`
+	Attempts       int64     `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus int64     `db:"response_status" json:"response_status"`
+	ResponseBody   string    `db:"response_body" json:"response_body"`
+	Error          string    `db:"error" json:"error"`
+	ID             int64     `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const updateWebhookDeliveryPayload = `-- name: UpdateWebhookDeliveryPayload :exec
+UPDATE webhook_deliveries
+SET payload = ?
+WHERE id = ?
+`
+
+type UpdateWebhookDeliveryPayloadParams struct {
+	Payload string `db:"payload" json:"payload"`
+	ID      int64  `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDeliveryPayload(ctx context.Context, arg UpdateWebhookDeliveryPayloadParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDeliveryPayload, arg.Payload, arg.ID)
+	return err
+}
+
+const upsertPushSubscription = `-- name: UpsertPushSubscription :one
+INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (endpoint) DO UPDATE SET user_id = excluded.user_id, p256dh = excluded.p256dh, auth = excluded.auth
+RETURNING id, user_id, endpoint, p256dh, auth, created_at
+`
+
+type UpsertPushSubscriptionParams struct {
+	UserID   int64  `db:"user_id" json:"user_id"`
+	Endpoint string `db:"endpoint" json:"endpoint"`
+	P256dh   string `db:"p256dh" json:"p256dh"`
+	Auth     string `db:"auth" json:"auth"`
+}
+
+func (q *Queries) UpsertPushSubscription(ctx context.Context, arg UpsertPushSubscriptionParams) (PushSubscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertPushSubscription,
+		arg.UserID,
+		arg.Endpoint,
+		arg.P256dh,
+		arg.Auth,
+	)
+	var i PushSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Endpoint,
+		&i.P256dh,
+		&i.Auth,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertSubscription = `-- name: UpsertSubscription :one
+INSERT INTO subscriptions (user_id, stripe_subscription_id, stripe_customer_id, status, price_id, current_period_end, cancel_at_period_end)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (stripe_subscription_id) DO UPDATE
+SET status = excluded.status,
+    price_id = excluded.price_id,
+    current_period_end = excluded.current_period_end,
+    cancel_at_period_end = excluded.cancel_at_period_end,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, user_id, stripe_subscription_id, stripe_customer_id, status, price_id, current_period_end, cancel_at_period_end, created_at, updated_at
+`
+
+type UpsertSubscriptionParams struct {
+	UserID               int64     `db:"user_id" json:"user_id"`
+	StripeSubscriptionID string    `db:"stripe_subscription_id" json:"stripe_subscription_id"`
+	StripeCustomerID     string    `db:"stripe_customer_id" json:"stripe_customer_id"`
+	Status               string    `db:"status" json:"status"`
+	PriceID              string    `db:"price_id" json:"price_id"`
+	CurrentPeriodEnd     time.Time `db:"current_period_end" json:"current_period_end"`
+	CancelAtPeriodEnd    bool      `db:"cancel_at_period_end" json:"cancel_at_period_end"`
+}
+
+func (q *Queries) UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertSubscription,
+		arg.UserID,
+		arg.StripeSubscriptionID,
+		arg.StripeCustomerID,
+		arg.Status,
+		arg.PriceID,
+		arg.CurrentPeriodEnd,
+		arg.CancelAtPeriodEnd,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.StripeSubscriptionID,
+		&i.StripeCustomerID,
+		&i.Status,
+		&i.PriceID,
+		&i.CurrentPeriodEnd,
+		&i.CancelAtPeriodEnd,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
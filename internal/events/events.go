@@ -0,0 +1,236 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+/*
+   Package events provides an in-process event bus so modules can react to domain events
+   without depending on each other.
+
+   How to use:
+   1. Define an event type implementing EventName - see types.go for the built-in events
+   2. Subscribe handlers for the event type with Subscribe
+   3. Publish events with Publish
+
+   Example basic usage:
+       bus := events.New(events.Logging(logger), events.Recovery())
+
+       events.Subscribe(bus, func(ctx context.Context, e events.UserRegistered) error {
+           return sendWelcomeMail(ctx, e.Email)
+       }, events.Async())
+
+       err := bus.Publish(ctx, events.UserRegistered{UserID: 1, Username: "bob", Email: "bob@example.com"})
+
+   Example getting the bus in a handler:
+       bus := container.MustGet[*events.Bus](c, "events")
+
+   Example reacting to every event - e.g. for audit logging:
+       bus.SubscribeAll(func(ctx context.Context, e events.Event) error {
+           logger.Info("Event", "name", e.EventName())
+           return nil
+       })
+
+   Notes:
+   - Synchronous handlers run in subscription order before Publish returns, their errors are joined
+   - Async handlers run in their own goroutine with a context that is never cancelled,
+     their errors are only visible to middleware - use Logging to record them
+   - Middleware wraps every handler call, the first middleware is the outermost
+   - Call Wait during shutdown to let running async handlers finish, or Stop to give up when a context
+     is done - the container calls Stop on shutdown
+*/
+
+// Event is a domain event published on the bus
+type Event interface {
+	EventName() string
+}
+
+// Handler handles a published event
+type Handler func(ctx context.Context, event Event) error
+
+// Middleware wraps handler calls - e.g. for logging or recovery
+type Middleware func(next Handler) Handler
+
+// Option configures a subscription
+type Option func(*subscription)
+
+// Async delivers events to the handler in a separate goroutine
+func Async() Option {
+	return func(s *subscription) {
+		s.async = true
+	}
+}
+
+// subscription is a handler subscribed to one event type or to all events
+type subscription struct {
+	id      uint64
+	handler Handler
+	async   bool
+}
+
+// Bus dispatches published events to the subscribed handlers
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[reflect.Type][]*subscription
+	all         []*subscription
+	middleware  []Middleware
+	nextID      uint64
+	running     sync.WaitGroup
+}
+
+// New creates an event bus with the given middleware
+func New(middleware ...Middleware) *Bus {
+	return &Bus{
+		subscribers: make(map[reflect.Type][]*subscription),
+		middleware:  middleware,
+	}
+}
+
+// Use adds middleware to the bus
+func (b *Bus) Use(middleware ...Middleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.middleware = append(b.middleware, middleware...)
+}
+
+// Subscribe registers a handler for events of type E and returns a function removing it
+func Subscribe[E Event](b *Bus, handler func(ctx context.Context, event E) error, opts ...Option) func() {
+	eventType := reflect.TypeFor[E]()
+	sub := b.newSubscription(func(ctx context.Context, event Event) error {
+		return handler(ctx, event.(E))
+	}, opts)
+
+	b.mu.Lock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subscribers[eventType] = removeSubscription(b.subscribers[eventType], sub.id)
+	}
+}
+
+// SubscribeAll registers a handler for every event and returns a function removing it
+func (b *Bus) SubscribeAll(handler Handler, opts ...Option) func() {
+	sub := b.newSubscription(handler, opts)
+
+	b.mu.Lock()
+	b.all = append(b.all, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.all = removeSubscription(b.all, sub.id)
+	}
+}
+
+// Publish delivers the event to its subscribers and the handlers subscribed to all events
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	subs := append(append([]*subscription{}, b.subscribers[reflect.TypeOf(event)]...), b.all...)
+	middleware := b.middleware
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		handler := sub.handler
+		for i := len(middleware) - 1; i >= 0; i-- {
+			handler = middleware[i](handler)
+		}
+
+		if sub.async {
+			b.running.Add(1)
+			go func() {
+				defer b.running.Done()
+				handler(context.WithoutCancel(ctx), event)
+			}()
+			continue
+		}
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until all running async handlers have returned
+func (b *Bus) Wait() {
+	b.running.Wait()
+}
+
+// Stop waits for running async handlers to return until ctx is done
+func (b *Bus) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("async handlers still running: %w", ctx.Err())
+	}
+}
+
+// newSubscription creates a subscription with a unique id
+func (b *Bus) newSubscription(handler Handler, opts []Option) *subscription {
+	b.mu.Lock()
+	b.nextID++
+	sub := &subscription{id: b.nextID, handler: handler}
+	b.mu.Unlock()
+
+	for _, opt := range opts {
+		opt(sub)
+	}
+	return sub
+}
+
+// removeSubscription returns a copy of subs without the subscription, publishers may still hold the old slice
+func removeSubscription(subs []*subscription, id uint64) []*subscription {
+	kept := make([]*subscription, 0, len(subs))
+	for _, sub := range subs {
+		if sub.id != id {
+			kept = append(kept, sub)
+		}
+	}
+	return kept
+}
+
+// Logging logs every handled event, failures are logged as errors
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+			if err != nil {
+				logger.ErrorContext(ctx, "Event handler failed", "event", event.EventName(), "duration", time.Since(start), "error", err)
+			} else {
+				logger.DebugContext(ctx, "Event handled", "event", event.EventName(), "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Recovery turns handler panics into errors so one handler can't take down the publisher
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event Event) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("events: handler for %s panicked: %v", event.EventName(), r)
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
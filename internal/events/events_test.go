@@ -0,0 +1,134 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublishSync(t *testing.T) {
+	bus := New()
+
+	var got []string
+	Subscribe(bus, func(ctx context.Context, e UserRegistered) error {
+		got = append(got, "first:"+e.Username)
+		return nil
+	})
+	Subscribe(bus, func(ctx context.Context, e UserRegistered) error {
+		got = append(got, "second:"+e.Username)
+		return nil
+	})
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error {
+		got = append(got, "message")
+		return nil
+	})
+
+	if err := bus.Publish(context.Background(), UserRegistered{Username: "bob"}); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if strings.Join(got, ",") != "first:bob,second:bob" {
+		t.Errorf("handlers called = %v", got)
+	}
+}
+
+func TestPublishJoinsErrors(t *testing.T) {
+	bus := New()
+	errA := errors.New("a")
+	errB := errors.New("b")
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error { return errA })
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error { return errB })
+
+	err := bus.Publish(context.Background(), MessagePosted{})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Publish error = %v, want both handler errors", err)
+	}
+}
+
+func TestPublishAsync(t *testing.T) {
+	bus := New()
+	var calls atomic.Int32
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error {
+		calls.Add(1)
+		return errors.New("ignored")
+	}, Async())
+
+	if err := bus.Publish(context.Background(), MessagePosted{}); err != nil {
+		t.Errorf("Publish returned error for async handler: %v", err)
+	}
+	bus.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("async handler called %d times, want 1", calls.Load())
+	}
+}
+
+func TestStop(t *testing.T) {
+	bus := New()
+	release := make(chan struct{})
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error {
+		<-release
+		return nil
+	}, Async())
+	bus.Publish(context.Background(), MessagePosted{})
+
+	// Stop gives up when the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop error = %v, want context.DeadlineExceeded", err)
+	}
+
+	close(release)
+	if err := bus.Stop(context.Background()); err != nil {
+		t.Errorf("Stop returned error after handlers finished: %v", err)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := New()
+	var calls int
+	unsubscribe := Subscribe(bus, func(ctx context.Context, e MessagePosted) error {
+		calls++
+		return nil
+	})
+	unsubscribeAll := bus.SubscribeAll(func(ctx context.Context, e Event) error {
+		calls++
+		return nil
+	})
+
+	bus.Publish(context.Background(), MessagePosted{})
+	unsubscribe()
+	unsubscribeAll()
+	bus.Publish(context.Background(), MessagePosted{})
+
+	if calls != 2 {
+		t.Errorf("handlers called %d times, want 2", calls)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, e Event) error {
+				order = append(order, name)
+				return next(ctx, e)
+			}
+		}
+	}
+	bus := New(trace("outer"), Recovery())
+	bus.Use(trace("inner"))
+	Subscribe(bus, func(ctx context.Context, e MessagePosted) error {
+		panic("boom")
+	})
+
+	err := bus.Publish(context.Background(), MessagePosted{})
+	if err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("Publish error = %v, want recovered panic", err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("middleware order = %v", order)
+	}
+}
@@ -0,0 +1,56 @@
+package events
+
+import "encoding/json"
+
+// Domain events published by the application - add your own here
+
+// UserRegistered is published after a user account is created
+type UserRegistered struct {
+	UserID   int64
+	Username string
+	Email    string
+}
+
+// EventName returns the event name
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// MessagePosted is published after a message is broadcast to the websocket clients
+type MessagePosted struct {
+	Message string
+}
+
+// EventName returns the event name
+func (MessagePosted) EventName() string { return "message.posted" }
+
+// FlagChanged is published after a feature flag is created, updated or deleted
+type FlagChanged struct {
+	Name    string
+	Enabled bool
+	Deleted bool
+}
+
+// EventName returns the event name
+func (FlagChanged) EventName() string { return "flag.changed" }
+
+// StripeEvent is published for every Stripe webhook event with a valid signature
+type StripeEvent struct {
+	ID   string
+	Type string
+	// Data is the event's data.object, e.g. the subscription or invoice
+	Data json.RawMessage
+}
+
+// EventName returns the event name
+func (StripeEvent) EventName() string { return "stripe.event" }
+
+// SubscriptionChanged is published after a user's subscription is created, updated or canceled
+type SubscriptionChanged struct {
+	UserID         int64
+	SubscriptionID string
+	Status         string
+	PriceID        string
+	Active         bool
+}
+
+// EventName returns the event name
+func (SubscriptionChanged) EventName() string { return "subscription.changed" }
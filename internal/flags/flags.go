@@ -0,0 +1,171 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"mookie/internal/auth"
+	"mookie/internal/cache"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+   Package flags provides feature flags with percentage rollouts and per-user and per-role targeting.
+   Flags are loaded through a lookup function (usually backed by the feature_flags table) and cached.
+
+   How to use:
+   1. Create a service with a lookup function and a cache
+   2. Store the service in the request context - see middleware.Flags
+   3. Check flags with Enabled
+
+   Example basic usage:
+       service := flags.New(lookupFlag, cache.NewMemoryCache(), 30*time.Second)
+
+       if flags.Enabled(r.Context(), "new-ui") {
+           pages.NewFront().Render(r.Context(), w)
+           return
+       }
+
+   Example checking a flag for a specific user outside a request:
+       enabled := service.EnabledFor(ctx, "new-ui", user)
+
+   A flag is enabled for a user when:
+   1. The flag exists and is enabled - this is the global kill switch
+   2. The user is listed in UserIDs - always on, ignoring roles and rollout
+   3. The user has one of Roles, if any roles are set
+   4. The user falls within the rollout Percentage
+
+   Notes:
+   - Unknown flags are disabled
+   - Rollouts hash the flag name with the user ID, so users keep their bucket and buckets differ between flags
+   - Anonymous users are only included in 100% rollouts
+   - Flags are cached for the TTL, call Invalidate after changing a flag
+*/
+
+// ErrNotFound should be returned by the lookup when the flag doesn't exist
+var ErrNotFound = errors.New("flags: flag not found")
+
+// Flag is a feature flag and its targeting rules
+type Flag struct {
+	Name    string
+	Enabled bool
+	// Percentage of users the flag is rolled out to, from 0 to 100
+	Percentage int
+	Roles      []string
+	UserIDs    []string
+}
+
+// EnabledFor reports whether the flag is enabled for the user, user may be nil for anonymous requests
+func (f *Flag) EnabledFor(user *auth.AuthUser) bool {
+	if !f.Enabled {
+		return false
+	}
+	if user != nil && slices.Contains(f.UserIDs, user.ID) {
+		return true
+	}
+	if len(f.Roles) > 0 && (user == nil || !slices.ContainsFunc(f.Roles, user.HasRole)) {
+		return false
+	}
+	if f.Percentage >= 100 {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	return bucket(f.Name, user.ID) < f.Percentage
+}
+
+// bucket returns the stable rollout bucket of the user for the flag, from 0 to 99
+func bucket(name, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + userID))
+	return int(h.Sum32() % 100)
+}
+
+// Lookup loads a flag by name, returning ErrNotFound if it doesn't exist
+type Lookup func(ctx context.Context, name string) (*Flag, error)
+
+// Service checks flags loaded through the lookup, caching them for the TTL
+type Service struct {
+	lookup Lookup
+	cache  cache.Cache
+	ttl    time.Duration
+}
+
+// New creates a flag service
+func New(lookup Lookup, c cache.Cache, ttl time.Duration) *Service {
+	return &Service{lookup: lookup, cache: c, ttl: ttl}
+}
+
+// Get returns the flag, unknown flags are returned disabled
+func (s *Service) Get(ctx context.Context, name string) (*Flag, error) {
+	if item, err := s.cache.Get(cacheKey(name)); err == nil {
+		return item.Value.(*Flag), nil
+	}
+
+	flag, err := s.lookup(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		// Cache unknown flags too so checks for them don't hit the lookup every time
+		flag, err = &Flag{Name: name}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(cacheKey(name), flag, s.ttl)
+	return flag, nil
+}
+
+// EnabledFor reports whether the flag is enabled for the user, lookup errors disable the flag
+func (s *Service) EnabledFor(ctx context.Context, name string, user *auth.AuthUser) bool {
+	flag, err := s.Get(ctx, name)
+	if err != nil {
+		return false
+	}
+	return flag.EnabledFor(user)
+}
+
+// Invalidate drops the cached flag so the next check loads it again
+func (s *Service) Invalidate(name string) {
+	s.cache.Delete(cacheKey(name))
+}
+
+// cacheKey returns the cache key of a flag
+func cacheKey(name string) string {
+	return "flags:" + name
+}
+
+// SplitList splits a comma separated list of roles or user IDs, dropping blanks
+func SplitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// JoinList joins roles or user IDs into the comma separated form stored in the database
+func JoinList(list []string) string {
+	return strings.Join(list, ",")
+}
+
+// contextKey is an unexported type for context keys defined in this package
+type contextKey struct{}
+
+// WithService returns a copy of ctx carrying the flag service
+func WithService(ctx context.Context, s *Service) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// Enabled reports whether the flag is enabled for the authenticated user in ctx
+// It returns false if ctx carries no flag service
+func Enabled(ctx context.Context, name string) bool {
+	s, ok := ctx.Value(contextKey{}).(*Service)
+	if !ok {
+		return false
+	}
+	return s.EnabledFor(ctx, name, auth.UserFromContext(ctx))
+}
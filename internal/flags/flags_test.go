@@ -0,0 +1,102 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"mookie/internal/auth"
+	"mookie/internal/cache"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFlagEnabledFor(t *testing.T) {
+	admin := &auth.AuthUser{ID: "1", Roles: []string{"admin"}}
+	member := &auth.AuthUser{ID: "2"}
+
+	tests := []struct {
+		name string
+		flag Flag
+		user *auth.AuthUser
+		want bool
+	}{
+		{name: "disabled", flag: Flag{Percentage: 100}, user: admin, want: false},
+		{name: "enabled for everyone", flag: Flag{Enabled: true, Percentage: 100}, user: nil, want: true},
+		{name: "role match", flag: Flag{Enabled: true, Percentage: 100, Roles: []string{"admin"}}, user: admin, want: true},
+		{name: "role mismatch", flag: Flag{Enabled: true, Percentage: 100, Roles: []string{"admin"}}, user: member, want: false},
+		{name: "role anonymous", flag: Flag{Enabled: true, Percentage: 100, Roles: []string{"admin"}}, user: nil, want: false},
+		{name: "user listed", flag: Flag{Enabled: true, Roles: []string{"admin"}, UserIDs: []string{"2"}}, user: member, want: true},
+		{name: "zero rollout", flag: Flag{Enabled: true}, user: member, want: false},
+		{name: "partial rollout anonymous", flag: Flag{Enabled: true, Percentage: 50}, user: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.flag.EnabledFor(tt.user); got != tt.want {
+				t.Errorf("EnabledFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutPercentage(t *testing.T) {
+	flag := Flag{Name: "new-ui", Enabled: true, Percentage: 30}
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		user := &auth.AuthUser{ID: strconv.Itoa(i)}
+		if flag.EnabledFor(user) {
+			enabled++
+		}
+		if flag.EnabledFor(user) != flag.EnabledFor(user) {
+			t.Fatal("rollout is not stable for the same user")
+		}
+	}
+	if enabled < 250 || enabled > 350 {
+		t.Errorf("30%% rollout enabled %d of 1000 users", enabled)
+	}
+}
+
+func TestServiceCachesAndInvalidates(t *testing.T) {
+	lookups := 0
+	current := &Flag{Name: "new-ui", Enabled: true, Percentage: 100}
+	service := New(func(ctx context.Context, name string) (*Flag, error) {
+		lookups++
+		if name != "new-ui" {
+			return nil, ErrNotFound
+		}
+		return current, nil
+	}, cache.NewMemoryCache(), time.Minute)
+	ctx := WithService(context.Background(), service)
+
+	if !Enabled(ctx, "new-ui") || !Enabled(ctx, "new-ui") {
+		t.Error("expected new-ui to be enabled")
+	}
+	if lookups != 1 {
+		t.Errorf("lookups = %d, want 1 with caching", lookups)
+	}
+
+	current = &Flag{Name: "new-ui"}
+	service.Invalidate("new-ui")
+	if Enabled(ctx, "new-ui") {
+		t.Error("expected new-ui to be disabled after invalidation")
+	}
+
+	if Enabled(ctx, "unknown") {
+		t.Error("expected unknown flag to be disabled")
+	}
+}
+
+func TestEnabledWithoutService(t *testing.T) {
+	if Enabled(context.Background(), "new-ui") {
+		t.Error("expected false without a service in the context")
+	}
+}
+
+func TestServiceLookupError(t *testing.T) {
+	service := New(func(ctx context.Context, name string) (*Flag, error) {
+		return nil, errors.New("database down")
+	}, cache.NewMemoryCache(), time.Minute)
+	if service.EnabledFor(context.Background(), "new-ui", nil) {
+		t.Error("expected lookup errors to disable the flag")
+	}
+}
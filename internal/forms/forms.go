@@ -0,0 +1,168 @@
+package forms
+
+import (
+	"context"
+	"mookie/internal/csrf"
+	"mookie/internal/validate"
+	"net/http"
+	"net/url"
+)
+
+/*
+   Package forms bridges submitted form data and validation results with templ.
+   It provides templ components for labeled inputs that re-render the submitted
+   values, display field errors and inject the CSRF token.
+
+   How to use:
+   1. Create a Form from the request with New (old input + CSRF token)
+   2. Validate with Validate, or add errors with AddError or by assigning Errors
+   3. Pass the Form to a templ page and render fields with the components
+
+   Example in a handler:
+       func CreatePost(w http.ResponseWriter, r *http.Request) {
+           f := forms.New(r)
+           if f.Value("title") == "" {
+               f.AddError("title", "Title is required")
+           }
+           if f.HasErrors() {
+               w.WriteHeader(http.StatusUnprocessableEntity)
+               pages.NewPost(f).Render(r.Context(), w)
+               return
+           }
+           // ...
+       }
+
+   Example validating with the shared validator rules:
+       v := container.MustGet[*validate.Validator](c, "validator")
+       if !f.Validate(r.Context(), v, map[string]string{"title": "required,max=100", "email": "omitempty,email"}) {
+           w.WriteHeader(http.StatusUnprocessableEntity)
+           pages.NewPost(f).Render(r.Context(), w)
+           return
+       }
+
+   Example in a templ page:
+       templ NewPost(f *forms.Form) {
+           <form method="POST" action="/posts">
+               @forms.CSRF(f)
+               @forms.Input(f, forms.Field{Name: "title", Label: "Title", Required: true})
+               @forms.TextArea(f, forms.Field{Name: "body", Label: "Body"})
+               <button type="submit">Save</button>
+           </form>
+       }
+
+   Notes:
+   - The CSRF token is only present when the request went through the CSRF middleware
+   - Password inputs never re-render the submitted value
+*/
+
+// Form holds the submitted values, validation errors and CSRF token of a form
+type Form struct {
+	Values    url.Values
+	Errors    map[string]string
+	CSRFToken string
+}
+
+// Field describes a form field rendered by the components
+type Field struct {
+	Name  string
+	Label string
+	// Type is the HTML input type, defaults to text
+	Type        string
+	Placeholder string
+	Required    bool
+}
+
+// New creates a Form from the submitted request values
+func New(r *http.Request) *Form {
+	r.ParseForm()
+	return &Form{
+		Values:    r.PostForm,
+		Errors:    make(map[string]string),
+		CSRFToken: csrf.Token(r.Context()),
+	}
+}
+
+// Empty creates a Form without submitted values, e.g. for rendering a blank form
+func Empty(r *http.Request) *Form {
+	return &Form{
+		Values:    url.Values{},
+		Errors:    make(map[string]string),
+		CSRFToken: csrf.Token(r.Context()),
+	}
+}
+
+// Value returns the submitted value of a field
+func (f *Form) Value(name string) string {
+	if f.Values == nil {
+		return ""
+	}
+	return f.Values.Get(name)
+}
+
+// Set sets the value of a field, e.g. to pre-fill an edit form
+func (f *Form) Set(name, value string) {
+	if f.Values == nil {
+		f.Values = url.Values{}
+	}
+	f.Values.Set(name, value)
+}
+
+// AddError adds a validation error for a field, keeping the first error per field
+func (f *Form) AddError(name, msg string) {
+	if f.Errors == nil {
+		f.Errors = make(map[string]string)
+	}
+	if _, exists := f.Errors[name]; !exists {
+		f.Errors[name] = msg
+	}
+}
+
+// Error returns the validation error of a field
+func (f *Form) Error(name string) string {
+	return f.Errors[name]
+}
+
+// HasErrors reports whether the form has any validation errors
+func (f *Form) HasErrors() bool {
+	return len(f.Errors) > 0
+}
+
+// inputType returns the HTML input type of a field
+func inputType(field Field) string {
+	if field.Type == "" {
+		return "text"
+	}
+	return field.Type
+}
+
+// inputValue returns the value to re-render, passwords are never echoed back
+// Checkboxes always submit "true", an unchecked checkbox submits nothing
+func inputValue(f *Form, field Field) string {
+	switch field.Type {
+	case "password":
+		return ""
+	case "checkbox":
+		return "true"
+	}
+	return f.Value(field.Name)
+}
+
+// Validate checks the submitted values against the rules keyed by field name, adding the errors to the form
+// It returns true if the form is valid, rule errors (e.g. unknown rules) are added under the empty field name
+// so they still show up in the Errors summary
+func (f *Form) Validate(ctx context.Context, v *validate.Validator, rules map[string]string) bool {
+	values := make(map[string]string, len(rules))
+	for name := range rules {
+		values[name] = f.Value(name)
+	}
+
+	err := v.Values(ctx, values, rules)
+	if errs := validate.As(err); errs != nil {
+		for name, msg := range errs {
+			f.AddError(name, msg)
+		}
+	} else if err != nil {
+		f.AddError("", err.Error())
+	}
+	return !f.HasErrors()
+}
@@ -31,15 +31,33 @@ import (
        logger.Debug("Config loaded", "config", cfg)
        logger.Error("Connection failed", "error", err)
 
+   Example with readable output during development:
+       logger := logger.NewFormat(logger.FormatText, slog.LevelDebug)
+
    Notes:
    - Always writes to stdout
    - Additional writers are optional
    - Nil writers are filtered out
-   - Uses slog's text handler for readable output
+   - New uses slog's JSON handler for log collectors, NewFormat with FormatText slog's text handler
+   - Pass a *slog.LevelVar as the level to change it at runtime, e.g. after a config reload
 */
 
-// New creates a new logger with the given log level and io.writer
-func New(level slog.Level, writers ...io.Writer) *slog.Logger {
+// Format is the output format of a logger
+type Format string
+
+// Output formats
+const (
+	FormatJSON Format = "json"
+	FormatText Format = "text"
+)
+
+// New creates a new JSON logger with the given log level and io.writer
+func New(level slog.Leveler, writers ...io.Writer) *slog.Logger {
+	return NewFormat(FormatJSON, level, writers...)
+}
+
+// NewFormat creates a new logger writing in the given format, unknown formats write JSON
+func NewFormat(format Format, level slog.Leveler, writers ...io.Writer) *slog.Logger {
 	// Always include stdout writer
 	validWriters := []io.Writer{os.Stdout}
 
@@ -58,5 +76,8 @@ func New(level slog.Level, writers ...io.Writer) *slog.Logger {
 	}
 
 	// Create new logger
+	if format == FormatText {
+		return slog.New(slog.NewTextHandler(mWriter, opts))
+	}
 	return slog.New(slog.NewJSONHandler(mWriter, opts))
 }
@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// LogTransport logs messages instead of sending them - useful during development
+type LogTransport struct {
+	logger *slog.Logger
+}
+
+// NewLogTransport creates a transport writing messages to the logger
+func NewLogTransport(logger *slog.Logger) *LogTransport {
+	return &LogTransport{logger: logger}
+}
+
+// Send logs the message envelope and text body
+func (t *LogTransport) Send(ctx context.Context, msg *Message) error {
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	attachments := make([]string, len(msg.Attachments))
+	for i, attachment := range msg.Attachments {
+		attachments[i] = attachment.Filename
+	}
+	t.logger.InfoContext(ctx, "Mail sent",
+		"from", msg.From,
+		"to", recipients,
+		"subject", msg.Subject,
+		"text", msg.Text,
+		"html", msg.HTML != "",
+		"attachments", attachments,
+	)
+	return nil
+}
+
+// MaildirTransport writes messages into a maildir - useful to inspect the full messages during development
+type MaildirTransport struct {
+	dir string
+}
+
+// maildirCounter keeps file names unique within the process
+var maildirCounter atomic.Int64
+
+// NewMaildirTransport creates a transport writing to the maildir, creating its folders if needed
+func NewMaildirTransport(dir string) (*MaildirTransport, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &MaildirTransport{dir: dir}, nil
+}
+
+// Send writes the message to tmp and moves it to new once complete
+func (t *MaildirTransport) Send(ctx context.Context, msg *Message) error {
+	if _, err := msg.Recipients(); err != nil {
+		return err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().Unix(), os.Getpid(), maildirCounter.Add(1), hostname)
+	tmp := filepath.Join(t.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.dir, "new", name))
+}
@@ -0,0 +1,319 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+/*
+   Package mail provides email sending with interchangeable transports - SMTP for production,
+   and log or maildir transports for development.
+
+   How to use:
+   1. Create a transport - NewSMTPTransport, NewLogTransport or NewMaildirTransport
+   2. Create a mailer with the transport and the default sender
+   3. Build a message and send it with the mailer
+
+   Example basic usage:
+       mailer := mail.New(mail.NewLogTransport(logger), "mookie <noreply@example.com>")
+
+       msg := mail.NewMessage("Welcome", "user@example.com")
+       msg.Text = "Welcome to mookie"
+       if err := msg.SetHTML(ctx, emails.Welcome(user)); err != nil {
+           return err
+       }
+       msg.Attach("terms.pdf", "application/pdf", terms)
+
+       if err := mailer.Send(ctx, msg); err != nil {
+           logger.Error("Error sending mail", "error", err)
+       }
+
+   Example getting the mailer in a handler:
+       mailer := container.MustGet[*mail.Mailer](c, "mail")
+
+   Notes:
+   - Messages with both Text and HTML are sent as multipart/alternative, so clients pick the best one
+   - Attachments wrap the body in multipart/mixed
+   - The maildir transport writes messages to <dir>/new, open them with any mail client
+   - Messages without a From use the mailer's default sender
+*/
+
+// ErrNoRecipients is returned when sending a message without recipients
+var ErrNoRecipients = errors.New("mail: message has no recipients")
+
+// Transport delivers built messages
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email with text and HTML alternatives and optional attachments
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// NewMessage creates a message with the given subject and recipients
+func NewMessage(subject string, to ...string) *Message {
+	return &Message{Subject: subject, To: to}
+}
+
+// SetHTML renders the templ component into the HTML body
+func (m *Message) SetHTML(ctx context.Context, component templ.Component) error {
+	var buf bytes.Buffer
+	if err := component.Render(ctx, &buf); err != nil {
+		return fmt.Errorf("mail: error rendering html body: %w", err)
+	}
+	m.HTML = buf.String()
+	return nil
+}
+
+// Attach adds an attachment, the content type is guessed from the filename when empty
+func (m *Message) Attach(filename, contentType string, data []byte) {
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	m.Attachments = append(m.Attachments, Attachment{Filename: filename, ContentType: contentType, Data: data})
+}
+
+// AttachFile adds the file at path as an attachment
+func (m *Message) AttachFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m.Attach(filepath.Base(path), "", data)
+	return nil
+}
+
+// Recipients returns the addresses of all To, Cc and Bcc recipients
+func (m *Message) Recipients() ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, address := range list {
+			parsed, err := mail.ParseAddress(address)
+			if err != nil {
+				return nil, fmt.Errorf("mail: invalid recipient %q: %w", address, err)
+			}
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return recipients, nil
+}
+
+// Bytes builds the MIME encoded message, Bcc recipients are left out of the headers
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	header := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Cc", strings.Join(m.Cc, ", "))
+	header("Reply-To", m.ReplyTo)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(m.From))
+	for key, value := range m.Headers {
+		header(textproto.CanonicalMIMEHeaderKey(key), value)
+	}
+	header("MIME-Version", "1.0")
+
+	body := m.bodyPart()
+	if len(m.Attachments) == 0 {
+		if err := body.writeTo(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := []part{body}
+	for _, attachment := range m.Attachments {
+		parts = append(parts, attachmentPart(attachment))
+	}
+	if err := multipartPart("multipart/mixed", parts).writeTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// part is a MIME entity - its headers and a function writing its encoded content
+type part struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer) error
+}
+
+// writeTo writes the headers followed by the content
+func (p part) writeTo(w io.Writer) error {
+	for key, values := range p.header {
+		for _, value := range values {
+			if _, err := fmt.Fprintf(w, "%s: %s\r\n", key, value); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(w, "\r\n"); err != nil {
+		return err
+	}
+	return p.write(w)
+}
+
+// bodyPart returns the text, HTML or alternative body
+func (m *Message) bodyPart() part {
+	switch {
+	case m.HTML == "":
+		return textPart("text/plain", m.Text)
+	case m.Text == "":
+		return textPart("text/html", m.HTML)
+	}
+	// Parts in ascending order of preference
+	return multipartPart("multipart/alternative", []part{textPart("text/plain", m.Text), textPart("text/html", m.HTML)})
+}
+
+// textPart returns a quoted-printable text part
+func textPart(contentType, body string) part {
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		write: func(w io.Writer) error {
+			qp := quotedprintable.NewWriter(w)
+			if _, err := qp.Write([]byte(body)); err != nil {
+				return err
+			}
+			return qp.Close()
+		},
+	}
+}
+
+// attachmentPart returns a base64 encoded attachment part
+func attachmentPart(attachment Attachment) part {
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		},
+		write: func(w io.Writer) error {
+			return writeBase64(w, attachment.Data)
+		},
+	}
+}
+
+// multipartPart returns a multipart entity of the given type containing the parts
+func multipartPart(mediaType string, parts []part) part {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	return part{
+		header: textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType(mediaType, map[string]string{"boundary": boundary})},
+		},
+		write: func(w io.Writer) error {
+			mw := multipart.NewWriter(w)
+			if err := mw.SetBoundary(boundary); err != nil {
+				return err
+			}
+			for _, p := range parts {
+				pw, err := mw.CreatePart(p.header)
+				if err != nil {
+					return err
+				}
+				if err := p.write(pw); err != nil {
+					return err
+				}
+			}
+			return mw.Close()
+		},
+	}
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+// messageID generates a unique Message-ID using the sender's domain
+func messageID(from string) string {
+	domain := "localhost"
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(parsed.Address, "@"); at >= 0 {
+			domain = parsed.Address[at+1:]
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+// Mailer sends messages through a transport with a default sender
+type Mailer struct {
+	transport Transport
+	from      string
+}
+
+// New creates a mailer sending through the transport, from is used when a message has no sender
+func New(transport Transport, from string) *Mailer {
+	return &Mailer{transport: transport, from: from}
+}
+
+// Send validates the message and sends it through the transport
+func (m *Mailer) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = m.from
+	}
+	if _, err := mail.ParseAddress(msg.From); err != nil {
+		return fmt.Errorf("mail: invalid sender %q: %w", msg.From, err)
+	}
+	if _, err := msg.Recipients(); err != nil {
+		return err
+	}
+	return m.transport.Send(ctx, msg)
+}
@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMessageBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         *Message
+		contentType string
+		parts       []string
+	}{
+		{
+			name:        "text only",
+			msg:         &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "Hi", Text: "hello"},
+			contentType: "text/plain",
+		},
+		{
+			name:        "html only",
+			msg:         &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "Hi", HTML: "<p>hello</p>"},
+			contentType: "text/html",
+		},
+		{
+			name:        "text and html",
+			msg:         &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "Hi", Text: "hello", HTML: "<p>hello</p>"},
+			contentType: "multipart/alternative",
+			parts:       []string{"text/plain", "text/html"},
+		},
+		{
+			name: "attachment",
+			msg: &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "Hi", Text: "hello",
+				Attachments: []Attachment{{Filename: "a.txt", ContentType: "text/plain", Data: []byte("file")}}},
+			contentType: "multipart/mixed",
+			parts:       []string{"text/plain", "text/plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.msg.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes returned error: %v", err)
+			}
+			parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+			if err != nil {
+				t.Fatalf("ReadMessage returned error: %v", err)
+			}
+			if got := parsed.Header.Get("Subject"); got != "Hi" {
+				t.Errorf("Subject = %q, want %q", got, "Hi")
+			}
+			mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatalf("invalid Content-Type: %v", err)
+			}
+			if mediaType != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", mediaType, tt.contentType)
+			}
+			if tt.parts == nil {
+				return
+			}
+
+			reader := multipart.NewReader(parsed.Body, params["boundary"])
+			var parts []string
+			for {
+				part, err := reader.NextPart()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("NextPart returned error: %v", err)
+				}
+				partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+				parts = append(parts, partType)
+			}
+			if strings.Join(parts, ",") != strings.Join(tt.parts, ",") {
+				t.Errorf("parts = %v, want %v", parts, tt.parts)
+			}
+		})
+	}
+}
+
+func TestMessageBytesOmitsBcc(t *testing.T) {
+	msg := &Message{From: "a@example.com", To: []string{"b@example.com"}, Bcc: []string{"secret@example.com"}, Text: "hello"}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes returned error: %v", err)
+	}
+	if strings.Contains(string(data), "secret@example.com") {
+		t.Error("Bcc recipient leaked into the message")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	msg := &Message{To: []string{"Bob <b@example.com>"}, Cc: []string{"c@example.com"}, Bcc: []string{"d@example.com"}}
+	got, err := msg.Recipients()
+	if err != nil {
+		t.Fatalf("Recipients returned error: %v", err)
+	}
+	if strings.Join(got, ",") != "b@example.com,c@example.com,d@example.com" {
+		t.Errorf("Recipients = %v", got)
+	}
+
+	if _, err := (&Message{}).Recipients(); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Recipients error = %v, want ErrNoRecipients", err)
+	}
+}
+
+func TestMailerMaildir(t *testing.T) {
+	dir := t.TempDir()
+	transport, err := NewMaildirTransport(dir)
+	if err != nil {
+		t.Fatalf("NewMaildirTransport returned error: %v", err)
+	}
+	mailer := New(transport, "mookie <noreply@example.com>")
+
+	msg := NewMessage("Welcome", "user@example.com")
+	msg.Text = "hello"
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one message in new, got %d (%v)", len(entries), err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	if !strings.Contains(string(data), "From: mookie <noreply@example.com>") {
+		t.Error("default sender was not applied")
+	}
+}
@@ -0,0 +1,121 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// Encryption modes of the SMTP connection
+const (
+	EncryptionNone     = "none"
+	EncryptionSTARTTLS = "starttls"
+	EncryptionTLS      = "tls"
+)
+
+// SMTPConfig defines the SMTP server connection
+type SMTPConfig struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	Encryption string
+}
+
+// SMTPTransport sends messages through an SMTP server
+type SMTPTransport struct {
+	config SMTPConfig
+}
+
+// NewSMTPTransport creates a transport for the SMTP server
+func NewSMTPTransport(config SMTPConfig) (*SMTPTransport, error) {
+	switch config.Encryption {
+	case EncryptionNone, EncryptionSTARTTLS, EncryptionTLS:
+	default:
+		return nil, fmt.Errorf("mail: unknown smtp encryption %q", config.Encryption)
+	}
+	return &SMTPTransport{config: config}, nil
+}
+
+// Send delivers the message over a new SMTP connection
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("mail: invalid sender %q: %w", msg.From, err)
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	client, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if t.config.Username != "" {
+		auth := smtp.PlainAuth("", t.config.Username, t.config.Password, t.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mail: smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("mail: recipient %s: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the server with the configured encryption, the context bounds the whole session
+func (t *SMTPTransport) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(t.config.Host, strconv.Itoa(t.config.Port))
+	tlsConfig := &tls.Config{ServerName: t.config.Host}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if t.config.Encryption == EncryptionTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, t.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mail: smtp handshake: %w", err)
+	}
+	if t.config.Encryption == EncryptionSTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("mail: starttls: %w", err)
+		}
+	}
+	return client, nil
+}
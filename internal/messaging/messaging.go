@@ -0,0 +1,281 @@
+package messaging
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mookie/internal/db/sqlc"
+	"sync"
+	"time"
+)
+
+/*
+   Package messaging sends messages over non-email channels - SMS and Web Push - through
+   pluggable providers. Messages are queued in the outbound_messages table and sent by a cron
+   task, so senders never wait for a gateway and failed sends are retried.
+
+   How to use:
+   1. Create a service with the database and register a provider per channel
+   2. Queue messages with SendSMS, PushToUser or Enqueue
+   3. Run ProcessDue periodically, e.g. from the cron runner
+
+   Example basic usage:
+       service := messaging.New(db, logger, messaging.Options{})
+       service.Register(messaging.NewTwilio(messaging.TwilioConfig{AccountSID: sid, AuthToken: token, From: "+15550100"}))
+
+       push, err := messaging.NewWebPush(messaging.WebPushConfig{PublicKey: pub, PrivateKey: priv, Subject: "mailto:ops@example.com"})
+       service.Register(push)
+
+       runner.Add("messaging", func(ctx context.Context) error {
+           return service.ProcessDue(ctx)
+       }, time.Minute)
+
+   Example sending a login code:
+       err := service.SendSMS(ctx, "+15550123", "Your login code is 123456")
+
+   Example push notifications:
+       // The browser's PushSubscription JSON, posted by the page after subscribing
+       err := service.Subscribe(ctx, userID, subscription)
+       err := service.PushToUser(ctx, userID, "New comment", "Alice replied to your post", "/posts/42")
+
+   Message statuses:
+   - pending: waiting for its first or next attempt
+   - sent: accepted by the provider
+   - dead: all attempts failed, or the push subscription expired
+
+   Notes:
+   - Queueing for a channel without a registered provider returns ErrNoProvider, PushToUser
+     does nothing without a push provider so callers can push unconditionally
+   - Push subscriptions rejected as expired by the push service are deleted
+   - Failed sends are retried with exponential backoff: RetryBase, 2x, 4x, ... up to MaxAttempts
+   - SendSMS is the channel for one-time codes, e.g. for two-factor authentication - a code
+     may reach the phone after it expired if the gateway is down, keep code lifetimes generous
+*/
+
+// Channels messages are sent over
+const (
+	ChannelSMS  = "sms"
+	ChannelPush = "push"
+)
+
+// Message statuses
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+var (
+	// ErrNoProvider is returned when queueing a message for a channel without a provider
+	ErrNoProvider = errors.New("messaging: no provider for channel")
+	// ErrGone is returned by providers when the recipient no longer exists, the message isn't retried
+	ErrGone = errors.New("messaging: recipient gone")
+)
+
+// Message is a message to a single recipient
+type Message struct {
+	Channel string
+	// To is an E.164 phone number for SMS and a JSON encoded Subscription for push
+	To    string
+	Title string
+	Body  string
+	Link  string
+}
+
+// Provider sends messages of one channel
+type Provider interface {
+	// Channel returns the channel the provider sends
+	Channel() string
+	// Send sends a message, returning ErrGone if the recipient no longer exists
+	Send(ctx context.Context, msg Message) error
+}
+
+// Options configures sending, zero values use the defaults
+type Options struct {
+	// MaxAttempts before a message is dead, defaults to 5
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt, doubled after every further failure, defaults to 30 seconds
+	RetryBase time.Duration
+	// BatchSize is the number of due messages processed per ProcessDue call, defaults to 50
+	BatchSize int
+}
+
+// Service queues messages and sends them with the registered providers
+type Service struct {
+	queries   *sqlc.Queries
+	logger    *slog.Logger
+	opts      Options
+	mu        sync.RWMutex
+	providers map[string]Provider
+	now       func() time.Time
+}
+
+// New creates a messaging service
+func New(db *sql.DB, logger *slog.Logger, opts Options) *Service {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	return &Service{
+		queries:   sqlc.New(db),
+		logger:    logger,
+		opts:      opts,
+		providers: make(map[string]Provider),
+		now:       time.Now,
+	}
+}
+
+// Register sets the provider of its channel, replacing any previous one
+func (s *Service) Register(p Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers[p.Channel()] = p
+}
+
+// Enabled reports whether a provider is registered for the channel
+func (s *Service) Enabled(channel string) bool {
+	return s.provider(channel) != nil
+}
+
+// Enqueue queues a message for sending
+func (s *Service) Enqueue(ctx context.Context, msg Message) error {
+	if !s.Enabled(msg.Channel) {
+		return fmt.Errorf("%w: %s", ErrNoProvider, msg.Channel)
+	}
+	_, err := s.queries.CreateOutboundMessage(ctx, sqlc.CreateOutboundMessageParams{
+		Channel:       msg.Channel,
+		Recipient:     msg.To,
+		Title:         msg.Title,
+		Body:          msg.Body,
+		Link:          msg.Link,
+		NextAttemptAt: s.now().UTC(),
+	})
+	return err
+}
+
+// SendSMS queues a text message to a phone number in E.164 format
+func (s *Service) SendSMS(ctx context.Context, to, body string) error {
+	return s.Enqueue(ctx, Message{Channel: ChannelSMS, To: to, Body: body})
+}
+
+// Subscribe stores a push subscription of the user, replacing an existing one with the same endpoint
+func (s *Service) Subscribe(ctx context.Context, userID int64, sub Subscription) error {
+	if err := sub.validate(); err != nil {
+		return err
+	}
+	_, err := s.queries.UpsertPushSubscription(ctx, sqlc.UpsertPushSubscriptionParams{
+		UserID:   userID,
+		Endpoint: sub.Endpoint,
+		P256dh:   sub.Keys.P256dh,
+		Auth:     sub.Keys.Auth,
+	})
+	return err
+}
+
+// Unsubscribe deletes a push subscription of the user, reporting whether it existed
+func (s *Service) Unsubscribe(ctx context.Context, userID int64, endpoint string) (bool, error) {
+	affected, err := s.queries.DeletePushSubscription(ctx, sqlc.DeletePushSubscriptionParams{UserID: userID, Endpoint: endpoint})
+	return affected > 0, err
+}
+
+// PushToUser queues a push message to every subscription of the user
+func (s *Service) PushToUser(ctx context.Context, userID int64, title, body, link string) error {
+	if !s.Enabled(ChannelPush) {
+		return nil
+	}
+	subs, err := s.queries.ListPushSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, row := range subs {
+		to, err := json.Marshal(subscriptionFromRow(row))
+		if err != nil {
+			return err
+		}
+		if err := s.Enqueue(ctx, Message{Channel: ChannelPush, To: string(to), Title: title, Body: body, Link: link}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessDue sends the messages that are due, failures are rescheduled and don't stop the batch
+func (s *Service) ProcessDue(ctx context.Context) error {
+	due, err := s.queries.ListDueOutboundMessages(ctx, sqlc.ListDueOutboundMessagesParams{
+		Now:   s.now().UTC(),
+		Limit: int64(s.opts.BatchSize),
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, row := range due {
+		if err := s.send(ctx, row); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send sends a single message and records the outcome
+func (s *Service) send(ctx context.Context, row sqlc.OutboundMessage) error {
+	update := sqlc.UpdateOutboundMessageParams{
+		Status:        StatusSent,
+		Attempts:      row.Attempts + 1,
+		NextAttemptAt: row.NextAttemptAt,
+		ID:            row.ID,
+	}
+
+	msg := Message{Channel: row.Channel, To: row.Recipient, Title: row.Title, Body: row.Body, Link: row.Link}
+	sendErr := fmt.Errorf("%w: %s", ErrNoProvider, row.Channel)
+	if p := s.provider(row.Channel); p != nil {
+		sendErr = p.Send(ctx, msg)
+	}
+
+	if sendErr != nil {
+		update.Error = sendErr.Error()
+		switch {
+		case errors.Is(sendErr, ErrGone):
+			update.Status = StatusDead
+			s.forget(ctx, msg)
+		case update.Attempts >= int64(s.opts.MaxAttempts):
+			update.Status = StatusDead
+			s.logger.Warn("Outbound message dead", "message", row.ID, "channel", row.Channel, "attempts", update.Attempts, "error", sendErr)
+		default:
+			update.Status = StatusPending
+			update.NextAttemptAt = s.now().UTC().Add(s.opts.RetryBase << (update.Attempts - 1))
+		}
+	}
+
+	return s.queries.UpdateOutboundMessage(ctx, update)
+}
+
+// forget deletes the push subscription of a message whose recipient is gone
+func (s *Service) forget(ctx context.Context, msg Message) {
+	if msg.Channel != ChannelPush {
+		return
+	}
+	var sub Subscription
+	if err := json.Unmarshal([]byte(msg.To), &sub); err != nil {
+		return
+	}
+	if err := s.queries.DeletePushSubscriptionByEndpoint(ctx, sub.Endpoint); err != nil {
+		s.logger.Error("Failed to delete expired push subscription", "error", err)
+	}
+}
+
+// provider returns the provider of a channel, nil if there is none
+func (s *Service) provider(channel string) Provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.providers[channel]
+}
@@ -0,0 +1,187 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mookie/internal/db"
+	"mookie/internal/db/sqlc"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeProvider records sent messages and fails with err if set
+type fakeProvider struct {
+	channel string
+	sent    []Message
+	err     error
+}
+
+func (p *fakeProvider) Channel() string { return p.channel }
+
+func (p *fakeProvider) Send(ctx context.Context, msg Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.sent = append(p.sent, msg)
+	return nil
+}
+
+// newTestService returns a service backed by a temporary database with one user
+func newTestService(t *testing.T) (*Service, *sqlc.Queries) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	queries := sqlc.New(database)
+	if _, err := queries.CreateUser(context.Background(), sqlc.CreateUserParams{Username: "alice", Email: "alice@example.com", Password: "x"}); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(database, logger, Options{MaxAttempts: 2, RetryBase: time.Minute}), queries
+}
+
+// outbound returns the pending messages, including those not due yet
+func outbound(t *testing.T, s *Service) []sqlc.OutboundMessage {
+	t.Helper()
+	rows, err := s.queries.ListDueOutboundMessages(context.Background(), sqlc.ListDueOutboundMessagesParams{
+		Now:   time.Now().Add(24 * time.Hour),
+		Limit: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestSendSMS(t *testing.T) {
+	s, _ := newTestService(t)
+	sms := &fakeProvider{channel: ChannelSMS}
+
+	if err := s.SendSMS(context.Background(), "+15550123", "code"); !errors.Is(err, ErrNoProvider) {
+		t.Fatalf("err = %v, want ErrNoProvider", err)
+	}
+
+	s.Register(sms)
+	if err := s.SendSMS(context.Background(), "+15550123", "Your code is 123456"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sms.sent) != 0 {
+		t.Fatal("expected the message to be queued, not sent")
+	}
+	if err := s.ProcessDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sms.sent) != 1 || sms.sent[0].To != "+15550123" || sms.sent[0].Body != "Your code is 123456" {
+		t.Fatalf("sent = %+v", sms.sent)
+	}
+	if pending := outbound(t, s); len(pending) != 0 {
+		t.Errorf("expected no pending messages, got %d", len(pending))
+	}
+}
+
+func TestRetries(t *testing.T) {
+	s, _ := newTestService(t)
+	sms := &fakeProvider{channel: ChannelSMS, err: errors.New("gateway down")}
+	s.Register(sms)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.SendSMS(context.Background(), "+15550123", "hello")
+	s.ProcessDue(context.Background())
+
+	pending := outbound(t, s)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].Error != "gateway down" {
+		t.Fatalf("after the first failure: %+v", pending)
+	}
+	if !pending[0].NextAttemptAt.After(now.UTC()) {
+		t.Errorf("next attempt = %v, want a minute later", pending[0].NextAttemptAt)
+	}
+
+	// Not due yet
+	s.ProcessDue(context.Background())
+	if pending := outbound(t, s); pending[0].Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 before the retry is due", pending[0].Attempts)
+	}
+
+	now = now.Add(time.Minute)
+	s.ProcessDue(context.Background())
+	if pending := outbound(t, s); len(pending) != 0 {
+		t.Errorf("expected the message to be dead after MaxAttempts, got %+v", pending)
+	}
+}
+
+func TestPushToUser(t *testing.T) {
+	s, queries := newTestService(t)
+	ctx := context.Background()
+
+	var sub Subscription
+	sub.Endpoint = "https://push.example.com/abc"
+	sub.Keys.P256dh = "key"
+	sub.Keys.Auth = "auth"
+	if err := s.Subscribe(ctx, 1, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a push provider nothing is queued
+	if err := s.PushToUser(ctx, 1, "Hello", "World", "/"); err != nil {
+		t.Fatal(err)
+	}
+	if pending := outbound(t, s); len(pending) != 0 {
+		t.Fatalf("expected nothing queued without a provider, got %d", len(pending))
+	}
+
+	push := &fakeProvider{channel: ChannelPush}
+	s.Register(push)
+	s.PushToUser(ctx, 1, "Hello", "World", "/inbox")
+	s.ProcessDue(ctx)
+	if len(push.sent) != 1 || push.sent[0].Title != "Hello" || push.sent[0].Link != "/inbox" {
+		t.Fatalf("sent = %+v", push.sent)
+	}
+
+	// Expired subscriptions are deleted and their messages not retried
+	push.err = ErrGone
+	s.PushToUser(ctx, 1, "Again", "", "")
+	s.ProcessDue(ctx)
+	if pending := outbound(t, s); len(pending) != 0 {
+		t.Errorf("expected the message to be dead, got %+v", pending)
+	}
+	subs, _ := queries.ListPushSubscriptionsByUser(ctx, 1)
+	if len(subs) != 0 {
+		t.Errorf("expected the expired subscription to be deleted, got %d", len(subs))
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+
+	var sub Subscription
+	sub.Endpoint = "http://push.example.com/abc"
+	sub.Keys.P256dh = "key"
+	sub.Keys.Auth = "auth"
+	if err := s.Subscribe(ctx, 1, sub); err == nil {
+		t.Error("expected plain HTTP endpoints to be rejected")
+	}
+
+	sub.Endpoint = "https://push.example.com/abc"
+	if err := s.Subscribe(ctx, 1, sub); err != nil {
+		t.Fatal(err)
+	}
+	// Subscribing again replaces the subscription
+	if err := s.Subscribe(ctx, 1, sub); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := s.Unsubscribe(ctx, 1, sub.Endpoint); err != nil || !removed {
+		t.Errorf("unsubscribe = %v, %v", removed, err)
+	}
+	if removed, _ := s.Unsubscribe(ctx, 1, sub.Endpoint); removed {
+		t.Error("expected the second unsubscribe to find nothing")
+	}
+}
@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioConfig configures a Twilio compatible SMS gateway
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	// From is the sending phone number or messaging service SID
+	From string
+	// BaseURL of the API, defaults to https://api.twilio.com - change it for compatible gateways
+	BaseURL string
+	// Client sends the requests, defaults to a client with a 10 second timeout
+	Client *http.Client
+}
+
+// Twilio sends SMS through the Twilio Messages API
+type Twilio struct {
+	config TwilioConfig
+}
+
+// NewTwilio creates a Twilio SMS provider
+func NewTwilio(config TwilioConfig) *Twilio {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.twilio.com"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Twilio{config: config}
+}
+
+// Channel returns ChannelSMS
+func (t *Twilio) Channel() string {
+	return ChannelSMS
+}
+
+// twilioError is the error body of the Twilio API
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Send posts the message to the Messages API
+func (t *Twilio) Send(ctx context.Context, msg Message) error {
+	form := url.Values{"To": {msg.To}, "Body": {msg.Body}}
+	if strings.HasPrefix(t.config.From, "MG") {
+		form.Set("MessagingServiceSid", t.config.From)
+	} else {
+		form.Set("From", t.config.From)
+	}
+
+	endpoint := t.config.BaseURL + "/2010-04-01/Accounts/" + url.PathEscape(t.config.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.config.AccountSID, t.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	var apiErr twilioError
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		return fmt.Errorf("messaging: twilio responded with %d: %s (code %d)", resp.StatusCode, apiErr.Message, apiErr.Code)
+	}
+	return fmt.Errorf("messaging: twilio responded with %d", resp.StatusCode)
+}
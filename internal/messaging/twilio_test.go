@@ -0,0 +1,46 @@
+package messaging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwilioSend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		user, pass, _ := r.BasicAuth()
+		if user != "AC123" || pass != "token" {
+			t.Errorf("basic auth = %s:%s", user, pass)
+		}
+		r.ParseForm()
+		if r.Form.Get("To") != "+15550123" || r.Form.Get("From") != "+15550100" || r.Form.Get("Body") != "hello" {
+			t.Errorf("form = %v", r.Form)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio(TwilioConfig{AccountSID: "AC123", AuthToken: "token", From: "+15550100", BaseURL: server.URL + "/"})
+	if err := twilio.Send(context.Background(), Message{To: "+15550123", Body: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTwilioError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number"}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio(TwilioConfig{AccountSID: "AC123", AuthToken: "token", From: "MG123", BaseURL: server.URL})
+	err := twilio.Send(context.Background(), Message{To: "+1invalid", Body: "hello"})
+	if err == nil || !strings.Contains(err.Error(), "Invalid 'To' Phone Number") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mookie/internal/db/sqlc"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Subscription is a browser push subscription, the JSON of PushSubscription.toJSON()
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// validate checks the subscription has an HTTPS endpoint and both keys
+func (s Subscription) validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("messaging: push subscription endpoint must be an https URL")
+	}
+	if s.Keys.P256dh == "" || s.Keys.Auth == "" {
+		return errors.New("messaging: push subscription keys missing")
+	}
+	return nil
+}
+
+// WebPushConfig configures the Web Push provider
+type WebPushConfig struct {
+	// PublicKey and PrivateKey are the VAPID key pair, base64url encoded - see GenerateVAPIDKeys
+	PublicKey  string
+	PrivateKey string
+	// Subject identifies the sender to push services, a mailto: or https: URL
+	Subject string
+	// TTL is how long push services keep undelivered messages, defaults to 24 hours
+	TTL time.Duration
+	// Client sends the requests, defaults to a client with a 10 second timeout
+	Client *http.Client
+}
+
+// WebPush sends encrypted push messages to browser push subscriptions
+type WebPush struct {
+	config     WebPushConfig
+	privateKey *ecdsa.PrivateKey
+	publicKey  []byte
+}
+
+// pushPayload is the JSON delivered to the service worker's push event
+type pushPayload struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	Link  string `json:"link,omitempty"`
+}
+
+// NewWebPush creates a Web Push provider
+func NewWebPush(config WebPushConfig) (*WebPush, error) {
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.Subject == "" {
+		return nil, errors.New("messaging: VAPID subject missing")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("messaging: VAPID private key: %w", err)
+	}
+	public := key.PublicKey().Bytes()
+	if config.PublicKey != "" && config.PublicKey != base64.RawURLEncoding.EncodeToString(public) {
+		return nil, errors.New("messaging: VAPID public key doesn't match the private key")
+	}
+
+	// Uncompressed point: 0x04 || X || Y
+	signer := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+	return &WebPush{config: config, privateKey: signer, publicKey: public}, nil
+}
+
+// GenerateVAPIDKeys returns a new base64url encoded VAPID key pair
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with (applicationServerKey)
+func (p *WebPush) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(p.publicKey)
+}
+
+// Channel returns ChannelPush
+func (p *WebPush) Channel() string {
+	return ChannelPush
+}
+
+// Send encrypts the message for the subscription in msg.To and posts it to its push service
+func (p *WebPush) Send(ctx context.Context, msg Message) error {
+	var sub Subscription
+	if err := json.Unmarshal([]byte(msg.To), &sub); err != nil {
+		return fmt.Errorf("%w: invalid subscription: %v", ErrGone, err)
+	}
+	payload, err := json.Marshal(pushPayload{Title: msg.Title, Body: msg.Body, Link: msg.Link})
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrGone, err)
+	}
+	authorization, err := p.authorization(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(p.config.TTL.Seconds())))
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: push service responded with %d", ErrGone, resp.StatusCode)
+	default:
+		return fmt.Errorf("messaging: push service responded with %d", resp.StatusCode)
+	}
+}
+
+// authorization returns the VAPID Authorization header for a push service endpoint (RFC 8292)
+func (p *WebPush) authorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": p.config.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, p.privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + p.PublicKey(), nil
+}
+
+// recordSize is the record size announced in the encrypted content header
+const recordSize = 4096
+
+// encrypt encrypts a payload for a subscription as a single aes128gcm record (RFC 8291)
+func encrypt(sub Subscription, plaintext []byte) ([]byte, error) {
+	userPublic, err := decodeKey(sub.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	authSecret, err := decodeKey(sub.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	remote, err := ecdh.P256().NewPublicKey(userPublic)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+
+	local, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := local.ECDH(remote)
+	if err != nil {
+		return nil, err
+	}
+	localPublic := local.PublicKey().Bytes()
+
+	// IKM = HKDF(auth secret, shared secret, "WebPush: info" || 0x00 || ua_public || as_public)
+	info := append([]byte("WebPush: info\x00"), userPublic...)
+	info = append(info, localPublic...)
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, authSecret, info), ikm); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: aes128gcm\x00")), key); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: nonce\x00")), nonce); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext)+1+gcm.Overhead() > recordSize {
+		return nil, errors.New("payload too large")
+	}
+
+	// Header: salt || record size || key ID length || key ID (the sender public key)
+	out := append([]byte{}, salt...)
+	out = binary.BigEndian.AppendUint32(out, recordSize)
+	out = append(out, byte(len(localPublic)))
+	out = append(out, localPublic...)
+
+	// The last (and only) record is padded with the 0x02 delimiter
+	return gcm.Seal(out, nonce, append(plaintext, 0x02), nil), nil
+}
+
+// decodeKey decodes a base64url key, with or without padding
+func decodeKey(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// subscriptionFromRow converts a database row to a Subscription
+func subscriptionFromRow(row sqlc.PushSubscription) Subscription {
+	var sub Subscription
+	sub.Endpoint = row.Endpoint
+	sub.Keys.P256dh = row.P256dh
+	sub.Keys.Auth = row.Auth
+	return sub
+}
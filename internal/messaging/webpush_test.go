@@ -0,0 +1,178 @@
+package messaging
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// testSubscriber is a browser side push subscription with its private key
+type testSubscriber struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newTestSubscriber(t *testing.T) *testSubscriber {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &testSubscriber{key: key, auth: auth}
+}
+
+// subscription returns the subscription JSON for an endpoint
+func (s *testSubscriber) subscription(endpoint string) Subscription {
+	var sub Subscription
+	sub.Endpoint = endpoint
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(s.key.PublicKey().Bytes())
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(s.auth)
+	return sub
+}
+
+// decrypt decrypts an aes128gcm body the way the browser does
+func (s *testSubscriber) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, rs, idLen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != recordSize || idLen != 65 {
+		t.Fatalf("record size %d, key ID length %d", rs, idLen)
+	}
+	senderPublic := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	sender, err := ecdh.P256().NewPublicKey(senderPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := s.key.ECDH(sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := append([]byte("WebPush: info\x00"), s.key.PublicKey().Bytes()...)
+	info = append(info, senderPublic...)
+	ikm := make([]byte, 32)
+	io.ReadFull(hkdf.New(sha256.New, shared, s.auth, info), ikm)
+	key := make([]byte, 16)
+	io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: aes128gcm\x00")), key)
+	nonce := make([]byte, 12)
+	io.ReadFull(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: nonce\x00")), nonce)
+
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("decrypting: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatal("missing last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func newTestWebPush(t *testing.T) *WebPush {
+	t.Helper()
+	public, private, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewWebPush(WebPushConfig{PublicKey: public, PrivateKey: private, Subject: "mailto:ops@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestWebPushSend(t *testing.T) {
+	p := newTestWebPush(t)
+	subscriber := newTestSubscriber(t)
+
+	var got pushPayload
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") != "86400" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		authorization = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(subscriber.decrypt(t, body), &got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	to, _ := json.Marshal(subscriber.subscription(server.URL + "/push/abc"))
+	if err := p.Send(context.Background(), Message{Channel: ChannelPush, To: string(to), Title: "Hello", Body: "World", Link: "/inbox"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != (pushPayload{Title: "Hello", Body: "World", Link: "/inbox"}) {
+		t.Errorf("payload = %+v", got)
+	}
+	verifyVAPID(t, p, authorization, server.URL)
+}
+
+// verifyVAPID checks the VAPID header is a valid ES256 JWT for the audience
+func verifyVAPID(t *testing.T, p *WebPush, header, audience string) {
+	t.Helper()
+	token, key, ok := strings.Cut(strings.TrimPrefix(header, "vapid t="), ", k=")
+	if !ok || key != p.PublicKey() {
+		t.Fatalf("authorization = %q", header)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token = %q", token)
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var c struct {
+		Aud string `json:"aud"`
+		Sub string `json:"sub"`
+	}
+	json.Unmarshal(claims, &c)
+	if c.Aud != audience || c.Sub != "mailto:ops@example.com" {
+		t.Errorf("claims = %s", claims)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&p.privateKey.PublicKey, digest[:], r, s) {
+		t.Error("invalid JWT signature")
+	}
+}
+
+func TestWebPushGone(t *testing.T) {
+	p := newTestWebPush(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	to, _ := json.Marshal(newTestSubscriber(t).subscription(server.URL))
+	if err := p.Send(context.Background(), Message{To: string(to), Title: "Hi"}); !errors.Is(err, ErrGone) {
+		t.Errorf("err = %v, want ErrGone", err)
+	}
+}
+
+func TestNewWebPushKeyMismatch(t *testing.T) {
+	public, _, _ := GenerateVAPIDKeys()
+	_, private, _ := GenerateVAPIDKeys()
+	if _, err := NewWebPush(WebPushConfig{PublicKey: public, PrivateKey: private, Subject: "mailto:a@b"}); err == nil {
+		t.Error("expected mismatched keys to be rejected")
+	}
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+   Package metrics records business metrics - messages posted, signups, job durations - for
+   services and handlers, without them having to deal with Prometheus collectors. The metrics
+   are exposed on the metrics endpoint together with the HTTP and runtime metrics.
+
+   How to use:
+   1. Create a registry with the Prometheus registerer, or with nil when metrics are disabled
+   2. Create the metrics once, e.g. in a service constructor
+   3. Record values, passing one label value per label name
+
+   Example basic usage:
+       m := metrics.New(registry)   // registry is nil when metrics are disabled
+
+       posted := m.Counter("messages_posted_total", "Number of messages posted.")
+       posted.Inc()
+
+       signups := m.Counter("signups_total", "Number of signups.", "source")
+       signups.Inc("invite")
+
+       queue := m.Gauge("mail_queue_size", "Number of queued emails.")
+       queue.Set(12)
+
+   Example timing a job:
+       jobs := m.Timer("job_duration_seconds", "Duration of background jobs.", "job")
+       stop := jobs.Start("cleanup")
+       defer stop()
+
+       // or
+       err := jobs.Time(cleanup, "cleanup")
+
+   Notes:
+   - With a nil registerer the metrics work but aren't exported, so code can record unconditionally
+   - Creating a metric with the name of an existing one returns the existing metric, the help,
+     labels and buckets must match
+   - Keep label values to a small fixed set, every distinct value is a new time series
+*/
+
+// Registry creates metrics registered with a Prometheus registerer
+type Registry struct {
+	registerer prometheus.Registerer
+}
+
+// New creates a registry, a nil registerer disables exporting
+func New(registerer prometheus.Registerer) *Registry {
+	return &Registry{registerer: registerer}
+}
+
+// Enabled reports whether the metrics are exported
+func (r *Registry) Enabled() bool {
+	return r.registerer != nil
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	vec *prometheus.CounterVec
+}
+
+// Counter creates a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	vec = register(r, vec)
+	if len(labels) == 0 {
+		// Export metrics without labels as zero before anything is recorded
+		vec.WithLabelValues()
+	}
+	return &Counter{vec: vec}
+}
+
+// Inc adds one
+func (c *Counter) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// Add adds a non-negative value
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(value)
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	vec *prometheus.GaugeVec
+}
+
+// Gauge creates a gauge with the given label names
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+	vec = register(r, vec)
+	if len(labels) == 0 {
+		// Export metrics without labels as zero before anything is recorded
+		vec.WithLabelValues()
+	}
+	return &Gauge{vec: vec}
+}
+
+// Set sets the value
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Set(value)
+}
+
+// Inc adds one
+func (g *Gauge) Inc(labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Inc()
+}
+
+// Dec subtracts one
+func (g *Gauge) Dec(labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Dec()
+}
+
+// Add adds a value, negative values subtract
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Add(value)
+}
+
+// Histogram counts observed values in buckets
+type Histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+// Histogram creates a histogram with the given buckets and label names, nil buckets use prometheus.DefBuckets
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labels)
+	vec = register(r, vec)
+	if len(labels) == 0 {
+		// Export metrics without labels as zero before anything is recorded
+		vec.WithLabelValues()
+	}
+	return &Histogram{vec: vec}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(value)
+}
+
+// Timer records durations in seconds in a histogram
+type Timer struct {
+	histogram *Histogram
+}
+
+// Timer creates a timer with the default buckets and the given label names
+func (r *Registry) Timer(name, help string, labels ...string) *Timer {
+	return &Timer{histogram: r.Histogram(name, help, nil, labels...)}
+}
+
+// Observe records a duration
+func (t *Timer) Observe(d time.Duration, labelValues ...string) {
+	t.histogram.Observe(d.Seconds(), labelValues...)
+}
+
+// Start starts timing and returns a function recording the elapsed time when called
+func (t *Timer) Start(labelValues ...string) func() {
+	start := time.Now()
+	return func() {
+		t.Observe(time.Since(start), labelValues...)
+	}
+}
+
+// Time runs fn and records how long it took, returning its error
+func (t *Timer) Time(fn func() error, labelValues ...string) error {
+	defer t.Start(labelValues...)()
+	return fn()
+}
+
+// register registers the collector or returns the already registered one
+func register[C prometheus.Collector](r *Registry, collector C) C {
+	if r.registerer == nil {
+		return collector
+	}
+	if err := r.registerer.Register(collector); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector.(C)
+		}
+		panic(err)
+	}
+	return collector
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCounterAndGauge(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+
+	posted := m.Counter("messages_posted_total", "Number of messages posted.")
+	posted.Inc()
+	posted.Add(2)
+	if got := testutil.ToFloat64(posted.vec); got != 3 {
+		t.Errorf("counter = %v, want 3", got)
+	}
+
+	queue := m.Gauge("queue_size", "Queue size.", "queue")
+	queue.Set(5, "mail")
+	queue.Inc("mail")
+	queue.Dec("mail")
+	queue.Add(-2, "mail")
+	if got := testutil.ToFloat64(queue.vec.WithLabelValues("mail")); got != 3 {
+		t.Errorf("gauge = %v, want 3", got)
+	}
+
+	if n, err := testutil.GatherAndCount(registry); err != nil || n != 2 {
+		t.Errorf("gathered %d series, err = %v, want 2", n, err)
+	}
+}
+
+func TestExistingMetricIsReused(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	first := m.Counter("signups_total", "Number of signups.", "source")
+	second := m.Counter("signups_total", "Number of signups.", "source")
+
+	first.Inc("invite")
+	second.Inc("invite")
+	if got := testutil.ToFloat64(first.vec.WithLabelValues("invite")); got != 2 {
+		t.Errorf("counter = %v, want 2", got)
+	}
+}
+
+func TestTimer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry)
+	jobs := m.Timer("job_duration_seconds", "Duration of jobs.", "job")
+
+	stop := jobs.Start("cleanup")
+	stop()
+	jobs.Observe(time.Second, "cleanup")
+	errJob := errors.New("failed")
+	if err := jobs.Time(func() error { return errJob }, "cleanup"); err != errJob {
+		t.Errorf("err = %v, want the job error", err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := families[0].GetMetric()[0].GetHistogram().GetSampleCount(); count != 3 {
+		t.Errorf("sample count = %d, want 3", count)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	m := New(nil)
+	if m.Enabled() {
+		t.Error("expected a nil registerer to disable exporting")
+	}
+
+	// Recording without a registerer must not panic
+	m.Counter("a_total", "A.").Inc()
+	m.Gauge("b", "B.").Set(1)
+	m.Histogram("c", "C.", []float64{1, 2}).Observe(1.5)
+	m.Timer("d_seconds", "D.").Start()()
+}
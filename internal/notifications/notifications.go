@@ -0,0 +1,226 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/params"
+	"mookie/internal/websocket"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+   Package notifications provides in-app notifications stored in the notifications table
+   and delivered in real time to the owning user's websocket clients.
+
+   How to use:
+   1. Create a service with the database and the websocket hub
+   2. Create notifications with Notify - usually from event bus subscribers
+   3. List and mark notifications read from the user's handlers
+
+   Example creating notifications from domain events:
+       service := notifications.New(db, hub)
+
+       events.Subscribe(bus, func(ctx context.Context, e events.UserRegistered) error {
+           _, err := service.Notify(ctx, notifications.Notification{
+               UserID: e.UserID,
+               Type:   "welcome",
+               Title:  "Welcome to mookie",
+           })
+           return err
+       }, events.Async())
+
+   Example listing the user's notifications in a handler:
+       page, err := service.List(ctx, userID, q)
+       unread, err := service.UnreadCount(ctx, userID)
+
+   Websocket messages sent to the user's clients:
+       {"type": "notification", "payload": <notification JSON>}
+       {"type": "notifications.unread", "payload": {"unread": 3}}
+
+   Notes:
+   - Websocket clients receive the user's notifications once they join the user's Room
+   - Delivery is best effort, offline users see their notifications when they list them
+   - MarkRead returns ErrNotFound for notifications of other users
+   - OnNotify hooks are called after every stored notification, e.g. to send push messages
+*/
+
+// Websocket message types sent to the user's clients
+const (
+	MessageTypeNotification = "notification"
+	MessageTypeUnread       = "notifications.unread"
+)
+
+// Room returns the websocket room of the user's clients
+func Room(userID int64) string {
+	return "notifications:" + strconv.FormatInt(userID, 10)
+}
+
+// ErrNotFound is returned when the notification doesn't exist or belongs to another user
+var ErrNotFound = errors.New("notifications: notification not found")
+
+// Notification is a message for a single user
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body,omitempty"`
+	Link      string     `json:"link,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Read reports whether the user has read the notification
+func (n Notification) Read() bool {
+	return n.ReadAt != nil
+}
+
+// NotifyHook is called after a notification is stored
+type NotifyHook func(ctx context.Context, n Notification)
+
+// Service stores notifications and delivers them to websocket clients
+type Service struct {
+	queries *sqlc.Queries
+	hub     *websocket.Hub
+	mu      sync.RWMutex
+	hooks   []NotifyHook
+}
+
+// New creates a notification service, hub may be nil to disable real-time delivery
+func New(db *sql.DB, hub *websocket.Hub) *Service {
+	return &Service{queries: sqlc.New(db), hub: hub}
+}
+
+// OnNotify registers a hook called after every stored notification
+func (s *Service) OnNotify(hook NotifyHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// Notify stores the notification and sends it to the user's websocket clients
+func (s *Service) Notify(ctx context.Context, n Notification) (*Notification, error) {
+	row, err := s.queries.CreateNotification(ctx, sqlc.CreateNotificationParams{
+		UserID: n.UserID,
+		Type:   n.Type,
+		Title:  n.Title,
+		Body:   n.Body,
+		Link:   n.Link,
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := fromRow(row)
+
+	s.send(created.UserID, MessageTypeNotification, created)
+	s.sendUnread(ctx, created.UserID)
+
+	s.mu.RLock()
+	hooks := s.hooks
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, created)
+	}
+	return &created, nil
+}
+
+// List returns a page of the user's notifications, newest first
+func (s *Service) List(ctx context.Context, userID int64, q *params.Query) (params.Page[Notification], error) {
+	rows, err := s.queries.ListNotifications(ctx, sqlc.ListNotificationsParams{
+		UserID: userID,
+		Limit:  q.Limit(),
+		Offset: q.Offset(),
+	})
+	if err != nil {
+		return params.Page[Notification]{}, err
+	}
+	total, err := s.queries.CountNotifications(ctx, userID)
+	if err != nil {
+		return params.Page[Notification]{}, err
+	}
+
+	items := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, fromRow(row))
+	}
+	return params.NewPage(q, items, total), nil
+}
+
+// UnreadCount returns the number of unread notifications of the user
+func (s *Service) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	return s.queries.CountUnreadNotifications(ctx, userID)
+}
+
+// MarkRead marks one of the user's notifications read
+func (s *Service) MarkRead(ctx context.Context, userID, id int64) error {
+	affected, err := s.queries.MarkNotificationRead(ctx, sqlc.MarkNotificationReadParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	s.sendUnread(ctx, userID)
+	return nil
+}
+
+// MarkAllRead marks all of the user's notifications read
+func (s *Service) MarkAllRead(ctx context.Context, userID int64) error {
+	if err := s.queries.MarkAllNotificationsRead(ctx, userID); err != nil {
+		return err
+	}
+	s.sendUnread(ctx, userID)
+	return nil
+}
+
+// sendUnread sends the current unread count to the user's websocket clients
+func (s *Service) sendUnread(ctx context.Context, userID int64) {
+	unread, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		return
+	}
+	s.send(userID, MessageTypeUnread, map[string]int64{"unread": unread})
+}
+
+// send delivers a JSON payload to the websocket clients of the user
+func (s *Service) send(userID int64, messageType string, v any) {
+	if s.hub == nil {
+		return
+	}
+	room := Room(userID)
+	if len(s.hub.RoomClients(room)) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	s.hub.BroadcastToRoom(room, websocket.Message{
+		Mode:    websocket.MessageModeText,
+		Type:    messageType,
+		Payload: payload,
+	})
+}
+
+// fromRow converts a database row to a Notification
+func fromRow(row sqlc.Notification) Notification {
+	n := Notification{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Type:      row.Type,
+		Title:     row.Title,
+		Body:      row.Body,
+		Link:      row.Link,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.ReadAt.Valid {
+		n.ReadAt = &row.ReadAt.Time
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"mookie/internal/db"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/params"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+// newTestService returns a service backed by a temporary database with two users
+func newTestService(t *testing.T) (*Service, int64, int64) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	queries := sqlc.New(database)
+	var ids []int64
+	for _, name := range []string{"alice", "bob"} {
+		user, err := queries.CreateUser(context.Background(), sqlc.CreateUserParams{Username: name, Email: name + "@example.com", Password: "x"})
+		if err != nil {
+			t.Fatalf("CreateUser returned error: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	return New(database, nil), ids[0], ids[1]
+}
+
+func TestNotifyAndList(t *testing.T) {
+	service, alice, bob := newTestService(t)
+	ctx := context.Background()
+
+	for _, title := range []string{"first", "second"} {
+		if _, err := service.Notify(ctx, Notification{UserID: alice, Type: "test", Title: title}); err != nil {
+			t.Fatalf("Notify returned error: %v", err)
+		}
+	}
+	service.Notify(ctx, Notification{UserID: bob, Type: "test", Title: "other"})
+
+	q, _ := params.Parse(url.Values{}, params.Options{})
+	page, err := service.List(ctx, alice, q)
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 2 {
+		t.Fatalf("List returned %d of %d, want 2 of 2", len(page.Items), page.Total)
+	}
+	if page.Items[0].Title != "second" {
+		t.Errorf("first item = %q, want newest first", page.Items[0].Title)
+	}
+}
+
+func TestMarkRead(t *testing.T) {
+	service, alice, bob := newTestService(t)
+	ctx := context.Background()
+
+	n, _ := service.Notify(ctx, Notification{UserID: alice, Type: "test", Title: "first"})
+	service.Notify(ctx, Notification{UserID: alice, Type: "test", Title: "second"})
+
+	if err := service.MarkRead(ctx, bob, n.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("MarkRead for another user error = %v, want ErrNotFound", err)
+	}
+	if err := service.MarkRead(ctx, alice, n.ID); err != nil {
+		t.Fatalf("MarkRead returned error: %v", err)
+	}
+	if unread, _ := service.UnreadCount(ctx, alice); unread != 1 {
+		t.Errorf("UnreadCount = %d, want 1", unread)
+	}
+
+	if err := service.MarkAllRead(ctx, alice); err != nil {
+		t.Fatalf("MarkAllRead returned error: %v", err)
+	}
+	if unread, _ := service.UnreadCount(ctx, alice); unread != 0 {
+		t.Errorf("UnreadCount = %d, want 0", unread)
+	}
+}
+
+func TestOnNotify(t *testing.T) {
+	service, alice, _ := newTestService(t)
+
+	var got []Notification
+	service.OnNotify(func(ctx context.Context, n Notification) {
+		got = append(got, n)
+	})
+	n, err := service.Notify(context.Background(), Notification{UserID: alice, Type: "test", Title: "hello"})
+	if err != nil {
+		t.Fatalf("Notify returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != n.ID || got[0].Title != "hello" {
+		t.Errorf("hook got %+v, want the stored notification", got)
+	}
+}
@@ -0,0 +1,376 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+   Package openapi provides a route registry that records metadata about registered
+   routes and generates an OpenAPI 3 document from it.
+
+   How to use:
+   1. Create a new Registry with the API title and version
+   2. Register routes through Handle (registers on the mux and records metadata)
+   3. Serve the generated document with Handler() and optionally SwaggerUI()
+
+   Example basic usage:
+       api := openapi.New("mookie", "1.0.0")
+
+       api.Handle(mux, openapi.Route{
+           Method:   "POST",
+           Path:     "/users",
+           Summary:  "Create a user",
+           Tags:     []string{"users"},
+           Request:  CreateUserRequest{},
+           Response: UserResponse{},
+       }, handlers.CreateUser(c))
+
+       // Serve the spec and the Swagger UI
+       mux.Handle("GET /openapi.json", api.Handler())
+       mux.Handle("GET /docs", openapi.SwaggerUI("/openapi.json"))
+
+   Example with path and header parameters:
+       // Path parameters are detected from the ServeMux pattern automatically
+       api.Handle(mux, openapi.Route{
+           Method:  "GET",
+           Path:    "/users/{id}",
+           Summary: "Get a user by ID",
+           Params: []openapi.Param{
+               {Name: "X-Tenant", In: "header", Description: "Tenant identifier"},
+           },
+           Response: UserResponse{},
+       }, handlers.GetUser(c))
+
+   Notes:
+   - Thread-safe
+   - Request and Response are example values, only their types are used
+   - Struct schemas are generated via reflection using json tags
+   - Named struct types are placed under components/schemas and referenced
+   - Routes with Hidden set are registered on the mux but not documented
+*/
+
+// Param describes a non-body request parameter
+type Param struct {
+	Name        string
+	In          string // path, query, header or cookie
+	Description string
+	Required    bool
+}
+
+// Route describes a registered route
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Param
+	// Request is an example value of the request body type, nil if there is no body
+	Request any
+	// Response is an example value of the response body type, nil if there is no body
+	Response any
+	// ContentType of the response, defaults to application/json
+	ContentType string
+	// Hidden routes are not included in the generated document
+	Hidden bool
+}
+
+// Registry records routes and generates an OpenAPI document
+type Registry struct {
+	title   string
+	version string
+	routes  []Route
+	mu      sync.RWMutex
+}
+
+// New creates a new Registry
+func New(title, version string) *Registry {
+	return &Registry{
+		title:   title,
+		version: version,
+	}
+}
+
+// Add records route metadata without registering a handler
+func (r *Registry) Add(route Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route)
+}
+
+// Handle registers the handler on the mux using the route method and path and records the route
+func (r *Registry) Handle(mux *http.ServeMux, route Route, handler http.Handler) {
+	pattern := route.Path
+	if route.Method != "" {
+		pattern = route.Method + " " + route.Path
+	}
+	mux.Handle(pattern, handler)
+	r.Add(route)
+}
+
+// Routes returns a copy of the registered routes
+func (r *Registry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	routes := make([]Route, len(r.routes))
+	copy(routes, r.routes)
+	return routes
+}
+
+// Document generates the OpenAPI 3 document for the registered routes
+func (r *Registry) Document() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]any)
+
+	for _, route := range r.Routes() {
+		if route.Hidden {
+			continue
+		}
+
+		path, pathParams := convertPath(route.Path)
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[path] = item
+		}
+
+		method := strings.ToLower(route.Method)
+		if method == "" {
+			method = "get"
+		}
+		item[method] = operation(route, pathParams, schemas)
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   r.title,
+			"version": r.version,
+		},
+		"paths": paths,
+	}
+	if len(schemas) > 0 {
+		doc["components"] = map[string]any{"schemas": schemas}
+	}
+	return doc
+}
+
+// Handler serves the OpenAPI document as JSON
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.Document()); err != nil {
+			http.Error(w, "failed to encode openapi document", http.StatusInternalServerError)
+		}
+	}
+}
+
+// SwaggerUI serves a Swagger UI page loading the spec from specURL
+func SwaggerUI(specURL string) http.HandlerFunc {
+	page := strings.ReplaceAll(swaggerPage, "{{SPEC_URL}}", specURL)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}
+}
+
+// operation builds the operation object for a route
+func operation(route Route, pathParams []string, schemas map[string]any) map[string]any {
+	op := map[string]any{}
+	if route.Summary != "" {
+		op["summary"] = route.Summary
+	}
+	if route.Description != "" {
+		op["description"] = route.Description
+	}
+	if len(route.Tags) > 0 {
+		op["tags"] = route.Tags
+	}
+
+	// Path parameters from the pattern come first, followed by explicit ones
+	var params []map[string]any
+	for _, name := range pathParams {
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	for _, p := range route.Params {
+		param := map[string]any{
+			"name":     p.Name,
+			"in":       p.In,
+			"required": p.Required || p.In == "path",
+			"schema":   map[string]any{"type": "string"},
+		}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if route.Request != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": schemaFor(reflect.TypeOf(route.Request), schemas),
+				},
+			},
+		}
+	}
+
+	response := map[string]any{"description": "OK"}
+	if route.Response != nil || route.ContentType != "" {
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		media := map[string]any{}
+		if route.Response != nil {
+			media["schema"] = schemaFor(reflect.TypeOf(route.Response), schemas)
+		}
+		response["content"] = map[string]any{contentType: media}
+	}
+	op["responses"] = map[string]any{"200": response}
+
+	return op
+}
+
+// convertPath converts a ServeMux pattern path to an OpenAPI path and returns the path parameter names
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			// {$} only anchors the end of the path
+			if name == "$" {
+				segments[i] = ""
+				continue
+			}
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema for the given type, registering named structs in schemas
+func schemaFor(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as base64 by encoding/json
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		name := t.Name()
+		if _, exists := schemas[name]; !exists {
+			// Reserve the name first so recursive types terminate
+			schemas[name] = map[string]any{}
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+
+	// Interfaces and other kinds can hold anything
+	return map[string]any{}
+}
+
+// structSchema builds an object schema from the exported fields of a struct
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+
+		properties[name] = schemaFor(field.Type, schemas)
+		if !omitempty && field.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html>
+	<head>
+		<title>API documentation</title>
+		<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css"/>
+	</head>
+	<body>
+		<div id="swagger-ui"></div>
+		<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+		<script>
+			window.ui = SwaggerUIBundle({url: "{{SPEC_URL}}", dom_id: "#swagger-ui"});
+		</script>
+	</body>
+</html>
+`
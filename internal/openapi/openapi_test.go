@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email,omitempty"`
+	Password  string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	Friends   []testUser `json:"friends,omitempty"`
+}
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		wantPath   string
+		wantParams []string
+	}{
+		{"/", "/", nil},
+		{"/users/{id}", "/users/{id}", []string{"id"}},
+		{"/files/{path...}", "/files/{path}", []string{"path"}},
+		{"/users/{id}/posts/{post}", "/users/{id}/posts/{post}", []string{"id", "post"}},
+		{"/exact/{$}", "/exact/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			path, params := convertPath(tt.pattern)
+			if path != tt.wantPath {
+				t.Errorf("got path %q, want %q", path, tt.wantPath)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("got params %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestRegistry_Handle(t *testing.T) {
+	api := New("test", "1.0.0")
+	mux := http.NewServeMux()
+
+	api.Handle(mux, Route{Method: "GET", Path: "/users/{id}", Response: testUser{}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.PathValue("id")))
+		}))
+
+	// Route should be served by the mux
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+	if rec.Body.String() != "42" {
+		t.Errorf("got body %q, want 42", rec.Body.String())
+	}
+
+	// Route should be recorded
+	if len(api.Routes()) != 1 {
+		t.Errorf("expected 1 route, got %d", len(api.Routes()))
+	}
+}
+
+func TestRegistry_Document(t *testing.T) {
+	api := New("test", "1.0.0")
+	api.Add(Route{Method: "POST", Path: "/users", Summary: "Create", Request: testUser{}, Response: &testUser{}})
+	api.Add(Route{Method: "GET", Path: "/users/{id}", Response: testUser{}})
+	api.Add(Route{Method: "GET", Path: "/hidden", Hidden: true})
+
+	doc := api.Document()
+	paths := doc["paths"].(map[string]any)
+
+	if _, ok := paths["/hidden"]; ok {
+		t.Error("hidden route should not be documented")
+	}
+
+	post := paths["/users"].(map[string]any)["post"].(map[string]any)
+	if post["summary"] != "Create" {
+		t.Errorf("got summary %v, want Create", post["summary"])
+	}
+	if _, ok := post["requestBody"]; !ok {
+		t.Error("expected request body")
+	}
+
+	get := paths["/users/{id}"].(map[string]any)["get"].(map[string]any)
+	params := get["parameters"].([]map[string]any)
+	if len(params) != 1 || params[0]["name"] != "id" || params[0]["in"] != "path" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	user := schemas["testUser"].(map[string]any)
+	props := user["properties"].(map[string]any)
+	if _, ok := props["Password"]; ok {
+		t.Error("fields tagged with json:\"-\" should be skipped")
+	}
+	if props["created_at"].(map[string]any)["format"] != "date-time" {
+		t.Errorf("expected date-time format, got %v", props["created_at"])
+	}
+	if !reflect.DeepEqual(user["required"], []string{"created_at", "id", "name"}) {
+		t.Errorf("unexpected required fields %v", user["required"])
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	api := New("test", "1.0.0")
+	api.Add(Route{Method: "GET", Path: "/"})
+
+	rec := httptest.NewRecorder()
+	api.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("got openapi version %v", doc["openapi"])
+	}
+}
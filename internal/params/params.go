@@ -0,0 +1,253 @@
+package params
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+   Package params parses pagination, sorting and filtering query parameters
+   into typed structs with validation, allowed-field enforcement and defaults.
+
+   Supported query format:
+       ?page=2&per_page=50&sort=-created_at,username&filter[status]=active
+
+   How to use:
+   1. Define Options for the list endpoint (allowed sort and filter fields, defaults)
+   2. Parse the request query with Parse
+   3. Use the resulting Query to build the database query (Limit, Offset, OrderBy)
+
+   Example basic usage:
+       var userListOptions = params.Options{
+           DefaultPerPage: 20,
+           MaxPerPage:     100,
+           AllowedSorts:   []string{"created_at", "username"},
+           AllowedFilters: []string{"status"},
+           DefaultSort:    "-created_at",
+       }
+
+       func ListUsers(c *container.Container) http.HandlerFunc {
+           return func(w http.ResponseWriter, r *http.Request) {
+               q, err := params.Parse(r.URL.Query(), userListOptions)
+               if err != nil {
+                   http.Error(w, err.Error(), http.StatusBadRequest)
+                   return
+               }
+
+               users, err := queries.ListUsers(r.Context(), sqlc.ListUsersParams{
+                   Limit:  q.Limit(),
+                   Offset: q.Offset(),
+               })
+               // ...
+           }
+       }
+
+   Example building an ORDER BY clause:
+       // Safe to concatenate - only allowed fields can appear in the Query
+       query := "SELECT * FROM users ORDER BY " + q.OrderBy("id DESC")
+
+   Notes:
+   - Sort fields prefixed with "-" are sorted descending
+   - Unknown sort or filter fields are rejected with a *Error
+   - All validation problems are reported at once
+   - Page defaults to 1, per_page defaults to Options.DefaultPerPage
+*/
+
+// Default values used when Options leave them unset
+const (
+	DefaultPerPage    = 20
+	DefaultMaxPerPage = 100
+)
+
+// ErrInvalidParams is wrapped by all validation errors returned by Parse
+var ErrInvalidParams = errors.New("params: invalid query parameters")
+
+// Error holds all validation problems found while parsing, keyed by parameter name
+type Error struct {
+	Fields map[string]string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s: %s", k, e.Fields[k]))
+	}
+	return "invalid query parameters: " + strings.Join(parts, "; ")
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidParams)
+func (e *Error) Unwrap() error {
+	return ErrInvalidParams
+}
+
+// Options configures parsing for a list endpoint
+type Options struct {
+	DefaultPerPage int
+	MaxPerPage     int
+	// AllowedSorts lists fields that may be used in sort
+	AllowedSorts []string
+	// AllowedFilters lists fields that may be used in filter[field]
+	AllowedFilters []string
+	// DefaultSort is used when no sort is given, same format as the query value
+	DefaultSort string
+}
+
+// Sort is a single sort field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Query holds the parsed query parameters
+type Query struct {
+	Page    int
+	PerPage int
+	Sorts   []Sort
+	Filters map[string]string
+}
+
+// Parse parses the query values according to opts
+func Parse(values url.Values, opts Options) (*Query, error) {
+	if opts.DefaultPerPage <= 0 {
+		opts.DefaultPerPage = DefaultPerPage
+	}
+	if opts.MaxPerPage <= 0 {
+		opts.MaxPerPage = DefaultMaxPerPage
+	}
+
+	q := &Query{
+		Page:    1,
+		PerPage: opts.DefaultPerPage,
+		Filters: make(map[string]string),
+	}
+	problems := make(map[string]string)
+
+	if v := values.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			problems["page"] = "must be a positive integer"
+		} else {
+			q.Page = page
+		}
+	}
+
+	if v := values.Get("per_page"); v != "" {
+		perPage, err := strconv.Atoi(v)
+		switch {
+		case err != nil || perPage < 1:
+			problems["per_page"] = "must be a positive integer"
+		case perPage > opts.MaxPerPage:
+			problems["per_page"] = fmt.Sprintf("must not exceed %d", opts.MaxPerPage)
+		default:
+			q.PerPage = perPage
+		}
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = opts.DefaultSort
+	}
+	if sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			s := Sort{Field: field}
+			if strings.HasPrefix(field, "-") {
+				s.Field = field[1:]
+				s.Desc = true
+			}
+			if !slices.Contains(opts.AllowedSorts, s.Field) {
+				problems["sort"] = fmt.Sprintf("unknown field %q", s.Field)
+				continue
+			}
+			q.Sorts = append(q.Sorts, s)
+		}
+	}
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		field := key[len("filter[") : len(key)-1]
+		if !slices.Contains(opts.AllowedFilters, field) {
+			problems[key] = "unknown filter field"
+			continue
+		}
+		q.Filters[field] = vals[0]
+	}
+
+	if len(problems) > 0 {
+		return nil, &Error{Fields: problems}
+	}
+	return q, nil
+}
+
+// Limit returns the number of rows to fetch, for use in LIMIT clauses
+func (q *Query) Limit() int64 {
+	return int64(q.PerPage)
+}
+
+// Offset returns the number of rows to skip, for use in OFFSET clauses
+func (q *Query) Offset() int64 {
+	return int64((q.Page - 1) * q.PerPage)
+}
+
+// Filter returns the value of a filter and whether it was set
+func (q *Query) Filter(field string) (string, bool) {
+	v, ok := q.Filters[field]
+	return v, ok
+}
+
+// OrderBy returns an ORDER BY expression (without the keyword) built from the sorts
+// Returns fallback if no sort was requested
+func (q *Query) OrderBy(fallback string) string {
+	if len(q.Sorts) == 0 {
+		return fallback
+	}
+	parts := make([]string, 0, len(q.Sorts))
+	for _, s := range q.Sorts {
+		if s.Desc {
+			parts = append(parts, s.Field+" DESC")
+		} else {
+			parts = append(parts, s.Field+" ASC")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Page holds a page of results and pagination metadata, suitable for JSON responses
+type Page[T any] struct {
+	Items      []T   `json:"items"`
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// NewPage creates a Page from the query, the current items and the total item count
+func NewPage[T any](q *Query, items []T, total int64) Page[T] {
+	totalPages := total / int64(q.PerPage)
+	if total%int64(q.PerPage) != 0 {
+		totalPages++
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{
+		Items:      items,
+		Page:       q.Page,
+		PerPage:    q.PerPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
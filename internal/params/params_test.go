@@ -0,0 +1,78 @@
+package params
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+var testOptions = Options{
+	DefaultPerPage: 10,
+	MaxPerPage:     50,
+	AllowedSorts:   []string{"created_at", "username"},
+	AllowedFilters: []string{"status"},
+	DefaultSort:    "-created_at",
+}
+
+func TestParse(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		q, err := Parse(url.Values{}, testOptions)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if q.Page != 1 || q.PerPage != 10 {
+			t.Errorf("got page %d per_page %d, want 1 and 10", q.Page, q.PerPage)
+		}
+		if q.OrderBy("id") != "created_at DESC" {
+			t.Errorf("got order by %q", q.OrderBy("id"))
+		}
+	})
+
+	t.Run("full query", func(t *testing.T) {
+		values, _ := url.ParseQuery("page=3&per_page=25&sort=-created_at,username&filter[status]=active")
+		q, err := Parse(values, testOptions)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if q.Limit() != 25 || q.Offset() != 50 {
+			t.Errorf("got limit %d offset %d, want 25 and 50", q.Limit(), q.Offset())
+		}
+		if q.OrderBy("id") != "created_at DESC, username ASC" {
+			t.Errorf("got order by %q", q.OrderBy("id"))
+		}
+		if v, ok := q.Filter("status"); !ok || v != "active" {
+			t.Errorf("got filter %q, want active", v)
+		}
+	})
+
+	t.Run("invalid values are aggregated", func(t *testing.T) {
+		values, _ := url.ParseQuery("page=0&per_page=500&sort=password&filter[role]=admin")
+		_, err := Parse(values, testOptions)
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Fatalf("expected ErrInvalidParams, got %v", err)
+		}
+
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected *Error, got %T", err)
+		}
+		for _, key := range []string{"page", "per_page", "sort", "filter[role]"} {
+			if _, ok := perr.Fields[key]; !ok {
+				t.Errorf("expected problem for %s", key)
+			}
+		}
+	})
+}
+
+func TestNewPage(t *testing.T) {
+	q := &Query{Page: 2, PerPage: 10}
+	page := NewPage(q, []string{"a"}, 21)
+	if page.TotalPages != 3 {
+		t.Errorf("got %d total pages, want 3", page.TotalPages)
+	}
+
+	empty := NewPage[string](q, nil, 0)
+	if empty.Items == nil {
+		t.Error("items should never be nil")
+	}
+}
@@ -0,0 +1,276 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"slices"
+	"strconv"
+	"time"
+)
+
+/*
+   Package payments provides Stripe subscriptions: hosted checkout, the billing portal and a
+   webhook keeping the customers and subscriptions tables in sync, so features can be gated on
+   billing state. The Stripe API is called over plain HTTP, no Stripe SDK is needed.
+
+   How to use:
+   1. Create the products and prices in the Stripe dashboard and configure the price IDs
+   2. Create a service with the Stripe client and the event bus
+   3. Send users to Checkout to subscribe and to Portal to manage their subscription
+   4. Point a Stripe webhook endpoint at HandleWebhook, with at least the customer.subscription.* events
+   5. Gate handlers with middleware.RequireActiveSubscription or check HasActiveSubscription
+
+   Example basic usage:
+       stripe := payments.NewStripe(payments.StripeConfig{SecretKey: key, WebhookSecret: secret})
+       service := payments.New(db, stripe, bus, logger, payments.Options{Prices: []string{"price_123"}})
+
+       session, err := service.Checkout(ctx, userID, "price_123", "https://example.com/welcome", "https://example.com/pricing")
+       http.Redirect(w, r, session.URL, http.StatusSeeOther)
+
+   Example webhook handler:
+       payload, _ := io.ReadAll(r.Body)
+       err := service.HandleWebhook(r.Context(), payload, r.Header.Get("Stripe-Signature"))
+
+   Example reacting to billing changes:
+       events.Subscribe(bus, func(ctx context.Context, e events.SubscriptionChanged) error {
+           if !e.Active {
+               // Downgrade the user's workspace
+           }
+           return nil
+       })
+
+   Published events:
+   - events.StripeEvent for every webhook event with a valid signature, e.g. invoice.payment_failed
+   - events.SubscriptionChanged after a subscription of a known customer is stored
+
+   Notes:
+   - Active and trialing subscriptions are active, all other statuses (past_due, canceled, ...) aren't
+   - Stripe customers are created on the first checkout and linked to the user, events for
+     customers created outside the application are published but not stored
+   - Stripe retries webhooks on errors and may deliver events twice, storing subscriptions is idempotent
+*/
+
+// Statuses of active subscriptions
+var activeStatuses = []string{"active", "trialing"}
+
+var (
+	// ErrUnknownPrice is returned by Checkout for prices that aren't configured
+	ErrUnknownPrice = errors.New("payments: unknown price")
+	// ErrNoCustomer is returned by Portal for users who never checked out
+	ErrNoCustomer = errors.New("payments: user is not a customer")
+	// ErrNoSubscription is returned by ActiveSubscription for users without an active subscription
+	ErrNoSubscription = errors.New("payments: no active subscription")
+)
+
+// Options configures the service
+type Options struct {
+	// Prices are the Stripe price IDs users may subscribe to, the first is the default
+	Prices []string
+}
+
+// Service links users to Stripe customers and keeps their subscriptions in sync
+type Service struct {
+	queries *sqlc.Queries
+	stripe  *Stripe
+	bus     *events.Bus
+	logger  *slog.Logger
+	opts    Options
+}
+
+// New creates a payments service
+func New(db *sql.DB, stripe *Stripe, bus *events.Bus, logger *slog.Logger, opts Options) *Service {
+	return &Service{queries: sqlc.New(db), stripe: stripe, bus: bus, logger: logger, opts: opts}
+}
+
+// Prices returns the price IDs users may subscribe to
+func (s *Service) Prices() []string {
+	return s.opts.Prices
+}
+
+// Checkout creates a checkout session subscribing the user to a price, an empty price ID uses the default
+func (s *Service) Checkout(ctx context.Context, userID int64, priceID, successURL, cancelURL string) (*Session, error) {
+	if priceID == "" && len(s.opts.Prices) > 0 {
+		priceID = s.opts.Prices[0]
+	}
+	if !slices.Contains(s.opts.Prices, priceID) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPrice, priceID)
+	}
+	customerID, err := s.customer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.stripe.CreateCheckoutSession(ctx, CheckoutParams{
+		CustomerID:        customerID,
+		PriceID:           priceID,
+		ClientReferenceID: strconv.FormatInt(userID, 10),
+		SuccessURL:        successURL,
+		CancelURL:         cancelURL,
+	})
+}
+
+// Portal creates a billing portal session for the user, returning to returnURL
+func (s *Service) Portal(ctx context.Context, userID int64, returnURL string) (*Session, error) {
+	customer, err := s.queries.GetCustomerByUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoCustomer
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.stripe.CreatePortalSession(ctx, customer.StripeCustomerID, returnURL)
+}
+
+// ActiveSubscription returns the user's active subscription, ErrNoSubscription if there is none
+func (s *Service) ActiveSubscription(ctx context.Context, userID int64) (*sqlc.Subscription, error) {
+	sub, err := s.queries.GetActiveSubscriptionByUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// HasActiveSubscription reports whether the user has an active subscription
+func (s *Service) HasActiveSubscription(ctx context.Context, userID int64) (bool, error) {
+	_, err := s.ActiveSubscription(ctx, userID)
+	if errors.Is(err, ErrNoSubscription) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Subscriptions returns all subscriptions of the user, newest first
+func (s *Service) Subscriptions(ctx context.Context, userID int64) ([]sqlc.Subscription, error) {
+	return s.queries.ListSubscriptionsByUser(ctx, userID)
+}
+
+// HandleWebhook verifies a Stripe webhook, stores subscription changes and publishes the event
+// Returns ErrInvalidSignature if the payload isn't signed with the webhook secret
+func (s *Service) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := s.stripe.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		if err := s.syncSubscription(ctx, event.Data.Object); err != nil {
+			return err
+		}
+	}
+	return s.bus.Publish(ctx, events.StripeEvent{ID: event.ID, Type: event.Type, Data: event.Data.Object})
+}
+
+// stripeSubscription is the part of a Stripe subscription object that is stored
+type stripeSubscription struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	Items             struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// syncSubscription stores a subscription object of a known customer and publishes SubscriptionChanged
+func (s *Service) syncSubscription(ctx context.Context, object json.RawMessage) error {
+	var sub stripeSubscription
+	if err := json.Unmarshal(object, &sub); err != nil {
+		return fmt.Errorf("payments: invalid subscription object: %w", err)
+	}
+	customer, err := s.queries.GetCustomerByStripeID(ctx, sub.Customer)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.logger.Warn("Ignoring subscription of unknown customer", "subscription", sub.ID, "customer", sub.Customer)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Newer API versions moved the period end from the subscription to its items
+	var priceID string
+	periodEnd := sub.CurrentPeriodEnd
+	if len(sub.Items.Data) > 0 {
+		priceID = sub.Items.Data[0].Price.ID
+		if periodEnd == 0 {
+			periodEnd = sub.Items.Data[0].CurrentPeriodEnd
+		}
+	}
+
+	row, err := s.queries.UpsertSubscription(ctx, sqlc.UpsertSubscriptionParams{
+		UserID:               customer.UserID,
+		StripeSubscriptionID: sub.ID,
+		StripeCustomerID:     sub.Customer,
+		Status:               sub.Status,
+		PriceID:              priceID,
+		CurrentPeriodEnd:     time.Unix(periodEnd, 0).UTC(),
+		CancelAtPeriodEnd:    sub.CancelAtPeriodEnd,
+	})
+	if err != nil {
+		return err
+	}
+	return s.bus.Publish(ctx, events.SubscriptionChanged{
+		UserID:         row.UserID,
+		SubscriptionID: row.StripeSubscriptionID,
+		Status:         row.Status,
+		PriceID:        row.PriceID,
+		Active:         Active(row),
+	})
+}
+
+// customer returns the Stripe customer ID of the user, creating the customer if needed
+func (s *Service) customer(ctx context.Context, userID int64) (string, error) {
+	customer, err := s.queries.GetCustomerByUser(ctx, userID)
+	if err == nil {
+		return customer.StripeCustomerID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	id, err := s.stripe.CreateCustomer(ctx, user.Email, map[string]string{"user_id": strconv.FormatInt(userID, 10)})
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.queries.CreateCustomer(ctx, sqlc.CreateCustomerParams{UserID: userID, StripeCustomerID: id}); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Active reports whether a subscription's status is active or trialing
+func Active(sub sqlc.Subscription) bool {
+	return slices.Contains(activeStatuses, sub.Status)
+}
+
+// contextKey is the type of context keys of this package
+type contextKey struct{}
+
+// WithSubscription returns a context carrying the subscription
+func WithSubscription(ctx context.Context, sub *sqlc.Subscription) context.Context {
+	return context.WithValue(ctx, contextKey{}, sub)
+}
+
+// SubscriptionFromContext returns the subscription set by RequireActiveSubscription, nil if there is none
+func SubscriptionFromContext(ctx context.Context) *sqlc.Subscription {
+	sub, _ := ctx.Value(contextKey{}).(*sqlc.Subscription)
+	return sub
+}
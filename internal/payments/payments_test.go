@@ -0,0 +1,191 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mookie/internal/db"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+// newTestService returns a service against a fake Stripe API with one user, and the number of created customers
+func newTestService(t *testing.T) (*Service, *events.Bus, *atomic.Int32) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if _, err := sqlc.New(database).CreateUser(context.Background(), sqlc.CreateUserParams{Username: "alice", Email: "alice@example.com", Password: "x"}); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+
+	var customers atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/customers", func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			t.Errorf("secret key = %q", user)
+		}
+		if r.FormValue("email") != "alice@example.com" || r.FormValue("metadata[user_id]") != "1" {
+			t.Errorf("customer form = %v", r.Form)
+		}
+		customers.Add(1)
+		fmt.Fprint(w, `{"id": "cus_1"}`)
+	})
+	mux.HandleFunc("POST /v1/checkout/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("customer") != "cus_1" || r.FormValue("line_items[0][price]") != "price_basic" || r.FormValue("client_reference_id") != "1" {
+			t.Errorf("checkout form = %v", r.Form)
+		}
+		fmt.Fprint(w, `{"id": "cs_1", "url": "https://checkout.stripe.com/c/cs_1"}`)
+	})
+	mux.HandleFunc("POST /v1/billing_portal/sessions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "bps_1", "url": "https://billing.stripe.com/p/bps_1"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	bus := events.New()
+	stripe := NewStripe(StripeConfig{SecretKey: "sk_test", WebhookSecret: testSecret, BaseURL: server.URL})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(database, stripe, bus, logger, Options{Prices: []string{"price_basic", "price_pro"}}), bus, &customers
+}
+
+// signed returns a Stripe-Signature header for the payload
+func signed(payload string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + signWebhook(testSecret, timestamp, []byte(payload))
+}
+
+// subscriptionEvent returns a customer.subscription event payload
+func subscriptionEvent(eventType, customer, status string) string {
+	return fmt.Sprintf(`{"id": "evt_1", "type": %q, "data": {"object": {
+		"id": "sub_1", "customer": %q, "status": %q, "cancel_at_period_end": false,
+		"items": {"data": [{"current_period_end": 1893456000, "price": {"id": "price_basic"}}]}
+	}}}`, eventType, customer, status)
+}
+
+func TestCheckout(t *testing.T) {
+	service, _, customers := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.Checkout(ctx, 1, "price_other", "https://example.com/ok", "https://example.com/cancel"); !errors.Is(err, ErrUnknownPrice) {
+		t.Fatalf("err = %v, want ErrUnknownPrice", err)
+	}
+	if _, err := service.Portal(ctx, 1, "https://example.com"); !errors.Is(err, ErrNoCustomer) {
+		t.Fatalf("err = %v, want ErrNoCustomer", err)
+	}
+
+	for range 2 {
+		session, err := service.Checkout(ctx, 1, "", "https://example.com/ok", "https://example.com/cancel")
+		if err != nil {
+			t.Fatalf("Checkout returned error: %v", err)
+		}
+		if session.URL != "https://checkout.stripe.com/c/cs_1" {
+			t.Errorf("session = %+v", session)
+		}
+	}
+	if n := customers.Load(); n != 1 {
+		t.Errorf("created %d customers, want 1", n)
+	}
+	if _, err := service.Portal(ctx, 1, "https://example.com"); err != nil {
+		t.Errorf("Portal returned error: %v", err)
+	}
+}
+
+func TestWebhookSubscription(t *testing.T) {
+	service, bus, _ := newTestService(t)
+	ctx := context.Background()
+	service.Checkout(ctx, 1, "", "https://example.com/ok", "https://example.com/cancel")
+
+	var changes []events.SubscriptionChanged
+	events.Subscribe(bus, func(ctx context.Context, e events.SubscriptionChanged) error {
+		changes = append(changes, e)
+		return nil
+	})
+	var received []string
+	events.Subscribe(bus, func(ctx context.Context, e events.StripeEvent) error {
+		received = append(received, e.Type)
+		return nil
+	})
+
+	payload := subscriptionEvent("customer.subscription.created", "cus_1", "active")
+	if err := service.HandleWebhook(ctx, []byte(payload), signed(payload, time.Now())); err != nil {
+		t.Fatalf("HandleWebhook returned error: %v", err)
+	}
+	sub, err := service.ActiveSubscription(ctx, 1)
+	if err != nil {
+		t.Fatalf("ActiveSubscription returned error: %v", err)
+	}
+	if sub.PriceID != "price_basic" || sub.CurrentPeriodEnd.Unix() != 1893456000 {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	payload = subscriptionEvent("customer.subscription.deleted", "cus_1", "canceled")
+	service.HandleWebhook(ctx, []byte(payload), signed(payload, time.Now()))
+	if active, _ := service.HasActiveSubscription(ctx, 1); active {
+		t.Error("expected the canceled subscription to be inactive")
+	}
+	if subs, _ := service.Subscriptions(ctx, 1); len(subs) != 1 {
+		t.Errorf("got %d subscriptions, want the one updated in place", len(subs))
+	}
+
+	if len(changes) != 2 || !changes[0].Active || changes[1].Active || changes[1].Status != "canceled" {
+		t.Errorf("changes = %+v", changes)
+	}
+	if len(received) != 2 || received[1] != "customer.subscription.deleted" {
+		t.Errorf("received = %v", received)
+	}
+}
+
+func TestWebhookUnknownCustomer(t *testing.T) {
+	service, _, _ := newTestService(t)
+	payload := subscriptionEvent("customer.subscription.created", "cus_unknown", "active")
+	if err := service.HandleWebhook(context.Background(), []byte(payload), signed(payload, time.Now())); err != nil {
+		t.Fatalf("HandleWebhook returned error: %v", err)
+	}
+	if subs, _ := service.Subscriptions(context.Background(), 1); len(subs) != 0 {
+		t.Errorf("expected no stored subscriptions, got %d", len(subs))
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	payload := []byte(`{"id": "evt_1", "type": "invoice.paid", "data": {"object": {}}}`)
+	now := time.Now()
+	valid := signed(string(payload), now)
+
+	tests := []struct {
+		name   string
+		header string
+		at     time.Time
+		ok     bool
+	}{
+		{"valid", valid, now, true},
+		{"rotated secret", valid + ",v1=" + signWebhook("whsec_old", "1", payload), now, true},
+		{"expired", valid, now.Add(10 * time.Minute), false},
+		{"wrong signature", "t=" + strconv.FormatInt(now.Unix(), 10) + ",v1=00", now, false},
+		{"missing", "", now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := verifyWebhook(testSecret, payload, tt.header, tt.at)
+			if tt.ok && (err != nil || event.Type != "invoice.paid") {
+				t.Errorf("event = %+v, err = %v", event, err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("err = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
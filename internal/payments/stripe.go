@@ -0,0 +1,210 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature is returned by VerifyWebhook when the Stripe-Signature header doesn't match
+var ErrInvalidSignature = errors.New("payments: invalid webhook signature")
+
+// StripeConfig configures the Stripe API client
+type StripeConfig struct {
+	SecretKey string
+	// WebhookSecret is the signing secret of the webhook endpoint, whsec_...
+	WebhookSecret string
+	// BaseURL of the API, defaults to https://api.stripe.com
+	BaseURL string
+	// Client sends the requests, defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+// Stripe is a minimal client of the Stripe API covering customers, checkout and the billing portal
+type Stripe struct {
+	config StripeConfig
+}
+
+// NewStripe creates a Stripe API client
+func NewStripe(config StripeConfig) *Stripe {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.stripe.com"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Stripe{config: config}
+}
+
+// Session is a checkout or billing portal session, redirect the user to URL
+type Session struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// stripeError is the error body of the Stripe API
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CreateCustomer creates a customer and returns its ID
+func (s *Stripe) CreateCustomer(ctx context.Context, email string, metadata map[string]string) (string, error) {
+	form := url.Values{"email": {email}}
+	for k, v := range metadata {
+		form.Set("metadata["+k+"]", v)
+	}
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := s.post(ctx, "/v1/customers", form, &customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+// CheckoutParams describes a subscription checkout session
+type CheckoutParams struct {
+	CustomerID string
+	PriceID    string
+	// ClientReferenceID is passed back in the checkout.session.completed event
+	ClientReferenceID string
+	SuccessURL        string
+	CancelURL         string
+}
+
+// CreateCheckoutSession creates a hosted checkout session for a subscription
+func (s *Stripe) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*Session, error) {
+	form := url.Values{
+		"mode":                    {"subscription"},
+		"customer":                {params.CustomerID},
+		"line_items[0][price]":    {params.PriceID},
+		"line_items[0][quantity]": {"1"},
+		"success_url":             {params.SuccessURL},
+		"cancel_url":              {params.CancelURL},
+	}
+	if params.ClientReferenceID != "" {
+		form.Set("client_reference_id", params.ClientReferenceID)
+	}
+	var session Session
+	if err := s.post(ctx, "/v1/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CreatePortalSession creates a billing portal session where the customer manages their subscriptions
+func (s *Stripe) CreatePortalSession(ctx context.Context, customerID, returnURL string) (*Session, error) {
+	form := url.Values{"customer": {customerID}, "return_url": {returnURL}}
+	var session Session
+	if err := s.post(ctx, "/v1/billing_portal/sessions", form, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// post sends a form encoded request and decodes the JSON response into v
+func (s *Stripe) post(ctx context.Context, path string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.config.SecretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr stripeError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("payments: stripe responded with %d: %s (%s)", resp.StatusCode, apiErr.Error.Message, apiErr.Error.Type)
+		}
+		return fmt.Errorf("payments: stripe responded with %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Event is a Stripe webhook event
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// webhookTolerance is how old a webhook signature timestamp may be
+const webhookTolerance = 5 * time.Minute
+
+// VerifyWebhook checks the Stripe-Signature header of a webhook payload and parses the event
+func (s *Stripe) VerifyWebhook(payload []byte, header string) (*Event, error) {
+	return verifyWebhook(s.config.WebhookSecret, payload, header, time.Now())
+}
+
+// verifyWebhook checks a "t=<timestamp>,v1=<signature>,..." header at the given time
+func verifyWebhook(secret string, payload []byte, header string, now time.Time) (*Event, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return nil, fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+
+	expected := signWebhook(secret, timestamp, payload)
+	valid := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("payments: invalid webhook payload: %w", err)
+	}
+	return &event, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<payload>"
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
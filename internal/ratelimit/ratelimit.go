@@ -0,0 +1,312 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"mookie/internal/cache"
+	"sync"
+	"time"
+)
+
+/*
+   Package ratelimit limits how often a key - a client IP, a user ID, a websocket client - may do
+   something. The limiter state is stored in a cache.Cache, so limits are shared by everything
+   using the same cache.
+
+   Two algorithms are available:
+   - Token bucket: Rate tokens are refilled every Per and up to Burst can be spent at once,
+     good for smoothing bursty traffic such as API requests or websocket messages
+   - Sliding window: at most Rate events in any Per window, weighted between the current and
+     previous fixed window, good for strict counts such as login attempts
+
+   How to use:
+   1. Create a limiter with a cache, a key prefix and a limit
+   2. Call Allow with a key before doing the limited thing, and reject the caller if it's not allowed
+   3. Use Wait instead to block until the key is allowed again
+
+   Example basic usage:
+       limiter := ratelimit.NewTokenBucket(cache.NewMemoryCache(), "api", ratelimit.Limit{Rate: 100, Per: time.Minute})
+
+       result, err := limiter.Allow(clientIP)
+       if err == nil && !result.Allowed {
+           w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())+1))
+           http.Error(w, "too many requests", http.StatusTooManyRequests)
+           return
+       }
+
+   Example counting failures only:
+       limiter := ratelimit.NewSlidingWindow(store, "login", ratelimit.Limit{Rate: 10, Per: 15 * time.Minute})
+       if result, _ := limiter.Peek(ip); !result.Allowed {
+           // Too many failed attempts, reject without checking the password
+       }
+       if !passwordMatches {
+           limiter.Allow(ip)   // Counts the failure
+       }
+
+   Example waiting for a slot:
+       if err := limiter.Wait(ctx, "mail"); err != nil {
+           return err   // The context was cancelled first
+       }
+
+   Notes:
+   - Limiter state is stored as values of unexported types, a cache that serializes values
+     across processes must be able to encode them
+   - Cache errors are returned together with an allowed result, so callers can fail open
+   - Updates are atomic within a limiter, but not across processes sharing a cache
+   - SetLimit changes the limit at runtime, stored token buckets keep their tokens and refill at the new rate
+*/
+
+// ErrInvalidLimit is returned by Wait when the limit can never allow a request
+var ErrInvalidLimit = errors.New("ratelimit: limit never allows requests")
+
+// Limit is the number of events allowed per period
+type Limit struct {
+	// Rate is the number of events allowed every Per
+	Rate int
+	// Per is the period of the rate
+	Per time.Duration
+	// Burst is the token bucket capacity, defaults to Rate - ignored by the sliding window
+	Burst int
+}
+
+// Result describes the state of a key after a call to Allow or Peek
+type Result struct {
+	// Allowed reports whether the event is allowed
+	Allowed bool
+	// Limit is the maximum number of events allowed at once
+	Limit int
+	// Remaining is the number of events still allowed right now
+	Remaining int
+	// ResetAt is when the key is back to its full limit
+	ResetAt time.Time
+	// RetryAfter is how long to wait before the next event is allowed, zero if it's allowed now
+	RetryAfter time.Duration
+}
+
+// algorithm computes a result from the stored state of a key and returns the new state
+type algorithm interface {
+	take(state any, now time.Time, consume bool) (any, Result)
+	// withLimit returns the algorithm with a different limit
+	withLimit(limit Limit) algorithm
+}
+
+// Limiter limits events per key
+type Limiter struct {
+	cache     cache.Cache
+	prefix    string
+	limit     Limit
+	algorithm algorithm
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+// NewTokenBucket creates a token bucket limiter storing its state in c under prefix
+func NewTokenBucket(c cache.Cache, prefix string, limit Limit) *Limiter {
+	return newLimiter(c, prefix, limit, tokenBucket{}.withLimit(limit))
+}
+
+// NewSlidingWindow creates a sliding window limiter storing its state in c under prefix
+func NewSlidingWindow(c cache.Cache, prefix string, limit Limit) *Limiter {
+	return newLimiter(c, prefix, limit, slidingWindow{limit: limit})
+}
+
+// newLimiter creates a limiter with the given algorithm
+func newLimiter(c cache.Cache, prefix string, limit Limit, alg algorithm) *Limiter {
+	return &Limiter{cache: c, prefix: prefix, limit: limit, algorithm: alg, now: time.Now}
+}
+
+// SetLimit changes the limit, e.g. after a config reload - the stored state of keys is kept
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.algorithm = l.algorithm.withLimit(limit)
+	l.limit = limit
+}
+
+// Allow records an event for key if it's allowed
+func (l *Limiter) Allow(key string) (Result, error) {
+	return l.take(key, true)
+}
+
+// Peek returns the state of key without recording an event
+func (l *Limiter) Peek(key string) (Result, error) {
+	return l.take(key, false)
+}
+
+// Wait blocks until an event for key is allowed and records it, or until ctx is done
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	limit := l.limit
+	l.mu.Unlock()
+	if limit.Rate <= 0 || limit.Per <= 0 {
+		return ErrInvalidLimit
+	}
+	for {
+		result, err := l.Allow(key)
+		if err != nil || result.Allowed {
+			return err
+		}
+
+		timer := time.NewTimer(max(result.RetryAfter, time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reset clears the state of key, e.g. after a successful login
+func (l *Limiter) Reset(key string) error {
+	return l.cache.Delete(l.cacheKey(key))
+}
+
+// take loads the state of key, applies the algorithm and stores the new state
+func (l *Limiter) take(key string, consume bool) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Missing and expired state both mean a fresh key
+	var state any
+	if item, err := l.cache.Get(l.cacheKey(key)); err == nil {
+		state = item.Value
+	}
+
+	now := l.now()
+	state, result := l.algorithm.take(state, now, consume)
+	if !consume {
+		return result, nil
+	}
+
+	// The state is worthless once the key is back to its full limit
+	ttl := result.ResetAt.Sub(now)
+	if ttl <= 0 {
+		ttl = time.Millisecond
+	}
+	if err := l.cache.Set(l.cacheKey(key), state, ttl); err != nil {
+		return Result{Allowed: true, Limit: result.Limit, Remaining: result.Remaining, ResetAt: result.ResetAt}, err
+	}
+	return result, nil
+}
+
+// cacheKey returns the cache key of a limiter key
+func (l *Limiter) cacheKey(key string) string {
+	return "ratelimit:" + l.prefix + ":" + key
+}
+
+// tokenBucket refills Rate tokens every Per up to Burst, every event spends a token
+type tokenBucket struct {
+	limit Limit
+}
+
+// bucketState is the stored state of a token bucket
+type bucketState struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// withLimit returns a token bucket with the limit, Burst defaults to Rate
+func (b tokenBucket) withLimit(limit Limit) algorithm {
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Rate
+	}
+	return tokenBucket{limit: limit}
+}
+
+// take refills the bucket up to now and spends a token if one is available
+func (b tokenBucket) take(state any, now time.Time, consume bool) (any, Result) {
+	capacity := float64(b.limit.Burst)
+	if b.limit.Rate <= 0 || b.limit.Per <= 0 {
+		return state, Result{Limit: b.limit.Burst, ResetAt: now}
+	}
+	perToken := b.limit.Per / time.Duration(b.limit.Rate)
+
+	s, ok := state.(bucketState)
+	if !ok {
+		s = bucketState{Tokens: capacity, Updated: now}
+	}
+	if elapsed := now.Sub(s.Updated); elapsed > 0 {
+		s.Tokens = math.Min(capacity, s.Tokens+float64(elapsed)/float64(perToken))
+	}
+	s.Updated = now
+
+	result := Result{Limit: b.limit.Burst}
+	if s.Tokens >= 1 {
+		result.Allowed = true
+		if consume {
+			s.Tokens--
+		}
+	} else {
+		result.RetryAfter = time.Duration((1 - s.Tokens) * float64(perToken))
+	}
+	result.Remaining = int(s.Tokens)
+	result.ResetAt = now.Add(time.Duration((capacity - s.Tokens) * float64(perToken)))
+	return s, result
+}
+
+// slidingWindow allows Rate events per Per, estimating the events of the last Per from the
+// count of the current fixed window and the weighted count of the previous one
+type slidingWindow struct {
+	limit Limit
+}
+
+// windowState is the stored state of a sliding window
+type windowState struct {
+	Start    time.Time
+	Current  int
+	Previous int
+}
+
+// withLimit returns a sliding window with the limit
+func (sw slidingWindow) withLimit(limit Limit) algorithm {
+	return slidingWindow{limit: limit}
+}
+
+// take advances the windows up to now and counts an event if the estimate is below the limit
+func (sw slidingWindow) take(state any, now time.Time, consume bool) (any, Result) {
+	if sw.limit.Rate <= 0 || sw.limit.Per <= 0 {
+		return state, Result{Limit: sw.limit.Rate, ResetAt: now}
+	}
+	per := sw.limit.Per
+
+	start := now.Truncate(per)
+	s, ok := state.(windowState)
+	switch {
+	case !ok || !start.Before(s.Start.Add(2*per)):
+		s = windowState{Start: start}
+	case start.After(s.Start):
+		s = windowState{Start: start, Previous: s.Current}
+	}
+
+	weight := 1 - float64(now.Sub(s.Start))/float64(per)
+	estimate := float64(s.Previous)*weight + float64(s.Current)
+
+	result := Result{Limit: sw.limit.Rate}
+	if estimate+1 <= float64(sw.limit.Rate) {
+		result.Allowed = true
+		if consume {
+			s.Current++
+			estimate++
+		}
+	} else if s.Previous > 0 {
+		// Wait until enough of the previous window has slid out
+		needed := estimate + 1 - float64(sw.limit.Rate)
+		wait := time.Duration(needed / float64(s.Previous) * float64(per))
+		if wait > s.Start.Add(per).Sub(now) {
+			wait = s.Start.Add(per).Sub(now)
+		}
+		result.RetryAfter = wait
+	} else {
+		result.RetryAfter = s.Start.Add(per).Sub(now)
+	}
+	result.Remaining = max(0, sw.limit.Rate-int(math.Ceil(estimate)))
+
+	// The current window's events count until the end of the next window
+	result.ResetAt = s.Start.Add(2 * per)
+	if s.Current == 0 {
+		result.ResetAt = s.Start.Add(per)
+	}
+	return s, result
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"mookie/internal/cache"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a limiter clock and a function advancing it
+func fakeClock(l *Limiter) func(time.Duration) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTokenBucket(t *testing.T) {
+	l := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{Rate: 10, Per: 10 * time.Second, Burst: 3})
+	advance := fakeClock(l)
+
+	for i := 0; i < 3; i++ {
+		result, err := l.Allow("a")
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: allowed = %v, err = %v", i, result.Allowed, err)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("request %d: remaining = %d, want %d", i, result.Remaining, 2-i)
+		}
+	}
+
+	result, _ := l.Allow("a")
+	if result.Allowed {
+		t.Fatal("expected the empty bucket to reject")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("retry after = %v, want 1s", result.RetryAfter)
+	}
+	if result.Limit != 3 {
+		t.Errorf("limit = %d, want 3", result.Limit)
+	}
+
+	// Other keys have their own bucket
+	if result, _ := l.Allow("b"); !result.Allowed {
+		t.Error("expected another key to be allowed")
+	}
+
+	advance(time.Second)
+	if result, _ := l.Allow("a"); !result.Allowed {
+		t.Error("expected a refilled token to be allowed")
+	}
+
+	// The bucket never holds more than the burst
+	advance(time.Hour)
+	result, _ = l.Peek("a")
+	if result.Remaining != 3 {
+		t.Errorf("remaining after refill = %d, want 3", result.Remaining)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l := NewSlidingWindow(cache.NewMemoryCache(), "test", Limit{Rate: 4, Per: time.Minute})
+	advance := fakeClock(l)
+
+	for i := 0; i < 4; i++ {
+		if result, _ := l.Allow("a"); !result.Allowed {
+			t.Fatalf("request %d rejected", i)
+		}
+	}
+	result, _ := l.Allow("a")
+	if result.Allowed || result.Remaining != 0 {
+		t.Fatalf("expected the full window to reject, got %+v", result)
+	}
+	if result.RetryAfter != time.Minute {
+		t.Errorf("retry after = %v, want 1m", result.RetryAfter)
+	}
+
+	// Halfway through the next window half of the previous events still count
+	advance(90 * time.Second)
+	result, _ = l.Peek("a")
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("expected 2 remaining halfway through the next window, got %+v", result)
+	}
+	l.Allow("a")
+	l.Allow("a")
+	if result, _ := l.Allow("a"); result.Allowed {
+		t.Error("expected the weighted previous window to reject")
+	}
+
+	// Two windows later nothing counts anymore
+	advance(2 * time.Minute)
+	if result, _ := l.Peek("a"); result.Remaining != 4 {
+		t.Errorf("remaining = %d, want 4", result.Remaining)
+	}
+}
+
+func TestPeekDoesNotConsume(t *testing.T) {
+	l := NewSlidingWindow(cache.NewMemoryCache(), "test", Limit{Rate: 1, Per: time.Minute})
+	fakeClock(l)
+
+	for i := 0; i < 3; i++ {
+		if result, _ := l.Peek("a"); !result.Allowed {
+			t.Fatal("peek consumed an event")
+		}
+	}
+	l.Allow("a")
+	if result, _ := l.Peek("a"); result.Allowed {
+		t.Error("expected peek to see the recorded event")
+	}
+}
+
+func TestReset(t *testing.T) {
+	l := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{Rate: 1, Per: time.Hour})
+	fakeClock(l)
+
+	l.Allow("a")
+	if result, _ := l.Allow("a"); result.Allowed {
+		t.Fatal("expected the second request to be rejected")
+	}
+	if err := l.Reset("a"); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := l.Allow("a"); !result.Allowed {
+		t.Error("expected the reset key to be allowed")
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{Rate: 100, Per: time.Second, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("waited %v, want at least 15ms for two refills", elapsed)
+	}
+
+	slow := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{Rate: 1, Per: time.Hour})
+	slow.Allow("a")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slow.Wait(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	if err := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{}).Wait(context.Background(), "a"); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("err = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	l := NewTokenBucket(cache.NewMemoryCache(), "test", Limit{Rate: 1, Per: time.Minute})
+	advance := fakeClock(l)
+
+	if result, _ := l.Allow("a"); !result.Allowed {
+		t.Fatal("expected the first request to be allowed")
+	}
+	if result, _ := l.Allow("a"); result.Allowed {
+		t.Fatal("expected the second request to be rejected")
+	}
+
+	// The empty bucket is kept and refills at the new rate
+	l.SetLimit(Limit{Rate: 3, Per: time.Minute})
+	result, _ := l.Allow("a")
+	if result.Allowed || result.Limit != 3 || result.RetryAfter != 20*time.Second {
+		t.Fatalf("after raising the limit: %+v", result)
+	}
+	advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		if result, _ := l.Allow("a"); !result.Allowed {
+			t.Fatalf("request %d after the refill was rejected", i)
+		}
+	}
+}
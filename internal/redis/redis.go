@@ -0,0 +1,334 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+	Package redis is a minimal Redis client for publish/subscribe, e.g. to relay websocket broadcasts
+	between the instances of a deployment.
+
+	Example basic usage:
+	   client, err := redis.New("redis://:password@localhost:6379")
+
+	   // Deliver the messages of a channel until ctx is done or the connection fails
+	   go client.Subscribe(ctx, "events", func(payload []byte) {
+		   log.Println("received", string(payload))
+	   })
+
+	   // Send a message to the subscribers of every instance
+	   receivers, err := client.Publish(ctx, "events", []byte("hello"))
+
+	Notes:
+	- Only PING, PUBLISH and SUBSCRIBE are supported, there is no key/value API
+	- URLs are redis://[[username]:password@]host[:port], rediss:// connects with TLS, the database
+	  number is ignored since channels are shared by all databases
+	- Commands share one connection, which is dialed on first use and again after an error
+	- Subscribe holds its own connection and returns when it fails, callers resubscribe - messages
+	  published in between are lost, Redis doesn't keep them
+*/
+
+// DefaultTimeout bounds dialing and commands without a context deadline
+const DefaultTimeout = 5 * time.Second
+
+// Error is an error reply of the server
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Client sends commands to a Redis server
+type Client struct {
+	addr     string
+	username string
+	password string
+	tls      *tls.Config
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// New returns a client of the server at rawURL, it connects on first use
+func New(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("redis: invalid URL: %w", err)
+	}
+	c := &Client{addr: u.Host}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		c.tls = &tls.Config{ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("redis: invalid URL scheme %q, expected redis or rediss", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("redis: URL without host")
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	return c, nil
+}
+
+// Ping checks the connection to the server
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// Publish sends payload to the subscribers of channel and returns how many received it
+func (c *Client) Publish(ctx context.Context, channel string, payload []byte) (int64, error) {
+	reply, err := c.do(ctx, "PUBLISH", channel, string(payload))
+	if err != nil {
+		return 0, err
+	}
+	receivers, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected PUBLISH reply %v", reply)
+	}
+	return receivers, nil
+}
+
+// Subscribe calls handler with the messages of channel until ctx is done, returning ctx.Err(), or the
+// connection fails. handler is called in the goroutine of Subscribe, one message at a time.
+func (c *Client) Subscribe(ctx context.Context, channel string, handler func(payload []byte)) error {
+	conn, reader, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Unblock the reads when ctx is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := command(conn, reader, deadline(ctx), "SUBSCRIBE", channel); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		// Messages are arrays of "message", the channel and the payload
+		push, ok := reply.([]any)
+		if !ok || len(push) != 3 {
+			continue
+		}
+		if kind, _ := push[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		payload, _ := push[2].([]byte)
+		handler(payload)
+	}
+}
+
+// Close closes the connection of the commands, Subscribe connections close with their context
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
+
+// do runs a command on the shared connection, dropping the connection on errors other than error replies
+func (c *Client) do(ctx context.Context, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		conn, reader, err := c.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.conn, c.reader = conn, reader
+	}
+	// Unblock the command when ctx is done
+	conn := c.conn
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := write(c.conn, deadline(ctx), args); err != nil {
+		c.drop()
+		return nil, err
+	}
+	reply, err := readReply(c.reader)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		c.drop()
+	}
+	return reply, err
+}
+
+// drop closes the shared connection after an error, the caller holds the lock
+func (c *Client) drop() {
+	c.conn.Close()
+	c.conn, c.reader = nil, nil
+}
+
+// dial connects to the server and authenticates
+func (c *Client) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := &net.Dialer{Timeout: DefaultTimeout}
+	var conn net.Conn
+	var err error
+	if c.tls != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("redis: connecting to %s: %w", c.addr, err)
+	}
+	reader := bufio.NewReader(conn)
+
+	if c.password != "" {
+		args := []string{"AUTH", c.password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, c.password}
+		}
+		stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+		err := command(conn, reader, deadline(ctx), args...)
+		stop()
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return conn, reader, nil
+}
+
+// command writes a command to conn and reads its reply, returning error replies as errors
+func command(conn net.Conn, reader *bufio.Reader, deadline time.Time, args ...string) error {
+	if err := write(conn, deadline, args); err != nil {
+		return err
+	}
+	_, err := readReply(reader)
+	return err
+}
+
+// deadline returns the deadline of ctx, or DefaultTimeout from now
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(DefaultTimeout)
+}
+
+// write sends a command as an array of bulk strings
+func write(conn net.Conn, deadline time.Time, args []string) error {
+	conn.SetDeadline(deadline)
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return fmt.Errorf("redis: writing command: %w", err)
+	}
+	return nil
+}
+
+// readReply reads a reply: simple strings as string, integers as int64, bulk strings as []byte or nil,
+// arrays as []any and error replies as Error
+func readReply(reader *bufio.Reader) (any, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer reply %q", line)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk string length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, fmt.Errorf("redis: reading reply: %w", err)
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			// Error replies inside arrays are kept as values
+			item, err := readReply(reader)
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				item, err = replyErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+// readLine reads a line without its CRLF
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("redis: reading reply: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Flash levels
+const (
+	FlashSuccess = "success"
+	FlashInfo    = "info"
+	FlashWarning = "warning"
+	FlashError   = "error"
+)
+
+// flashCookie stores the flash messages until the next rendered page
+const flashCookie = "flash"
+
+// Flash is a one-time message shown on the next rendered page, usually after a redirect
+type Flash struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// AddFlash queues a message for the next page rendered with Render
+func AddFlash(w http.ResponseWriter, r *http.Request, level, message string) {
+	flashes := pendingFlashes(w)
+	if flashes == nil {
+		flashes = requestFlashes(r)
+	}
+	flashes = append(flashes, Flash{Level: level, Message: message})
+
+	data, err := json.Marshal(flashes)
+	if err != nil {
+		return
+	}
+	removeFlashCookie(w)
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookie,
+		Value:    base64.RawURLEncoding.EncodeToString(data),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// consumeFlashes returns the flashes of the request and the ones added to this response, and clears them
+func consumeFlashes(w http.ResponseWriter, r *http.Request) []Flash {
+	flashes := pendingFlashes(w)
+	if flashes == nil {
+		flashes = requestFlashes(r)
+	}
+	if flashes == nil {
+		return nil
+	}
+
+	removeFlashCookie(w)
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return flashes
+}
+
+// requestFlashes decodes the flashes sent by the client, nil if there are none
+func requestFlashes(r *http.Request) []Flash {
+	cookie, err := r.Cookie(flashCookie)
+	if err != nil {
+		return nil
+	}
+	return decodeFlashes(cookie.Value)
+}
+
+// pendingFlashes decodes the flashes already set on the response, nil if there are none
+func pendingFlashes(w http.ResponseWriter) []Flash {
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	for _, cookie := range slices.Backward(cookies) {
+		if cookie.Name == flashCookie && cookie.MaxAge >= 0 {
+			return decodeFlashes(cookie.Value)
+		}
+	}
+	return nil
+}
+
+// removeFlashCookie removes flash cookies already set on the response
+func removeFlashCookie(w http.ResponseWriter) {
+	w.Header()["Set-Cookie"] = slices.DeleteFunc(w.Header()["Set-Cookie"], func(header string) bool {
+		return strings.HasPrefix(header, flashCookie+"=")
+	})
+}
+
+// decodeFlashes decodes a flash cookie value, invalid values are ignored
+func decodeFlashes(value string) []Flash {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var flashes []Flash
+	if err := json.Unmarshal(data, &flashes); err != nil {
+		return nil
+	}
+	return flashes
+}
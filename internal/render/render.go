@@ -0,0 +1,161 @@
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+/*
+   Package render provides response rendering helpers for templ components and JSON,
+   including content negotiation so one handler can serve browsers and API clients.
+
+   How to use:
+   1. Build the templ component and the JSON value in the handler
+   2. Call Auto to render whichever the client asked for
+
+   Example basic usage:
+       func GetUser(w http.ResponseWriter, r *http.Request) {
+           user := loadUser(r)
+           render.Auto(w, r, pages.User(user), user)
+       }
+
+   The format is chosen by:
+   1. The ?format=json or ?format=html query parameter if present
+   2. The Accept header - the highest quality of application/json and text/html wins
+   3. HTML when neither is expressed
+
+   Example rendering a specific format:
+       render.JSON(w, http.StatusCreated, user)
+       render.HTML(w, r, http.StatusOK, pages.User(user))
+
+   Example rendering a page with view data:
+       func Profile(w http.ResponseWriter, r *http.Request) {
+           render.Render(w, r, pages.Profile(), profile)
+       }
+
+       // Inside any template rendered by Render - the current user, CSRF token, flashes,
+       // request ID and path are filled in automatically
+       templ Profile() {
+           if user := render.View(ctx).User; user != nil {
+               <p>Signed in as { user.Username }</p>
+           }
+       }
+
+   Example flash messages shown on the next rendered page:
+       render.AddFlash(w, r, render.FlashSuccess, "Profile saved")
+       http.Redirect(w, r, "/profile", http.StatusSeeOther)
+
+   Notes:
+   - Auto sets Vary: Accept so caches keep both representations apart
+   - A nil component always renders JSON, a nil value always renders HTML
+   - Flashes are stored in a cookie and cleared by the first page rendered with Render
+*/
+
+// Supported formats
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+)
+
+// mediaTypes maps formats to the media types that select them
+var mediaTypes = map[string][]string{
+	FormatHTML: {"text/html", "application/xhtml+xml"},
+	FormatJSON: {"application/json"},
+}
+
+// Auto renders the component or the JSON value based on the requested format
+func Auto(w http.ResponseWriter, r *http.Request, component templ.Component, v any) error {
+	return AutoStatus(w, r, http.StatusOK, component, v)
+}
+
+// AutoStatus is Auto with a custom status code
+func AutoStatus(w http.ResponseWriter, r *http.Request, status int, component templ.Component, v any) error {
+	w.Header().Add("Vary", "Accept")
+
+	switch {
+	case component == nil:
+		return JSON(w, status, v)
+	case v == nil:
+		return HTML(w, r, status, component)
+	}
+
+	if Format(r) == FormatJSON {
+		return JSON(w, status, v)
+	}
+	return HTML(w, r, status, component)
+}
+
+// Format returns the format requested by the client, either FormatHTML or FormatJSON
+func Format(r *http.Request) string {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case FormatJSON:
+		return FormatJSON
+	case FormatHTML:
+		return FormatHTML
+	}
+
+	best, bestQ := FormatHTML, 0.0
+	for _, accepted := range parseAccept(r.Header.Get("Accept")) {
+		for _, format := range []string{FormatHTML, FormatJSON} {
+			for _, mediaType := range mediaTypes[format] {
+				if accepted.mediaType == mediaType && accepted.q > bestQ {
+					best, bestQ = format, accepted.q
+				}
+			}
+		}
+	}
+	return best
+}
+
+// JSON writes v as a JSON response
+func JSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// HTML renders the templ component as an HTML response
+func HTML(w http.ResponseWriter, r *http.Request, status int, component templ.Component) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	return component.Render(r.Context(), w)
+}
+
+// acceptEntry is a single media range from the Accept header
+type acceptEntry struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses the Accept header ordered by quality, highest first
+func parseAccept(header string) []acceptEntry {
+	var entries []acceptEntry
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.ToLower(key) == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		entries = append(entries, acceptEntry{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+	return entries
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		want   string
+	}{
+		{"no preference", "/", "", FormatHTML},
+		{"browser", "/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", FormatHTML},
+		{"api client", "/", "application/json", FormatJSON},
+		{"json preferred by quality", "/", "text/html;q=0.5, application/json", FormatJSON},
+		{"query override", "/?format=json", "text/html", FormatJSON},
+		{"query override html", "/?format=html", "application/json", FormatHTML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := Format(r); got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuto(t *testing.T) {
+	component := templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, err := io.WriteString(w, "<p>hello</p>")
+		return err
+	})
+	value := map[string]string{"message": "hello"}
+
+	t.Run("renders html", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Auto(w, httptest.NewRequest("GET", "/", nil), component, value)
+		if w.Body.String() != "<p>hello</p>" {
+			t.Errorf("got body %q", w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Error("expected Vary: Accept header")
+		}
+	})
+
+	t.Run("renders json", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		Auto(w, r, component, value)
+		if strings.TrimSpace(w.Body.String()) != `{"message":"hello"}` {
+			t.Errorf("got body %q", w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("got content type %q", w.Header().Get("Content-Type"))
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"context"
+	"mookie/internal/auth"
+	"mookie/internal/csrf"
+	"net/http"
+
+	"github.com/a-h/templ"
+)
+
+// ViewData is the request data every page and layout can read with View(ctx)
+type ViewData struct {
+	// User is the authenticated user, nil for anonymous requests
+	User *auth.AuthUser
+	// Flashes are the messages added with AddFlash since the last rendered page
+	Flashes   []Flash
+	CSRFToken string
+	RequestID string
+	Path      string
+	// Data is the page data passed to Render
+	Data any
+}
+
+// viewDataKey is the context key of the view data
+type viewDataKey struct{}
+
+// NewViewData collects the view data of a request and consumes its flash messages
+func NewViewData(w http.ResponseWriter, r *http.Request, data any) *ViewData {
+	requestID, _ := r.Context().Value("request_id").(string)
+	return &ViewData{
+		User:      auth.UserFromContext(r.Context()),
+		Flashes:   consumeFlashes(w, r),
+		CSRFToken: csrf.Token(r.Context()),
+		RequestID: requestID,
+		Path:      r.URL.Path,
+		Data:      data,
+	}
+}
+
+// WithViewData returns a copy of ctx carrying the view data
+func WithViewData(ctx context.Context, view *ViewData) context.Context {
+	return context.WithValue(ctx, viewDataKey{}, view)
+}
+
+// View returns the view data of the page being rendered, empty outside Render
+func View(ctx context.Context) *ViewData {
+	if view, ok := ctx.Value(viewDataKey{}).(*ViewData); ok {
+		return view
+	}
+	return &ViewData{}
+}
+
+// Render renders the component with the request's view data, or data as JSON if the client asked for it
+func Render(w http.ResponseWriter, r *http.Request, component templ.Component, data any) error {
+	return RenderStatus(w, r, http.StatusOK, component, data)
+}
+
+// RenderStatus is Render with a custom status code
+func RenderStatus(w http.ResponseWriter, r *http.Request, status int, component templ.Component, data any) error {
+	// Flashes are only consumed when a page shows them
+	if component != nil && (data == nil || Format(r) == FormatHTML) {
+		r = r.WithContext(WithViewData(r.Context(), NewViewData(w, r, data)))
+	}
+	return AutoStatus(w, r, status, component, data)
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mookie/internal/auth"
+	"mookie/internal/csrf"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/templ"
+)
+
+// viewComponent writes the view data it sees
+var viewComponent = templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+	view := View(ctx)
+	username := ""
+	if view.User != nil {
+		username = view.User.Username
+	}
+	_, err := fmt.Fprintf(w, "%s|%s|%s|%s|%v|%d", username, view.CSRFToken, view.RequestID, view.Path, view.Data, len(view.Flashes))
+	return err
+})
+
+func TestRender(t *testing.T) {
+	r := httptest.NewRequest("GET", "/profile", nil)
+	ctx := auth.WithUser(r.Context(), &auth.AuthUser{Username: "alice"})
+	ctx = csrf.WithToken(ctx, "token")
+	ctx = context.WithValue(ctx, "request_id", "req-1")
+	r = r.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+	if err := Render(w, r, viewComponent, "data"); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if got, want := w.Body.String(), "alice|token|req-1|/profile|data|0"; got != want {
+		t.Errorf("view data = %q, want %q", got, want)
+	}
+}
+
+func TestViewOutsideRender(t *testing.T) {
+	if view := View(context.Background()); view == nil || view.User != nil {
+		t.Errorf("View outside Render = %+v, want empty view data", view)
+	}
+}
+
+func TestFlashes(t *testing.T) {
+	// Add two flashes and redirect
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/save", nil)
+	AddFlash(w, r, FlashSuccess, "Saved")
+	AddFlash(w, r, FlashInfo, "Again")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("response sets %d cookies, want a single flash cookie", len(cookies))
+	}
+
+	// The next rendered page shows and clears them
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	var flashes []Flash
+	Render(w, r, templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		flashes = View(ctx).Flashes
+		return nil
+	}), nil)
+
+	want := []Flash{{Level: FlashSuccess, Message: "Saved"}, {Level: FlashInfo, Message: "Again"}}
+	if fmt.Sprint(flashes) != fmt.Sprint(want) {
+		t.Errorf("flashes = %v, want %v", flashes, want)
+	}
+	cleared := w.Result().Cookies()
+	if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("flash cookie not cleared: %v", cleared)
+	}
+
+	t.Run("json responses keep flashes", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", "application/json")
+		r.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		Render(w, r, viewComponent, map[string]string{})
+		if len(w.Result().Cookies()) != 0 {
+			t.Error("JSON response consumed the flashes")
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d", w.Code)
+		}
+	})
+}
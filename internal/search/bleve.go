@@ -0,0 +1,129 @@
+//go:build bleve
+
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// typeField holds the document type, it's excluded from full text matching
+const typeField = "doc_type"
+
+// Bleve is an index stored in an embedded Bleve index directory
+type Bleve struct {
+	index bleve.Index
+}
+
+// NewBleve opens the Bleve index at path, creating it if it doesn't exist
+func NewBleve(path string) (Index, error) {
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		typeMapping := bleve.NewKeywordFieldMapping()
+		typeMapping.Analyzer = keyword.Name
+		typeMapping.IncludeInAll = false
+
+		mapping := bleve.NewIndexMapping()
+		mapping.DefaultMapping.AddFieldMappingsAt(typeField, typeMapping)
+		index, err = bleve.New(path, mapping)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Bleve{index: index}, nil
+}
+
+// Index adds or replaces documents
+func (b *Bleve) Index(ctx context.Context, docs ...Document) error {
+	batch := b.index.NewBatch()
+	for _, doc := range docs {
+		fields := make(map[string]any, len(doc.Fields)+1)
+		for name, value := range doc.Fields {
+			fields[name] = value
+		}
+		fields[typeField] = doc.Type
+		if err := batch.Index(bleveID(doc.Type, doc.ID), fields); err != nil {
+			return err
+		}
+	}
+	return b.index.Batch(batch)
+}
+
+// Delete removes a document
+func (b *Bleve) Delete(ctx context.Context, docType, id string) error {
+	return b.index.Delete(bleveID(docType, id))
+}
+
+// DeleteType removes all documents of a type
+func (b *Bleve) DeleteType(ctx context.Context, docType string) error {
+	typeQuery := bleve.NewTermQuery(docType)
+	typeQuery.SetField(typeField)
+	for {
+		req := bleve.NewSearchRequestOptions(typeQuery, 1000, 0, false)
+		result, err := b.index.SearchInContext(ctx, req)
+		if err != nil {
+			return err
+		}
+		if len(result.Hits) == 0 {
+			return nil
+		}
+		batch := b.index.NewBatch()
+		for _, hit := range result.Hits {
+			batch.Delete(hit.ID)
+		}
+		if err := b.index.Batch(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// Search returns the documents matching any word, best matches first
+func (b *Bleve) Search(ctx context.Context, req Request) (Result, error) {
+	var q query.Query = bleve.NewMatchQuery(req.Text)
+	if len(req.Types) > 0 {
+		types := make([]query.Query, 0, len(req.Types))
+		for _, t := range req.Types {
+			typeQuery := bleve.NewTermQuery(t)
+			typeQuery.SetField(typeField)
+			types = append(types, typeQuery)
+		}
+		q = bleve.NewConjunctionQuery(q, bleve.NewDisjunctionQuery(types...))
+	}
+
+	searchReq := bleve.NewSearchRequestOptions(q, int(req.Limit), int(req.Offset), false)
+	searchReq.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	result, err := b.index.SearchInContext(ctx, searchReq)
+	if err != nil {
+		return Result{}, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		docType, id, _ := strings.Cut(match.ID, ":")
+		hit := Hit{Type: docType, ID: id, Score: match.Score, Highlights: make(map[string]string)}
+		// Bleve escapes the fragments and wraps matches in <mark> itself
+		for field, fragments := range match.Fragments {
+			if field != typeField && len(fragments) > 0 {
+				hit.Highlights[field] = fragments[0]
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return Result{Hits: hits, Total: int64(result.Total)}, nil
+}
+
+// Close closes the index
+func (b *Bleve) Close() error {
+	return b.index.Close()
+}
+
+// bleveID is the document ID in the index, unique across types
+func bleveID(docType, id string) string {
+	return docType + ":" + id
+}
@@ -0,0 +1,8 @@
+//go:build !bleve
+
+package search
+
+// NewBleve returns ErrUnavailable, build with the bleve tag to include the Bleve index
+func NewBleve(path string) (Index, error) {
+	return nil, ErrUnavailable
+}
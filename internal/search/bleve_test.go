@@ -0,0 +1,17 @@
+//go:build bleve
+
+package search
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBleve(t *testing.T) {
+	index, err := NewBleve(filepath.Join(t.TempDir(), "search.bleve"))
+	if err != nil {
+		t.Fatalf("NewBleve returned error: %v", err)
+	}
+	defer index.Close()
+	testIndex(t, index)
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// FTS5 is an index stored in an SQLite FTS5 virtual table with one row per document field
+type FTS5 struct {
+	db *sql.DB
+}
+
+// NewFTS5 creates the search_index table if needed - the SQLite driver must be built with the sqlite_fts5 tag
+func NewFTS5(db *sql.DB) (*FTS5, error) {
+	_, err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		doc_type UNINDEXED,
+		doc_id UNINDEXED,
+		field UNINDEXED,
+		content
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("search: creating FTS5 table (build with -tags sqlite_fts5): %w", err)
+	}
+	return &FTS5{db: db}, nil
+}
+
+// Index adds or replaces documents
+func (f *FTS5) Index(ctx context.Context, docs ...Document) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, doc := range docs {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM search_index WHERE doc_type = ? AND doc_id = ?`, doc.Type, doc.ID); err != nil {
+			return err
+		}
+		for field, content := range doc.Fields {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO search_index (doc_type, doc_id, field, content) VALUES (?, ?, ?, ?)`,
+				doc.Type, doc.ID, field, content); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete removes a document
+func (f *FTS5) Delete(ctx context.Context, docType, id string) error {
+	_, err := f.db.ExecContext(ctx, `DELETE FROM search_index WHERE doc_type = ? AND doc_id = ?`, docType, id)
+	return err
+}
+
+// DeleteType removes all documents of a type
+func (f *FTS5) DeleteType(ctx context.Context, docType string) error {
+	_, err := f.db.ExecContext(ctx, `DELETE FROM search_index WHERE doc_type = ?`, docType)
+	return err
+}
+
+// Search returns the documents with any field matching any word, ranked by the sum of the field BM25 scores
+func (f *FTS5) Search(ctx context.Context, req Request) (Result, error) {
+	match := matchExpression(req.Text)
+	if match == "" {
+		return Result{}, nil
+	}
+
+	where := "search_index MATCH ?"
+	args := []any{match}
+	if len(req.Types) > 0 {
+		where += " AND doc_type IN (?" + strings.Repeat(", ?", len(req.Types)-1) + ")"
+		for _, t := range req.Types {
+			args = append(args, t)
+		}
+	}
+
+	var result Result
+	err := f.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM (SELECT 1 FROM search_index WHERE `+where+` GROUP BY doc_type, doc_id)`, args...,
+	).Scan(&result.Total)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// bm25 is lower for better matches, it can't be used inside an aggregate so the matches are materialized first
+	rows, err := f.db.QueryContext(ctx,
+		`WITH matches AS MATERIALIZED (
+			SELECT doc_type, doc_id, bm25(search_index) AS score FROM search_index WHERE `+where+`
+		)
+		SELECT doc_type, doc_id, SUM(score) AS total FROM matches
+		GROUP BY doc_type, doc_id ORDER BY total, doc_type, doc_id LIMIT ? OFFSET ?`,
+		append(args, req.Limit, req.Offset)...,
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hit Hit
+		if err := rows.Scan(&hit.Type, &hit.ID, &hit.Score); err != nil {
+			return Result{}, err
+		}
+		hit.Score = -hit.Score
+		result.Hits = append(result.Hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return Result{}, err
+	}
+
+	for i := range result.Hits {
+		highlights, err := f.highlights(ctx, match, result.Hits[i])
+		if err != nil {
+			return Result{}, err
+		}
+		result.Hits[i].Highlights = highlights
+	}
+	return result, nil
+}
+
+// Close does nothing, the database is owned by the application
+func (f *FTS5) Close() error {
+	return nil
+}
+
+// highlights returns a snippet of every matching field of a hit
+func (f *FTS5) highlights(ctx context.Context, match string, hit Hit) (map[string]string, error) {
+	rows, err := f.db.QueryContext(ctx,
+		`SELECT field, snippet(search_index, 3, ?, ?, '…', 16) FROM search_index
+		WHERE search_index MATCH ? AND doc_type = ? AND doc_id = ?`,
+		markStart, markEnd, match, hit.Type, hit.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := make(map[string]string)
+	for rows.Next() {
+		var field, fragment string
+		if err := rows.Scan(&field, &fragment); err != nil {
+			return nil, err
+		}
+		highlights[field] = highlightHTML(fragment)
+	}
+	return highlights, rows.Err()
+}
+
+// matchExpression turns query text into an FTS5 expression matching any word by prefix,
+// words are quoted so FTS5 operators in the text are searched literally
+func matchExpression(text string) string {
+	words := terms(text)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " OR ")
+}
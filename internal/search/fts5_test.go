@@ -0,0 +1,23 @@
+//go:build sqlite_fts5
+
+package search
+
+import (
+	"mookie/internal/db"
+	"path/filepath"
+	"testing"
+)
+
+func TestFTS5(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer database.Close()
+
+	index, err := NewFTS5(database)
+	if err != nil {
+		t.Fatalf("NewFTS5 returned error: %v", err)
+	}
+	testIndex(t, index)
+}
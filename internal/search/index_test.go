@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// testIndex runs the behaviour shared by all index implementations
+func testIndex(t *testing.T, index Index) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := index.Index(ctx,
+		Document{Type: "users", ID: "1", Fields: map[string]string{"username": "alice", "email": "alice@example.com"}},
+		Document{Type: "users", ID: "2", Fields: map[string]string{"username": "bob", "email": "bob@example.com"}},
+		Document{Type: "flags", ID: "1", Fields: map[string]string{"name": "alice-beta", "description": "<b>Beta</b> for alice"}},
+	)
+	if err != nil {
+		t.Fatalf("Index returned error: %v", err)
+	}
+
+	t.Run("matches across types", func(t *testing.T) {
+		result, err := index.Search(ctx, Request{Text: "alice", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if result.Total != 2 || len(result.Hits) != 2 {
+			t.Fatalf("Search returned %d of %d hits, want 2 of 2", len(result.Hits), result.Total)
+		}
+	})
+
+	t.Run("filters by type", func(t *testing.T) {
+		result, err := index.Search(ctx, Request{Text: "alice", Types: []string{"flags"}, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if len(result.Hits) != 1 || result.Hits[0].Type != "flags" || result.Hits[0].ID != "1" {
+			t.Fatalf("Search returned %+v, want flags/1", result.Hits)
+		}
+		highlight := result.Hits[0].Highlights["description"]
+		if !strings.Contains(highlight, "<mark>alice</mark>") || strings.Contains(highlight, "<b>") {
+			t.Errorf("description highlight = %q, want escaped text with a marked match", highlight)
+		}
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		result, err := index.Search(ctx, Request{Text: "alice", Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if result.Total != 2 || len(result.Hits) != 1 {
+			t.Errorf("Search returned %d of %d hits, want 1 of 2", len(result.Hits), result.Total)
+		}
+	})
+
+	t.Run("replaces and deletes documents", func(t *testing.T) {
+		index.Index(ctx, Document{Type: "users", ID: "2", Fields: map[string]string{"username": "robert"}})
+		if result, _ := index.Search(ctx, Request{Text: "bob", Limit: 10}); result.Total != 0 {
+			t.Errorf("replaced document still matches its old content")
+		}
+
+		index.Delete(ctx, "users", "1")
+		index.DeleteType(ctx, "flags")
+		result, err := index.Search(ctx, Request{Text: "alice robert", Limit: 10})
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if len(result.Hits) != 1 || result.Hits[0].ID != "2" {
+			t.Errorf("Search after deletes returned %+v, want users/2 only", result.Hits)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"html"
+	"mookie/internal/params"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+   Package search provides full text search over application data behind a pluggable Index.
+   Two indexes are included: SQLite FTS5 stored in the application database and an embedded
+   Bleve index stored in its own directory.
+
+   How to use:
+   1. Create an index - NewFTS5 or NewBleve
+   2. Create a service with the index and register a mapping for every document type
+   3. Run Reindex periodically, e.g. from the cron runner, to pick up changed records
+   4. Query the service from a handler
+
+   Example basic usage:
+       index, err := search.NewFTS5(db)
+       service := search.New(index)
+       service.Register(search.Mapping{
+           Type:   "users",
+           Fields: []string{"username", "email"},
+           Load: func(ctx context.Context, since time.Time) ([]search.Document, error) {
+               // Load the users updated since the previous run, zero since means all users
+           },
+       })
+       runner.Add("search", func(ctx context.Context) error {
+           return service.Reindex(ctx)
+       }, time.Minute)
+
+   Example querying:
+       page, err := service.Search(ctx, "alice", []string{"users"}, q)
+       for _, hit := range page.Items {
+           fmt.Println(hit.Type, hit.ID, hit.Highlights["username"])
+       }
+
+   Notes:
+   - FTS5 requires building with the sqlite_fts5 tag: go build -tags sqlite_fts5
+   - Bleve requires building with the bleve tag: go build -tags bleve, otherwise NewBleve returns ErrUnavailable
+   - Only fields listed in the mapping are indexed
+   - The first Reindex of a type replaces all its documents, later runs only load records changed since
+     the previous run, so deleted records stay searchable until they are removed with Remove or the
+     application restarts
+   - Highlights are HTML with matches wrapped in <mark>, the rest of the text is escaped
+*/
+
+// ErrUnavailable is returned when the index isn't compiled into the binary
+var ErrUnavailable = errors.New("search: index not available in this build")
+
+// Document is a record to index, identified by its type and ID
+type Document struct {
+	Type   string
+	ID     string
+	Fields map[string]string
+}
+
+// Request is a search query passed to an index
+type Request struct {
+	Text   string
+	Types  []string
+	Limit  int64
+	Offset int64
+}
+
+// Hit is a matching document
+type Hit struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Result is a page of hits and the total number of matching documents
+type Result struct {
+	Hits  []Hit
+	Total int64
+}
+
+// Index stores documents and answers queries
+type Index interface {
+	// Index adds or replaces documents
+	Index(ctx context.Context, docs ...Document) error
+	// Delete removes a document
+	Delete(ctx context.Context, docType, id string) error
+	// DeleteType removes all documents of a type
+	DeleteType(ctx context.Context, docType string) error
+	// Search returns the documents matching the request, best matches first
+	Search(ctx context.Context, req Request) (Result, error)
+	// Close releases the index
+	Close() error
+}
+
+// Loader returns the documents of a type changed since the given time, all documents if since is zero
+type Loader func(ctx context.Context, since time.Time) ([]Document, error)
+
+// Mapping registers a document type with the service
+type Mapping struct {
+	Type   string
+	Fields []string
+	Load   Loader
+}
+
+// Service indexes the registered document types and queries the index
+type Service struct {
+	index    Index
+	mu       sync.Mutex
+	mappings []Mapping
+	lastRun  map[string]time.Time
+	now      func() time.Time
+}
+
+// New creates a search service
+func New(index Index) *Service {
+	return &Service{index: index, lastRun: make(map[string]time.Time), now: time.Now}
+}
+
+// Register adds a document type, it's fully indexed on the next Reindex
+func (s *Service) Register(m Mapping) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mappings = append(s.mappings, m)
+}
+
+// Reindex indexes the documents changed since the previous run of every registered type
+func (s *Service) Reindex(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, m := range s.mappings {
+		if err := s.reindex(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rebuild forgets the previous runs and indexes all documents again
+func (s *Service) Rebuild(ctx context.Context) error {
+	s.mu.Lock()
+	clear(s.lastRun)
+	s.mu.Unlock()
+	return s.Reindex(ctx)
+}
+
+// Remove deletes a document from the index, e.g. when the record is deleted
+func (s *Service) Remove(ctx context.Context, docType, id string) error {
+	return s.index.Delete(ctx, docType, id)
+}
+
+// Search returns a page of documents matching text, limited to types if any are given
+func (s *Service) Search(ctx context.Context, text string, types []string, q *params.Query) (params.Page[Hit], error) {
+	if strings.TrimSpace(text) == "" {
+		return params.NewPage[Hit](q, nil, 0), nil
+	}
+	result, err := s.index.Search(ctx, Request{
+		Text:   text,
+		Types:  types,
+		Limit:  q.Limit(),
+		Offset: q.Offset(),
+	})
+	if err != nil {
+		return params.Page[Hit]{}, err
+	}
+	return params.NewPage(q, result.Hits, result.Total), nil
+}
+
+// Types returns the registered document types
+func (s *Service) Types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	types := make([]string, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		types = append(types, m.Type)
+	}
+	return types
+}
+
+// reindex loads and indexes the changed documents of a single type
+func (s *Service) reindex(ctx context.Context, m Mapping) error {
+	// Records changed while loading are picked up again by the next run
+	startedAt := s.now()
+	since, incremental := s.lastRun[m.Type]
+
+	docs, err := m.Load(ctx, since)
+	if err != nil {
+		return err
+	}
+	if !incremental {
+		if err := s.index.DeleteType(ctx, m.Type); err != nil {
+			return err
+		}
+	}
+
+	for i := range docs {
+		docs[i].Type = m.Type
+		docs[i].Fields = selectFields(docs[i].Fields, m.Fields)
+	}
+	if len(docs) > 0 {
+		if err := s.index.Index(ctx, docs...); err != nil {
+			return err
+		}
+	}
+
+	s.lastRun[m.Type] = startedAt
+	return nil
+}
+
+// selectFields keeps only the mapped fields of a document
+func selectFields(fields map[string]string, names []string) map[string]string {
+	selected := make(map[string]string, len(names))
+	for _, name := range names {
+		if value, ok := fields[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+// Markers wrapped around matches by the indexes, replaced by <mark> once the text is escaped
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// highlightHTML escapes a fragment and turns the match markers into <mark> elements
+func highlightHTML(fragment string) string {
+	fragment = html.EscapeString(fragment)
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(fragment)
+}
+
+// terms splits query text into words
+func terms(text string) []string {
+	return strings.Fields(text)
+}
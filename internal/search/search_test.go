@@ -0,0 +1,158 @@
+package search
+
+import (
+	"context"
+	"mookie/internal/params"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// memoryIndex records the calls made by the service
+type memoryIndex struct {
+	docs         map[string]Document
+	deletedTypes []string
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{docs: make(map[string]Document)}
+}
+
+func (m *memoryIndex) Index(ctx context.Context, docs ...Document) error {
+	for _, doc := range docs {
+		m.docs[doc.Type+":"+doc.ID] = doc
+	}
+	return nil
+}
+
+func (m *memoryIndex) Delete(ctx context.Context, docType, id string) error {
+	delete(m.docs, docType+":"+id)
+	return nil
+}
+
+func (m *memoryIndex) DeleteType(ctx context.Context, docType string) error {
+	m.deletedTypes = append(m.deletedTypes, docType)
+	for key, doc := range m.docs {
+		if doc.Type == docType {
+			delete(m.docs, key)
+		}
+	}
+	return nil
+}
+
+func (m *memoryIndex) Search(ctx context.Context, req Request) (Result, error) {
+	return Result{Hits: []Hit{{Type: "users", ID: "1"}}, Total: 1}, nil
+}
+
+func (m *memoryIndex) Close() error {
+	return nil
+}
+
+// sortedKeys returns the keys of the indexed documents
+func (m *memoryIndex) sortedKeys() []string {
+	keys := make([]string, 0, len(m.docs))
+	for key := range m.docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestReindex(t *testing.T) {
+	index := newMemoryIndex()
+	index.Index(context.Background(), Document{Type: "users", ID: "stale"})
+	service := New(index)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	var calls []time.Time
+	service.Register(Mapping{
+		Type:   "users",
+		Fields: []string{"username"},
+		Load: func(ctx context.Context, since time.Time) ([]Document, error) {
+			calls = append(calls, since)
+			if since.IsZero() {
+				return []Document{
+					{ID: "1", Fields: map[string]string{"username": "alice", "password": "secret"}},
+					{ID: "2", Fields: map[string]string{"username": "bob"}},
+				}, nil
+			}
+			return []Document{{ID: "2", Fields: map[string]string{"username": "robert"}}}, nil
+		},
+	})
+
+	t.Run("first run replaces all documents", func(t *testing.T) {
+		if err := service.Reindex(context.Background()); err != nil {
+			t.Fatalf("Reindex returned error: %v", err)
+		}
+		if got := index.sortedKeys(); !reflect.DeepEqual(got, []string{"users:1", "users:2"}) {
+			t.Errorf("indexed %v, want users:1 and users:2", got)
+		}
+		if _, ok := index.docs["users:1"].Fields["password"]; ok {
+			t.Error("unmapped field was indexed")
+		}
+	})
+
+	t.Run("later runs load changes since the previous run", func(t *testing.T) {
+		previous := now
+		now = now.Add(time.Minute)
+		if err := service.Reindex(context.Background()); err != nil {
+			t.Fatalf("Reindex returned error: %v", err)
+		}
+		if !calls[1].Equal(previous) {
+			t.Errorf("second load since %v, want %v", calls[1], previous)
+		}
+		if len(index.deletedTypes) != 1 {
+			t.Errorf("DeleteType called %d times, want once", len(index.deletedTypes))
+		}
+		if got := index.docs["users:2"].Fields["username"]; got != "robert" {
+			t.Errorf("users:2 username = %q, want robert", got)
+		}
+	})
+
+	t.Run("rebuild loads everything again", func(t *testing.T) {
+		if err := service.Rebuild(context.Background()); err != nil {
+			t.Fatalf("Rebuild returned error: %v", err)
+		}
+		if !calls[2].IsZero() || len(index.deletedTypes) != 2 {
+			t.Errorf("rebuild loaded since %v with %d deletes, want zero time and 2 deletes", calls[2], len(index.deletedTypes))
+		}
+	})
+}
+
+func TestSearchEmptyText(t *testing.T) {
+	service := New(newMemoryIndex())
+	q, _ := params.Parse(url.Values{}, params.Options{})
+
+	page, err := service.Search(context.Background(), "   ", nil, q)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if page.Total != 0 || len(page.Items) != 0 {
+		t.Errorf("empty query returned %d hits, want none", page.Total)
+	}
+}
+
+func TestHighlightHTML(t *testing.T) {
+	got := highlightHTML("<b>" + markStart + "alice" + markEnd + " & bob")
+	want := "&lt;b&gt;<mark>alice</mark> &amp; bob"
+	if got != want {
+		t.Errorf("highlightHTML = %q, want %q", got, want)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := map[string]string{
+		"alice":        `"alice"*`,
+		"alice bob":    `"alice"* OR "bob"*`,
+		`say "hi" NOT`: `"say"* OR """hi"""* OR "NOT"*`,
+		"  ":           "",
+	}
+	for text, want := range tests {
+		if got := matchExpression(text); got != want {
+			t.Errorf("matchExpression(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func init() {
+	sql.Register("sqlite3-tracing-test", WrapDriver(&sqlite3.SQLiteDriver{}))
+}
+
+func TestWrapDriver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	db, err := sql.Open("sqlite3-tracing-test", ":memory:")
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, parent := Tracer("test").Start(context.Background(), "parent")
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t (id INTEGER)"); err != nil {
+		t.Fatalf("Exec returned error: %v", err)
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, "-- name: CountT :one\nSELECT COUNT(*) FROM t").Scan(&count); err != nil {
+		t.Fatalf("Query returned error: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT * FROM missing"); err == nil {
+		t.Fatal("expected an error for a missing table")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	names := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		names[span.Name()] = span
+	}
+	for _, name := range []string{"db.exec", "db.CountT", "parent"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("missing span %q, got %d spans", name, len(spans))
+		}
+	}
+	if span, ok := names["db.CountT"]; ok && span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("query span is not a child of the request span")
+	}
+
+	failed := 0
+	for _, span := range spans {
+		if span.Status().Code.String() == "Error" {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("spans with error status = %d, want 1", failed)
+	}
+}
+
+func TestSqlcName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: GetUser :one\nSELECT 1": "GetUser",
+		"SELECT 1":                        "",
+	}
+	for query, want := range tests {
+		if got := sqlcName(query); got != want {
+			t.Errorf("sqlcName(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
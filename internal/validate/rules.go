@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtinRules are the rules every validator starts with
+var builtinRules = map[string]rule{
+	"required": {fn: required, message: "{field} is required"},
+	"min":      {fn: compareSize(func(size, n float64) bool { return size >= n }), message: "{field} must be at least {param}"},
+	"max":      {fn: compareSize(func(size, n float64) bool { return size <= n }), message: "{field} must be at most {param}"},
+	"len":      {fn: compareSize(func(size, n float64) bool { return size == n }), message: "{field} must be exactly {param}"},
+	"oneof":    {fn: oneOf, message: "{field} must be one of {param}"},
+	"email":    {fn: stringRule(isEmail), message: "{field} must be a valid email address"},
+	"url":      {fn: stringRule(isURL), message: "{field} must be a valid URL"},
+	"alpha":    {fn: stringRule(allRunes(unicode.IsLetter)), message: "{field} may only contain letters"},
+	"alphanum": {fn: stringRule(allRunes(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })), message: "{field} may only contain letters and digits"},
+	"numeric":  {fn: stringRule(isNumeric), message: "{field} must be a number"},
+}
+
+// isEmpty reports whether the value is the zero value, or an empty string, slice or map
+func isEmpty(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+// required fails for empty values
+func required(value reflect.Value, _ string) bool {
+	return !isEmpty(value)
+}
+
+// compareSize compares the length of strings, slices and maps, or the value of numbers with the param
+func compareSize(cmp func(size, n float64) bool) Func {
+	return func(value reflect.Value, param string) bool {
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil || !value.IsValid() {
+			return false
+		}
+		switch value.Kind() {
+		case reflect.String:
+			return cmp(float64(utf8.RuneCountInString(value.String())), n)
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return cmp(float64(value.Len()), n)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp(float64(value.Int()), n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return cmp(float64(value.Uint()), n)
+		case reflect.Float32, reflect.Float64:
+			return cmp(value.Float(), n)
+		}
+		return false
+	}
+}
+
+// oneOf checks the value against the space separated options
+func oneOf(value reflect.Value, param string) bool {
+	if !value.IsValid() {
+		return false
+	}
+	var s string
+	switch value.Kind() {
+	case reflect.String:
+		s = value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(value.Uint(), 10)
+	default:
+		return false
+	}
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
+
+// stringRule applies a string check to string values, empty strings pass so rules combine with omitempty and required
+func stringRule(check func(s string) bool) Func {
+	return func(value reflect.Value, _ string) bool {
+		if !value.IsValid() || value.Kind() != reflect.String {
+			return false
+		}
+		s := value.String()
+		return s == "" || check(s)
+	}
+}
+
+// isEmail checks for a bare email address without a display name
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// isURL checks for an absolute URL with a scheme and host
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// isNumeric checks that the string parses as a number
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// allRunes checks every rune of the string
+func allRunes(check func(r rune) bool) func(s string) bool {
+	return func(s string) bool {
+		for _, r := range s {
+			if !check(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
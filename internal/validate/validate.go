@@ -0,0 +1,362 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+/*
+   Package validate validates structs through `validate` struct tags, and single values and
+   form values through the same rule strings. Error messages are looked up by key so they can
+   be translated.
+
+   How to use:
+   1. Create a validator with New (usually the one registered as "validator" in the container)
+   2. Tag struct fields with rules, or pass rule strings to Var and Values
+   3. Check the returned error - it's an Errors map of field name to message
+
+   Example validating an API request body:
+       type Address struct {
+           City string `json:"city" validate:"required"`
+       }
+       type SignUp struct {
+           Username  string    `json:"username" validate:"required,min=3,max=32,username"`
+           Email     string    `json:"email" validate:"required,email"`
+           Role      string    `json:"role" validate:"omitempty,oneof=member editor"`
+           Addresses []Address `json:"addresses" validate:"max=3"`
+       }
+
+       v := container.MustGet[*validate.Validator](c, "validator")
+       if err := v.Struct(r.Context(), &req); err != nil {
+           render.JSON(w, http.StatusUnprocessableEntity, err)
+           return
+       }
+       // Errors look like {"email": "email must be a valid email address", "addresses[0].city": "addresses[0].city is required"}
+
+   Example validating form values and rendering the errors:
+       f := forms.New(r)
+       if !f.Validate(r.Context(), v, map[string]string{"title": "required,max=100"}) {
+           pages.NewPost(f).Render(r.Context(), w)
+           return
+       }
+
+   Example programmatic checks:
+       errs := validate.Errors{}
+       errs.Check("end", req.End.After(req.Start), "end must be after start")
+       if err := errs.Err(); err != nil {
+           return err
+       }
+
+   Example registering a custom rule:
+       v.Register("username", func(value reflect.Value, param string) bool {
+           return usernamePattern.MatchString(value.String())
+       }, "{field} may only contain lowercase letters, digits and dashes")
+
+   Built-in rules:
+   - required: not the zero value, non-empty strings, slices and maps
+   - omitempty: skip the remaining rules when the value is empty
+   - min=n, max=n, len=n: length of strings (in characters), slices and maps, or the number itself
+   - oneof=a b c: one of the space separated values
+   - email, url, alpha, alphanum, numeric
+
+   Translations:
+   - Messages are looked up by the key "validate.<rule>" through the Translator
+   - Without a translator the English messages registered with the rule are used
+   - Plug in a message catalog with SetTranslator, the fallback is passed along for missing keys
+   - Messages may use the {field} and {param} placeholders
+
+   Notes:
+   - Field names come from the json tag, then the form tag, then the Go field name
+   - Nested structs and slices of structs are validated with dotted and indexed field names
+   - Only the first failing rule of a field is reported
+*/
+
+// Func checks a value against a rule, param is the part after "=" in the rule
+type Func func(value reflect.Value, param string) bool
+
+// Translator returns the message for a failed rule - key is "validate.<rule>",
+// fallback is the registered English message with placeholders replaced
+type Translator func(ctx context.Context, key string, params map[string]string, fallback string) string
+
+// Errors maps field names to their validation message
+type Errors map[string]string
+
+// Error lists the invalid fields and their messages
+func (e Errors) Error() string {
+	fields := slices.Sorted(maps.Keys(e))
+	msgs := make([]string, len(fields))
+	for i, field := range fields {
+		msgs[i] = e[field]
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Add adds a message for the field unless the field already has one
+func (e Errors) Add(field, msg string) {
+	if _, exists := e[field]; !exists {
+		e[field] = msg
+	}
+}
+
+// Check adds the message for the field if ok is false
+func (e Errors) Check(field string, ok bool, msg string) {
+	if !ok {
+		e.Add(field, msg)
+	}
+}
+
+// Err returns the errors as an error, or nil if there are none
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// As returns the validation errors from err, or nil if err isn't a validation error
+func As(err error) Errors {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return nil
+}
+
+// rule is a registered rule function and its English message
+type rule struct {
+	fn      Func
+	message string
+}
+
+// Validator validates values against the built-in and registered rules
+type Validator struct {
+	mu         sync.RWMutex
+	rules      map[string]rule
+	translator Translator
+}
+
+// New creates a validator with the built-in rules
+func New() *Validator {
+	v := &Validator{rules: make(map[string]rule)}
+	for name, r := range builtinRules {
+		v.rules[name] = r
+	}
+	return v
+}
+
+// Register adds or replaces a rule, message is the English message used when no translation exists
+func (v *Validator) Register(name string, fn Func, message string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule{fn: fn, message: message}
+}
+
+// SetTranslator sets the translator used for messages
+func (v *Validator) SetTranslator(t Translator) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.translator = t
+}
+
+// Struct validates the tagged fields of a struct or pointer to a struct, returning Errors or nil
+func (v *Validator) Struct(ctx context.Context, s any) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return fmt.Errorf("validate: nil %T", s)
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected a struct, got %T", s)
+	}
+
+	errs := Errors{}
+	if err := v.validateStruct(ctx, value, "", errs); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// Var validates a single value against a rule string, returning Errors with the field or nil
+func (v *Validator) Var(ctx context.Context, field string, value any, rules string) error {
+	errs := Errors{}
+	if err := v.validateValue(ctx, reflect.ValueOf(value), field, rules, errs); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+// Values validates string values, e.g. submitted form values, against rule strings keyed by field
+func (v *Validator) Values(ctx context.Context, values map[string]string, rules map[string]string) error {
+	errs := Errors{}
+	fields := slices.Sorted(maps.Keys(rules))
+	for _, field := range fields {
+		if err := v.validateValue(ctx, reflect.ValueOf(values[field]), field, rules[field], errs); err != nil {
+			return err
+		}
+	}
+	return errs.Err()
+}
+
+// validateStruct validates the fields of a struct value, prefixing field names with prefix
+func (v *Validator) validateStruct(ctx context.Context, value reflect.Value, prefix string, errs Errors) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous {
+			// Embedded struct fields are promoted to the parent
+			name = ""
+		}
+		path := joinPath(prefix, name)
+		field := value.Field(i)
+
+		if rules := sf.Tag.Get("validate"); rules != "" && rules != "-" {
+			if err := v.validateValue(ctx, field, path, rules, errs); err != nil {
+				return err
+			}
+		}
+		if err := v.validateNested(ctx, field, path, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested validates structs, pointers to structs and slices of structs inside a field
+func (v *Validator) validateNested(ctx context.Context, value reflect.Value, path string, errs Errors) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return v.validateNested(ctx, value.Elem(), path, errs)
+	case reflect.Struct:
+		if hasValidateTags(value.Type()) {
+			return v.validateStruct(ctx, value, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.validateNested(ctx, value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateValue applies the comma separated rules to the value, stopping at the first failure
+func (v *Validator) validateValue(ctx context.Context, value reflect.Value, field, rules string, errs Errors) error {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			break
+		}
+		value = value.Elem()
+	}
+
+	for _, spec := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(spec), "=")
+		if name == "" {
+			continue
+		}
+		if name == "omitempty" {
+			if isEmpty(value) {
+				return nil
+			}
+			continue
+		}
+
+		v.mu.RLock()
+		r, ok := v.rules[name]
+		v.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("validate: unknown rule %q on field %s", name, field)
+		}
+		if !r.fn(value, param) {
+			errs.Add(field, v.message(ctx, name, r.message, field, param))
+			return nil
+		}
+	}
+	return nil
+}
+
+// message returns the translated message of a failed rule
+func (v *Validator) message(ctx context.Context, name, message, field, param string) string {
+	params := map[string]string{"field": field, "param": param}
+	fallback := message
+	for key, value := range params {
+		fallback = strings.ReplaceAll(fallback, "{"+key+"}", value)
+	}
+
+	v.mu.RLock()
+	translator := v.translator
+	v.mu.RUnlock()
+	if translator == nil {
+		return fallback
+	}
+	return translator(ctx, "validate."+name, params, fallback)
+}
+
+// fieldName returns the name of a struct field from its json or form tag
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// joinPath joins a parent path and a field name with a dot
+func joinPath(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+// tagCache remembers which struct types have validate tags somewhere inside
+var tagCache sync.Map
+
+// hasValidateTags reports whether the struct type or any nested struct type has validate tags
+func hasValidateTags(t reflect.Type) bool {
+	if cached, ok := tagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	// Guard against recursive types while computing
+	tagCache.Store(t, false)
+
+	found := false
+	for i := 0; i < t.NumField() && !found; i++ {
+		sf := t.Field(i)
+		if sf.Tag.Get("validate") != "" {
+			found = true
+			break
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			found = hasValidateTags(ft)
+		}
+	}
+	tagCache.Store(t, found)
+	return found
+}
@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type signUp struct {
+	Username  string    `json:"username" validate:"required,min=3,max=8"`
+	Email     string    `json:"email" validate:"required,email"`
+	Role      string    `json:"role" validate:"omitempty,oneof=member editor"`
+	Age       int       `json:"age" validate:"min=18"`
+	Website   string    `form:"website" validate:"omitempty,url"`
+	Home      *address  `json:"home"`
+	Addresses []address `json:"addresses" validate:"max=2"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := signUp{Username: "bob", Email: "bob@example.com", Age: 20, Addresses: []address{{City: "Oslo"}}}
+
+	tests := []struct {
+		name   string
+		modify func(s *signUp)
+		want   map[string]string
+	}{
+		{name: "valid", modify: func(s *signUp) {}, want: nil},
+		{name: "required", modify: func(s *signUp) { s.Username = "" }, want: map[string]string{"username": "username is required"}},
+		{name: "min length", modify: func(s *signUp) { s.Username = "bo" }, want: map[string]string{"username": "username must be at least 3"}},
+		{name: "max length", modify: func(s *signUp) { s.Username = "bobbobbob" }, want: map[string]string{"username": "username must be at most 8"}},
+		{name: "email", modify: func(s *signUp) { s.Email = "bob" }, want: map[string]string{"email": "email must be a valid email address"}},
+		{name: "omitempty skips", modify: func(s *signUp) { s.Role = "" }, want: nil},
+		{name: "oneof", modify: func(s *signUp) { s.Role = "admin" }, want: map[string]string{"role": "role must be one of member editor"}},
+		{name: "number", modify: func(s *signUp) { s.Age = 17 }, want: map[string]string{"age": "age must be at least 18"}},
+		{name: "form tag", modify: func(s *signUp) { s.Website = "nope" }, want: map[string]string{"website": "website must be a valid URL"}},
+		{name: "nested pointer", modify: func(s *signUp) { s.Home = &address{} }, want: map[string]string{"home.city": "home.city is required"}},
+		{name: "slice of structs", modify: func(s *signUp) { s.Addresses = append(s.Addresses, address{}) }, want: map[string]string{"addresses[1].city": "addresses[1].city is required"}},
+		{name: "slice length", modify: func(s *signUp) {
+			s.Addresses = []address{{City: "a"}, {City: "b"}, {City: "c"}}
+		}, want: map[string]string{"addresses": "addresses must be at most 2"}},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			s.Addresses = append([]address{}, valid.Addresses...)
+			tt.modify(&s)
+
+			err := v.Struct(context.Background(), &s)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Struct returned error: %v", err)
+				}
+				return
+			}
+			errs := As(err)
+			if !reflect.DeepEqual(map[string]string(errs), tt.want) {
+				t.Errorf("Struct errors = %v, want %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestVarAndValues(t *testing.T) {
+	v := New()
+	ctx := context.Background()
+
+	if err := v.Var(ctx, "code", "abc1", "alphanum,len=4"); err != nil {
+		t.Errorf("Var returned error: %v", err)
+	}
+	if errs := As(v.Var(ctx, "code", "ab-1", "alphanum")); errs["code"] == "" {
+		t.Error("expected alphanum to fail")
+	}
+
+	err := v.Values(ctx, map[string]string{"title": ""}, map[string]string{"title": "required", "count": "omitempty,numeric"})
+	if errs := As(err); len(errs) != 1 || errs["title"] == "" {
+		t.Errorf("Values errors = %v, want title error only", errs)
+	}
+}
+
+func TestRegisterAndTranslate(t *testing.T) {
+	v := New()
+	v.Register("even", func(value reflect.Value, _ string) bool {
+		return value.Int()%2 == 0
+	}, "{field} must be even")
+	v.SetTranslator(func(ctx context.Context, key string, params map[string]string, fallback string) string {
+		if key == "validate.even" {
+			return params["field"] + " doit être pair"
+		}
+		return fallback
+	})
+
+	errs := As(v.Var(context.Background(), "n", 3, "even"))
+	if errs["n"] != "n doit être pair" {
+		t.Errorf("message = %q", errs["n"])
+	}
+}
+
+func TestUnknownRule(t *testing.T) {
+	err := New().Var(context.Background(), "x", "", "nope")
+	if err == nil || As(err) != nil {
+		t.Errorf("expected a non-validation error for an unknown rule, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errs := Errors{}
+	errs.Check("a", true, "ignored")
+	if errs.Err() != nil {
+		t.Error("expected no error")
+	}
+	errs.Check("b", false, "b is wrong")
+	errs.Add("b", "second message is ignored")
+	err := errs.Err()
+	if !errors.As(err, &Errors{}) || !strings.Contains(err.Error(), "b is wrong") || strings.Contains(err.Error(), "second") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
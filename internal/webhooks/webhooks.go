@@ -0,0 +1,299 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"mookie/internal/params"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+   Package webhooks delivers domain events to external HTTP endpoints. Subscriptions are stored
+   in the webhooks table, every matching event becomes a row in webhook_deliveries which doubles
+   as the delivery queue, processed periodically by a cron task.
+
+   How to use:
+   1. Create a dispatcher with the database
+   2. Subscribe it to the event bus - matching events are queued as deliveries
+   3. Run ProcessDue periodically, e.g. from the cron runner
+
+   Example basic usage:
+       dispatcher := webhooks.New(db, logger, webhooks.Options{})
+       dispatcher.Subscribe(bus)
+       runner.Add("webhooks", func(ctx context.Context) error {
+           return dispatcher.ProcessDue(ctx)
+       }, time.Minute)
+
+   Request sent for every delivery:
+       POST <webhook url>
+       Content-Type: application/json
+       X-Webhook-Event: user.registered
+       X-Webhook-Delivery: 42
+       X-Webhook-Timestamp: 1735689600
+       X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret>
+
+       {"id": 42, "event": "user.registered", "created_at": "...", "data": {...}}
+
+   Example verifying a delivery on the receiving side:
+       body, _ := io.ReadAll(r.Body)
+       if !webhooks.Verify(secret, r.Header.Get("X-Webhook-Timestamp"), body, r.Header.Get("X-Webhook-Signature")) {
+           http.Error(w, "invalid signature", http.StatusUnauthorized)
+       }
+
+   Delivery statuses:
+   - pending: waiting for its first or next attempt
+   - succeeded: the endpoint responded with 2xx
+   - dead: all attempts failed, redeliver manually with Redeliver
+
+   Notes:
+   - Webhooks subscribe to comma separated event names, "*" matches every event
+   - Failed attempts are retried with exponential backoff: RetryBase, 2x, 4x, ... up to MaxAttempts
+   - The delivery payload is stored when the event is queued, so retries send identical bodies
+*/
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// ErrNotFound is returned when redelivering a delivery that doesn't exist
+var ErrNotFound = errors.New("webhooks: delivery not found")
+
+// maxResponseBody limits how much of the response body is stored for debugging
+const maxResponseBody = 4096
+
+// Options configures deliveries, zero values use the defaults
+type Options struct {
+	// Client sends the requests, defaults to a client with a 10 second timeout
+	Client *http.Client
+	// MaxAttempts before a delivery is dead, defaults to 8
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt, doubled after every further failure, defaults to 30 seconds
+	RetryBase time.Duration
+	// BatchSize is the number of due deliveries processed per ProcessDue call, defaults to 50
+	BatchSize int
+}
+
+// Dispatcher queues events as deliveries and sends them to the subscribed endpoints
+type Dispatcher struct {
+	queries *sqlc.Queries
+	logger  *slog.Logger
+	opts    Options
+	now     func() time.Time
+}
+
+// New creates a dispatcher
+func New(db *sql.DB, logger *slog.Logger, opts Options) *Dispatcher {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	return &Dispatcher{queries: sqlc.New(db), logger: logger, opts: opts, now: time.Now}
+}
+
+// Subscribe queues deliveries for every event published on the bus
+func (d *Dispatcher) Subscribe(bus *events.Bus) func() {
+	return bus.SubscribeAll(d.Enqueue)
+}
+
+// payload is the JSON body of a delivery
+type payload struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Enqueue queues a delivery of the event for every active webhook subscribed to it
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event) error {
+	hooks, err := d.queries.ListActiveWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := event.EventName()
+	now := d.now().UTC()
+	for _, hook := range hooks {
+		if !Matches(hook.Events, name) {
+			continue
+		}
+		delivery, err := d.queries.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+			WebhookID:     hook.ID,
+			Event:         name,
+			Payload:       "{}",
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The payload includes the delivery ID, so it's written once the row exists
+		body, err := json.Marshal(payload{ID: delivery.ID, Event: name, CreatedAt: now, Data: event})
+		if err != nil {
+			return err
+		}
+		delivery.Payload = string(body)
+		if err := d.queries.UpdateWebhookDeliveryPayload(ctx, sqlc.UpdateWebhookDeliveryPayloadParams{Payload: delivery.Payload, ID: delivery.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessDue sends the deliveries that are due, failures are rescheduled and don't stop the batch
+func (d *Dispatcher) ProcessDue(ctx context.Context) error {
+	due, err := d.queries.ListDueWebhookDeliveries(ctx, sqlc.ListDueWebhookDeliveriesParams{
+		Now:   d.now().UTC(),
+		Limit: int64(d.opts.BatchSize),
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, row := range due {
+		if err := d.deliver(ctx, row.WebhookDelivery, row.Url, row.Secret); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Redeliver queues a delivery again for immediate delivery, resetting its attempts
+func (d *Dispatcher) Redeliver(ctx context.Context, id int64) error {
+	affected, err := d.queries.RetryWebhookDelivery(ctx, sqlc.RetryWebhookDeliveryParams{NextAttemptAt: d.now().UTC(), ID: id})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Deliveries lists the deliveries of a webhook, newest first
+func (d *Dispatcher) Deliveries(ctx context.Context, webhookID int64, q *params.Query) (params.Page[sqlc.WebhookDelivery], error) {
+	rows, err := d.queries.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     q.Limit(),
+		Offset:    q.Offset(),
+	})
+	if err != nil {
+		return params.Page[sqlc.WebhookDelivery]{}, err
+	}
+	total, err := d.queries.CountWebhookDeliveries(ctx, webhookID)
+	if err != nil {
+		return params.Page[sqlc.WebhookDelivery]{}, err
+	}
+	return params.NewPage(q, rows, total), nil
+}
+
+// deliver sends a single delivery and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery sqlc.WebhookDelivery, url, secret string) error {
+	update := sqlc.UpdateWebhookDeliveryParams{
+		Status:        StatusSucceeded,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+		ID:            delivery.ID,
+	}
+
+	status, body, sendErr := d.send(ctx, delivery, url, secret)
+	update.ResponseStatus = int64(status)
+	update.ResponseBody = body
+	if sendErr != nil {
+		update.Error = sendErr.Error()
+		if update.Attempts >= int64(d.opts.MaxAttempts) {
+			update.Status = StatusDead
+			d.logger.Warn("Webhook delivery dead", "delivery", delivery.ID, "url", url, "attempts", update.Attempts, "error", sendErr)
+		} else {
+			update.Status = StatusPending
+			update.NextAttemptAt = d.now().UTC().Add(d.backoff(update.Attempts))
+		}
+	}
+
+	if err := d.queries.UpdateWebhookDelivery(ctx, update); err != nil {
+		return err
+	}
+	return nil
+}
+
+// send posts the signed payload and returns the response status and truncated body
+func (d *Dispatcher) send(ctx context.Context, delivery sqlc.WebhookDelivery, url, secret string) (int, string, error) {
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mookie-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(secret, timestamp, body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("webhooks: endpoint responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (d *Dispatcher) backoff(attempts int64) time.Duration {
+	return d.opts.RetryBase << (attempts - 1)
+}
+
+// Matches reports whether the comma separated event list subscribes to the event
+func Matches(eventList, event string) bool {
+	return slices.ContainsFunc(strings.Split(eventList, ","), func(name string) bool {
+		name = strings.TrimSpace(name)
+		return name == "*" || name == event
+	})
+}
+
+// Sign returns the signature header value of a body sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header in constant time
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
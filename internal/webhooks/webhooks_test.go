@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mookie/internal/db"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"mookie/internal/params"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestDispatcher returns a dispatcher backed by a temporary database with one webhook
+func newTestDispatcher(t *testing.T, hookURL, eventList string) (*Dispatcher, *sqlc.Queries) {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	queries := sqlc.New(database)
+	if _, err := queries.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{
+		Url:    hookURL,
+		Secret: "secret",
+		Events: eventList,
+		Active: true,
+	}); err != nil {
+		t.Fatalf("CreateWebhook returned error: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(database, logger, Options{MaxAttempts: 2, RetryBase: time.Minute}), queries
+}
+
+// deliveries returns all deliveries of the first webhook
+func deliveries(t *testing.T, d *Dispatcher) []sqlc.WebhookDelivery {
+	t.Helper()
+	q, _ := params.Parse(url.Values{}, params.Options{})
+	page, err := d.Deliveries(context.Background(), 1, q)
+	if err != nil {
+		t.Fatalf("Deliveries returned error: %v", err)
+	}
+	return page.Items
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		list, event string
+		want        bool
+	}{
+		{"*", "user.registered", true},
+		{"user.registered", "user.registered", true},
+		{"message.posted, user.registered", "user.registered", true},
+		{"message.posted", "user.registered", false},
+		{"", "user.registered", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.list, tt.event); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.list, tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"test"}`)
+	signature := Sign("secret", "1700000000", body)
+
+	if !Verify("secret", "1700000000", body, signature) {
+		t.Error("Verify rejected a valid signature")
+	}
+	if Verify("other", "1700000000", body, signature) {
+		t.Error("Verify accepted a signature made with another secret")
+	}
+	if Verify("secret", "1700000001", body, signature) {
+		t.Error("Verify accepted a signature for another timestamp")
+	}
+}
+
+func TestDeliverySucceeds(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("secret", r.Header.Get("X-Webhook-Timestamp"), body, r.Header.Get("X-Webhook-Signature")) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Webhook-Event") != "user.registered" {
+			t.Errorf("X-Webhook-Event = %q", r.Header.Get("X-Webhook-Event"))
+		}
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher(t, server.URL, "user.registered")
+	ctx := context.Background()
+
+	dispatcher.Enqueue(ctx, events.UserRegistered{UserID: 1, Username: "alice"})
+	dispatcher.Enqueue(ctx, events.MessagePosted{Message: "not subscribed"})
+	if err := dispatcher.ProcessDue(ctx); err != nil {
+		t.Fatalf("ProcessDue returned error: %v", err)
+	}
+
+	if received.Load() != 1 {
+		t.Fatalf("endpoint received %d deliveries, want 1", received.Load())
+	}
+	list := deliveries(t, dispatcher)
+	if len(list) != 1 || list[0].Status != StatusSucceeded || list[0].Attempts != 1 {
+		t.Errorf("deliveries = %+v, want one succeeded after 1 attempt", list)
+	}
+}
+
+func TestDeliveryRetriesAndDies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher(t, server.URL, "*")
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	dispatcher.Enqueue(ctx, events.MessagePosted{Message: "hello"})
+	if err := dispatcher.ProcessDue(ctx); err != nil {
+		t.Fatalf("ProcessDue returned error: %v", err)
+	}
+	list := deliveries(t, dispatcher)
+	if list[0].Status != StatusPending || list[0].ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("delivery after first failure = %+v, want pending with status 503", list[0])
+	}
+	if !list[0].NextAttemptAt.Equal(now.UTC().Add(time.Minute)) {
+		t.Errorf("next attempt at %v, want %v", list[0].NextAttemptAt, now.UTC().Add(time.Minute))
+	}
+
+	// Not due yet
+	dispatcher.ProcessDue(ctx)
+	if list := deliveries(t, dispatcher); list[0].Attempts != 1 {
+		t.Fatalf("attempts = %d before the retry is due, want 1", list[0].Attempts)
+	}
+
+	now = now.Add(time.Minute)
+	dispatcher.ProcessDue(ctx)
+	list = deliveries(t, dispatcher)
+	if list[0].Status != StatusDead || list[0].Attempts != 2 {
+		t.Fatalf("delivery after max attempts = %+v, want dead after 2 attempts", list[0])
+	}
+
+	if err := dispatcher.Redeliver(ctx, list[0].ID); err != nil {
+		t.Fatalf("Redeliver returned error: %v", err)
+	}
+	list = deliveries(t, dispatcher)
+	if list[0].Status != StatusPending || list[0].Attempts != 0 {
+		t.Errorf("delivery after redeliver = %+v, want pending with 0 attempts", list[0])
+	}
+	if err := dispatcher.Redeliver(ctx, 999); err != ErrNotFound {
+		t.Errorf("Redeliver unknown delivery = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"mookie/internal/auth"
+)
+
+/*
+   Authenticated upgrades only open websockets for authenticated users and attach the user to the
+   client, so messages can be sent to all the connections of a user.
+
+   Example:
+       client, err := websocket.UpgradeAuthenticated(w, r, upgrader, authenticator, hub)
+       if err != nil {
+           // The response was written, 401 for unauthorized requests
+           return
+       }
+       if err := hub.AddClient(client); err != nil {
+           client.Close()
+           return
+       }
+       client.Start()
+
+       // Later, from anywhere
+       hub.SendToUser(client.User.ID, Message{Type: "hello"})
+
+   Notes:
+   - Requests already authenticated by middleware.RequireAuth aren't authenticated again
+   - Clients get a random ID so a user can connect several times, the client isn't added to the hub
+     or started
+   - Browsers can't set headers on websocket requests, authenticate with cookies or cached
+     basic auth credentials
+*/
+
+// ErrUnauthorized is returned by UpgradeAuthenticated for requests without a valid user
+var ErrUnauthorized = errors.New("websocket upgrade unauthorized")
+
+// UpgradeAuthenticated authenticates the request and upgrades it to a client of hub with the user attached,
+// unauthorized requests are answered with 401 and ErrUnauthorized is returned.
+func UpgradeAuthenticated(w http.ResponseWriter, r *http.Request, upgrader *websocket.Upgrader, authenticator auth.Authenticator, hub *Hub) (*Client, error) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		var err error
+		if user, err = authenticator.Authenticate(r); err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+	}
+
+	// Upgrade writes the error response itself
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClient("", conn, hub)
+	client.User = user
+	return client, nil
+}
+
+// SendToUser sends a message to the clients of the authenticated user with the given ID.
+func (h *Hub) SendToUser(userID string, message Message) {
+	h.SendToClients(h.userClients(userID), message)
+	h.publish(context.Background(), Broadcast{UserID: userID, Message: message})
+}
+
+// userClients returns the clients of the authenticated user with the given ID
+func (h *Hub) userClients(userID string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	clients := make([]*Client, 0, len(h.users[userID]))
+	for client := range h.users[userID] {
+		clients = append(clients, client)
+	}
+	return clients
+}
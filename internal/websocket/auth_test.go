@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"mookie/internal/auth"
+)
+
+// tokenAuthenticator authenticates requests with the token "secret" as user 42
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(r *http.Request) (*auth.AuthUser, error) {
+	if r.URL.Query().Get("token") != "secret" {
+		return nil, auth.ErrInvalidCredentials
+	}
+	return &auth.AuthUser{ID: "42", Username: "alice"}, nil
+}
+
+func TestUpgradeAuthenticated(t *testing.T) {
+	hub := NewHub()
+	clients := make(chan *Client, 1)
+	errs := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := UpgradeAuthenticated(w, r, &websocket.Upgrader{}, tokenAuthenticator{}, hub)
+		if err != nil {
+			errs <- err
+			return
+		}
+		clients <- client
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?token=wrong", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+	if err := <-errs; !errors.Is(err, ErrUnauthorized) || !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected ErrUnauthorized wrapping the authenticator error, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=secret", nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	select {
+	case client := <-clients:
+		if client.User == nil || client.User.Username != "alice" {
+			t.Errorf("expected the client of user 42, got %+v", client.User)
+		}
+		if client.ID == "" || client.ID == "42" {
+			t.Errorf("expected a random client ID, got %q", client.ID)
+		}
+		client.Close()
+	case <-time.After(time.Second):
+		t.Fatal("expected a client")
+	}
+}
+
+func TestUpgradeAuthenticated_ContextUser(t *testing.T) {
+	// Requests authenticated by the middleware chain are accepted without credentials
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(auth.WithUser(r.Context(), &auth.AuthUser{ID: "7"}))
+		client, err := UpgradeAuthenticated(w, r, &websocket.Upgrader{}, tokenAuthenticator{}, NewHub())
+		if err == nil {
+			client.Close()
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("expected the context user to be accepted, got %v", err)
+	}
+	conn.Close()
+}
+
+func TestHub_SendToUser(t *testing.T) {
+	hub := NewHub()
+	phone, laptop, other := NewClient("phone", nil, hub), NewClient("laptop", nil, hub), NewClient("other", nil, hub)
+	phone.User = &auth.AuthUser{ID: "42"}
+	laptop.User = &auth.AuthUser{ID: "42"}
+	other.User = &auth.AuthUser{ID: "7"}
+	// Anonymous clients are never matched, even with the user ID as client ID
+	anonymous := NewClient("42", nil, hub)
+	for _, client := range []*Client{phone, laptop, other, anonymous} {
+		hub.AddClient(client)
+	}
+
+	hub.SendToUser("42", Message{Type: "hello"})
+	for _, client := range []*Client{phone, laptop} {
+		select {
+		case msg := <-client.send:
+			if msg.Type != "hello" {
+				t.Errorf("expected the hello message, got %+v", msg)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected the clients of the user to receive the message")
+		}
+	}
+	select {
+	case msg := <-other.send:
+		t.Errorf("expected other users not to receive the message, got %+v", msg)
+	case msg := <-anonymous.send:
+		t.Errorf("expected anonymous clients not to receive the message, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,9 +1,18 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"mookie/internal/auth"
+	"mookie/internal/ratelimit"
 )
 
 /*
@@ -46,7 +55,13 @@ import (
            Mode:    MessageModeBinary,
        }
 
-   Example receiving messages:
+   Example receiving messages with handlers registered on the hub, see dispatch.go:
+       hub.On("chat", func(ctx context.Context, c *Client, payload []byte) error {
+           // Handle chat message
+           return nil
+       })
+
+   Example receiving messages of types without a handler:
        for msg := range client.Reader() {
            switch msg.Type {
            case "chat":
@@ -61,24 +76,42 @@ import (
    Notes:
    - Supports both text and binary WebSocket frames
    - Automatically handles WebSocket control frames (ping/pong)
+   - Pings the client and disconnects it when it stops answering, with the keepalive settings of the hub
    - Thread-safe message handling through channels
    - Automatic cleanup on connection close
    - JSON message encoding/decoding
    - Integrates with Hub for broadcast capabilities
    - Buffered channels (256 messages)
+   - UpgradeAuthenticated upgrades authenticated requests only and sets the client's User
+   - Incoming messages can be rate limited per client with LimitMessages, messages over the
+     limit are answered with an error message and dropped
 */
 
 // Client represents a WebSocket client
 type Client struct {
-	ID      string
+	ID string
+	// User is the authenticated user of clients upgraded by UpgradeAuthenticated, nil otherwise
+	User    *auth.AuthUser
 	conn    *websocket.Conn
 	send    chan Message
 	receive chan Message
 	hub     *Hub
+	limiter *ratelimit.Limiter
+	limitID string
+	// rooms holds the rooms the client is a member of, guarded by the lock of the hub
+	rooms map[string]bool
+	// pongTimeout and pingInterval are copied from the hub on Start, 0 disables keepalive
+	pongTimeout  time.Duration
+	pingInterval time.Duration
+	// closeOnce makes Close safe to call from both the hub and readPump
+	closeOnce sync.Once
 }
 
-// NewClient creates a new WebSocket client
+// NewClient creates a new WebSocket client, an empty id is replaced with a random one
 func NewClient(id string, conn *websocket.Conn, hub *Hub) *Client {
+	if id == "" {
+		id = uuid.New().String()
+	}
 	return &Client{
 		ID:      id,
 		conn:    conn,
@@ -93,20 +126,32 @@ func (c *Client) Start() error {
 	if c.conn == nil {
 		return errors.New("connection not initialized")
 	}
+	if c.hub != nil {
+		c.pongTimeout, c.pingInterval = c.hub.keepAlive()
+	}
 	go c.readPump()
 	go c.writePump()
 	return nil
 }
 
-// Close the client connection
+// Close the client connection, calls after the first one do nothing
 func (c *Client) Close() {
 	if c.conn == nil {
 		return
 	}
 
-	c.conn.Close()
-	close(c.send)
-	close(c.receive)
+	c.closeOnce.Do(func() {
+		c.conn.Close()
+		close(c.send)
+		close(c.receive)
+	})
+}
+
+// LimitMessages limits the incoming data messages of the client, key identifies the client in the limiter
+// Call it before Start
+func (c *Client) LimitMessages(limiter *ratelimit.Limiter, key string) {
+	c.limiter = limiter
+	c.limitID = key
 }
 
 // Reader returns the receive channel
@@ -126,6 +171,14 @@ func (c *Client) readPump() {
 		c.Close()
 	}()
 
+	// Clients that don't answer the pings of writePump in time fail the next read
+	if c.pongTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(c.pongTimeout))
+		})
+	}
+
 	for {
 		messageType, payload, err := c.conn.ReadMessage()
 		if err != nil {
@@ -159,8 +212,26 @@ func (c *Client) handleMessage(messageType int, payload []byte) error {
 
 // handleDataMessage processes incoming data messages
 func (c *Client) handleDataMessage(messageType int, payload []byte) error {
+	ctx, span := tracer.Start(context.Background(), "websocket.receive")
+	defer span.End()
+	span.SetAttributes(attribute.String("websocket.client_id", c.ID), attribute.Int("websocket.payload_size", len(payload)))
+
+	// Limiter errors let the message through
+	if c.limiter != nil {
+		if result, err := c.limiter.Allow(c.limitID); err == nil && !result.Allowed {
+			span.SetStatus(codes.Error, "rate limited")
+			c.send <- Message{
+				Type:    MessageTypeError,
+				Payload: []byte("Rate limit exceeded"),
+				Mode:    messageType,
+			}
+			return nil
+		}
+	}
+
 	var msg Message
 	if err := json.Unmarshal(payload, &msg); err != nil {
+		span.SetStatus(codes.Error, "invalid message")
 		c.send <- Message{
 			Type:    MessageTypeError,
 			Payload: []byte("Invalid message"),
@@ -169,15 +240,59 @@ func (c *Client) handleDataMessage(messageType int, payload []byte) error {
 		return nil
 	}
 
+	span.SetAttributes(attribute.String("websocket.message_type", msg.Type))
 	msg.ClientID = c.ID
 	msg.Mode = messageType
+
+	// Messages with a handler aren't sent to the receive channel
+	if c.hub != nil {
+		if handler, ok := c.hub.handler(msg.Type); ok {
+			if err := c.dispatch(ctx, handler, msg); err != nil {
+				span.SetStatus(codes.Error, err.Error())
+				text := err.Error()
+				if errors.Is(err, errHandlerPanic) {
+					text = "Internal error"
+				}
+				c.send <- Message{
+					Type:    MessageTypeError,
+					Payload: []byte(text),
+					Mode:    messageType,
+				}
+			}
+			return nil
+		}
+	}
+
 	c.receive <- msg
 	return nil
 }
 
-// writePump writes messages to the WebSocket connection
+// writePump writes messages to the WebSocket connection and pings the client
 func (c *Client) writePump() {
-	for msg := range c.send {
+	var ping <-chan time.Time
+	if c.pingInterval > 0 {
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		var msg Message
+		select {
+		case m, ok := <-c.send:
+			if !ok {
+				return
+			}
+			msg = m
+		case <-ping:
+			// A failed ping closes the connection, readPump then removes the client
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingWriteTimeout)); err != nil {
+				c.conn.Close()
+				return
+			}
+			continue
+		}
+
 		switch msg.Mode {
 		case MessageModeBinary:
 			data, err := json.Marshal(msg)
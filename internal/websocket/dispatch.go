@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+/*
+   Handlers route the inbound messages of clients by type, instead of every consumer reading
+   client.Reader() with its own switch.
+
+   Example:
+       type chatMessage struct {
+           Room string `json:"room"`
+           Text string `json:"text"`
+       }
+
+       hub.On("chat", websocket.HandleJSON(func(ctx context.Context, c *websocket.Client, msg chatMessage) error {
+           if msg.Text == "" {
+               return errors.New("empty message")
+           }
+           hub.BroadcastToRoom(msg.Room, websocket.Message{Type: "chat", Payload: []byte(msg.Text)})
+           return nil
+       }))
+
+   The browser sends the JSON payload base64 encoded, like the payloads it receives:
+       ws.send(JSON.stringify({type: "chat", payload: btoa(JSON.stringify({room: "general", text: "Hi"}))}))
+
+   Notes:
+   - Handlers run in the read goroutine of the client, one message at a time, start a goroutine
+     for slow work - the client isn't read, and can't answer pings, until the handler returns
+   - Errors are sent back to the client as an error message with the error text, panics as
+     "Internal error" and are logged with their stack to the logger of the hub - return errors
+     meant for the client
+   - Messages of types without a handler are sent to client.Reader() as before
+   - Register the handlers before clients connect, a handler registered again replaces the previous one
+*/
+
+// ErrInvalidPayload is returned by HandleJSON handlers for payloads that don't decode
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// Handler handles an inbound message of a client, payload is the decoded message payload
+type Handler func(ctx context.Context, client *Client, payload []byte) error
+
+// On registers the handler of the inbound messages of type typ.
+func (h *Hub) On(typ string, handler Handler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[typ] = handler
+}
+
+// HandleJSON returns a handler decoding the JSON payload into a T before calling handle.
+func HandleJSON[T any](handle func(ctx context.Context, client *Client, payload T) error) Handler {
+	return func(ctx context.Context, client *Client, payload []byte) error {
+		var v T
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return ErrInvalidPayload
+		}
+		return handle(ctx, client, v)
+	}
+}
+
+// handler returns the handler of the messages of type typ
+func (h *Hub) handler(typ string) (Handler, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	handler, ok := h.handlers[typ]
+	return handler, ok
+}
+
+// dispatch calls handler with the payload of msg, a panic is logged and returned as an error
+func (c *Client) dispatch(ctx context.Context, handler Handler, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errHandlerPanic, r)
+			c.hub.mu.RLock()
+			logger := c.hub.logger
+			c.hub.mu.RUnlock()
+			logger.Error("Websocket handler panicked", "type", msg.Type, "client_id", c.ID, "error", err, "stack", string(debug.Stack()))
+		}
+	}()
+	return handler(ctx, c, msg.Payload)
+}
+
+// errHandlerPanic is returned by dispatch for handlers that panicked, the client receives "Internal error"
+var errHandlerPanic = errors.New("websocket handler panicked")
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+// roundTrip sends msg on conn and returns the next message received
+func roundTrip(t *testing.T, conn *websocket.Conn, msg Message) Message {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var reply Message
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatalf("expected a reply to %s: %v", msg.Type, err)
+	}
+	return reply
+}
+
+func TestHub_On(t *testing.T) {
+	hub := NewHub()
+	logs := &lockedBuffer{}
+	hub.SetLogger(slog.New(slog.NewTextHandler(logs, nil)))
+	hub.On("chat", HandleJSON(func(ctx context.Context, c *Client, msg chatMessage) error {
+		if msg.Text == "" {
+			return errors.New("empty message")
+		}
+		c.Writer() <- Message{Type: "chat", Payload: []byte(c.ID + ": " + msg.Text)}
+		return nil
+	}))
+	hub.On("crash", func(context.Context, *Client, []byte) error {
+		panic("boom")
+	})
+	conn := dialHub(t, hub)
+	if !waitClients(hub, 1, time.Second) {
+		t.Fatal("expected the client in the hub")
+	}
+	client := hub.GetClients()[0]
+
+	tests := []struct {
+		name    string
+		msg     Message
+		want    Message
+		wantErr bool
+	}{
+		{"decoded", Message{Type: "chat", Payload: []byte(`{"text": "hi"}`)}, Message{Type: "chat", Payload: []byte(client.ID + ": hi")}, false},
+		{"handler error", Message{Type: "chat", Payload: []byte(`{}`)}, Message{Type: MessageTypeError, Payload: []byte("empty message")}, true},
+		{"invalid payload", Message{Type: "chat", Payload: []byte(`not json`)}, Message{Type: MessageTypeError, Payload: []byte("invalid payload")}, true},
+		{"panic", Message{Type: "crash"}, Message{Type: MessageTypeError, Payload: []byte("Internal error")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := roundTrip(t, conn, tt.msg)
+			if reply.Type != tt.want.Type || string(reply.Payload) != string(tt.want.Payload) {
+				t.Errorf("expected %s %q, got %s %q", tt.want.Type, tt.want.Payload, reply.Type, reply.Payload)
+			}
+		})
+	}
+
+	// Panics are logged with their stack
+	if log := logs.String(); !strings.Contains(log, "Websocket handler panicked") || !strings.Contains(log, "boom") || !strings.Contains(log, "stack=") {
+		t.Errorf("expected the panic to be logged with its stack, got %q", log)
+	}
+
+	// Types without a handler are sent to the reader, handled types aren't
+	if err := conn.WriteJSON(Message{Type: "other", Payload: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-client.Reader():
+		if msg.Type != "other" || msg.ClientID != client.ID {
+			t.Errorf("expected the other message of the client, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the message without handler in the reader")
+	}
+	if n := len(client.Reader()); n != 0 {
+		t.Errorf("expected only the message without handler in the reader, got %d more", n)
+	}
+}
@@ -0,0 +1,58 @@
+package websocket
+
+/*
+   Hooks let application code follow the clients of a hub, e.g. to track presence, log sessions or
+   tell the other clients who joined and left.
+
+   Example:
+       hub.OnConnect(func(c *Client) {
+           hub.BroadcastExcept(c, Message{Type: "presence.join", Payload: []byte(c.ID)})
+       })
+       hub.OnDisconnect(func(c *Client) {
+           hub.Broadcast(Message{Type: "presence.leave", Payload: []byte(c.ID)})
+       })
+
+   Notes:
+   - Hooks are called in the goroutine adding or removing the client, after the hub is unlocked,
+     so they may use the hub but should return quickly
+   - OnConnect hooks are called when AddClient succeeds, before the client is started
+   - OnDisconnect hooks are called once per client, when it disconnects, is removed or the hub is closed
+   - The client carries its ID and, for authenticated clients, its User
+*/
+
+// ClientHook is called with a client added to or removed from a hub
+type ClientHook func(client *Client)
+
+// OnConnect adds a hook called with every client added to the hub.
+func (h *Hub) OnConnect(hook ClientHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onConnect = append(h.onConnect, hook)
+}
+
+// OnDisconnect adds a hook called with every client removed from the hub.
+func (h *Hub) OnDisconnect(hook ClientHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onDisconnect = append(h.onDisconnect, hook)
+}
+
+// connected calls the OnConnect hooks, the caller doesn't hold the lock
+func (h *Hub) connected(client *Client) {
+	h.mu.RLock()
+	hooks := h.onConnect
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(client)
+	}
+}
+
+// disconnected calls the OnDisconnect hooks, the caller doesn't hold the lock
+func (h *Hub) disconnected(client *Client) {
+	h.mu.RLock()
+	hooks := h.onDisconnect
+	h.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(client)
+	}
+}
@@ -1,15 +1,22 @@
 package websocket
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 /*
    How to use:
    1. Create a new Hub
-   2. Add clients as they connect
-   3. Use Broadcast() or SendToClients() to send messages
+   2. Add clients as they connect, their IDs must be unique
+   3. Use Broadcast() or SendToClients() to send messages, BroadcastToRoom() for the clients of a room or
+      SendToUser() for the clients of an authenticated user
    4. Remove clients when they disconnect
    5. Close hub when shutting down
 
@@ -26,6 +33,20 @@ import (
            Payload: []byte("Server starting"),
        })
 
+       // Echo a message to everyone but its author
+       hub.BroadcastExcept(sender, msg)
+
+       // Send to the clients of admins
+       hub.BroadcastWhere(func(c *Client) bool {
+           return c.User != nil && c.User.HasRole("admin")
+       }, msg)
+
+       // Send to a client by ID
+       hub.SendToID("user123", Message{
+           Type: "private",
+           Payload: []byte("Hello"),
+       })
+
        // Send to specific clients
        hub.SendToClients([]*Client{client1, client2}, Message{
            Type: "private",
@@ -37,64 +58,186 @@ import (
 
    Notes:
    - Thread-safe client management
-   - Supports broadcasting to all clients
+   - Clients are registered by ID, AddClient rejects IDs already in use with ErrDuplicateClientID
+   - Supports broadcasting to all clients, all but the sender with BroadcastExcept or those matching a
+     filter with BroadcastWhere
    - Supports sending to specific clients
-   - Handles client cleanup on disconnect
+   - Handles client cleanup on disconnect, including room membership
+   - OnConnect and OnDisconnect hooks are called as clients are added and removed
+   - Pings clients and disconnects those that stop answering, see KeepAlive
+   - BroadcastContext records a span in the caller's trace when tracing is set up
+   - With a Relay, broadcasts also reach the clients of other instances
 */
 
+// tracer records broadcast and receive spans, it's a no-op unless a tracer provider is installed
+var tracer = otel.Tracer("mookie/internal/websocket")
+
+// ErrHubClosed is returned by HealthCheck once the hub is closed
+var ErrHubClosed = errors.New("websocket hub is closed")
+
+// ErrDuplicateClientID is returned by AddClient for an ID used by another client of the hub
+var ErrDuplicateClientID = errors.New("websocket client ID is already in use")
+
 // Hub maintains the set of active clients and broadcasts messages to the clients.
 type Hub struct {
-	clients []*Client
-	mu      sync.RWMutex
+	clients map[string]*Client
+	// users holds the clients of each authenticated user by user ID - see auth.go
+	users map[string]map[*Client]bool
+	// rooms holds the members of each room, a room is removed with its last member - see rooms.go
+	rooms map[string]map[*Client]bool
+	// handlers handle the inbound messages by type - see dispatch.go
+	handlers map[string]Handler
+	// onConnect and onDisconnect are called as clients are added and removed - see hooks.go
+	onConnect    []ClientHook
+	onDisconnect []ClientHook
+	// relay forwards broadcasts to the hubs of other instances - see relay.go
+	relay Relay
+	// logger logs the panics of handlers - see dispatch.go
+	logger *slog.Logger
+	// pongTimeout and pingInterval are the keepalive settings of the clients - see keepalive.go
+	pongTimeout  time.Duration
+	pingInterval time.Duration
+	closed       bool
+	mu           sync.RWMutex
 }
 
 // NewHub creates a new Hub.
 func NewHub() *Hub {
 	return &Hub{
-		clients: make([]*Client, 0),
+		clients:      make(map[string]*Client),
+		users:        make(map[string]map[*Client]bool),
+		rooms:        make(map[string]map[*Client]bool),
+		handlers:     make(map[string]Handler),
+		logger:       slog.Default(),
+		pongTimeout:  DefaultPongTimeout,
+		pingInterval: DefaultPingInterval,
 	}
 }
 
-// AddClient adds a client to the hub.
+// SetLogger sets the logger of the hub, slog.Default() unless set.
+func (h *Hub) SetLogger(logger *slog.Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logger = logger
+}
+
+// AddClient adds a client to the hub, ErrDuplicateClientID is returned if its ID is in use.
 func (h *Hub) AddClient(client *Client) error {
 	if client == nil {
 		return errors.New("client cannot be nil")
 	}
+	if err := h.add(client); err != nil {
+		return err
+	}
+	h.connected(client)
+	return nil
+}
+
+// add registers a client by ID and user
+func (h *Hub) add(client *Client) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.clients = append(h.clients, client)
+	if _, ok := h.clients[client.ID]; ok {
+		return ErrDuplicateClientID
+	}
+	h.clients[client.ID] = client
+	if client.User != nil {
+		clients, ok := h.users[client.User.ID]
+		if !ok {
+			clients = make(map[*Client]bool)
+			h.users[client.User.ID] = clients
+		}
+		clients[client] = true
+	}
 	return nil
 }
 
-// RemoveClient removes a client from the hub.
+// RemoveClient removes a client from the hub and its rooms.
 func (h *Hub) RemoveClient(client *Client) {
 	if client == nil {
 		return
 	}
+	if h.remove(client) {
+		h.disconnected(client)
+	}
+}
 
+// remove unregisters a client and removes it from its rooms, false if it isn't in the hub
+func (h *Hub) remove(client *Client) bool {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	for i, c := range h.clients {
-		if c == client {
-			h.clients = append(h.clients[:i], h.clients[i+1:]...)
-			break
+	// Another client may have been added with the ID since
+	if h.clients[client.ID] != client {
+		return false
+	}
+	delete(h.clients, client.ID)
+	if client.User != nil {
+		delete(h.users[client.User.ID], client)
+		if len(h.users[client.User.ID]) == 0 {
+			delete(h.users, client.User.ID)
 		}
 	}
+	for room := range client.rooms {
+		h.leave(client, room)
+	}
+	return true
+}
+
+// GetClientByID returns the client with the given ID, false if there is none.
+func (h *Hub) GetClientByID(id string) (*Client, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	client, ok := h.clients[id]
+	return client, ok
+}
+
+// SendToID sends a message to the client with the given ID, ErrClientNotInHub is returned if there is none.
+func (h *Hub) SendToID(id string, message Message) error {
+	client, ok := h.GetClientByID(id)
+	if !ok {
+		return ErrClientNotInHub
+	}
+	h.SendToClients([]*Client{client}, message)
+	return nil
 }
 
 // Broadcast sends a message to all clients in the hub.
 func (h *Hub) Broadcast(message Message) {
-	h.mu.RLock()
-	clients := make([]*Client, len(h.clients))
-	copy(clients, h.clients) // Copy to avoid holding lock during send
-	h.mu.RUnlock()
+	h.BroadcastContext(context.Background(), message)
+}
 
-	for _, client := range clients {
-		go func(c *Client) {
-			c.Writer() <- message
-		}(client)
+// BroadcastContext sends a message to all clients in the hub, recording a span in the trace of ctx.
+func (h *Hub) BroadcastContext(ctx context.Context, message Message) {
+	_, span := tracer.Start(ctx, "websocket.broadcast")
+	defer span.End()
+
+	clients := h.GetClients() // Copy to avoid holding lock during send
+
+	span.SetAttributes(
+		attribute.String("websocket.message_type", message.Type),
+		attribute.Int("websocket.clients", len(clients)),
+	)
+
+	h.SendToClients(clients, message)
+	h.publish(ctx, Broadcast{Message: message})
+}
+
+// BroadcastExcept sends a message to all clients in the hub except sender, e.g. the author of a chat message.
+func (h *Hub) BroadcastExcept(sender *Client, message Message) {
+	h.BroadcastWhere(func(c *Client) bool { return c != sender }, message)
+}
+
+// BroadcastWhere sends a message to the clients in the hub for which match returns true.
+// match is called without holding the lock of the hub, it may use the hub.
+func (h *Hub) BroadcastWhere(match func(*Client) bool, message Message) {
+	var clients []*Client
+	for _, client := range h.GetClients() {
+		if match(client) {
+			clients = append(clients, client)
+		}
 	}
+	h.SendToClients(clients, message)
 }
 
 // SendToClients sends a message to a list of clients.
@@ -109,16 +252,44 @@ func (h *Hub) SendToClients(clients []*Client, message Message) {
 // Close closes the hub and all clients.
 func (h *Hub) Close() {
 	h.mu.Lock()
-	defer h.mu.Unlock()
-	for _, client := range h.clients {
+	clients := h.clients
+	for _, client := range clients {
 		client.Close()
 	}
-	h.clients = nil
+	h.clients = make(map[string]*Client)
+	h.users = make(map[string]map[*Client]bool)
+	h.rooms = make(map[string]map[*Client]bool)
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		h.disconnected(client)
+	}
 }
 
-// GetClients returns a list of clients in the hub.
+// HealthCheck reports ErrHubClosed once the hub is closed
+func (h *Hub) HealthCheck(context.Context) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return ErrHubClosed
+	}
+	return nil
+}
+
+// Stop closes the hub and all clients on shutdown
+func (h *Hub) Stop(context.Context) error {
+	h.Close()
+	return nil
+}
+
+// GetClients returns a list of clients in the hub, in no particular order.
 func (h *Hub) GetClients() []*Client {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	return h.clients
+	clients := make([]*Client, 0, len(h.clients))
+	for _, client := range h.clients {
+		clients = append(clients, client)
+	}
+	return clients
 }
@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"mookie/internal/auth"
+)
+
+func TestHub_ClientRegistry(t *testing.T) {
+	hub := NewHub()
+	alice := NewClient("alice", nil, hub)
+	alice.User = &auth.AuthUser{ID: "1"}
+	if err := hub.AddClient(alice); err != nil {
+		t.Fatalf("failed to add client: %v", err)
+	}
+	if err := hub.AddClient(NewClient("alice", nil, hub)); !errors.Is(err, ErrDuplicateClientID) {
+		t.Errorf("expected ErrDuplicateClientID, got %v", err)
+	}
+
+	if client, ok := hub.GetClientByID("alice"); !ok || client != alice {
+		t.Errorf("expected alice, got %v, %v", client, ok)
+	}
+	if _, ok := hub.GetClientByID("bob"); ok {
+		t.Error("expected no client for an unknown ID")
+	}
+
+	if err := hub.SendToID("alice", Message{Type: "hello"}); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	select {
+	case msg := <-alice.send:
+		if msg.Type != "hello" {
+			t.Errorf("expected the hello message, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected alice to receive the message")
+	}
+	if err := hub.SendToID("bob", Message{Type: "hello"}); !errors.Is(err, ErrClientNotInHub) {
+		t.Errorf("expected ErrClientNotInHub, got %v", err)
+	}
+
+	// Removing frees the ID, the user and the rooms
+	hub.JoinRoom(alice, "general")
+	hub.RemoveClient(alice)
+	if _, ok := hub.GetClientByID("alice"); ok {
+		t.Error("expected alice to be removed")
+	}
+	if len(hub.users) != 0 || len(hub.Rooms()) != 0 {
+		t.Errorf("expected no users and rooms left, got %v and %v", hub.users, hub.Rooms())
+	}
+
+	// A client removed late doesn't remove the client added with its ID since
+	replacement := NewClient("alice", nil, hub)
+	if err := hub.AddClient(replacement); err != nil {
+		t.Fatalf("expected the ID to be free, got %v", err)
+	}
+	hub.RemoveClient(alice)
+	if client, ok := hub.GetClientByID("alice"); !ok || client != replacement {
+		t.Error("expected the replacement to stay")
+	}
+}
+
+func TestNewClient_RandomID(t *testing.T) {
+	a, b := NewClient("", nil, nil), NewClient("", nil, nil)
+	if a.ID == "" || a.ID == b.ID {
+		t.Errorf("expected distinct random IDs, got %q and %q", a.ID, b.ID)
+	}
+}
+
+func TestHub_BroadcastFilters(t *testing.T) {
+	hub := NewHub()
+	alice, bob, admin := NewClient("alice", nil, hub), NewClient("bob", nil, hub), NewClient("admin", nil, hub)
+	admin.User = &auth.AuthUser{ID: "1", Roles: []string{"admin"}}
+	for _, client := range []*Client{alice, bob, admin} {
+		hub.AddClient(client)
+	}
+
+	tests := []struct {
+		name      string
+		broadcast func(Message)
+		want      []*Client
+	}{
+		{"except", func(msg Message) { hub.BroadcastExcept(alice, msg) }, []*Client{bob, admin}},
+		{"where", func(msg Message) {
+			hub.BroadcastWhere(func(c *Client) bool { return c.User != nil && c.User.HasRole("admin") }, msg)
+		}, []*Client{admin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.broadcast(Message{Type: tt.name})
+			for _, client := range tt.want {
+				select {
+				case msg := <-client.send:
+					if msg.Type != tt.name {
+						t.Errorf("expected the %s message, got %+v", tt.name, msg)
+					}
+				case <-time.After(time.Second):
+					t.Errorf("expected %s to receive the message", client.ID)
+				}
+			}
+			// The others receive nothing
+			select {
+			case msg := <-alice.send:
+				t.Errorf("expected alice not to receive the message, got %+v", msg)
+			case <-time.After(50 * time.Millisecond):
+			}
+			if tt.name == "where" && len(bob.send) != 0 {
+				t.Error("expected bob not to receive the message")
+			}
+		})
+	}
+}
+
+func TestHub_Hooks(t *testing.T) {
+	hub := NewHub()
+	var connected, disconnected []string
+	hub.OnConnect(func(c *Client) {
+		// Hooks may use the hub
+		if _, ok := hub.GetClientByID(c.ID); !ok {
+			t.Errorf("expected %s to be in the hub", c.ID)
+		}
+		connected = append(connected, c.ID)
+	})
+	hub.OnDisconnect(func(c *Client) { disconnected = append(disconnected, c.ID) })
+
+	alice, bob := NewClient("alice", nil, hub), NewClient("bob", nil, hub)
+	hub.AddClient(alice)
+	hub.AddClient(bob)
+	// Rejected clients aren't reported
+	hub.AddClient(NewClient("alice", nil, hub))
+	hub.RemoveClient(alice)
+	// Removing twice reports once
+	hub.RemoveClient(alice)
+	hub.Close()
+
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(connected, want) {
+		t.Errorf("expected connects %v, got %v", want, connected)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(disconnected, want) {
+		t.Errorf("expected disconnects %v, got %v", want, disconnected)
+	}
+}
+
+func TestHub_StopConnected(t *testing.T) {
+	hub := NewHub()
+	conn := dialHub(t, hub)
+	if !waitClients(hub, 1, time.Second) {
+		t.Fatal("expected the client in the hub")
+	}
+	client := hub.GetClients()[0]
+
+	// Stop closes the client, readPump closing it again once its read fails must not panic
+	if err := hub.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	client.Close()
+	time.Sleep(50 * time.Millisecond)
+	if err := hub.HealthCheck(context.Background()); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("expected ErrHubClosed, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import "time"
+
+/*
+   Keepalive detects clients that vanish without a close frame, e.g. a laptop closing its lid or a
+   dropped mobile connection, which would otherwise stay in the hub forever.
+
+   Example:
+       hub := websocket.NewHub()
+       // Ping every 25s, disconnect clients that don't answer within 30s
+       hub.KeepAlive(30*time.Second, 25*time.Second)
+
+   Notes:
+   - The write pump pings the client every ping interval, every pong extends the read deadline by
+     the pong timeout
+   - Clients missing the deadline are disconnected, removed from the hub and their rooms
+   - Browsers answer pings on their own, other clients must read the connection to answer them
+   - The settings apply to the clients started afterwards, a pong timeout of 0 disables keepalive
+*/
+
+const (
+	// DefaultPongTimeout is the time clients have to answer a ping
+	DefaultPongTimeout = 60 * time.Second
+	// DefaultPingInterval is the time between pings, shorter than the pong timeout
+	DefaultPingInterval = DefaultPongTimeout * 9 / 10
+	// pingWriteTimeout bounds the time to write a ping
+	pingWriteTimeout = 10 * time.Second
+)
+
+// KeepAlive sets how often clients are pinged and how long they have to answer, a pong timeout of 0
+// disables keepalive - a ping interval not shorter than the pong timeout is set to 9/10 of it.
+func (h *Hub) KeepAlive(pongTimeout, pingInterval time.Duration) {
+	if pongTimeout <= 0 {
+		pongTimeout, pingInterval = 0, 0
+	} else if pingInterval <= 0 || pingInterval >= pongTimeout {
+		pingInterval = pongTimeout * 9 / 10
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pongTimeout = pongTimeout
+	h.pingInterval = pingInterval
+}
+
+// keepAlive returns the pong timeout and ping interval of the hub's clients
+func (h *Hub) keepAlive() (time.Duration, time.Duration) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.pongTimeout, h.pingInterval
+}
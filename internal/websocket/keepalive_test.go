@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialHub starts a server adding its websocket clients to hub and connects to it
+func dialHub(t *testing.T, hub *Hub) *websocket.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := NewClient("", conn, hub)
+		hub.AddClient(client)
+		client.Start()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitClients waits up to timeout for the hub to have n clients
+func waitClients(hub *Hub, n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		hub.mu.RLock()
+		count := len(hub.clients)
+		hub.mu.RUnlock()
+		if count == n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestClient_KeepAlive(t *testing.T) {
+	hub := NewHub()
+	hub.KeepAlive(200*time.Millisecond, 50*time.Millisecond)
+
+	// Pongs are sent while reading, the reading client answers the pings
+	alive := dialHub(t, hub)
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	// The silent client never reads, so it never answers
+	dialHub(t, hub)
+
+	if !waitClients(hub, 2, time.Second) {
+		t.Fatal("expected both clients in the hub")
+	}
+	if !waitClients(hub, 1, 2*time.Second) {
+		t.Fatal("expected the silent client to be removed")
+	}
+	// The answering client stays past several pong timeouts
+	time.Sleep(500 * time.Millisecond)
+	if !waitClients(hub, 1, 100*time.Millisecond) {
+		t.Error("expected the answering client to stay")
+	}
+}
+
+func TestHub_KeepAliveSettings(t *testing.T) {
+	tests := []struct {
+		pongTimeout, pingInterval time.Duration
+		wantPong, wantPing        time.Duration
+	}{
+		{30 * time.Second, 25 * time.Second, 30 * time.Second, 25 * time.Second},
+		{30 * time.Second, 0, 30 * time.Second, 27 * time.Second},
+		{30 * time.Second, time.Minute, 30 * time.Second, 27 * time.Second},
+		{0, 25 * time.Second, 0, 0},
+	}
+	for _, tt := range tests {
+		hub := NewHub()
+		hub.KeepAlive(tt.pongTimeout, tt.pingInterval)
+		pong, ping := hub.keepAlive()
+		if pong != tt.wantPong || ping != tt.wantPing {
+			t.Errorf("KeepAlive(%v, %v) = %v, %v, expected %v, %v", tt.pongTimeout, tt.pingInterval, pong, ping, tt.wantPong, tt.wantPing)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"mookie/internal/redis"
+)
+
+// Resubscription delays of RedisRelay after the subscription fails, doubled on every failure
+const (
+	redisMinBackoff = 100 * time.Millisecond
+	redisMaxBackoff = 10 * time.Second
+)
+
+// redisPublishBuffer is the number of broadcasts waiting to be published, further broadcasts are dropped
+const redisPublishBuffer = 1024
+
+// RedisRelay relays the broadcasts of a hub to the hubs of other instances through a Redis channel
+type RedisRelay struct {
+	hub     *Hub
+	client  *redis.Client
+	channel string
+	logger  *slog.Logger
+	// origin identifies the broadcasts of this instance, which are delivered locally already
+	origin string
+	// queue holds the encoded broadcasts until they're published, so broadcasts don't wait for Redis
+	queue  chan []byte
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// redisEnvelope is a broadcast published to the Redis channel
+type redisEnvelope struct {
+	Origin  string  `json:"origin"`
+	Room    string  `json:"room,omitempty"`
+	UserID  string  `json:"user_id,omitempty"`
+	Mode    int     `json:"mode"`
+	Message Message `json:"message"`
+}
+
+// NewRedisRelay creates a relay of hub publishing to and subscribed to channel, set it with SetRelay
+// and start it to deliver the broadcasts of other instances
+func NewRedisRelay(hub *Hub, client *redis.Client, channel string, logger *slog.Logger) *RedisRelay {
+	return &RedisRelay{
+		hub:     hub,
+		client:  client,
+		channel: channel,
+		logger:  logger,
+		origin:  uuid.New().String(),
+		queue:   make(chan []byte, redisPublishBuffer),
+	}
+}
+
+// Publish queues a broadcast for the other instances without waiting for Redis, broadcasts are dropped
+// while the queue is full and errors are logged
+func (r *RedisRelay) Publish(_ context.Context, broadcast Broadcast) {
+	data, err := json.Marshal(redisEnvelope{
+		Origin:  r.origin,
+		Room:    broadcast.Room,
+		UserID:  broadcast.UserID,
+		Mode:    broadcast.Message.Mode,
+		Message: broadcast.Message,
+	})
+	if err != nil {
+		r.logger.Warn("Error encoding websocket broadcast", "error", err)
+		return
+	}
+	select {
+	case r.queue <- data:
+	default:
+		r.logger.Warn("Websocket relay queue full, dropping broadcast", "channel", r.channel)
+	}
+}
+
+// Start publishes the queued broadcasts and subscribes to the channel in the background,
+// resubscribing after failures until Stop
+func (r *RedisRelay) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done.Add(2)
+	go r.publish(ctx)
+	go r.run(ctx)
+	return nil
+}
+
+// Stop ends the subscription and closes the client, queued broadcasts are dropped
+func (r *RedisRelay) Stop(ctx context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+		done := make(chan struct{})
+		go func() {
+			r.done.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	return r.client.Close()
+}
+
+// HealthCheck pings the Redis server
+func (r *RedisRelay) HealthCheck(ctx context.Context) error {
+	return r.client.Ping(ctx)
+}
+
+// publish sends the queued broadcasts to the channel until ctx is done
+func (r *RedisRelay) publish(ctx context.Context) {
+	defer r.done.Done()
+	for {
+		select {
+		case data := <-r.queue:
+			if _, err := r.client.Publish(ctx, r.channel, data); err != nil && ctx.Err() == nil {
+				r.logger.Warn("Error relaying websocket broadcast", "channel", r.channel, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run delivers the broadcasts of other instances until ctx is done
+func (r *RedisRelay) run(ctx context.Context) {
+	defer r.done.Done()
+	backoff := redisMinBackoff
+	for {
+		started := time.Now()
+		err := r.client.Subscribe(ctx, r.channel, r.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		// A subscription that lasted resets the delay
+		if time.Since(started) > redisMaxBackoff {
+			backoff = redisMinBackoff
+		}
+		r.logger.Warn("Websocket relay subscription failed", "channel", r.channel, "retry_in", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, redisMaxBackoff)
+	}
+}
+
+// receive delivers a broadcast of another instance to the local clients
+func (r *RedisRelay) receive(payload []byte) {
+	var envelope redisEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		r.logger.Warn("Invalid websocket broadcast received", "channel", r.channel, "error", err)
+		return
+	}
+	if envelope.Origin == r.origin {
+		return
+	}
+	message := envelope.Message
+	message.Mode = envelope.Mode
+	r.hub.Deliver(Broadcast{Room: envelope.Room, UserID: envelope.UserID, Message: message})
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import "context"
+
+/*
+   Relays forward the broadcasts of a hub to the hubs of other instances, so deployments running
+   several instances behind a load balancer reach every client whichever instance it's connected to.
+
+   Example:
+       relay := websocket.NewRedisRelay(hub, client, "mookie:websocket", logger)
+       hub.SetRelay(relay)
+       relay.Start(ctx)
+
+       // Reaches the clients of every instance
+       hub.Broadcast(Message{Type: "announcement", Payload: []byte("Deploying")})
+
+   Notes:
+   - Broadcast, BroadcastToRoom and SendToUser are relayed, BroadcastExcept, BroadcastWhere, SendToID
+     and SendToClients target clients of this instance and aren't
+   - Local clients receive the message directly, relays call Deliver with the broadcasts of other
+     instances, which isn't relayed again
+   - Room memberships are local, a room broadcast reaches the members of the room on every instance
+*/
+
+// Broadcast is a message relayed between instances, for all clients, the members of Room or the
+// clients of the user UserID
+type Broadcast struct {
+	Room    string
+	UserID  string
+	Message Message
+}
+
+// Relay forwards the broadcasts of a hub to the hubs of other instances, Publish reports its own
+// errors and shouldn't block for long since broadcasts wait for it
+type Relay interface {
+	Publish(ctx context.Context, broadcast Broadcast)
+}
+
+// SetRelay sets the relay forwarding the broadcasts of the hub, nil for none.
+func (h *Hub) SetRelay(relay Relay) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.relay = relay
+}
+
+// Deliver sends a broadcast of another instance to the local clients.
+func (h *Hub) Deliver(broadcast Broadcast) {
+	switch {
+	case broadcast.Room != "":
+		h.SendToClients(h.RoomClients(broadcast.Room), broadcast.Message)
+	case broadcast.UserID != "":
+		h.SendToClients(h.userClients(broadcast.UserID), broadcast.Message)
+	default:
+		h.SendToClients(h.GetClients(), broadcast.Message)
+	}
+}
+
+// publish forwards a broadcast to the relay, if any
+func (h *Hub) publish(ctx context.Context, broadcast Broadcast) {
+	h.mu.RLock()
+	relay := h.relay
+	h.mu.RUnlock()
+	if relay != nil {
+		relay.Publish(ctx, broadcast)
+	}
+}
@@ -0,0 +1,218 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mookie/internal/auth"
+	"mookie/internal/redis"
+)
+
+// memoryRelay delivers the broadcasts of a hub to its peers and records them
+type memoryRelay struct {
+	mu         sync.Mutex
+	peers      []*Hub
+	broadcasts []Broadcast
+}
+
+func (r *memoryRelay) Publish(_ context.Context, broadcast Broadcast) {
+	r.mu.Lock()
+	r.broadcasts = append(r.broadcasts, broadcast)
+	r.mu.Unlock()
+	for _, peer := range r.peers {
+		peer.Deliver(broadcast)
+	}
+}
+
+func (r *memoryRelay) published() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.broadcasts)
+}
+
+// expectMessage fails unless client receives a message of type typ
+func expectMessage(t *testing.T, client *Client, typ string) {
+	t.Helper()
+	select {
+	case msg := <-client.send:
+		if msg.Type != typ {
+			t.Errorf("expected %s to receive %s, got %+v", client.ID, typ, msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected %s to receive %s", client.ID, typ)
+	}
+}
+
+// expectNoMessage fails if any of the clients receives a message
+func expectNoMessage(t *testing.T, clients ...*Client) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+	for _, client := range clients {
+		if n := len(client.send); n != 0 {
+			t.Errorf("expected %s to receive nothing, got %d messages", client.ID, n)
+			for len(client.send) > 0 {
+				<-client.send
+			}
+		}
+	}
+}
+
+func TestHub_Relay(t *testing.T) {
+	local, remote := NewHub(), NewHub()
+	relay, remoteRelay := &memoryRelay{peers: []*Hub{remote}}, &memoryRelay{}
+	local.SetRelay(relay)
+	remote.SetRelay(remoteRelay)
+
+	alice := NewClient("alice", nil, local)
+	bob := NewClient("bob", nil, remote)
+	bob.User = &auth.AuthUser{ID: "42"}
+	carol := NewClient("carol", nil, remote)
+	local.AddClient(alice)
+	remote.AddClient(bob)
+	remote.AddClient(carol)
+	remote.JoinRoom(carol, "general")
+
+	// Local clients receive broadcasts once, remote clients through the relay
+	local.Broadcast(Message{Type: "all"})
+	expectMessage(t, alice, "all")
+	expectMessage(t, bob, "all")
+	expectMessage(t, carol, "all")
+	expectNoMessage(t, alice)
+
+	local.BroadcastToRoom("general", Message{Type: "room"})
+	expectMessage(t, carol, "room")
+	expectNoMessage(t, alice, bob)
+
+	local.SendToUser("42", Message{Type: "user"})
+	expectMessage(t, bob, "user")
+	expectNoMessage(t, alice, carol)
+
+	// Sends to local clients aren't relayed
+	local.BroadcastExcept(alice, Message{Type: "except"})
+	local.BroadcastWhere(func(*Client) bool { return true }, Message{Type: "where"})
+	expectMessage(t, alice, "where")
+	expectNoMessage(t, alice, bob, carol)
+
+	if n := relay.published(); n != 3 {
+		t.Errorf("expected 3 relayed broadcasts, got %d", n)
+	}
+	// Delivered broadcasts aren't relayed again
+	if n := remoteRelay.published(); n != 0 {
+		t.Errorf("expected delivered broadcasts not to be relayed, got %d", n)
+	}
+}
+
+func TestRedisRelay_Receive(t *testing.T) {
+	hub := NewHub()
+	client := NewClient("alice", nil, hub)
+	hub.AddClient(client)
+	hub.JoinRoom(client, "general")
+	redisClient, err := redis.New("redis://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay := NewRedisRelay(hub, redisClient, "websocket", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	envelope := func(origin string) []byte {
+		data, _ := json.Marshal(redisEnvelope{
+			Origin:  origin,
+			Room:    "general",
+			Mode:    MessageModeBinary,
+			Message: Message{Type: "edit", Payload: []byte{1, 2, 3}},
+		})
+		return data
+	}
+
+	// Broadcasts of other instances are delivered with their mode
+	relay.receive(envelope("other"))
+	select {
+	case msg := <-client.send:
+		if msg.Type != "edit" || msg.Mode != MessageModeBinary || string(msg.Payload) != "\x01\x02\x03" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the remote broadcast to be delivered")
+	}
+
+	// Broadcasts of this instance and invalid payloads are ignored
+	relay.receive(envelope(relay.origin))
+	relay.receive([]byte("not json"))
+	expectNoMessage(t, client)
+}
+
+// lockedBuffer is a buffer safe for the concurrent writes of a logger
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRedisRelay_Unreachable(t *testing.T) {
+	// A server accepting connections without ever answering
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	hub := NewHub()
+	client := NewClient("alice", nil, hub)
+	hub.AddClient(client)
+	redisClient, err := redis.New("redis://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := &lockedBuffer{}
+	relay := NewRedisRelay(hub, redisClient, "websocket", slog.New(slog.NewTextHandler(logs, nil)))
+	hub.SetRelay(relay)
+	relay.Start(context.Background())
+
+	// Broadcasts don't wait for Redis, those exceeding the queue are dropped
+	start := time.Now()
+	for range redisPublishBuffer + 10 {
+		hub.Broadcast(Message{Type: "all"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected broadcasts not to wait for Redis, took %s", elapsed)
+	}
+	expectMessage(t, client, "all")
+	if !strings.Contains(logs.String(), "dropping broadcast") {
+		t.Errorf("expected dropped broadcasts to be logged, got %q", logs.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start = time.Now()
+	relay.Stop(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Stop not to wait for Redis, took %s", elapsed)
+	}
+}
@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+/*
+   Rooms group the clients of a hub by name, e.g. a chat room, a document being edited together or
+   the clients of a user, so messages are sent to the members without filtering the client list.
+
+   Example:
+       // Join when the client connects, before starting it
+       hub.JoinRoom(client, "document:42")
+
+       // Send to the members of the room
+       hub.BroadcastToRoom("document:42", Message{
+           Type: "edit",
+           Payload: []byte(`{"line": 3}`),
+       })
+
+       hub.LeaveRoom(client, "document:42")
+
+   Notes:
+   - A client can be a member of several rooms, joining twice does nothing
+   - Rooms are created by their first member and removed with their last member
+   - RemoveClient removes the client from its rooms, clients disconnecting leave their rooms
+   - Join before starting the client, a client disconnecting before it joins would stay a member
+*/
+
+// ErrClientNotInHub is returned by JoinRoom and SendToID for clients that weren't added to the hub
+var ErrClientNotInHub = errors.New("websocket client is not in the hub")
+
+// JoinRoom adds a client of the hub to a room.
+func (h *Hub) JoinRoom(client *Client, room string) error {
+	if client == nil {
+		return errors.New("client cannot be nil")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[client.ID] != client {
+		return ErrClientNotInHub
+	}
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[*Client]bool)
+		h.rooms[room] = members
+	}
+	members[client] = true
+	if client.rooms == nil {
+		client.rooms = make(map[string]bool)
+	}
+	client.rooms[room] = true
+	return nil
+}
+
+// LeaveRoom removes a client from a room, nothing happens if it isn't a member.
+func (h *Hub) LeaveRoom(client *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(client, room)
+}
+
+// leave removes a client from a room and the room once empty, the caller holds the lock.
+func (h *Hub) leave(client *Client, room string) {
+	members, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+	delete(members, client)
+	delete(client.rooms, room)
+	if len(members) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// BroadcastToRoom sends a message to the clients of a room.
+func (h *Hub) BroadcastToRoom(room string, message Message) {
+	h.BroadcastToRoomContext(context.Background(), room, message)
+}
+
+// BroadcastToRoomContext sends a message to the clients of a room, recording a span in the trace of ctx.
+func (h *Hub) BroadcastToRoomContext(ctx context.Context, room string, message Message) {
+	_, span := tracer.Start(ctx, "websocket.broadcast_room")
+	defer span.End()
+
+	clients := h.RoomClients(room)
+	span.SetAttributes(
+		attribute.String("websocket.room", room),
+		attribute.String("websocket.message_type", message.Type),
+		attribute.Int("websocket.clients", len(clients)),
+	)
+
+	h.SendToClients(clients, message)
+	h.publish(ctx, Broadcast{Room: room, Message: message})
+}
+
+// RoomClients returns the clients of a room.
+func (h *Hub) RoomClients(room string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	clients := make([]*Client, 0, len(h.rooms[room]))
+	for client := range h.rooms[room] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Rooms returns the sorted names of the rooms with members.
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	slices.Sort(rooms)
+	return rooms
+}
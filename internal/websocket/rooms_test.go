@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHub_Rooms(t *testing.T) {
+	hub := NewHub()
+	alice, bob, carol := NewClient("alice", nil, hub), NewClient("bob", nil, hub), NewClient("carol", nil, hub)
+	for _, client := range []*Client{alice, bob, carol} {
+		hub.AddClient(client)
+	}
+
+	hub.JoinRoom(alice, "general")
+	hub.JoinRoom(bob, "general")
+	hub.JoinRoom(bob, "general")
+	hub.JoinRoom(carol, "random")
+	if rooms := hub.Rooms(); !reflect.DeepEqual(rooms, []string{"general", "random"}) {
+		t.Errorf("expected the rooms with members, got %v", rooms)
+	}
+	if n := len(hub.RoomClients("general")); n != 2 {
+		t.Errorf("expected 2 members, got %d", n)
+	}
+
+	// Messages reach the members of the room only
+	hub.BroadcastToRoom("general", Message{Type: "chat", Payload: []byte("hello")})
+	for _, client := range []*Client{alice, bob} {
+		select {
+		case msg := <-client.send:
+			if msg.Type != "chat" {
+				t.Errorf("expected the chat message, got %+v", msg)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected %s to receive the message", client.ID)
+		}
+	}
+	select {
+	case msg := <-carol.send:
+		t.Errorf("expected carol to receive nothing, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Rooms are removed with their last member
+	hub.LeaveRoom(carol, "random")
+	hub.LeaveRoom(carol, "missing")
+	if rooms := hub.Rooms(); !reflect.DeepEqual(rooms, []string{"general"}) {
+		t.Errorf("expected the empty room removed, got %v", rooms)
+	}
+
+	// Removed clients leave their rooms
+	hub.RemoveClient(alice)
+	if clients := hub.RoomClients("general"); len(clients) != 1 || clients[0] != bob {
+		t.Errorf("expected bob only, got %v", clients)
+	}
+	hub.RemoveClient(bob)
+	if rooms := hub.Rooms(); len(rooms) != 0 {
+		t.Errorf("expected no rooms, got %v", rooms)
+	}
+}
+
+func TestHub_JoinRoomErrors(t *testing.T) {
+	hub := NewHub()
+	if err := hub.JoinRoom(nil, "room"); err == nil {
+		t.Error("expected an error joining a nil client")
+	}
+	if err := hub.JoinRoom(NewClient("outside", nil, hub), "room"); !errors.Is(err, ErrClientNotInHub) {
+		t.Errorf("expected ErrClientNotInHub, got %v", err)
+	}
+	if rooms := hub.Rooms(); len(rooms) != 0 {
+		t.Errorf("expected no rooms, got %v", rooms)
+	}
+}
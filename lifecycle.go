@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mookie/config"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// lifecycle.go - Define process lifecycle helpers - PID file and startup summary
+
+// version of the application, set at build time with:
+// go build -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+// writePIDFile writes the current process ID to path
+// It refuses to overwrite the PID file of a process that is still running
+func writePIDFile(path string) error {
+	if data, err := os.ReadFile(path); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && processRunning(pid) {
+			return fmt.Errorf("pid file %s exists and process %d is still running", path, pid)
+		}
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// removePIDFile removes the PID file if it still belongs to this process
+func removePIDFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// listenerSummary describes the listeners the server will start
+func listenerSummary(cfg *config.Config) []string {
+	main := "tcp://" + net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.Port))
+	if cfg.Socket.Path != "" {
+		main = "unix://" + cfg.Socket.Path
+	}
+	if !cfg.TLS.Enabled {
+		return []string{"http " + main}
+	}
+
+	listeners := []string{"https " + main}
+	if cfg.TLS.AutoCert || cfg.TLS.RedirectHTTP {
+		listeners = append(listeners, "http redirect tcp://"+net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.TLS.HTTPPort)))
+	}
+	return listeners
+}
+
+// enabledSubsystems lists the optional subsystems enabled in the config
+func enabledSubsystems(cfg *config.Config) []string {
+	subsystems := []string{}
+	if cfg.TLS.Enabled {
+		if cfg.TLS.AutoCert {
+			subsystems = append(subsystems, "tls-autocert")
+		} else {
+			subsystems = append(subsystems, "tls")
+		}
+	}
+	if cfg.GraphQL.Enabled {
+		subsystems = append(subsystems, "graphql")
+	}
+	if cfg.Metrics.Enabled {
+		subsystems = append(subsystems, "metrics")
+	}
+	if cfg.Tracing.Enabled {
+		subsystems = append(subsystems, "tracing")
+	}
+	if cfg.SwaggerUI {
+		subsystems = append(subsystems, "swagger-ui")
+	}
+	if cfg.EmbedStatic {
+		subsystems = append(subsystems, "embedded-static")
+	}
+	if cfg.Search.Enabled {
+		subsystems = append(subsystems, "search-"+cfg.Search.Backend)
+	}
+	subsystems = append(subsystems, "mail-"+cfg.Mail.Transport)
+	return subsystems
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// processRunning reports whether a process with the given PID exists
+// On Windows FindProcess fails when the process doesn't exist
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
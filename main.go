@@ -1,52 +1,197 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"mookie/config"
+	"mookie/internal/assets"
+	"mookie/internal/messaging"
 	"mookie/routes"
-	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 /*
 Application structure:
 	- main.go: Entry point of the application
 	- setup.go: Define dependencies and set up the application
+	- lifecycle.go: Define process lifecycle helpers - PID file, version and startup summary
+	- doctor.go: Define environment checks run with -doctor and the effective config printed with -print-config
+	- assets.go: Define handling of embedded assets - extraction for customization
+	- server.go: Define listeners - TCP or unix socket, HTTP or HTTPS with certificate files or Let's Encrypt
 	- config/: Define configuration
+	- graph/: GraphQL schema and resolvers
 	- handlers/: Define route handlers
 	- internal/: Internal packages - should not be modified
-		- container/: Simple dependency injection container system
+		- admin/: CRUD scaffolding for a basic back office
+		- assets/: Static file fingerprinting, asset manifest and esbuild bundling hooks
+		- audit/: Audit log of user actions with request metadata and retention pruning
+		- auth/: Authenticator interface and basic auth implementation
+		- captcha/: Turnstile, hCaptcha and reCAPTCHA widget and server-side verification
+		- container/: Simple dependency injection container with lazy services and start/stop lifecycle
+		- crypto/: AES-GCM encryption and HMAC signing with versioned keys for rotation
+		- csrf/: Double-submit cookie CSRF token handling
 		- cron/: Simple package to register cron jobs and run at specified intervals
+		- dataloader/: Batching and caching loader for avoiding N+1 queries
 		- db/: Database setup and connection - SQLite + sqlc
+		- events/: In-process event bus with typed domain events
+		- flags/: Feature flags with percentage rollouts and user/role targeting
+		- forms/: Form helpers and templ components for inputs, errors and CSRF tokens
 		- logger/: Structured logging setup using slog, allows multiple writers
+		- mail/: Email messages with SMTP, log and maildir transports
+		- messaging/: SMS (Twilio compatible) and Web Push providers with a retrying outbound queue
+		- metrics/: Counter, gauge, histogram and timer facade for business metrics on the metrics endpoint
+		- notifications/: In-app notifications with real-time websocket delivery
+		- openapi/: Route registry generating an OpenAPI 3 document
+		- params/: Pagination, sorting and filtering query parameter parsing
+		- payments/: Stripe checkout, billing portal and signature verified webhooks syncing subscriptions
+		- ratelimit/: Token bucket and sliding window rate limiters backed by the cache
+		- redis/: Minimal Redis publish/subscribe client relaying websocket broadcasts between instances
+		- render/: Response rendering helpers with HTML/JSON content negotiation, view data and flash messages
+		- search/: Full text search with SQLite FTS5 and Bleve indexes
+		- tracing/: OpenTelemetry setup, database driver tracing and trace propagation
+		- validate/: Struct tag and rule string validation with translatable messages
+		- webhooks/: Outgoing webhooks with signed deliveries, retries and a delivery log
 		- websocket/: Simple websocket abstraction layer using Gorilla Websocket as the underlying library
 	- middleware/: Define middleware
 	- routes/: Define routes
-	- static/: Static files
-	- templates/: HTML templates using TEMPL template engine
-	- services/: Suggested location for custom business logic
+	- static/: Static files - embedded into the binary, served from disk unless EmbedStatic is set, fingerprinted for cache busting
+	- templates/: HTML templates using TEMPL template engine - compiled into the binary, layouts with named slots in templates/layout
+	- services/: Suggested location for custom business logic - declare its config with config.RegisterSection
 
 Application flow:
-	1. Parse command line flags
+	1. Parse command line flags - -port, -bind, -db and -log-level override the config file
+		- Optionally extract embedded assets and exit
+		- Optionally bundle and fingerprint static files and exit
+		- Optionally print a new VAPID key pair and exit
+		- Optionally write a commented sample config and exit
+		- Optionally encrypt a config value and exit
+		- Optionally run the doctor checks and exit
+		- Optionally print the effective config and exit
 	2. Set up dependencies
-		- Load config
+		- Load config - from Consul or etcd with -config-remote, falling back to the config file
 		- Set up logger
+		- Set up tracing
 		- Set up database
+		- Set up event bus
 		- Set up websocket hub and upgrader
+		- Set up webhooks and the cron runner
+		- Set start and stop hooks of services that don't implement container.Starter or container.Stopper
+		- Declare the dependencies of services on the database, so they stop before it's closed
 	3. Set up routes and pass the container to the routes setup function
 		- Routes define route handlers and middleware
-	4. Start the server
+		- Freeze the container, registering services afterwards panics
+	4. Start the services with container.StartAll, then the server
+		- Reload the config on changes and SIGHUP if WatchConfig is set
+		- HTTPS servers also start an HTTP listener for redirects and ACME challenges
+		- All listeners shut down gracefully on SIGINT/SIGTERM
+	5. Release resources with container.Shutdown and log the shutdown summary
+		- Services stop before their dependencies, otherwise in reverse registration order
+		- Stop the cron runner and close the websocket hub
+		- Wait for async event handlers to finish
+		- Close the database and flush buffered trace spans
 */
 
 func main() {
 	// Parse command line flags - define your own flags here if needed
 	configPath := flag.String("config", "config.toml", "path to config file")
+	configRemote := flag.String("config-remote", os.Getenv("MOOKIE_CONFIG_REMOTE"), "load the config from Consul or etcd, e.g. consul://127.0.0.1:8500/mookie/config - the config file is the fallback")
+	extractDir := flag.String("extract-assets", "", "write the embedded assets to this directory and exit")
+	buildAssets := flag.Bool("build-assets", false, "bundle static files with esbuild, write the asset manifest and exit")
+	genVAPIDKeys := flag.Bool("gen-vapid-keys", false, "print a new VAPID key pair for Web Push and exit")
+	genConfig := flag.String("gen-config", "", "write a commented config with every key and its default to this file, - for stdout, and exit")
+	encryptValue := flag.Bool("encrypt-value", false, "encrypt a config value read from stdin with MOOKIE_CONFIG_KEY, print it and exit")
+	doctor := flag.Bool("doctor", false, "check the environment, report all problems and exit")
+	printCfg := flag.Bool("print-config", false, "print the effective config with the source of every value, secrets masked, and exit")
+	// Flags setting config keys - -port, -bind, -db and -log-level override the config file
+	config.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	// Check the environment - inside doctor.go
+	if *doctor {
+		if !runDoctor(*configPath) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Print the effective config - inside doctor.go
+	if *printCfg {
+		if err := printConfig(os.Stdout, *configPath, *configRemote); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Dump the embedded assets for customization - inside assets.go
+	if *extractDir != "" {
+		if err := extractAssets(*extractDir); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Extracted embedded assets to %s", *extractDir)
+		return
+	}
+
+	// Bundle and fingerprint static files - inside assets.go
+	if *buildAssets {
+		manifest, err := buildStaticAssets(*configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote asset manifest with %d files to static/%s", manifest.Len(), assets.ManifestFile)
+		return
+	}
+
+	// Print Web Push keys for the Messaging config section
+	if *genVAPIDKeys {
+		publicKey, privateKey, err := messaging.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("VAPIDPublicKey = '%s'\nVAPIDPrivateKey = '%s'\n", publicKey, privateKey)
+		return
+	}
+
+	// Write a commented sample config, existing files aren't overwritten
+	if *genConfig != "" {
+		out := os.Stdout
+		if *genConfig != "-" {
+			f, err := os.OpenFile(*genConfig, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := config.WriteSample(out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Encrypt a secret for the config file - the value is read from stdin to keep it out of the shell history
+	if *encryptValue {
+		plaintext, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sealed, err := config.EncryptValue(strings.TrimRight(string(plaintext), "\r\n"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(sealed)
+		return
+	}
+
 	// Set up dependencies - inside setup.go
-	container, err := setupDependencies(configPath)
+	container, err := setupDependencies(configPath, configRemote)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -61,12 +206,75 @@ func main() {
 	// Setup routes and pass the dependency container
 	r := routes.Setup(container)
 
-	addr := fmt.Sprintf("%s:%d", cfg.BindAddress, cfg.Port)
-	// Start the web server
-	logger.Info("Starting server", "address", addr)
-	if err := http.ListenAndServe(addr, r); err != nil {
+	// Registering services from here on is a bug - tests override services before this point
+	container.Freeze()
+
+	// Write the PID file for process supervisors - inside lifecycle.go
+	if cfg.PIDFile != "" {
+		if err := writePIDFile(cfg.PIDFile); err != nil {
+			log.Fatal(err)
+		}
+		defer removePIDFile(cfg.PIDFile)
+	}
+
+	// Stop gracefully on interrupt or terminate signals
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Reload the config on changes and SIGHUP - subscribers registered with cfg.OnChange react
+	if cfg.WatchConfig {
+		cfg.OnChange(func(*config.Config) {
+			logger.Info("Config reloaded", "config", *configPath)
+		})
+		go func() {
+			err := cfg.Watch(ctx, func(err error) {
+				logger.Error("Failed to reload config", "error", err)
+			})
+			if err != nil {
+				logger.Error("Failed to watch config", "error", err)
+			}
+		}()
+	}
+
+	startedAt := time.Now()
+	logger.Info("Application starting",
+		"version", version,
+		"env", cfg.Env,
+		"pid", os.Getpid(),
+		"config", *configPath,
+		"listeners", listenerSummary(cfg),
+		"subsystems", enabledSubsystems(cfg),
+	)
+
+	// Start the services, e.g. the cron runner - inside setup.go, see setupLifecycle
+	if err := container.StartAll(ctx); err != nil {
 		log.Fatal(err)
 	}
+
+	// Start the web server - inside server.go
+	serveErr := serve(ctx, cfg, logger, r)
+
+	// Release resources held by the dependencies
+	// Stop the services before their dependencies - the cron runner and event bus before the database
+	shutdownStart := time.Now()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := container.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Error stopping services", "error", err)
+	}
+	cancel()
+
+	logger.Info("Application stopped",
+		"uptime", time.Since(startedAt).String(),
+		"shutdown_duration", time.Since(shutdownStart).String(),
+		"error", serveErr,
+	)
+	if serveErr != nil {
+		// os.Exit skips deferred calls
+		if cfg.PIDFile != "" {
+			removePIDFile(cfg.PIDFile)
+		}
+		os.Exit(1)
+	}
 }
 
 
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"mookie/internal/assets"
+	"net/http"
+)
+
+// Assets makes the asset manifest available to templates through assets.URL(ctx, name)
+func Assets(manifest *assets.Manifest) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(assets.WithManifest(r.Context(), manifest)))
+		})
+	}
+}
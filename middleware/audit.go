@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"mookie/internal/audit"
+	"net/http"
+)
+
+// Audit makes the audit recorder available to handlers through audit.Record(r.Context(), ...)
+// and attaches the request ID and client IP to recorded entries - it must run after the logger
+func Audit(recorder *audit.Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID, _ := r.Context().Value("request_id").(string)
+			ctx := audit.WithRecorder(r.Context(), recorder)
+			ctx = audit.WithRequest(ctx, requestID, realIP(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
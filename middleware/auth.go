@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"mookie/internal/auth"
+	"net/http"
+)
+
+// RequireAuth authenticates the request and stores the user in the request context
+// Unauthenticated requests are rejected with 401 and a basic auth challenge
+func RequireAuth(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := authenticator.Authenticate(r)
+			if err != nil {
+				if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+					w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+				}
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// RequireRole only lets through users that have the given role
+// It must run after RequireAuth in the chain
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := auth.UserFromContext(r.Context())
+			if user == nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if !user.HasRole(role) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
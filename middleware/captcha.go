@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"errors"
+	"mookie/internal/captcha"
+	"net/http"
+)
+
+// RequireCaptcha rejects form submissions without a valid captcha token with 403,
+// and with 503 when the captcha provider can't be reached
+// Wrap single routes with it, e.g. registration, login or password reset forms
+func RequireCaptcha(verifier *captcha.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := verifier.VerifyRequest(r); err != nil {
+				if errors.Is(err, captcha.ErrInvalid) {
+					http.Error(w, "captcha verification failed", http.StatusForbidden)
+					return
+				}
+				http.Error(w, "captcha verification unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
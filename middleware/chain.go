@@ -1,9 +1,17 @@
 package middleware
 
 import (
+	"mookie/config"
+	"mookie/internal/assets"
+	"mookie/internal/audit"
+	"mookie/internal/auth"
 	"mookie/internal/container"
+	"mookie/internal/flags"
+	"mookie/internal/ratelimit"
 	"log/slog"
 	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 /*
 	I wrote this comment cause I realize it's a bit confusing to get the hang of this at first :)
@@ -41,17 +49,126 @@ func Chain(handler http.Handler, middlewares ...func(http.Handler) http.Handler)
 
 // DefaultChain is a default chain of middlewares
 func DefaultChain(c *container.Container) func(http.Handler) http.Handler {
-	logger := c.MustGet("logger").(*slog.Logger)
+	logger := container.MustGet[*slog.Logger](c, "logger")
+	recoverPanics := Recover(logger, errorDetail(c))
+	flagService := container.MustGet[*flags.Service](c, "flags")
+	recorder := container.MustGet[*audit.Recorder](c, "audit")
+	manifest := container.MustGet[*assets.Manifest](c, "assets")
+	rateLimit := optionalLimiter(c, "ratelimit", RateLimit)
+	metrics := optionalMetrics(c)
+	tracing := optionalTracing(c)
 	return func(h http.Handler) http.Handler {
 		return Chain(h,
+			Flags(flagService),
+			Audit(recorder),
+			Assets(manifest),
+			rateLimit,
+			recoverPanics,
 			LoggerMiddleware(logger),
+			metrics,
+			tracing,
 			// BlankMiddleware,
 		)
 	}
 }
 
+// errorDetail reports whether 500 responses show panic details, following ErrorDetail on config reloads
+func errorDetail(c *container.Container) func() bool {
+	cfg := container.MustGet[*config.Config](c, "config")
+	return func() bool {
+		return cfg.Current().ErrorDetail
+	}
+}
+
+// optionalMetrics returns the metrics middleware if metrics are enabled, otherwise a pass-through
+func optionalMetrics(c *container.Container) func(http.Handler) http.Handler {
+	registry := container.GetOr[prometheus.Registerer](c, "metrics", nil)
+	if registry == nil {
+		return func(h http.Handler) http.Handler { return h }
+	}
+	return MetricsMiddleware(registry)
+}
+
+// optionalLimiter returns the middleware for the named limiter if it's registered, otherwise a pass-through
+func optionalLimiter(c *container.Container, name string, middleware func(*ratelimit.Limiter) func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	limiter := container.GetOr[*ratelimit.Limiter](c, name, nil)
+	if limiter == nil {
+		return func(h http.Handler) http.Handler { return h }
+	}
+	return middleware(limiter)
+}
+
+// optionalTracing returns the tracing middleware if tracing is enabled, otherwise a pass-through
+func optionalTracing(c *container.Container) func(http.Handler) http.Handler {
+	if _, err := c.Get("tracing"); err != nil {
+		return func(h http.Handler) http.Handler { return h }
+	}
+	return Tracing
+}
+
 /*
  *
  *
  * A place for more middleware chains
  */
+
+// AuthChain protects routes so only authenticated users can access them
+// Middlewares wrap from the bottom up, so the logger runs first and the CSRF check runs last
+func AuthChain(c *container.Container) func(http.Handler) http.Handler {
+	logger := container.MustGet[*slog.Logger](c, "logger")
+	recoverPanics := Recover(logger, errorDetail(c))
+	authenticator := container.MustResolveAs[auth.Authenticator](c)
+	loginLimit := optionalLimiter(c, "loginlimit", LoginLimit)
+	flagService := container.MustGet[*flags.Service](c, "flags")
+	recorder := container.MustGet[*audit.Recorder](c, "audit")
+	manifest := container.MustGet[*assets.Manifest](c, "assets")
+	rateLimit := optionalLimiter(c, "ratelimit", RateLimit)
+	metrics := optionalMetrics(c)
+	tracing := optionalTracing(c)
+	return func(h http.Handler) http.Handler {
+		return Chain(h,
+			Flags(flagService),
+			Audit(recorder),
+			Assets(manifest),
+			CSRF,
+			RequireAuth(authenticator),
+			loginLimit,
+			rateLimit,
+			recoverPanics,
+			LoggerMiddleware(logger),
+			metrics,
+			tracing,
+		)
+	}
+}
+
+// AdminChain protects routes so only authenticated users with the admin role can access them
+// Middlewares wrap from the bottom up, so the logger runs first and the CSRF check runs last
+func AdminChain(c *container.Container) func(http.Handler) http.Handler {
+	logger := container.MustGet[*slog.Logger](c, "logger")
+	recoverPanics := Recover(logger, errorDetail(c))
+	authenticator := container.MustResolveAs[auth.Authenticator](c)
+	loginLimit := optionalLimiter(c, "loginlimit", LoginLimit)
+	flagService := container.MustGet[*flags.Service](c, "flags")
+	recorder := container.MustGet[*audit.Recorder](c, "audit")
+	manifest := container.MustGet[*assets.Manifest](c, "assets")
+	rateLimit := optionalLimiter(c, "ratelimit", RateLimit)
+	metrics := optionalMetrics(c)
+	tracing := optionalTracing(c)
+	return func(h http.Handler) http.Handler {
+		return Chain(h,
+			Flags(flagService),
+			Audit(recorder),
+			Assets(manifest),
+			CSRF,
+			RequireRole("admin"),
+			RequireAuth(authenticator),
+			loginLimit,
+			rateLimit,
+			recoverPanics,
+			LoggerMiddleware(logger),
+			metrics,
+			tracing,
+		)
+	}
+}
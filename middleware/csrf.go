@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"mookie/internal/csrf"
+	"net/http"
+)
+
+// CSRF issues a CSRF token cookie and rejects unsafe requests without a matching token
+// The token is available to handlers and templates through csrf.Token(r.Context())
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reuse the token from the cookie or issue a new one
+		token := ""
+		if cookie, err := r.Cookie(csrf.CookieName); err == nil {
+			token = cookie.Value
+		}
+		if token == "" {
+			var err error
+			token, err = csrf.Generate()
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrf.CookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		if !csrf.IsSafeMethod(r.Method) {
+			if err := csrf.Verify(r); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(csrf.WithToken(r.Context(), token)))
+	})
+}
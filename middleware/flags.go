@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"mookie/internal/flags"
+	"net/http"
+)
+
+// Flags makes the feature flag service available to handlers and templates through flags.Enabled(r.Context(), name)
+func Flags(service *flags.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(flags.WithService(r.Context(), service)))
+		})
+	}
+}
@@ -23,13 +23,7 @@ func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			w.Header().Set("X-Request-ID", requestID)
 
 			// Get real IP if behind proxy
-			realIP := r.Header.Get("X-Real-IP")
-			if realIP == "" {
-				realIP = r.Header.Get("X-Forwarded-For")
-			}
-			if realIP == "" {
-				realIP = r.RemoteAddr
-			}
+			clientIP := realIP(r)
 
 			// Call the next middleware or final handler in the chain
 			next.ServeHTTP(w, r)
@@ -45,7 +39,7 @@ func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 				"method", r.Method,
 				"protocol", r.Proto,
 				"duration", time.Since(start).String(),
-				"ip", realIP,
+				"ip", clientIP,
 				"host", r.Host,
 				"path", r.URL.Path+queryParams,
 				"user_agent", r.UserAgent(),
@@ -54,3 +48,8 @@ func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// realIP returns the client IP, the peer address unless RealIP replaced it with the one forwarded by a trusted proxy
+func realIP(r *http.Request) string {
+	return remoteHost(r.RemoteAddr)
+}
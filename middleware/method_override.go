@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+/*
+	MethodOverride lets HTML forms (which only support GET and POST) express PUT, PATCH and DELETE.
+
+	A POST request is treated as the overriding method when it carries either the
+	X-HTTP-Method-Override header or a _method form field:
+
+		<form method="POST" action="/users/42">
+			<input type="hidden" name="_method" value="DELETE"/>
+		</form>
+
+	The method-aware ServeMux patterns (e.g. "DELETE /users/{id}") are matched by the mux itself,
+	so this middleware has to wrap the whole mux rather than be part of a per-route chain:
+
+		return middleware.MethodOverride()(mux)
+
+	Only the methods in the allowlist can be used as overrides - PUT, PATCH and DELETE by default.
+*/
+
+// DefaultOverridableMethods are the methods allowed by MethodOverride when none are given
+var DefaultOverridableMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// MethodOverride rewrites the method of POST requests using the X-HTTP-Method-Override header or _method form field
+func MethodOverride(allowed ...string) func(http.Handler) http.Handler {
+	if len(allowed) == 0 {
+		allowed = DefaultOverridableMethods
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				method := r.Header.Get("X-HTTP-Method-Override")
+				if method == "" {
+					method = r.PostFormValue("_method")
+				}
+				method = strings.ToUpper(method)
+				if slices.Contains(allowed, method) {
+					r.Method = method
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"mookie/internal/auth"
+	"mookie/internal/payments"
+	"net/http"
+	"strconv"
+)
+
+// RequireActiveSubscription rejects users without an active subscription with 402 Payment Required
+// and adds the subscription to the request context - see payments.SubscriptionFromContext
+// Place it after RequireAuth, e.g. middleware.AuthChain(c)(middleware.RequireActiveSubscription(service, logger)(handler))
+func RequireActiveSubscription(service *payments.Service, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := auth.UserFromContext(r.Context())
+			if user == nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			userID, err := strconv.ParseInt(user.ID, 10, 64)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			sub, err := service.ActiveSubscription(r.Context(), userID)
+			if errors.Is(err, payments.ErrNoSubscription) {
+				http.Error(w, "an active subscription is required", http.StatusPaymentRequired)
+				return
+			}
+			if err != nil {
+				logger.Error("failed to load subscription", "error", err)
+				http.Error(w, "failed to load subscription", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(payments.WithSubscription(r.Context(), sub)))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"mookie/internal/ratelimit"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit rejects clients exceeding the limiter with 429, keyed by client IP
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers
+func RateLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Cache errors let the request through rather than taking the site down
+			result, err := limiter.Allow(clientKey(r))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setRateLimitHeaders(w, result)
+			if !result.Allowed {
+				tooManyRequests(w, result)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LoginLimit protects credential checks against brute force - every 401 response to a request
+// with credentials counts as a failed attempt of the client IP, and clients out of attempts are
+// rejected with 429 before their credentials are checked
+// It must run before RequireAuth in the chain
+func LoginLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientKey(r)
+			if result, err := limiter.Peek(ip); err == nil && !result.Allowed {
+				tooManyRequests(w, result)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			if rec.status == http.StatusUnauthorized && r.Header.Get("Authorization") != "" {
+				limiter.Allow(ip)
+			}
+		})
+	}
+}
+
+// clientKey returns the client IP without the port, so all connections of a client share a limit
+// Forwarding headers only count through RealIP, clients could reset their limit with them otherwise
+func clientKey(r *http.Request) string {
+	return realIP(r)
+}
+
+// setRateLimitHeaders describes the limit of the client in the response headers
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+}
+
+// tooManyRequests rejects the request with 429 and a Retry-After header in whole seconds
+func tooManyRequests(w http.ResponseWriter, result ratelimit.Result) {
+	retryAfter := int(result.RetryAfter.Seconds())
+	if result.RetryAfter%time.Second != 0 {
+		retryAfter++
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
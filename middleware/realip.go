@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+/*
+	RealIP replaces the RemoteAddr of requests forwarded by trusted reverse proxies with the
+	address of the client, so rate limits, logs and audit entries see the client rather than the proxy.
+
+	Forwarding headers are set by whoever sends the request, so they're only read when the peer
+	is one of the trusted proxies. X-Forwarded-For is read from the right, each proxy appends the
+	address it received the request from - the right-most address that isn't a trusted proxy is
+	the client, anything left of it may be spoofed. X-Real-IP is used without X-Forwarded-For.
+
+		return middleware.RealIP(cfg.Server.TrustedPrefixes())(mux)
+
+	Without trusted proxies the headers are ignored and clients are identified by the peer address.
+*/
+
+// RealIP sets the RemoteAddr of requests from the trusted proxies to the forwarded client address
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trusted); ok {
+				r.RemoteAddr = ip.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the client address forwarded by a trusted proxy
+func forwardedIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, err := parseIP(remoteHost(r.RemoteAddr))
+	if err != nil || !isTrusted(peer, trusted) {
+		return netip.Addr{}, false
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		ip, err := parseIP(r.Header.Get("X-Real-IP"))
+		return ip, err == nil
+	}
+
+	// Every hop is a trusted proxy, the left-most is the closest to the client
+	var ip netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		if ip, err = parseIP(hops[i]); err != nil {
+			return netip.Addr{}, false
+		}
+		if !isTrusted(ip, trusted) {
+			break
+		}
+	}
+	return ip, true
+}
+
+// isTrusted reports whether ip is one of the trusted proxies
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP parses an address of a forwarding header, IPv4-mapped IPv6 addresses are unmapped
+func parseIP(s string) (netip.Addr, error) {
+	ip, err := netip.ParseAddr(strings.TrimSpace(s))
+	return ip.Unmap(), err
+}
+
+// remoteHost returns the host of a RemoteAddr, which usually carries a port
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"mookie/internal/cache"
+	"mookie/internal/ratelimit"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "no trusted proxies", remoteAddr: "203.0.113.7:1234", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "untrusted peer", trusted: trusted, remoteAddr: "203.0.113.7:1234", forwarded: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "trusted peer", trusted: trusted, remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "right-most untrusted hop", trusted: trusted, remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4, 198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "hops in several headers", trusted: trusted, remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "only trusted hops", trusted: trusted, remoteAddr: "10.0.0.1:1234", forwarded: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "invalid hop", trusted: trusted, remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4, bogus"}, want: "10.0.0.1"},
+		{name: "real ip header", trusted: trusted, remoteAddr: "[::1]:1234", realIP: "198.51.100.1", want: "198.51.100.1"},
+		{name: "invalid real ip header", trusted: trusted, remoteAddr: "10.0.0.1:1234", realIP: "bogus", want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(tt.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = realIP(r)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, forwarded := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("expected client %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoginLimit_SpoofedForwardedFor(t *testing.T) {
+	limiter := ratelimit.NewTokenBucket(cache.NewMemoryCache(cache.CleanupInterval(0)), "login", ratelimit.Limit{Rate: 3, Per: time.Minute})
+	unauthorized := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	handler := RealIP(nil)(LoginLimit(limiter)(unauthorized))
+
+	// A new X-Forwarded-For on every attempt doesn't give the client new attempts
+	for i := range 5 {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.RemoteAddr = "203.0.113.7:1234"
+		r.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+		r.Header.Set("X-Forwarded-For", netip.AddrFrom4([4]byte{198, 51, 100, byte(i)}).String())
+		r.Header.Set("X-Real-IP", netip.AddrFrom4([4]byte{192, 0, 2, byte(i)}).String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		want := http.StatusUnauthorized
+		if i >= 3 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Errorf("attempt %d: expected %d, got %d", i+1, want, w.Code)
+		}
+	}
+}
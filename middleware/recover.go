@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns panics in handlers into 500 responses and logs them with the stack trace
+// detail reports whether the response shows the panic and stack trace - the ErrorDetail setting,
+// it is called on every panic so config reloads apply
+func Recover(logger *slog.Logger, detail func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// The server aborts the response silently for ErrAbortHandler
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				stack := debug.Stack()
+				requestID, _ := r.Context().Value("request_id").(string)
+				logger.Error("Handler panicked",
+					"request_id", requestID,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", fmt.Sprint(rec),
+					"stack", string(stack),
+				)
+
+				// Headers may already be sent, the status can't be changed then
+				if detail() {
+					http.Error(w, fmt.Sprintf("panic: %v\n\n%s", rec, stack), http.StatusInternalServerError)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
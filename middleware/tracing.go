@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"mookie/internal/tracing"
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span per request, continuing the trace from the incoming traceparent header
+// Spans are named after the matched route pattern to group requests of the same route
+func Tracing(next http.Handler) http.Handler {
+	tracer := tracing.Tracer("mookie/middleware")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Pattern
+		if name == "" {
+			name = r.Method
+		}
+
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.HTTPRoute(r.Pattern),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
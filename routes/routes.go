@@ -1,18 +1,39 @@
 package routes
 
 import (
+	"io/fs"
+	"mookie/config"
 	"mookie/handlers"
+	"mookie/internal/admin"
+	"mookie/internal/assets"
+	"mookie/internal/audit"
 	"mookie/internal/container"
+	"mookie/internal/cron"
+	"mookie/internal/db/sqlc"
+	"mookie/internal/messaging"
+	"mookie/internal/openapi"
+	"mookie/internal/params"
+	"mookie/internal/payments"
+	"mookie/internal/search"
 	"mookie/middleware"
 	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 /*
 Define all the routes for the application here. Chain middleware together
 with the middleware.Chain function, and use the http.HandlerFunc function
 to convert your handler functions to http.Handler types.
+
+Routes registered through api.Handle are also documented in the generated
+OpenAPI document served at /openapi.json.
 */
 func Setup(c *container.Container) http.Handler {
+	cfg := container.MustGet[*config.Config](c, "config")
+	api := container.MustGet[*openapi.Registry](c, "openapi")
+
 	// Setup middlewares
 	// Default middleware chain - pass the dependency container
 	defaultChain := middleware.DefaultChain(c)
@@ -22,24 +43,249 @@ func Setup(c *container.Container) http.Handler {
 
 	// Define routes - replace with your own
 	// Load frontpage
-	mux.Handle("GET /", defaultChain(
+	api.Handle(mux, openapi.Route{
+		Method:      "GET",
+		Path:        "/",
+		Summary:     "Front page",
+		ContentType: "text/html",
+		Tags:        []string{"pages"},
+	}, defaultChain(
 		http.HandlerFunc(handlers.Front())),
 	)
 
 	// Post message
-	mux.Handle("POST /post-message", defaultChain(
+	api.Handle(mux, openapi.Route{
+		Method:  "POST",
+		Path:    "/post-message",
+		Summary: "Broadcast a message to all websocket clients",
+		Tags:    []string{"messages"},
+		Params: []openapi.Param{
+			{Name: "message", In: "header", Description: "Message to broadcast", Required: true},
+		},
+		Response: map[string]string{},
+	}, defaultChain(
 		http.HandlerFunc(handlers.PostMessage(c))),
 	)
 
 	// Websocket message stream
-	mux.Handle("GET /ws/message-stream", defaultChain(
+	api.Handle(mux, openapi.Route{
+		Method:      "GET",
+		Path:        "/ws/message-stream",
+		Summary:     "Websocket stream of broadcast messages",
+		Description: "Upgrades the connection to a websocket.",
+		Tags:        []string{"messages"},
+	}, defaultChain(
 		http.HandlerFunc(handlers.BroadcastMessage(c))),
 	)
 
-	// Serve static files from static folder as /static/*
-	fs := http.FileServer(http.Dir("static"))
-	staticHandler := http.StripPrefix("/static/", fs)
+	// Notifications of the authenticated user
+	authChain := middleware.AuthChain(c)
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/notifications",
+		Summary:  "List the authenticated user's notifications",
+		Tags:     []string{"notifications"},
+		Params:   []openapi.Param{{Name: "page", In: "query"}, {Name: "per_page", In: "query"}},
+		Response: handlers.NotificationList{},
+	}, authChain(handlers.Notifications(c)))
+	api.Handle(mux, openapi.Route{
+		Method:   "POST",
+		Path:     "/notifications/{id}/read",
+		Summary:  "Mark a notification read",
+		Tags:     []string{"notifications"},
+		Params:   []openapi.Param{{Name: "id", In: "path", Required: true}},
+		Response: map[string]int64{},
+	}, authChain(handlers.MarkNotificationRead(c)))
+	api.Handle(mux, openapi.Route{
+		Method:   "POST",
+		Path:     "/notifications/read-all",
+		Summary:  "Mark all notifications read",
+		Tags:     []string{"notifications"},
+		Response: map[string]int64{},
+	}, authChain(handlers.MarkAllNotificationsRead(c)))
+	api.Handle(mux, openapi.Route{
+		Method:      "GET",
+		Path:        "/ws/notifications",
+		Summary:     "Websocket stream of the authenticated user's notifications",
+		Description: "Upgrades the connection to a websocket.",
+		Tags:        []string{"notifications"},
+	}, authChain(handlers.NotificationStream(c)))
+
+	// Web Push subscriptions of the authenticated user
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/notifications/push-key",
+		Summary:  "Get the VAPID public key to subscribe to push notifications with",
+		Tags:     []string{"notifications"},
+		Response: handlers.PushKey{},
+	}, authChain(handlers.PushPublicKey(c)))
+	api.Handle(mux, openapi.Route{
+		Method:  "POST",
+		Path:    "/notifications/push-subscriptions",
+		Summary: "Subscribe a browser to push notifications",
+		Tags:    []string{"notifications"},
+		Request: messaging.Subscription{},
+	}, authChain(handlers.SubscribePush(c)))
+	api.Handle(mux, openapi.Route{
+		Method:  "DELETE",
+		Path:    "/notifications/push-subscriptions",
+		Summary: "Unsubscribe a browser from push notifications",
+		Tags:    []string{"notifications"},
+		Request: messaging.Subscription{},
+	}, authChain(handlers.UnsubscribePush(c)))
+
+	// Stripe billing of the authenticated user and the Stripe webhook
+	if _, err := c.Get("payments"); err == nil {
+		api.Handle(mux, openapi.Route{
+			Method:   "GET",
+			Path:     "/billing",
+			Summary:  "Get the authenticated user's subscriptions",
+			Tags:     []string{"billing"},
+			Response: handlers.BillingStatus{},
+		}, authChain(handlers.Billing(c)))
+		api.Handle(mux, openapi.Route{
+			Method:   "POST",
+			Path:     "/billing/checkout",
+			Summary:  "Create a Stripe checkout session",
+			Tags:     []string{"billing"},
+			Params:   []openapi.Param{{Name: "price", In: "query"}},
+			Response: payments.Session{},
+		}, authChain(handlers.Checkout(c)))
+		api.Handle(mux, openapi.Route{
+			Method:   "POST",
+			Path:     "/billing/portal",
+			Summary:  "Create a Stripe billing portal session",
+			Tags:     []string{"billing"},
+			Response: payments.Session{},
+		}, authChain(handlers.BillingPortal(c)))
+		api.Handle(mux, openapi.Route{
+			Method:      "POST",
+			Path:        "/webhooks/stripe",
+			Summary:     "Receive Stripe webhook events",
+			Description: "Requires a valid Stripe-Signature header.",
+			Tags:        []string{"billing"},
+		}, defaultChain(handlers.StripeWebhook(c)))
+	}
+
+	// Webhook delivery log - requires the admin role
+	adminChain := middleware.AdminChain(c)
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/admin/webhooks/{id}/deliveries",
+		Summary:  "List the deliveries of a webhook",
+		Tags:     []string{"webhooks"},
+		Params:   []openapi.Param{{Name: "id", In: "path", Required: true}, {Name: "page", In: "query"}, {Name: "per_page", In: "query"}},
+		Response: params.Page[sqlc.WebhookDelivery]{},
+	}, adminChain(handlers.WebhookDeliveries(c)))
+	api.Handle(mux, openapi.Route{
+		Method:   "POST",
+		Path:     "/admin/webhook-deliveries/{id}/redeliver",
+		Summary:  "Queue a webhook delivery for another attempt",
+		Tags:     []string{"webhooks"},
+		Params:   []openapi.Param{{Name: "id", In: "path", Required: true}},
+		Response: map[string]string{},
+	}, adminChain(handlers.RedeliverWebhook(c)))
+
+	// Background task stats and run history - requires the admin role
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/admin/cron",
+		Summary:  "List the background tasks with their run counters",
+		Tags:     []string{"cron"},
+		Response: []cron.Stats{},
+	}, adminChain(handlers.CronStats(c)))
+	api.Handle(mux, openapi.Route{
+		Method:      "GET",
+		Path:        "/admin/cron/history",
+		Summary:     "List the recorded runs of the background tasks",
+		Description: "Runs are recorded when Cron.HistoryDays is set.",
+		Tags:        []string{"cron"},
+		Params:      []openapi.Param{{Name: "task", In: "query"}, {Name: "page", In: "query"}, {Name: "per_page", In: "query"}},
+		Response:    params.Page[sqlc.CronHistory]{},
+	}, adminChain(handlers.CronHistory(c)))
+
+	// Audit log review - requires the admin role
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/admin/audit",
+		Summary:  "List audit log entries",
+		Tags:     []string{"audit"},
+		Params:   []openapi.Param{{Name: "filter[actor]", In: "query"}, {Name: "filter[action]", In: "query"}, {Name: "filter[object_type]", In: "query"}, {Name: "filter[object_id]", In: "query"}, {Name: "filter[from]", In: "query"}, {Name: "filter[to]", In: "query"}, {Name: "page", In: "query"}, {Name: "per_page", In: "query"}},
+		Response: params.Page[audit.Entry]{},
+	}, adminChain(handlers.AuditLog(c)))
+
+	// Debug pages - require the admin role, on by default in development
+	if cfg.DebugRoutes {
+		api.Handle(mux, openapi.Route{
+			Method:   "GET",
+			Path:     "/admin/debug/services",
+			Summary:  "List the services registered in the container",
+			Tags:     []string{"debug"},
+			Response: []container.ServiceInfo{},
+		}, adminChain(handlers.Services(c)))
+	}
+
+	// Full text search - requires the admin role as results link to the admin
+	if _, err := c.Get("search"); err == nil {
+		api.Handle(mux, openapi.Route{
+			Method:   "GET",
+			Path:     "/admin/search",
+			Summary:  "Search the indexed models",
+			Tags:     []string{"search"},
+			Params:   []openapi.Param{{Name: "q", In: "query"}, {Name: "type", In: "query"}, {Name: "page", In: "query"}, {Name: "per_page", In: "query"}},
+			Response: params.Page[search.Hit]{},
+		}, adminChain(handlers.Search(c)))
+	}
+
+	// Health endpoints for orchestrators - without the middleware chain so probes aren't logged or rate limited
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/healthz",
+		Summary:  "Liveness probe, ok while the process serves requests",
+		Tags:     []string{"health"},
+		Response: handlers.HealthStatus{},
+	}, handlers.Healthz())
+	api.Handle(mux, openapi.Route{
+		Method:   "GET",
+		Path:     "/readyz",
+		Summary:  "Readiness probe, runs the database, cache and websocket hub health checks",
+		Tags:     []string{"health"},
+		Response: handlers.HealthStatus{},
+	}, handlers.Readyz(c))
+
+	// OpenAPI document and optional Swagger UI
+	mux.Handle("GET /openapi.json", defaultChain(api.Handler()))
+	if cfg.SwaggerUI {
+		mux.Handle("GET /docs", defaultChain(openapi.SwaggerUI("/openapi.json")))
+	}
+
+	// GraphQL endpoint and the development playground - requires the admin role since the schema
+	// exposes the emails and roles of all users
+	if cfg.GraphQL.Enabled {
+		mux.Handle("POST /graphql", adminChain(handlers.GraphQL(c)))
+		if cfg.GraphQL.Playground && cfg.IsDevelopment() {
+			mux.Handle("GET /graphql", adminChain(handlers.GraphQLPlayground("/graphql")))
+		}
+	}
+
+	// Prometheus metrics
+	if cfg.Metrics.Enabled {
+		registry := container.MustGet[*prometheus.Registry](c, "metrics")
+		mux.Handle("GET "+cfg.Metrics.Path, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+
+	// Admin back office - requires the admin role
+	adm := container.MustGet[*admin.Admin](c, "admin")
+	adm.Mount(mux, "/admin", adminChain)
+
+	// Serve static files as /static/* - from the binary when EmbedStatic is enabled,
+	// otherwise from the static folder on disk, fingerprinted names are cached forever
+	staticFS := container.MustGet[fs.FS](c, "static")
+	manifest := container.MustGet[*assets.Manifest](c, "assets")
+	staticHandler := http.StripPrefix("/static/", manifest.Handler(staticFS))
 	mux.Handle("GET /static/", defaultChain(staticHandler))
 
-	return mux
+	// Allow HTML forms to use PUT/PATCH/DELETE routes - wraps the whole mux
+	// Take the client address from the trusted proxies before any middleware reads it
+	return middleware.RealIP(cfg.Server.TrustedPrefixes())(middleware.MethodOverride()(mux))
 }
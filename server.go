@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mookie/config"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// server.go - Define how the application listens for connections
+
+// shutdownTimeout is how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// runningServer is a server together with the function that starts it
+type runningServer struct {
+	name   string
+	server *http.Server
+	start  func() error
+}
+
+// serve starts the web server based on the config and blocks until ctx is cancelled or a listener fails
+// On cancellation all listeners are shut down gracefully
+func serve(ctx context.Context, cfg *config.Config, logger *slog.Logger, handler http.Handler) error {
+	servers, err := setupServers(cfg, logger, handler)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, len(servers))
+	for _, s := range servers {
+		go func() {
+			if err := s.start(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("%s server: %w", s.name, err)
+			}
+		}()
+	}
+
+	// Wait for a shutdown signal or the first listener to fail
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutting down servers")
+	case serveErr = <-errs:
+		logger.Error("Server failed, shutting down", "error", serveErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, s := range servers {
+		start := time.Now()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error shutting down server", "server", s.name, "error", err)
+			continue
+		}
+		logger.Info("Server stopped", "server", s.name, "duration", time.Since(start).String())
+	}
+
+	return serveErr
+}
+
+// setupServers creates the main server and, for HTTPS, the HTTP redirect/ACME challenge server
+func setupServers(cfg *config.Config, logger *slog.Logger, handler http.Handler) ([]runningServer, error) {
+	server := newServer(cfg.Server, handler)
+
+	listener, err := listen(cfg)
+	if err != nil {
+		return nil, err
+	}
+	addr := listener.Addr().String()
+
+	if !cfg.TLS.Enabled {
+		return []runningServer{{
+			name:   "http",
+			server: server,
+			start: func() error {
+				logger.Info("Starting server", "address", addr)
+				return server.Serve(listener)
+			},
+		}}, nil
+	}
+
+	// Answers ACME challenges when using autocert and redirects everything else to HTTPS
+	var httpHandler http.Handler = redirectToHTTPS(cfg.TLS.HTTPSPort)
+	var servers []runningServer
+
+	if !cfg.TLS.AutoCert {
+		// Certificates from files
+		if cfg.TLS.CertFile == "" || cfg.TLS.KeyFile == "" {
+			listener.Close()
+			return nil, errors.New("TLS.CertFile and TLS.KeyFile are required when TLS is enabled without AutoCert")
+		}
+		servers = append(servers, runningServer{
+			name:   "https",
+			server: server,
+			start: func() error {
+				logger.Info("Starting HTTPS server", "address", addr, "cert", cfg.TLS.CertFile)
+				return server.ServeTLS(listener, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			},
+		})
+	} else {
+		// Automatic certificates from Let's Encrypt
+		if len(cfg.TLS.Domains) == 0 {
+			listener.Close()
+			return nil, errors.New("TLS.Domains is required when AutoCert is enabled")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLS.Domains...),
+			Cache:      autocert.DirCache(cfg.TLS.CacheDir),
+			Email:      cfg.TLS.Email,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		httpHandler = manager.HTTPHandler(httpHandler)
+		servers = append(servers, runningServer{
+			name:   "https",
+			server: server,
+			start: func() error {
+				logger.Info("Starting HTTPS server", "address", addr, "domains", cfg.TLS.Domains)
+				return server.ServeTLS(listener, "", "")
+			},
+		})
+	}
+
+	// The HTTP listener is required for ACME http-01 challenges, optional otherwise
+	if cfg.TLS.AutoCert || cfg.TLS.RedirectHTTP {
+		httpAddr := net.JoinHostPort(cfg.BindAddress, fmt.Sprint(cfg.TLS.HTTPPort))
+		redirectServer := newServer(cfg.Server, httpHandler)
+		redirectServer.Addr = httpAddr
+		servers = append(servers, runningServer{
+			name:   "http redirect",
+			server: redirectServer,
+			start: func() error {
+				logger.Info("Starting HTTP redirect server", "address", httpAddr)
+				return redirectServer.ListenAndServe()
+			},
+		})
+	}
+
+	return servers, nil
+}
+
+// newServer creates an http.Server with the timeouts and header limit from the config
+func newServer(sc config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: sc.ReadHeaderTimeout,
+		ReadTimeout:       sc.ReadTimeout,
+		WriteTimeout:      sc.WriteTimeout,
+		IdleTimeout:       sc.IdleTimeout,
+		MaxHeaderBytes:    sc.MaxHeaderBytes,
+	}
+}
+
+// redirectToHTTPS permanently redirects requests to the same URL over HTTPS
+// httpsPort is the public HTTPS port, the port is left out of the URL when it's 443
+func redirectToHTTPS(httpsPort int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, fmt.Sprint(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}
+}
+
+// listen creates the main listener - a unix socket if Socket.Path is set, TCP otherwise
+func listen(cfg *config.Config) (net.Listener, error) {
+	if cfg.Socket.Path == "" {
+		return net.Listen("tcp", net.JoinHostPort(cfg.BindAddress, fmt.Sprint(cfg.Port)))
+	}
+
+	// Remove a stale socket left behind by a previous run
+	if info, err := os.Stat(cfg.Socket.Path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("socket path %s exists and is not a socket", cfg.Socket.Path)
+		}
+		if err := os.Remove(cfg.Socket.Path); err != nil {
+			return nil, fmt.Errorf("error removing stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", cfg.Socket.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setSocketPermissions(cfg.Socket); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// setSocketPermissions applies the configured mode and ownership to the socket file
+func setSocketPermissions(socket config.SocketConfig) error {
+	if socket.Mode != "" {
+		mode, err := strconv.ParseUint(socket.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid Socket.Mode %q: %w", socket.Mode, err)
+		}
+		if err := os.Chmod(socket.Path, os.FileMode(mode)); err != nil {
+			return fmt.Errorf("error setting socket mode: %w", err)
+		}
+	}
+
+	if socket.Owner == "" && socket.Group == "" {
+		return nil
+	}
+
+	// -1 leaves the owner or group unchanged
+	uid, gid := -1, -1
+	if socket.Owner != "" {
+		u, err := user.Lookup(socket.Owner)
+		if err != nil {
+			return fmt.Errorf("error looking up socket owner: %w", err)
+		}
+		uid, _ = strconv.Atoi(u.Uid)
+	}
+	if socket.Group != "" {
+		g, err := user.LookupGroup(socket.Group)
+		if err != nil {
+			return fmt.Errorf("error looking up socket group: %w", err)
+		}
+		gid, _ = strconv.Atoi(g.Gid)
+	}
+	if err := os.Chown(socket.Path, uid, gid); err != nil {
+		return fmt.Errorf("error setting socket ownership: %w", err)
+	}
+	return nil
+}
@@ -3,29 +3,85 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	ws "github.com/gorilla/websocket"
 	"golang.org/x/crypto/bcrypt"
+	"io/fs"
 	"log"
 	"log/slog"
 	"mookie/config"
+	"mookie/graph"
+	"mookie/handlers"
+	"mookie/internal/admin"
+	"mookie/internal/assets"
+	"mookie/internal/audit"
+	"mookie/internal/auth"
+	"mookie/internal/cache"
+	"mookie/internal/captcha"
 	"mookie/internal/container"
+	"mookie/internal/cron"
 	"mookie/internal/db"
 	"mookie/internal/db/sqlc"
+	"mookie/internal/events"
+	"mookie/internal/flags"
 	"mookie/internal/logger"
+	"mookie/internal/mail"
+	"mookie/internal/messaging"
+	"mookie/internal/metrics"
+	"mookie/internal/notifications"
+	"mookie/internal/openapi"
+	"mookie/internal/payments"
+	"mookie/internal/ratelimit"
+	"mookie/internal/redis"
+	"mookie/internal/search"
+	"mookie/internal/tracing"
+	"mookie/internal/validate"
+	"mookie/internal/webhooks"
 	"mookie/internal/websocket"
+	"mookie/static"
 	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Intervals of the cron tasks
+const (
+	// queueInterval is how often queued webhook deliveries and outbound messages are sent
+	queueInterval = 10 * time.Second
+	// searchInterval is how often changed records are reindexed
+	searchInterval = 10 * time.Second
+	// auditPruneInterval is how often audit entries past the retention period are deleted
+	auditPruneInterval = time.Hour
+	// auditPruneJitter spreads the pruning of instances sharing a database
+	auditPruneJitter = 5 * time.Minute
+	// historyPruneInterval is how often cron runs past the history period are deleted
+	historyPruneInterval = time.Hour
 )
 
+// slowServiceBuild is how long building a lazy service may take before it's logged as slow
+const slowServiceBuild = 100 * time.Millisecond
+
 // setupDependencies initializes and registers all application dependencies.
 // Add or modify dependencies here as needed for your project.
-func setupDependencies(configPath *string) (*container.Container, error) {
+func setupDependencies(configPath, configRemote *string) (*container.Container, error) {
 	// Create a new dependency injection container
 	container := container.New()
 
 	// Load the config
-	cfg := setupConfig(configPath)
+	cfg := setupConfig(configPath, configRemote)
 	container.Register("config", cfg)
 
 	// Setup logger
@@ -35,27 +91,784 @@ func setupDependencies(configPath *string) (*container.Container, error) {
 	// Debug log config
 	logger.Debug("Loaded config", "config", cfg)
 
+	// Set up tracing if enabled - database queries are traced through a wrapped driver
+	driverName := "sqlite3"
+	if cfg.Tracing.Enabled {
+		provider, err := tracing.Setup(context.Background(), tracing.Config{
+			ServiceName: cfg.Tracing.ServiceName,
+			Version:     version,
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		container.Register("tracing", provider)
+
+		driverName = "sqlite3-traced"
+		sql.Register(driverName, tracing.WrapDriver(&sqlite3.SQLiteDriver{}))
+	}
+
 	// Set up database
-	db, err := db.Open(cfg.DatabasePath)
+	db, err := db.OpenDriver(driverName, cfg.DatabasePath)
 	if err != nil {
 		log.Fatal(err)
 	}
 	container.Register("db", db)
 
+	// Set up the shared in-memory cache, bounded so per-IP and per-user keys can't grow it forever
+	var memoryCache *cache.MemoryCache
+	cacheOpts := []cache.Option{
+		cache.CleanupInterval(cfg.Cache.CleanupInterval),
+		cache.MaxBytes(cfg.Cache.MaxBytes),
+		cache.MaxItemBytes(cfg.Cache.MaxItemBytes),
+	}
+	if cfg.Cache.MaxEntries > 0 {
+		memoryCache = cache.NewMemoryCacheWithSize(cfg.Cache.MaxEntries, cacheOpts...)
+	} else {
+		memoryCache = cache.NewMemoryCache(cacheOpts...)
+	}
+	container.Register("cache", memoryCache)
+	// Cached data that survives restarts is kept in the database - get it as "cache.sqlite"
+	var codec cache.Codec = cache.GobCodec{}
+	if cfg.Cache.Codec == "json" {
+		codec = cache.JSONCodec{}
+	}
+	container.Register("cache.sqlite", cache.NewSQLiteCacheWithCodec(db, codec))
+
+	// Set up rate limits - limiter state is kept in the shared cache
+	setupRateLimits(container, cfg, memoryCache, logger)
+
+	// Set up the captcha verifier if a provider is configured - see middleware.RequireCaptcha
+	if cfg.Captcha.Provider != "" {
+		verifier, err := captcha.New(cfg.Captcha.Provider, cfg.Captcha.SiteKey, cfg.Captcha.SecretKey, captcha.Options{})
+		if err != nil {
+			return nil, err
+		}
+		container.Register("captcha", verifier)
+	}
+
+	// Set up the event bus - handler failures are logged and panics recovered
+	bus := events.New(events.Logging(logger), events.Recovery())
+	container.Register("events", bus)
+
 	// Set up websocket hub
+	// Clients that stop answering pings are disconnected
 	hub := websocket.NewHub()
+	hub.SetLogger(logger)
+	hub.KeepAlive(cfg.Server.WebsocketPongTimeout, cfg.Server.WebsocketPingInterval)
+	hub.OnConnect(func(client *websocket.Client) {
+		logger.Debug("Websocket client connected", "client_id", client.ID, "user_id", websocketUserID(client))
+	})
+	hub.OnDisconnect(func(client *websocket.Client) {
+		logger.Debug("Websocket client disconnected", "client_id", client.ID, "user_id", websocketUserID(client))
+	})
 	container.Register("hub", hub)
-	// Set up websocket upgrader - allow all origins for now
+	// Relay broadcasts between instances through Redis - the relay is started and stopped with the container
+	if cfg.Server.WebsocketRedisURL != "" {
+		redisClient, err := redis.New(cfg.Server.WebsocketRedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Server.WebsocketRedisURL: %w", err)
+		}
+		relay := websocket.NewRedisRelay(hub, redisClient, cfg.Server.WebsocketRedisChannel, logger)
+		hub.SetRelay(relay)
+		container.Register("hub.relay", relay)
+	}
+	// Set up websocket upgrader - the request host and AllowedOrigins may connect, the current config
+	// is read on every upgrade so reloads apply
 	upgrader := &ws.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
-			return true
+			return checkOrigin(r, cfg.Current().AllowedOrigins)
 		},
 	}
 	container.Register("upgrader", upgrader)
 
+	// Set up the metrics registry if enabled, application metrics are recorded but not exported otherwise
+	appMetrics := metrics.New(nil)
+	if cfg.Metrics.Enabled {
+		registry := setupMetrics(db, hub, memoryCache)
+		container.Register("metrics", registry)
+		appMetrics = metrics.New(registry)
+	}
+	setupBusinessMetrics(appMetrics, bus)
+	container.Register("appmetrics", appMetrics)
+
+	// Log slow factory builds and lookups of missing services, and export them as metrics
+	setupInstrumentation(container, logger, appMetrics)
+
+	// Set up the route registry used to generate the OpenAPI document
+	api := openapi.New("mookie", "1.0.0")
+	container.Register("openapi", api)
+
+	// Set up the GraphQL schema if enabled
+	if cfg.GraphQL.Enabled {
+		schema, err := graph.NewSchema(db)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing graphql schema: %w", err)
+		}
+		container.Register("graphql", schema)
+	}
+
+	// Set up the authenticator - basic auth against the users table
+	authenticator := auth.NewBasicAuthenticator(userCredentials(db))
+	container.Register("auth", authenticator)
+
+	// Set up the validator shared by API handlers and forms - register custom rules here
+	validator := validate.New()
+	validator.Register("username", func(value reflect.Value, _ string) bool {
+		return usernamePattern.MatchString(value.String())
+	}, "{field} may only contain lowercase letters, digits, dashes and underscores")
+	container.Register("validator", validator)
+
+	// Set up the mailer
+	mailer, err := setupMail(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	container.Register("mail", mailer)
+
+	// Set up feature flags - cached for 30 seconds, changes made in the admin invalidate the cache
+	flagService := flags.New(flagLookup(db), memoryCache, 30*time.Second)
+	events.Subscribe(bus, func(ctx context.Context, e events.FlagChanged) error {
+		flagService.Invalidate(e.Name)
+		return nil
+	})
+	container.Register("flags", flagService)
+
+	// Set up notifications - new users get a welcome notification
+	notificationService := notifications.New(db, hub)
+	events.Subscribe(bus, func(ctx context.Context, e events.UserRegistered) error {
+		_, err := notificationService.Notify(ctx, notifications.Notification{
+			UserID: e.UserID,
+			Type:   "welcome",
+			Title:  "Welcome to mookie, " + e.Username,
+		})
+		return err
+	}, events.Async())
+	container.Register("notifications", notificationService)
+
+	// Set up SMS and Web Push - notifications are pushed to the user's subscribed browsers
+	messagingService, err := setupMessaging(container, cfg, db, logger)
+	if err != nil {
+		return nil, err
+	}
+	notificationService.OnNotify(func(ctx context.Context, n notifications.Notification) {
+		if err := messagingService.PushToUser(ctx, n.UserID, n.Title, n.Body, n.Link); err != nil {
+			logger.Error("Failed to queue push notification", "error", err)
+		}
+	})
+	container.Register("messaging", messagingService)
+
+	// Set up Stripe payments if a secret key is configured - webhook events are published on the bus
+	if cfg.Payments.StripeSecretKey != "" {
+		stripe := payments.NewStripe(payments.StripeConfig{
+			SecretKey:     cfg.Payments.StripeSecretKey,
+			WebhookSecret: cfg.Payments.StripeWebhookSecret,
+		})
+		container.Register("payments", payments.New(db, stripe, bus, logger, payments.Options{Prices: cfg.Payments.Prices}))
+	}
+
+	// Set up the audit log - admin changes are recorded automatically
+	recorder := audit.New(db)
+	container.Register("audit", recorder)
+
+	// Set up outgoing webhooks - every published event is queued for the subscribed endpoints
+	dispatcher := webhooks.New(db, logger, webhooks.Options{})
+	dispatcher.Subscribe(bus)
+	container.Register("webhooks", dispatcher)
+
+	// Set up the cron runner for background tasks - services tagged cron.task are added when it starts,
+	// see setupLifecycle. Failed and panicking tasks are logged by the runner and counted
+	cronFailures := appMetrics.Counter("cron_task_failures_total", "Number of failed or panicking cron tasks.", "task")
+	cronRuns := appMetrics.Counter("cron_task_runs_total", "Number of finished cron task runs.", "task")
+	cronDuration := appMetrics.Timer("cron_task_duration_seconds", "Duration of cron tasks.", "task")
+	store := cron.NewSQLStore(db)
+	cronOpts := []cron.Option{
+		cron.Logging(logger),
+		// Record the last successful runs so tasks can catch up on runs missed while down
+		cron.Persist(store),
+		// Take a lease per tick so instances sharing the database run each task once
+		cron.Lock(store),
+		cron.OnError(func(name string, err error) { cronFailures.Inc(name) }),
+		cron.OnRun(func(run cron.RunInfo) {
+			cronRuns.Inc(run.Name)
+			cronDuration.Observe(run.Duration, run.Name)
+		}),
+	}
+	// Keep the runs for the admin, listed under /admin/cron/history
+	if cfg.Cron.HistoryDays > 0 {
+		cronOpts = append(cronOpts, cron.Record(store))
+	}
+	runner := cron.NewRunner(cronOpts...)
+	container.Register("cron", runner)
+	container.Register("cron.store", store)
+	container.Register("cron.webhooks", cron.Task{Name: "webhooks", Every: queueInterval, Run: func(ctx context.Context) error {
+		if err := dispatcher.ProcessDue(ctx); err != nil {
+			return fmt.Errorf("error processing webhook deliveries: %w", err)
+		}
+		return nil
+	}}, "cron.task")
+
+	container.Register("cron.messaging", cron.Task{Name: "messaging", Every: queueInterval, Run: func(ctx context.Context) error {
+		if err := messagingService.ProcessDue(ctx); err != nil {
+			return fmt.Errorf("error sending outbound messages: %w", err)
+		}
+		return nil
+	}}, "cron.task")
+
+	// Set up full text search if enabled - changed records are reindexed by the cron runner
+	if cfg.Search.Enabled {
+		searchService, err := setupSearch(cfg, db)
+		if err != nil {
+			return nil, err
+		}
+		container.Register("search", searchService)
+		container.Register("cron.search", cron.Task{Name: "search", Every: searchInterval, Run: func(ctx context.Context) error {
+			if err := searchService.Reindex(ctx); err != nil {
+				return fmt.Errorf("error reindexing search: %w", err)
+			}
+			return nil
+		}}, "cron.task")
+	}
+
+	// Prune audit entries past the retention period, retried a few times instead of waiting an hour
+	if cfg.Audit.RetentionDays > 0 {
+		retention := time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour
+		container.Register("cron.audit_prune", cron.Task{Name: "audit_prune", Every: auditPruneInterval, Jitter: auditPruneJitter, CatchUp: true, MaxAttempts: 3, RetryBase: time.Minute, Run: func(ctx context.Context) error {
+			if _, err := recorder.Prune(ctx, retention); err != nil {
+				return fmt.Errorf("error pruning audit log: %w", err)
+			}
+			return nil
+		}}, "cron.task")
+	}
+
+	// Prune the run history of the cron tasks
+	if cfg.Cron.HistoryDays > 0 {
+		retention := time.Duration(cfg.Cron.HistoryDays) * 24 * time.Hour
+		container.Register("cron.history_prune", cron.Task{Name: "history_prune", Every: historyPruneInterval, CatchUp: true, Run: func(ctx context.Context) error {
+			if _, err := store.PruneHistory(ctx, retention); err != nil {
+				return fmt.Errorf("error pruning cron history: %w", err)
+			}
+			return nil
+		}}, "cron.task")
+	}
+
+	// Save the memory cache so a restart keeps it warm, also saved at shutdown - see setupLifecycle
+	if cfg.Cache.SnapshotPath != "" && cfg.Cache.SnapshotInterval > 0 {
+		container.Register("cron.cache_snapshot", cron.Task{Name: "cache_snapshot", Every: cfg.Cache.SnapshotInterval, Run: func(ctx context.Context) error {
+			if _, err := memoryCache.SaveSnapshot(cfg.Cache.SnapshotPath); err != nil {
+				return fmt.Errorf("error saving cache snapshot: %w", err)
+			}
+			return nil
+		}}, "cron.task")
+	}
+
+	// Set up static files - served from the binary when EmbedStatic is enabled, fingerprinted for cache busting
+	staticFS, manifest, err := setupAssets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	container.Register("static", staticFS)
+	container.Register("assets", manifest)
+
+	// Set up the admin back office and register the models it manages
+	adm := admin.New(logger)
+	adm.Register(handlers.UsersResource(db, bus))
+	adm.Register(handlers.FeatureFlagsResource(db, bus))
+	adm.Register(handlers.WebhooksResource(db))
+	adm.OnChange(auditAdminChange(recorder, logger))
+	container.Register("admin", adm)
+
+	// Start and stop the services with the container - see lifecycle.go in internal/container
+	setupLifecycle(container)
+
+	// Register the health checks run by /readyz - see health.go in internal/container
+	setupHealthChecks(container)
+
 	return container, nil
 }
 
+// setupLifecycle sets the start and stop hooks of services that don't implement container.Starter or
+// container.Stopper, the event bus, cache and websocket hub implement them
+func setupLifecycle(c *container.Container) {
+	// Flush buffered spans to the collector, tracing is registered first so it stops last
+	if provider, err := container.Get[*sdktrace.TracerProvider](c, "tracing"); err == nil {
+		c.SetHooks("tracing", container.Hooks{OnStop: provider.Shutdown})
+		// Queries are traced until the database is closed
+		c.DependsOn("db", "tracing")
+	}
+
+	db := container.MustGet[*sql.DB](c, "db")
+	c.SetHooks("db", container.Hooks{OnStop: func(context.Context) error {
+		return db.Close()
+	}})
+
+	// Load the memory cache snapshot before serving requests and save it once nothing uses the cache -
+	// a missing or broken snapshot starts the cache cold
+	if cfg := container.MustGet[*config.Config](c, "config"); cfg.Cache.SnapshotPath != "" {
+		memoryCache := container.MustGet[*cache.MemoryCache](c, "cache")
+		logger := container.MustGet[*slog.Logger](c, "logger")
+		c.SetHooks("cache", container.Hooks{
+			OnStart: func(context.Context) error {
+				loaded, err := memoryCache.LoadSnapshot(cfg.Cache.SnapshotPath)
+				if err != nil {
+					logger.Warn("Error loading cache snapshot", "error", err)
+					return nil
+				}
+				logger.Info("Cache snapshot loaded", "path", cfg.Cache.SnapshotPath, "items", loaded)
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				saved, err := memoryCache.SaveSnapshot(cfg.Cache.SnapshotPath)
+				if err != nil {
+					err = fmt.Errorf("error saving cache snapshot: %w", err)
+				} else {
+					logger.Info("Cache snapshot saved", "path", cfg.Cache.SnapshotPath, "items", saved)
+				}
+				return errors.Join(err, memoryCache.Stop(ctx))
+			},
+		})
+	}
+
+	// Services using the database stop before it's closed, whatever order they were registered in -
+	// async event handlers and cron tasks query it and websocket clients save incoming messages
+	c.DependsOn("events", "db")
+	c.DependsOn("hub", "db")
+	c.DependsOn("cron", "db")
+	c.DependsOn("cache.sqlite", "db")
+
+	// Run background tasks such as webhook deliveries, contributed as services tagged cron.task with
+	// their interval - Cron.Schedules overrides the intervals by task name
+	runner := container.MustGet[*cron.Runner](c, "cron")
+	var started bool
+	stopped := make(chan struct{})
+	c.SetHooks("cron", container.Hooks{
+		OnStart: func(context.Context) error {
+			cfg := container.MustGet[*config.Config](c, "config")
+			logger := container.MustGet[*slog.Logger](c, "logger")
+			if !cfg.Cron.Enabled {
+				logger.Info("Background tasks disabled")
+				return nil
+			}
+			schedules, err := cron.ParseSchedules(cfg.Cron.Schedules)
+			if err != nil {
+				return fmt.Errorf("invalid Cron.Schedules: %w", err)
+			}
+			tasks, err := container.Tagged[cron.Task](c, "cron.task")
+			if err != nil {
+				return err
+			}
+			for _, task := range tasks {
+				if task.Every == 0 {
+					task.Every = cfg.Cron.Interval
+				}
+				if every, ok := schedules[task.Name]; ok {
+					task.Every = every
+				}
+				if task.Every == 0 {
+					logger.Info("Background task disabled", "task", task.Name)
+					continue
+				}
+				opts := []cron.TaskOption{cron.WithOverlap(task.Overlap), cron.WithJitter(task.Jitter), cron.WithTimeout(task.Timeout)}
+				if task.CatchUp {
+					opts = append(opts, cron.WithCatchUp())
+				}
+				if task.MaxAttempts > 1 {
+					opts = append(opts, cron.WithRetry(task.MaxAttempts, task.RetryBase))
+				}
+				if len(task.After) > 0 {
+					opts = append(opts, cron.WithAfter(task.After...))
+				}
+				runner.Add(task.Name, task.Run, task.Every, opts...)
+			}
+			started = true
+			go func() {
+				runner.Start()
+				close(stopped)
+			}()
+			return nil
+		},
+		// Cancel the running tasks and wait for them to return before the database is closed
+		OnStop: func(ctx context.Context) error {
+			runner.Stop()
+			if !started {
+				return nil
+			}
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// setupHealthChecks registers the health checks of the database, cache and websocket hub
+func setupHealthChecks(c *container.Container) {
+	db := container.MustGet[*sql.DB](c, "db")
+	c.RegisterHealthCheck("db", db.PingContext)
+
+	// A round trip through the shared cache
+	memoryCache := container.MustGet[cache.Cache](c, "cache")
+	c.RegisterHealthCheck("cache", func(context.Context) error {
+		if err := memoryCache.Set("health:check", true, time.Minute); err != nil {
+			return err
+		}
+		_, err := memoryCache.Get("health:check")
+		return err
+	})
+
+	hub := container.MustGet[*websocket.Hub](c, "hub")
+	c.RegisterHealthCheck("hub", hub.HealthCheck)
+	if relay, err := container.Get[*websocket.RedisRelay](c, "hub.relay"); err == nil {
+		c.RegisterHealthCheck("hub.relay", relay.HealthCheck)
+	}
+}
+
+// setupMessaging creates the messaging service with the configured SMS gateway and, if VAPID keys
+// are configured, Web Push - the Web Push provider is registered as "webpush" for its public key
+func setupMessaging(c *container.Container, cfg *config.Config, db *sql.DB, logger *slog.Logger) (*messaging.Service, error) {
+	service := messaging.New(db, logger, messaging.Options{})
+	msg := cfg.Messaging
+
+	switch msg.SMSProvider {
+	case "":
+	case "twilio":
+		service.Register(messaging.NewTwilio(messaging.TwilioConfig{
+			AccountSID: msg.TwilioAccountSID,
+			AuthToken:  msg.TwilioAuthToken,
+			From:       msg.TwilioFrom,
+			BaseURL:    msg.TwilioBaseURL,
+		}))
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", msg.SMSProvider)
+	}
+
+	if msg.VAPIDPrivateKey != "" {
+		push, err := messaging.NewWebPush(messaging.WebPushConfig{
+			PublicKey:  msg.VAPIDPublicKey,
+			PrivateKey: msg.VAPIDPrivateKey,
+			Subject:    msg.VAPIDSubject,
+		})
+		if err != nil {
+			return nil, err
+		}
+		service.Register(push)
+		c.Register("webpush", push)
+	}
+	return service, nil
+}
+
+// setupRateLimits registers the enabled limiters: "ratelimit" for HTTP requests per client IP,
+// "loginlimit" for failed logins per client IP and "wslimit" for messages per websocket client
+// The limits of registered limiters follow config reloads, enabling or disabling one needs a restart
+func setupRateLimits(c *container.Container, cfg *config.Config, store cache.Cache, logger *slog.Logger) {
+	limiters := map[string]*ratelimit.Limiter{}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Requests > 0 {
+		limiters["ratelimit"] = ratelimit.NewTokenBucket(store, "http", rateLimits(cfg)["ratelimit"])
+	}
+	if cfg.RateLimit.LoginAttempts > 0 {
+		limiters["loginlimit"] = ratelimit.NewSlidingWindow(store, "login", rateLimits(cfg)["loginlimit"])
+	}
+	if cfg.RateLimit.WebsocketMessages > 0 {
+		limiters["wslimit"] = ratelimit.NewTokenBucket(store, "websocket", rateLimits(cfg)["wslimit"])
+	}
+	for name, limiter := range limiters {
+		c.Register(name, limiter)
+	}
+
+	cfg.OnChange(func(next *config.Config) {
+		for name, limit := range rateLimits(next) {
+			limiter, ok := limiters[name]
+			if !ok {
+				continue
+			}
+			if limit.Rate <= 0 || limit.Per <= 0 || (name == "ratelimit" && !next.RateLimit.Enabled) {
+				logger.Warn("Disabling a rate limit needs a restart, keeping the previous limit", "limiter", name)
+				continue
+			}
+			limiter.SetLimit(limit)
+		}
+	})
+}
+
+// rateLimits returns the configured limits by limiter name
+func rateLimits(cfg *config.Config) map[string]ratelimit.Limit {
+	limits := cfg.RateLimit
+	return map[string]ratelimit.Limit{
+		"ratelimit":  {Rate: limits.Requests, Per: time.Duration(limits.WindowSeconds) * time.Second},
+		"loginlimit": {Rate: limits.LoginAttempts, Per: time.Duration(limits.LoginWindowSeconds) * time.Second},
+		"wslimit":    {Rate: limits.WebsocketMessages, Per: time.Second},
+	}
+}
+
+// setupAssets returns the static file system and its asset manifest, a manifest written by
+// -build-assets is used when present, otherwise the files are hashed at startup
+func setupAssets(cfg *config.Config) (fs.FS, *assets.Manifest, error) {
+	var staticFS fs.FS = os.DirFS("static")
+	if cfg.EmbedStatic {
+		staticFS = static.FS
+	}
+	if !cfg.Assets.Fingerprint {
+		return staticFS, assets.New(assets.DefaultPrefix), nil
+	}
+
+	manifest, err := assets.Load(staticFS, assets.DefaultPrefix)
+	if errors.Is(err, fs.ErrNotExist) {
+		manifest, err = assets.Build(staticFS, assets.DefaultPrefix)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building asset manifest: %w", err)
+	}
+	return staticFS, manifest, nil
+}
+
+// usernamePattern is the format accepted by the username validation rule
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_-]*$`)
+
+// setupMetrics creates the Prometheus registry with runtime, process, DB, cache and websocket hub metrics
+// HTTP metrics are contributed by the metrics middleware
+func setupMetrics(database *sql.DB, hub *websocket.Hub, memoryCache *cache.MemoryCache) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(database, "main"),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "websocket_clients",
+			Help: "Number of websocket clients connected to the hub.",
+		}, func() float64 {
+			return float64(len(hub.GetClients()))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_items",
+			Help: "Number of items in the memory cache.",
+		}, func() float64 {
+			return float64(memoryCache.Len())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of items evicted from the full memory cache.",
+		}, func() float64 {
+			return float64(memoryCache.Evictions())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_bytes",
+			Help: "Approximate memory footprint of the memory cache items, 0 without a Cache.MaxBytes budget.",
+		}, func() float64 {
+			return float64(memoryCache.Bytes())
+		}),
+	)
+	return registry
+}
+
+// setupBusinessMetrics counts domain events as business metrics
+func setupBusinessMetrics(m *metrics.Registry, bus *events.Bus) {
+	messages := m.Counter("messages_posted_total", "Number of messages posted.")
+	signups := m.Counter("user_signups_total", "Number of registered users.")
+	events.Subscribe(bus, func(ctx context.Context, e events.MessagePosted) error {
+		messages.Inc()
+		return nil
+	})
+	events.Subscribe(bus, func(ctx context.Context, e events.UserRegistered) error {
+		signups.Inc()
+		return nil
+	})
+}
+
+// setupInstrumentation logs and counts slow or failing factory builds and lookups of missing services
+func setupInstrumentation(c *container.Container, logger *slog.Logger, m *metrics.Registry) {
+	builds := m.Timer("container_build_duration_seconds", "Duration of building lazy and transient services.", "service")
+	misses := m.Counter("container_lookup_misses_total", "Number of lookups of services that aren't registered.", "service")
+	c.Instrument(container.Instrumentation{
+		OnBuild: func(name string, d time.Duration, err error) {
+			builds.Observe(d, name)
+			switch {
+			case err != nil:
+				logger.Error("Failed to build service", "service", name, "duration", d.String(), "error", err)
+			case d > slowServiceBuild:
+				logger.Warn("Slow service build", "service", name, "duration", d.String())
+			}
+		},
+		OnGet: func(name string, d time.Duration, err error) {
+			// Optional services are looked up with GetOr, a miss isn't necessarily a bug
+			if container.NotRegistered(err, name) {
+				misses.Inc(name)
+				logger.Debug("Service not found", "service", name)
+			}
+		},
+	})
+}
+
+// setupMail creates the mailer with the configured transport
+func setupMail(cfg *config.Config, logger *slog.Logger) (*mail.Mailer, error) {
+	var transport mail.Transport
+	switch cfg.Mail.Transport {
+	case "smtp":
+		smtpTransport, err := mail.NewSMTPTransport(mail.SMTPConfig{
+			Host:       cfg.Mail.Host,
+			Port:       cfg.Mail.Port,
+			Username:   cfg.Mail.Username,
+			Password:   cfg.Mail.Password,
+			Encryption: cfg.Mail.Encryption,
+		})
+		if err != nil {
+			return nil, err
+		}
+		transport = smtpTransport
+	case "maildir":
+		maildirTransport, err := mail.NewMaildirTransport(cfg.Mail.MaildirPath)
+		if err != nil {
+			return nil, fmt.Errorf("error creating maildir: %w", err)
+		}
+		transport = maildirTransport
+	case "log":
+		transport = mail.NewLogTransport(logger)
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", cfg.Mail.Transport)
+	}
+	return mail.New(transport, cfg.Mail.From), nil
+}
+
+// setupSearch creates the search service with the configured index and registers the searchable models
+func setupSearch(cfg *config.Config, database *sql.DB) (*search.Service, error) {
+	var index search.Index
+	var err error
+	switch cfg.Search.Backend {
+	case "fts5":
+		index, err = search.NewFTS5(database)
+	case "bleve":
+		index, err = search.NewBleve(cfg.Search.BlevePath)
+	default:
+		return nil, fmt.Errorf("unknown search backend %q", cfg.Search.Backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening search index: %w", err)
+	}
+
+	queries := sqlc.New(database)
+	service := search.New(index)
+	service.Register(search.Mapping{
+		Type:   "users",
+		Fields: []string{"username", "email"},
+		Load: func(ctx context.Context, since time.Time) ([]search.Document, error) {
+			users, err := queries.ListUsersUpdatedSince(ctx, searchSince(since))
+			if err != nil {
+				return nil, err
+			}
+			docs := make([]search.Document, 0, len(users))
+			for _, u := range users {
+				docs = append(docs, search.Document{
+					ID:     strconv.FormatInt(u.ID, 10),
+					Fields: map[string]string{"username": u.Username, "email": u.Email},
+				})
+			}
+			return docs, nil
+		},
+	})
+	service.Register(search.Mapping{
+		Type:   "flags",
+		Fields: []string{"name", "description"},
+		Load: func(ctx context.Context, since time.Time) ([]search.Document, error) {
+			list, err := queries.ListFeatureFlagsUpdatedSince(ctx, searchSince(since))
+			if err != nil {
+				return nil, err
+			}
+			docs := make([]search.Document, 0, len(list))
+			for _, f := range list {
+				docs = append(docs, search.Document{
+					ID:     strconv.FormatInt(f.ID, 10),
+					Fields: map[string]string{"name": f.Name, "description": f.Description},
+				})
+			}
+			return docs, nil
+		},
+	})
+	return service, nil
+}
+
+// searchSince formats a reindex time like CURRENT_TIMESTAMP for comparing with updated_at columns,
+// going back a second as the column has no sub-second precision
+func searchSince(since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+	return since.UTC().Add(-time.Second).Format(time.DateTime)
+}
+
+// auditAdminChange records changes made through the admin in the audit log
+func auditAdminChange(recorder *audit.Recorder, logger *slog.Logger) admin.ChangeHook {
+	return func(ctx context.Context, change admin.Change) {
+		actor := ""
+		if user := auth.UserFromContext(ctx); user != nil {
+			actor = user.Username
+		}
+		object := audit.Object{Type: change.Resource}
+		if change.ID != 0 {
+			object.ID = strconv.FormatInt(change.ID, 10)
+		}
+
+		// Updates only submit the editable fields, so only those are compared
+		before := change.Before
+		if change.Action == admin.ActionUpdate && before != nil {
+			before = make(map[string]string, len(change.After))
+			for name := range change.After {
+				before[name] = change.Before[name]
+			}
+		}
+
+		if _, err := recorder.Record(ctx, actor, "admin."+change.Action, object, audit.Diff(before, change.After)); err != nil {
+			logger.Error("Failed to record admin change", "resource", change.Resource, "error", err)
+		}
+	}
+}
+
+// userCredentials looks up users and their roles for the authenticator
+func userCredentials(database *sql.DB) auth.CredentialsLookup {
+	queries := sqlc.New(database)
+	return func(ctx context.Context, username string) (*auth.Credentials, error) {
+		user, err := queries.GetUserByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := queries.ListUserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Credentials{
+			User: auth.AuthUser{
+				ID:       strconv.FormatInt(user.ID, 10),
+				Username: user.Username,
+				Roles:    roles,
+			},
+			PasswordHash: user.Password,
+		}, nil
+	}
+}
+
+// flagLookup loads feature flags from the feature_flags table
+func flagLookup(database *sql.DB) flags.Lookup {
+	queries := sqlc.New(database)
+	return func(ctx context.Context, name string) (*flags.Flag, error) {
+		flag, err := queries.GetFeatureFlagByName(ctx, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, flags.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &flags.Flag{
+			Name:       flag.Name,
+			Enabled:    flag.Enabled,
+			Percentage: int(flag.RolloutPercentage),
+			Roles:      flags.SplitList(flag.Roles),
+			UserIDs:    flags.SplitList(flag.UserIds),
+		}, nil
+	}
+}
+
 // setupLogger is a helper function that creates a new logger with the specified configuration - log file and log level
 func setupLogger(cfg *config.Config) *slog.Logger {
 	var file *os.File
@@ -69,28 +882,82 @@ func setupLogger(cfg *config.Config) *slog.Logger {
 		}
 	}
 
-	logLevel := slog.LevelInfo
-	// If the log level is debug, set it to debug otherwise leave it as info
-	if cfg.LogLevel == "debug" {
-		logLevel = slog.LevelDebug
+	// The level follows LogLevel when the config is reloaded
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(parseLogLevel(cfg.LogLevel))
+	cfg.OnChange(func(next *config.Config) {
+		logLevel.Set(parseLogLevel(next.LogLevel))
+	})
+
+	// The format needs a restart
+	return logger.NewFormat(logger.Format(cfg.LogFormat), logLevel, file)
+}
+
+// websocketUserID returns the user ID of an authenticated websocket client, empty for anonymous clients
+func websocketUserID(client *websocket.Client) string {
+	if client.User == nil {
+		return ""
 	}
+	return client.User.ID
+}
 
-	return logger.New(logLevel, file)
+// checkOrigin reports whether a websocket upgrade comes from the request host or an allowed origin,
+// "*" allows any origin and requests without an Origin header aren't from browsers
+func checkOrigin(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(allowed, "*") {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
 }
 
-// setupConfig is a helper function that loads the configuration from the specified path
-func setupConfig(path *string) *config.Config {
-	cfg, err := config.NewWithPath(*path)
+// parseLogLevel returns the slog level of the LogLevel setting - debug, otherwise info
+func parseLogLevel(level string) slog.Level {
+	if level == "debug" {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// setupConfig is a helper function that loads the configuration from the specified path,
+// or from the remote URL if one is set - falling back to the path when the remote is unavailable
+func setupConfig(path, remoteURL *string) *config.Config {
+	if remoteURL == nil || *remoteURL == "" {
+		cfg, err := config.NewWithPath(*path)
+		if cfg == nil {
+			log.Fatalf("error loading config: %v", err)
+		}
+		return cfg
+	}
+
+	remote, err := config.ParseRemote(*remoteURL, os.Getenv("MOOKIE_CONFIG_REMOTE_TOKEN"))
+	if err != nil {
+		log.Fatalf("error loading config: %v", err)
+	}
+	cfg, err := config.NewWithRemote(*path, remote, func(err error) {
+		log.Printf("Using config file %s: %v", *path, err)
+	})
 	if cfg == nil {
 		log.Fatalf("error loading config: %v", err)
 	}
-
 	return cfg
 }
 
 // initDB initialized the db with predefined content - e.g. creating an admin user
 func initDB(c *container.Container) {
-	cfg := c.MustGet("config").(*config.Config)
+	cfg := container.MustGet[*config.Config](c, "config")
 	dbPath := cfg.DatabasePath
 
 	database, err := db.Open(dbPath)
@@ -103,25 +970,29 @@ func initDB(c *container.Container) {
 	ctx := context.Background()
 
 	// Check if admin user already exists
-	_, err = queries.GetUserByUsername(ctx, "admin")
+	user, err := queries.GetUserByUsername(ctx, "admin")
 	if err == nil {
 		fmt.Println("Admin user already exists, skipping creation")
-		return
-	}
+	} else {
+		// Admin user doesn't exist, create it
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	// Admin user doesn't exist, create it
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin123"), bcrypt.DefaultCost)
-	if err != nil {
-		log.Fatal(err)
+		user, err = queries.CreateUser(ctx, sqlc.CreateUserParams{
+			Username: "admin",
+			Email:    "admin@example.com",
+			Password: string(hashedPassword),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Created admin user: %+v\n", user)
 	}
 
-	user, err := queries.CreateUser(ctx, sqlc.CreateUserParams{
-		Username: "admin",
-		Email:    "admin@example.com",
-		Password: string(hashedPassword),
-	})
-	if err != nil {
+	// Make sure the admin user can access the admin back office
+	if err := queries.GrantUserRole(ctx, sqlc.GrantUserRoleParams{UserID: user.ID, Role: "admin"}); err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("Created admin user: %+v\n", user)
 }
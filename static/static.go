@@ -0,0 +1,18 @@
+package static
+
+import "embed"
+
+/*
+   Package static embeds the static files into the binary so the application
+   can be deployed as a single binary.
+
+   The embedded files are served instead of the on-disk static folder when
+   EmbedStatic is enabled in the config. Keep EmbedStatic disabled during
+   development to see changes to static files without recompiling.
+
+   Add new top level files or folders to the embed directive below, including
+   manifest.json and the bundle folder when using -build-assets.
+*/
+
+//go:embed css js favicon.ico logo.png
+var FS embed.FS
@@ -8,7 +8,10 @@ package pages
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-import components "mookie/templates/layout"
+import (
+	"mookie/internal/assets"
+	components "mookie/templates/layout"
+)
 
 func Front() templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
@@ -43,7 +46,20 @@ func Front() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<h1>mookie example</h1><h2>Hello world!</h2><p>This page features a websocket example, open in two tabs and send a message.</p><div id=\"messages\"></div><input type=\"text\" id=\"messageInput\" placeholder=\"Enter your message\"> <button onclick=\"sendMessage()\">Send</button><script src=\"/static/js/ws.js\"></script>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<h1>mookie example</h1><h2>Hello world!</h2><p>This page features a websocket example, open in two tabs and send a message.</p><div id=\"messages\"></div><input type=\"text\" id=\"messageInput\" placeholder=\"Enter your message\"> <button onclick=\"sendMessage()\">Send</button><script src=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(assets.URL(ctx, "js/ws.js"))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/pages/front.templ`, Line: 16, Col: 43}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\"></script>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}